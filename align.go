@@ -0,0 +1,153 @@
+package hjson
+
+import (
+	"bytes"
+	"strings"
+)
+
+// alignment holds the layout of the decoded members of an object or elements
+// of an array, so that values and comments that were aligned in columns stay
+// aligned after some values have been changed or added, for example with
+// Node.SetKey().
+//
+// Unless noted otherwise, columns are measured from the start of the key of
+// each member (or from the start of each element) in display width, see
+// displayWidth().
+type alignment struct {
+	// valueCol is the column where the values of all decoded members that are
+	// not objects or arrays start if they are aligned, otherwise -1.
+	valueCol int
+	// commentCols holds the columns in the decoded input where comments after
+	// values are aligned.
+	commentCols map[int]bool
+}
+
+// newAlignment returns the alignment of nodes, the members of an object with
+// the keys names as written by the encoder or the elements of an array if
+// names is nil. Returns nil if nothing is aligned.
+//
+// Values or comments count as aligned if two or more of them start in the same
+// column after padding of different lengths, which rules out columns that
+// are only shared by chance.
+func newAlignment(names []string, nodes []*Node) *alignment {
+	a := &alignment{valueCol: -1, commentCols: map[int]bool{}}
+	valuesAligned := names != nil
+	keyPads := map[int]bool{}
+	commentPads := map[int]map[int]bool{}
+	for i, node := range nodes {
+		valueCol, ok := origValueCol(names, i, node)
+		if !ok {
+			if node != nil && node.src != nil && strings.Trim(node.src.cm.Key, " \t\r\n") != "" {
+				// A comment between the key and the value.
+				valuesAligned = false
+			}
+			continue
+		}
+		if !isContainer(node.Value) {
+			// Objects and arrays are usually not aligned with other values.
+			if a.valueCol >= 0 && a.valueCol != valueCol {
+				valuesAligned = false
+			}
+			a.valueCol = valueCol
+			keyPads[len(node.src.cm.Key)] = true
+		}
+		if offset, pad, ok := commentOffset(node); ok && pad > 0 {
+			col := node.Pos.Column + offset
+			if commentPads[col] == nil {
+				commentPads[col] = map[int]bool{}
+			}
+			commentPads[col][pad] = true
+		}
+	}
+
+	if !valuesAligned || len(keyPads) < 2 {
+		a.valueCol = -1
+	}
+	for col, pads := range commentPads {
+		if len(pads) >= 2 {
+			a.commentCols[col] = true
+		}
+	}
+	if a.valueCol < 0 && len(a.commentCols) == 0 {
+		return nil
+	}
+	return a
+}
+
+func isContainer(v interface{}) bool {
+	switch v.(type) {
+	case []interface{}, *OrderedMap:
+		return true
+	}
+	return false
+}
+
+// origValueCol returns the column where the decoded value of the member or
+// element with index i started. Returns false if node was not decoded, or if
+// there was anything else than spaces between the key and the value.
+func origValueCol(names []string, i int, node *Node) (int, bool) {
+	if node == nil || node.src == nil {
+		return 0, false
+	}
+	if names == nil {
+		return 0, true
+	}
+	key := node.src.cm.Key
+	if strings.Trim(key, " ") != "" || node.Cm.Key != key {
+		return 0, false
+	}
+	return displayWidth(names[i]) + 1 + len(key), true
+}
+
+// commentOffset returns the distance from the start of the decoded value of
+// node to the comment after it, and the length of the padding before the
+// comment. Returns false if there is no such comment on the same line.
+func commentOffset(node *Node) (int, int, bool) {
+	after := node.src.cm.After
+	cm := strings.TrimLeft(after, " ")
+	if cm == "" || strings.Contains(node.Lit, "\n") ||
+		!(strings.HasPrefix(cm, "#") || strings.HasPrefix(cm, "//") || strings.HasPrefix(cm, "/*")) {
+
+		return 0, 0, false
+	}
+	pad := len(after) - len(cm)
+	return displayWidth(node.Lit) + pad, pad, true
+}
+
+// separator returns the text between the key name and the value of a member
+// that was not decoded, aligning the value with the other members. Only used
+// for values that are not objects or arrays.
+func (a *alignment) separator(name string) string {
+	n := a.valueCol - displayWidth(name) - 1
+	if n < 1 {
+		n = 1
+	}
+	return strings.Repeat(" ", n)
+}
+
+// after returns the comment to write after the value of the member or element
+// with index i, with its padding changed so that it starts in its original
+// column if that column is shared with other comments. The new value ends at
+// column end.
+func (a *alignment) after(names []string, i int, node *Node, after string, end int) string {
+	valueCol, ok := origValueCol(names, i, node)
+	if !ok || node.Cm.After != node.src.cm.After {
+		return after
+	}
+	offset, _, ok := commentOffset(node)
+	if !ok || !a.commentCols[node.Pos.Column+offset] {
+		return after
+	}
+	n := valueCol + offset - end
+	if n < 1 {
+		n = 1
+	}
+	return strings.Repeat(" ", n) + strings.TrimLeft(after, " ")
+}
+
+// column returns the display width of the text written by e on the current
+// line.
+func (e *hjsonEncoder) column() int {
+	b := e.Bytes()
+	return displayWidth(string(b[bytes.LastIndexByte(b, '\n')+1:]))
+}
@@ -0,0 +1,44 @@
+package hjson
+
+import (
+	"testing"
+)
+
+func TestAlignedEdits(t *testing.T) {
+	node, err := ParseNode([]byte(`name:    demo      # the name
+port:    8080      # the port
+debug:   false     # debugging
+list: [
+  1      # one
+  100    # hundred
+  "x"  # not aligned
+]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = node.SetKey("port", 80); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = node.SetKey("debug", "a value longer than the padding"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = node.SetKey("ttl", 30); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = node.NK("list").SetIndex(0, 12); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = node.NK("list").SetIndex(2, "yz"); err != nil {
+		t.Fatal(err)
+	}
+
+	verifyNodeContent(t, node, `name:    demo      # the name
+port:    80        # the port
+debug:   "a value longer than the padding" # debugging
+list: [
+  12     # one
+  100    # hundred
+  "yz"  # not aligned
+]
+ttl:     30`)
+}
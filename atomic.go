@@ -0,0 +1,112 @@
+package hjson
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// WriteFileAtomic encodes v as Hjson and writes it to the file path, so that
+// readers of the file and crashes never see a partially written file. The
+// data is written to a temporary file in the same directory, which is synced
+// to disk and then renamed to path, replacing any existing file. The file
+// gets the permissions perm.
+//
+// v is encoded by MarshalWithOptions() using the first of opts, or
+// DefaultOptions() if opts is empty. If FinalNewlines is 0 it is set to 1, so
+// that the file ends with a line feed.
+func WriteFileAtomic(path string, v interface{}, perm os.FileMode, opts ...EncoderOptions) error {
+	return writeEncodedAtomic(path, "", v, perm, opts)
+}
+
+// WriteFileAtomicWithBackup is like WriteFileAtomic(), but if the file path
+// already exists its current content is first saved to the file backupPath,
+// replacing any existing backup. The backup gets the permissions of the
+// original file.
+func WriteFileAtomicWithBackup(
+	path,
+	backupPath string,
+	v interface{},
+	perm os.FileMode,
+	opts ...EncoderOptions,
+) error {
+	return writeEncodedAtomic(path, backupPath, v, perm, opts)
+}
+
+func writeEncodedAtomic(
+	path,
+	backupPath string,
+	v interface{},
+	perm os.FileMode,
+	opts []EncoderOptions,
+) error {
+	options := DefaultOptions()
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	if options.FinalNewlines == 0 {
+		options.FinalNewlines = 1
+	}
+	data, err := MarshalWithOptions(v, options)
+	if err != nil {
+		return err
+	}
+
+	if backupPath != "" {
+		fi, err := os.Stat(path)
+		if err == nil {
+			var old []byte
+			if old, err = ioutil.ReadFile(path); err == nil {
+				err = writeFileAtomic(backupPath, old, fi.Mode().Perm())
+			}
+		}
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return writeFileAtomic(path, data, perm)
+}
+
+// writeFileAtomic writes data to a temporary file in the directory of path,
+// syncs it and renames it to path.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) (err error) {
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	f, err := ioutil.TempFile(dir, "."+name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	if _, err = f.Write(data); err != nil {
+		return err
+	}
+	if err = f.Chmod(perm); err != nil {
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(f.Name(), path); err != nil {
+		return err
+	}
+
+	// Sync the directory so that the rename is durable. Not all platforms
+	// support this, so errors are ignored.
+	if d, dirErr := os.Open(dir); dirErr == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
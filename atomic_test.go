@@ -0,0 +1,69 @@
+package hjson
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hjson-atomic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.hjson")
+	if err := WriteFileAtomic(path, map[string]int{"a": 1}, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := ioutil.ReadFile(path); err != nil || string(b) != "{\n  a: 1\n}\n" {
+		t.Errorf("Unexpected content: %q %v", b, err)
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("Unexpected file info: %v %v", fi, err)
+	}
+
+	options := DefaultOptions()
+	options.EmitRootBraces = false
+	backup := path + ".bak"
+	if err := WriteFileAtomicWithBackup(path, backup, map[string]int{"a": 2}, 0644, options); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := ioutil.ReadFile(path); err != nil || string(b) != "a: 2\n" {
+		t.Errorf("Unexpected content: %q %v", b, err)
+	}
+	if b, err := ioutil.ReadFile(backup); err != nil || string(b) != "{\n  a: 1\n}\n" {
+		t.Errorf("Unexpected backup: %q %v", b, err)
+	}
+
+	// A value that cannot be encoded leaves the file unchanged.
+	if err := WriteFileAtomic(path, make(chan int), 0644); err == nil {
+		t.Error("Expected an error")
+	}
+	if b, err := ioutil.ReadFile(path); err != nil || string(b) != "a: 2\n" {
+		t.Errorf("Unexpected content: %q %v", b, err)
+	}
+
+	// No backup is written for a new file, and no temporary files are left.
+	newPath := filepath.Join(dir, "new.hjson")
+	if err := WriteFileAtomicWithBackup(newPath, newPath+".bak", []int{1}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, fi := range files {
+		names = append(names, fi.Name())
+	}
+	if len(names) != 3 || names[0] != "config.hjson" || names[1] != "config.hjson.bak" || names[2] != "new.hjson" {
+		t.Errorf("Unexpected files: %v", names)
+	}
+
+	if err := WriteFileAtomic(filepath.Join(dir, "missing", "x.hjson"), 1, 0644); err == nil {
+		t.Error("Expected an error for a missing directory")
+	}
+}
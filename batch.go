@@ -0,0 +1,192 @@
+package hjson
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"sync"
+)
+
+// Diagnostic describes a problem found in an Hjson document, for example by a
+// FileProcessor called from ProcessFiles().
+type Diagnostic struct {
+	// Path of the file that the diagnostic refers to, if any.
+	Path string
+	// Line number (1-based), or 0 if unknown.
+	Line int
+	// Column number (1-based), or 0 if unknown.
+	Column int
+	// Message is a human readable description of the problem.
+	Message string
+	// Rule is the name of the lint rule that reported the problem, if any. See
+	// Lint().
+	Rule string
+	// Code is the stable code of the problem, for example "HJ030", if any. See
+	// Codes().
+	Code string
+}
+
+// String returns the diagnostic in the format
+// "path:line:column: message [rule]", leaving out any parts that are unknown.
+// If Code is set it is written before the rule, like "[HJ030 mixed-indent]".
+func (d Diagnostic) String() string {
+	var b bytes.Buffer
+	if d.Path != "" {
+		b.WriteString(d.Path + ":")
+	}
+	if d.Line > 0 {
+		b.WriteString(fmt.Sprintf("%d:", d.Line))
+		if d.Column > 0 {
+			b.WriteString(fmt.Sprintf("%d:", d.Column))
+		}
+	}
+	if b.Len() > 0 {
+		b.WriteString(" ")
+	}
+	b.WriteString(d.Message)
+	switch {
+	case d.Code != "" && d.Rule != "":
+		b.WriteString(" [" + d.Code + " " + d.Rule + "]")
+	case d.Code != "" || d.Rule != "":
+		b.WriteString(" [" + d.Code + d.Rule + "]")
+	}
+	return b.String()
+}
+
+// FileProcessor is called by ProcessFiles() once for every file. It receives
+// the path and the current content of the file. It returns the new content of
+// the file (or nil if the file should be left as it is) and any diagnostics
+// found in the file. If an error is returned the file is not written.
+type FileProcessor func(path string, data []byte) ([]byte, []Diagnostic, error)
+
+// BatchOptions defines options for ProcessFiles().
+type BatchOptions struct {
+	// Parallelism is the max number of files that are processed at the same
+	// time. If Parallelism < 1, runtime.NumCPU() is used.
+	Parallelism int
+	// DryRun causes ProcessFiles() to report which files would be changed,
+	// without writing anything to disk.
+	DryRun bool
+}
+
+// FileResult is the outcome of processing a single file in ProcessFiles().
+type FileResult struct {
+	Path string
+	// Changed is true if the FileProcessor returned content that differs from
+	// the original content. If BatchOptions.DryRun was false, the new content
+	// has been written to the file.
+	Changed     bool
+	Diagnostics []Diagnostic
+	Err         error
+}
+
+// BatchResult contains the results from ProcessFiles(), in the same order as
+// the paths given to ProcessFiles().
+type BatchResult struct {
+	Files []FileResult
+}
+
+// Diagnostics returns the diagnostics from all files, in file order.
+func (r BatchResult) Diagnostics() []Diagnostic {
+	var diags []Diagnostic
+	for _, f := range r.Files {
+		diags = append(diags, f.Diagnostics...)
+	}
+	return diags
+}
+
+// Changed returns the paths of all files that were changed (or would have
+// been changed, if BatchOptions.DryRun was true).
+func (r BatchResult) Changed() []string {
+	var paths []string
+	for _, f := range r.Files {
+		if f.Changed {
+			paths = append(paths, f.Path)
+		}
+	}
+	return paths
+}
+
+// ProcessFiles reads each file in paths, calls fn on its content and writes
+// the returned content back to the file if it differs from the original. The
+// files are processed concurrently, see BatchOptions.Parallelism. Diagnostics
+// returned by fn get their Path set to the path of the file, if not already
+// set.
+//
+// The returned BatchResult always contains one FileResult per path. The
+// returned error is the first error (in path order) found when reading,
+// processing or writing the files, or nil if all files were processed
+// successfully.
+func ProcessFiles(paths []string, fn FileProcessor, opts BatchOptions) (BatchResult, error) {
+	res := BatchResult{Files: make([]FileResult, len(paths))}
+
+	parallelism := opts.Parallelism
+	if parallelism < 1 {
+		parallelism = runtime.NumCPU()
+	}
+
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, path string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			res.Files[i] = processFile(path, fn, opts.DryRun)
+		}(i, path)
+	}
+	wg.Wait()
+
+	for _, f := range res.Files {
+		if f.Err != nil {
+			return res, f.Err
+		}
+	}
+
+	return res, nil
+}
+
+func processFile(path string, fn FileProcessor, dryRun bool) FileResult {
+	fr := FileResult{Path: path}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		fr.Err = err
+		return fr
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		fr.Err = err
+		return fr
+	}
+
+	out, diags, err := fn(path, data)
+	for i := range diags {
+		if diags[i].Path == "" {
+			diags[i].Path = path
+		}
+	}
+	fr.Diagnostics = diags
+	if err != nil {
+		fr.Err = fmt.Errorf("%s: %v", path, err)
+		return fr
+	}
+
+	if out == nil || bytes.Equal(out, data) {
+		return fr
+	}
+	fr.Changed = true
+
+	if !dryRun {
+		if err = writeFileAtomic(path, out, fi.Mode().Perm()); err != nil {
+			fr.Err = err
+		}
+	}
+
+	return fr
+}
@@ -0,0 +1,73 @@
+package hjson
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProcessFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hjson-batch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var paths []string
+	for _, name := range []string{"a.hjson", "b.hjson", "c.hjson"} {
+		p := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(p, []byte("key: "+name+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, p)
+	}
+
+	upper := func(path string, data []byte) ([]byte, []Diagnostic, error) {
+		if strings.HasSuffix(path, "b.hjson") {
+			return nil, []Diagnostic{{Line: 1, Message: "unchanged"}}, nil
+		}
+		return []byte(strings.ToUpper(string(data))), nil, nil
+	}
+
+	res, err := ProcessFiles(paths, upper, BatchOptions{Parallelism: 2, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Changed()) != 2 {
+		t.Errorf("Unexpected changed files: %v", res.Changed())
+	}
+	diags := res.Diagnostics()
+	if len(diags) != 1 || diags[0].Path != paths[1] {
+		t.Errorf("Unexpected diagnostics: %v", diags)
+	}
+	if diags[0].String() != paths[1]+":1: unchanged" {
+		t.Errorf("Unexpected diagnostic string: %s", diags[0].String())
+	}
+	if string(getContent(paths[0])) != "key: a.hjson\n" {
+		t.Errorf("File should not have been written in dry-run mode")
+	}
+
+	if _, err = ProcessFiles(paths, upper, BatchOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if string(getContent(paths[0])) != "KEY: A.HJSON\n" {
+		t.Errorf("Unexpected file content: %s", getContent(paths[0]))
+	}
+	if string(getContent(paths[1])) != "key: b.hjson\n" {
+		t.Errorf("Unexpected file content: %s", getContent(paths[1]))
+	}
+
+	failing := func(path string, data []byte) ([]byte, []Diagnostic, error) {
+		return nil, nil, errors.New("failed")
+	}
+	res, err = ProcessFiles(append(paths, filepath.Join(dir, "missing.hjson")), failing, BatchOptions{})
+	if err == nil {
+		t.Errorf("Should have returned an error")
+	}
+	if len(res.Files) != 4 || res.Files[3].Err == nil {
+		t.Errorf("Expected an error for the missing file")
+	}
+}
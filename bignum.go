@@ -0,0 +1,86 @@
+package hjson
+
+import (
+	"math/big"
+	"reflect"
+)
+
+var (
+	bigIntType   = reflect.TypeOf(big.Int{})
+	bigFloatType = reflect.TypeOf(big.Float{})
+)
+
+// isBigNumberType returns true if t is big.Int or big.Float.
+func isBigNumberType(t reflect.Type) bool {
+	return t == bigIntType || t == bigFloatType
+}
+
+// parseBigNumber parses text, a number, into a destination of type t, which
+// is big.Int or big.Float. Integers are parsed without rounding, and the
+// precision of a big.Float is chosen so that all digits of text are kept. The
+// value is returned as nil if it is assigned after json.Unmarshal(), because
+// json.Unmarshal() would round it.
+func (p *hjsonParser) parseBigNumber(text string, t reflect.Type) (interface{}, error) {
+	var v interface{}
+	if t == bigIntType {
+		n, ok := new(big.Int).SetString(text, 10)
+		if !ok {
+			return nil, p.errAt(MsgExpectedInteger)
+		}
+		v = *n
+	} else {
+		// At least 64 bits, the default precision of big.Float, and more than
+		// log2(10) bits per digit.
+		prec := uint(len(text)) * 4
+		if prec < 64 {
+			prec = 64
+		}
+		f, _, err := big.ParseFloat(text, 10, prec, big.ToNearestEven)
+		if err != nil {
+			return nil, p.errAt(MsgNumberOutOfRange)
+		}
+		v = *f
+	}
+	if !p.willMarshalToJSON {
+		return v, nil
+	}
+	p.fixups = append(p.fixups, destFixup{
+		path:  append([]interface{}(nil), p.path...),
+		value: v,
+	})
+	return nil, nil
+}
+
+// writeBigNumber writes value as a number if it is a big.Int or a finite
+// big.Float, or as a string if it is an infinite big.Float. It returns false
+// for other values.
+func (e *hjsonEncoder) writeBigNumber(
+	value reflect.Value,
+	noIndent bool,
+	separator string,
+	isRootObject,
+	isObjElement bool,
+	cm Comments,
+) (bool, error) {
+	var text string
+	switch value.Type() {
+	case bigIntType:
+		n := value.Interface().(big.Int)
+		text = n.Text(10)
+	case bigFloatType:
+		f := value.Interface().(big.Float)
+		text = f.Text('g', -1)
+		if f.IsInf() {
+			return true, e.str(reflect.ValueOf(text), noIndent, separator, isRootObject,
+				isObjElement, cm)
+		}
+	default:
+		return false, nil
+	}
+	l, r := "", ""
+	if e.EnableColor {
+		l, r = e.ColorStyle.Number[0], e.ColorStyle.Number[1]
+	}
+	e.WriteString(separator + l + text + r)
+	return true, nil
+}
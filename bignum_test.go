@@ -0,0 +1,72 @@
+package hjson
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestBigNumbers(t *testing.T) {
+	i, _ := new(big.Int).SetString("-123456789012345678901234567890", 10)
+	f, _, _ := big.ParseFloat("1.23456789012345678901234567890", 10, 128, big.ToNearestEven)
+	type amounts struct {
+		A *big.Int
+		B *big.Float
+		C big.Int
+		D []*big.Int
+		E map[string]*big.Float
+	}
+	v := amounts{A: i, B: f, C: *i, D: []*big.Int{big.NewInt(7)},
+		E: map[string]*big.Float{"x": big.NewFloat(0.5)}}
+
+	b, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := `{
+  A: -123456789012345678901234567890
+  B: 1.2345678901234567890123456789
+  C: -123456789012345678901234567890
+  D: [
+    7
+  ]
+  E: {
+    x: 0.5
+  }
+}`
+	compareStrings(t, b, exp)
+
+	var w amounts
+	if err := Unmarshal(b, &w); err != nil {
+		t.Fatal(err)
+	}
+	if w.A.Cmp(i) != 0 || w.C.Cmp(i) != 0 || w.D[0].Int64() != 7 ||
+		w.B.Text('g', -1) != "1.2345678901234567890123456789" ||
+		w.E["x"].Text('g', -1) != "0.5" {
+
+		t.Errorf("Unexpected result: %v %v %v %v %v", w.A, w.B, &w.C, w.D, w.E)
+	}
+
+	options := DefaultDecoderOptions()
+	options.Dialect = FormatJSON5
+	if err := UnmarshalWithOptions([]byte("{A: 0x10, B: .5}"), &w, options); err != nil {
+		t.Fatal(err)
+	}
+	if w.A.Int64() != 16 || w.B.Text('g', -1) != "0.5" {
+		t.Errorf("Unexpected result: %v %v", w.A, w.B)
+	}
+
+	err = Unmarshal([]byte("{A: 1.5}"), &w)
+	if pe, ok := err.(*ParseError); !ok || pe.Kind != MsgExpectedInteger {
+		t.Errorf("Expected %s, got %v", MsgExpectedInteger, err)
+	}
+
+	// Quoteless strings that are not numbers are still given to
+	// UnmarshalText().
+	var s struct{ F *big.Float }
+	if err := Unmarshal([]byte("F: +Inf"), &s); err != nil || !s.F.IsInf() {
+		t.Errorf("Unexpected result: %v %v", s.F, err)
+	}
+	if b, err := Marshal(s); err != nil || string(b) != "{\n  F: +Inf\n}" {
+		t.Errorf("Unexpected output: %s %v", b, err)
+	}
+}
@@ -0,0 +1,86 @@
+package hjson
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"unicode/utf8"
+)
+
+// The options of the hjson struct tag selecting the encoding of a []byte
+// field. Fields without any of these options are written as arrays of
+// numbers and decoded from such arrays or from base64 strings, like in
+// encoding/json.
+const (
+	// bytesBase64 writes the bytes as a base64 string (standard encoding).
+	bytesBase64 = "base64"
+	// bytesHex writes the bytes as a string of hexadecimal digits.
+	bytesHex = "hex"
+	// bytesText writes the bytes as they are, as a multiline string. The
+	// bytes must be valid UTF-8.
+	bytesText = "text"
+)
+
+func isBytesFormat(opt string) bool {
+	return opt == bytesBase64 || opt == bytesHex || opt == bytesText
+}
+
+func isByteSlice(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+// bytesField returns the []byte in a struct field tagged with the "base64",
+// "hex" or "text" option as a string in that format. nil is returned
+// unchanged, so that it is written as null.
+func bytesField(value reflect.Value, format string) (reflect.Value, error) {
+	v := value
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return value, nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Slice || v.Type().Elem().Kind() != reflect.Uint8 || v.IsNil() {
+		return value, nil
+	}
+	b := v.Bytes()
+	switch format {
+	case bytesHex:
+		return reflect.ValueOf(hex.EncodeToString(b)), nil
+	case bytesText:
+		if !utf8.Valid(b) {
+			return value, errors.New("Cannot write []byte as text, it is not valid UTF-8")
+		}
+		return reflect.ValueOf(string(b)), nil
+	}
+	return reflect.ValueOf(base64.StdEncoding.EncodeToString(b)), nil
+}
+
+// decodeBytesField converts val, the value read for a []byte struct field
+// tagged with the "base64", "hex" or "text" option, to the base64 string that
+// json.Unmarshal() expects. Values that are not strings are returned
+// unchanged.
+func (p *hjsonParser) decodeBytesField(format string, val interface{}) (interface{}, error) {
+	s, ok := val.(string)
+	if !ok {
+		return val, nil
+	}
+	var b []byte
+	var err error
+	switch format {
+	case bytesBase64:
+		b, err = base64.StdEncoding.DecodeString(s)
+	case bytesHex:
+		b, err = hex.DecodeString(s)
+	default:
+		b = []byte(s)
+	}
+	if err != nil {
+		return nil, p.errAtOffset(p.valueStart, MsgBadBytes, format, err.Error())
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
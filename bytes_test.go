@@ -0,0 +1,76 @@
+package hjson
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBytesField(t *testing.T) {
+	type data struct {
+		Raw    []byte
+		Key    []byte  `hjson:",base64"`
+		Digest []byte  `json:"digest" hjson:",hex"`
+		Script *[]byte `hjson:",text"`
+		Empty  []byte  `hjson:",hex"`
+	}
+	script := []byte("echo 1\necho 2")
+	v := data{
+		Raw:    []byte{1, 2},
+		Key:    []byte("key"),
+		Digest: []byte{0xde, 0xad, 0xbe, 0xef},
+		Script: &script,
+		Empty:  []byte{},
+	}
+	b, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := `{
+  Raw: [
+    1
+    2
+  ]
+  Key: a2V5
+  digest: deadbeef
+  Script:
+    '''
+    echo 1
+    echo 2
+    '''
+  Empty: ""
+}`
+	if string(b) != exp {
+		t.Errorf("Expected\n%s\ngot\n%s", exp, b)
+	}
+
+	var v2 data
+	if err := Unmarshal(b, &v2); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(v, v2) {
+		t.Errorf("Expected\n%#v\ngot\n%#v", v, v2)
+	}
+
+	// Untagged fields accept base64, like in encoding/json.
+	if err := Unmarshal([]byte("Raw: AQI=\nScript: single line"), &v2); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(v2.Raw, []byte{1, 2}) || string(*v2.Script) != "single line" {
+		t.Errorf("Unexpected result: %#v", v2)
+	}
+
+	if err := Unmarshal([]byte("{digest: \"ABC0\"}"), &v2); err != nil || !reflect.DeepEqual(v2.Digest, []byte{0xab, 0xc0}) {
+		t.Errorf("Unexpected result: %v %#v", err, v2.Digest)
+	}
+	for _, txt := range []string{"{digest: xyz\n}", "{Key: a2V5!\n}"} {
+		err := Unmarshal([]byte(txt), &v2)
+		if pe, ok := err.(*ParseError); !ok || pe.Kind != MsgBadBytes || pe.Column != strings.Index(txt, ":")+3 {
+			t.Errorf("Expected %s for %q, got %v", MsgBadBytes, txt, err)
+		}
+	}
+
+	if _, err := Marshal(data{Script: &[]byte{0xff}}); err == nil {
+		t.Error("Expected an error for invalid UTF-8")
+	}
+}
@@ -0,0 +1,108 @@
+package hjson
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// FieldEncoderFunc returns the text written for v, the value of a struct
+// field tagged with `hjson:",encoder=name"`. The text is written as a string,
+// in quotes if needed.
+type FieldEncoderFunc func(v interface{}) (string, error)
+
+// FieldDecoderFunc returns the value of a struct field tagged with
+// `hjson:",decoder=name"`, decoded from text, the string (or the text of the
+// number) found in the input. t is the type of the field, without any
+// pointers. The returned value must be assignable to t.
+type FieldDecoderFunc func(text string, t reflect.Type) (interface{}, error)
+
+type fieldCodec struct {
+	encode FieldEncoderFunc
+	decode FieldDecoderFunc
+}
+
+var fieldCodecs = struct {
+	sync.RWMutex
+	m map[string]fieldCodec
+}{m: map[string]fieldCodec{}}
+
+// RegisterFieldCodec registers functions that give individual struct fields
+// a custom text form, for example checksums, bitmasks or durations, without
+// wrapping their types. Fields select the functions by name with the options
+// "encoder" and "decoder" of the hjson tag:
+//
+//	Sum []byte `hjson:",encoder=hexBytes,decoder=hexBytes"`
+//
+// Either function can be nil. Registering a name again replaces the previous
+// functions. RegisterFieldCodec is safe for concurrent use, but is normally
+// called from init functions.
+func RegisterFieldCodec(name string, encode FieldEncoderFunc, decode FieldDecoderFunc) {
+	fieldCodecs.Lock()
+	defer fieldCodecs.Unlock()
+	fieldCodecs.m[name] = fieldCodec{encode: encode, decode: decode}
+}
+
+func lookupFieldCodec(name string) fieldCodec {
+	fieldCodecs.RLock()
+	defer fieldCodecs.RUnlock()
+	return fieldCodecs.m[name]
+}
+
+// encodeField calls the encoder registered as name for the struct field
+// value and returns the text to write, or value itself if it is nil.
+func encodeField(name string, value reflect.Value) (reflect.Value, error) {
+	for v := value; v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface; v = v.Elem() {
+		if v.IsNil() {
+			return value, nil
+		}
+	}
+	fn := lookupFieldCodec(name).encode
+	if fn == nil {
+		return value, errors.New("No field encoder registered as " + name)
+	}
+	text, err := fn(value.Interface())
+	if err != nil {
+		return value, errors.New("Field encoder " + name + " failed: " + err.Error())
+	}
+	return reflect.ValueOf(text), nil
+}
+
+// decodeField calls the decoder registered as name for val, the value read
+// for the struct field key of type t, in the object found at p.path. The
+// result is assigned after
+// json.Unmarshal(), so nil is returned in its place. Values that are not
+// strings or numbers, like null, are returned unchanged.
+func (p *hjsonParser) decodeField(name, key string, t reflect.Type, val interface{}) (interface{}, error) {
+	var text string
+	switch v := val.(type) {
+	case string:
+		text = v
+	case nil, bool, []interface{}, map[string]interface{}, *OrderedMap:
+		return val, nil
+	default:
+		text = strings.TrimSpace(string(p.data[p.valueStart:p.valueEnd]))
+	}
+	fn := lookupFieldCodec(name).decode
+	if fn == nil {
+		return nil, p.errAtOffset(p.valueStart, MsgBadFieldValue, text, key, name,
+			"no field decoder registered")
+	}
+	_, ut := unravelDestination(reflect.Value{}, t)
+	v, err := fn(text, ut)
+	if err == nil && v == nil {
+		err = errors.New("the decoder returned nil")
+	} else if err == nil && !reflect.TypeOf(v).AssignableTo(ut) {
+		err = errors.New("the decoder returned " + reflect.TypeOf(v).String() +
+			" instead of " + ut.String())
+	}
+	if err != nil {
+		return nil, p.errAtOffset(p.valueStart, MsgBadFieldValue, text, key, name, err.Error())
+	}
+	p.fixups = append(p.fixups, destFixup{
+		path:  append(append([]interface{}(nil), p.path...), key),
+		value: v,
+	})
+	return nil, nil
+}
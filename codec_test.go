@@ -0,0 +1,101 @@
+package hjson
+
+import (
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func init() {
+	RegisterFieldCodec("testHexBytes", func(v interface{}) (string, error) {
+		return strings.ToUpper(hex.EncodeToString(v.([]byte))), nil
+	}, func(text string, t reflect.Type) (interface{}, error) {
+		return hex.DecodeString(text)
+	})
+	RegisterFieldCodec("testFlags", func(v interface{}) (string, error) {
+		n := reflect.ValueOf(v).Elem().Uint()
+		var flags []string
+		for i, name := range []string{"read", "write", "exec"} {
+			if n&(1<<uint(i)) != 0 {
+				flags = append(flags, name)
+			}
+		}
+		return strings.Join(flags, "|"), nil
+	}, func(text string, t reflect.Type) (interface{}, error) {
+		var n uint64
+		for _, flag := range strings.Split(text, "|") {
+			switch flag {
+			case "read":
+				n |= 1
+			case "write":
+				n |= 2
+			case "exec":
+				n |= 4
+			default:
+				if i, err := strconv.ParseUint(flag, 10, 8); err == nil {
+					n |= i
+					continue
+				}
+				return nil, errors.New("unknown flag " + flag)
+			}
+		}
+		return reflect.ValueOf(n).Convert(t).Interface(), nil
+	})
+	RegisterFieldCodec("testEncodeOnly", func(v interface{}) (string, error) {
+		return "", errors.New("broken")
+	}, nil)
+}
+
+func TestFieldCodec(t *testing.T) {
+	type file struct {
+		Sum   []byte `hjson:",encoder=testHexBytes,decoder=testHexBytes"`
+		Flags *uint8 `json:"flags" hjson:",encoder=testFlags,decoder=testFlags"`
+		Name  string
+	}
+	flags := uint8(5)
+	f := file{Sum: []byte{0xde, 0xad}, Flags: &flags, Name: "x"}
+	b, err := Marshal(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := `{
+  Sum: DEAD
+  flags: read|exec
+  Name: x
+}`
+	if string(b) != exp {
+		t.Errorf("Expected\n%s\ngot\n%s", exp, b)
+	}
+
+	var f2 file
+	if err := Unmarshal(b, &f2); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(f, f2) {
+		t.Errorf("Expected\n%#v\ngot\n%#v", f, f2)
+	}
+	f2 = file{}
+	if err := Unmarshal([]byte("{flags: 3\nSum: null}"), &f2); err != nil || f2.Flags == nil || *f2.Flags != 3 || f2.Sum != nil {
+		t.Errorf("Unexpected result: %v %#v", err, f2)
+	}
+
+	err = Unmarshal([]byte("{Name: y\nflags: read|fly\n}"), &f2)
+	if pe, ok := err.(*ParseError); !ok || pe.Kind != MsgBadFieldValue || pe.Line != 2 || pe.Column != 8 ||
+		!strings.Contains(pe.Message, "unknown flag fly") {
+
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	var bad struct {
+		A int `hjson:",encoder=testEncodeOnly,decoder=testEncodeOnly"`
+	}
+	if _, err := Marshal(bad); err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := Unmarshal([]byte("{A: 1}"), &bad); err == nil || !strings.Contains(err.Error(), "no field decoder") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
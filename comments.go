@@ -0,0 +1,153 @@
+package hjson
+
+import (
+	"strings"
+)
+
+// CommentStyle defines the comment markers written by the encoder, see
+// EncoderOptions.CommentStyle.
+type CommentStyle int
+
+const (
+	// CommentStyleDefault writes comments from struct field tags with "#" and
+	// comments from hjson.Node trees as they are.
+	CommentStyleDefault CommentStyle = iota
+	// CommentStyleHash writes all comments as lines starting with "#".
+	CommentStyleHash
+	// CommentStyleSlash writes all comments as lines starting with "//".
+	CommentStyleSlash
+	// CommentStyleBlock writes all comments enclosed in "/*" and "*/".
+	CommentStyleBlock
+)
+
+// lineComment returns the text of a line comment containing text, using the
+// marker of style, or false if it cannot be written in that style.
+func (style CommentStyle) lineComment(text string) (string, bool) {
+	switch style {
+	case CommentStyleSlash:
+		return "//" + text, true
+	case CommentStyleBlock:
+		if strings.Contains(text, "*/") {
+			return "", false
+		}
+		if strings.HasSuffix(text, " ") || text == "" {
+			return "/*" + text + "*/", true
+		}
+		return "/*" + text + " */", true
+	}
+	return "#" + text, true
+}
+
+// tagComment returns the line of a comment set with the "comment" tag on a
+// struct field.
+func (style CommentStyle) tagComment(line string) string {
+	if cm, ok := style.lineComment(" " + line); ok {
+		return cm
+	}
+	return "# " + line
+}
+
+// restyle returns cm with the comment markers of all comments in it changed
+// to style.
+func (style CommentStyle) restyle(cm Comments) Comments {
+	if style == CommentStyleDefault {
+		return cm
+	}
+	cm.Before = style.restyleText(cm.Before, false)
+	cm.Key = style.restyleText(cm.Key, false)
+	cm.InsideFirst = style.restyleText(cm.InsideFirst, true)
+	cm.InsideLast = style.restyleText(cm.InsideLast, false)
+	cm.After = style.restyleText(cm.After, true)
+	return cm
+}
+
+// restyleText changes the markers of the comments in txt, which holds comments
+// and whitespace. A block comment can only be changed to line comments if
+// nothing but whitespace follows it on its line, so lineEnds must be true if
+// txt is always followed by a line feed. Comments that cannot be written in
+// the style are kept as they are.
+func (style CommentStyle) restyleText(txt string, lineEnds bool) string {
+	if !strings.ContainsAny(txt, "#/") {
+		return txt
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(txt); {
+		rest := txt[i:]
+		switch {
+		case rest[0] == '#' || strings.HasPrefix(rest, "//"):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			line := strings.TrimSuffix(rest[:end], "\r")
+			text := line[1:]
+			if line[0] == '/' {
+				text = line[2:]
+			}
+			if cm, ok := style.lineComment(text); ok {
+				sb.WriteString(cm)
+			} else {
+				sb.WriteString(line)
+			}
+			i += len(line)
+
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				sb.WriteString(rest)
+				return sb.String()
+			}
+			block := rest[:end+4]
+			i += len(block)
+			if style == CommentStyleBlock {
+				sb.WriteString(block)
+				continue
+			}
+			after := strings.TrimLeft(txt[i:], " \t")
+			if !(after == "" && lineEnds) && !strings.HasPrefix(after, "\n") &&
+				!strings.HasPrefix(after, "\r\n") {
+				// Something follows on the same line.
+				sb.WriteString(block)
+				continue
+			}
+			// Indent continuation lines like the first line of the comment.
+			start := strings.LastIndexByte(txt[:i-len(block)], '\n') + 1
+			indent := txt[start : i-len(block)]
+			if strings.Trim(indent, " \t") != "" {
+				indent = ""
+			}
+			var lines []string
+			for n, line := range strings.Split(block[2:len(block)-2], "\n") {
+				line = strings.TrimRight(line, " \t\r")
+				if n > 0 {
+					line = strings.TrimLeft(line, " \t")
+					if strings.HasPrefix(line, "*") {
+						line = line[1:]
+					} else if line != "" {
+						line = " " + line
+					}
+				}
+				lines = append(lines, line)
+			}
+			if len(lines) > 1 && lines[0] == "" {
+				lines = lines[1:]
+			}
+			if len(lines) > 1 && lines[len(lines)-1] == "" {
+				lines = lines[:len(lines)-1]
+			}
+			for n, line := range lines {
+				if n > 0 {
+					sb.WriteString("\n" + indent)
+				}
+				cm, _ := style.lineComment(line)
+				sb.WriteString(cm)
+			}
+
+		default:
+			sb.WriteByte(rest[0])
+			i++
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,290 @@
+package hjson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// destFixup is a value that cannot be unmarshalled by json.Unmarshal(), for
+// example a complex number, a RawMessage or a value returned by a Literal
+// for an interface{} destination. The parser outputs null in its
+// place and stores the value here, to be assigned to the destination after
+// json.Unmarshal().
+type destFixup struct {
+	// path holds the object keys (string) and array indexes (int) leading to
+	// the value from the root of the destination.
+	path []interface{}
+	// value is a complex128, a RawMessage or a value returned by a Literal.
+	value interface{}
+	// ignorable is true if the value might have no destination, like the
+	// values of unknown struct fields that json.Unmarshal() ignores.
+	ignorable bool
+}
+
+// describe returns a description of the value for error messages.
+func (f destFixup) describe() string {
+	if c, ok := f.value.(complex128); ok {
+		return "complex number " + formatComplex(c, 128)
+	}
+	if _, ok := f.value.(RawMessage); ok {
+		return "hjson.RawMessage"
+	}
+	return fmt.Sprintf("literal %v", f.value)
+}
+
+func isComplexKind(t reflect.Type) bool {
+	return t != nil && (t.Kind() == reflect.Complex64 || t.Kind() == reflect.Complex128)
+}
+
+// formatComplex formats c like "1.5+2i", using the shortest representation of
+// the real and imaginary parts for the given bit size (64 for complex64, 128
+// for complex128).
+func formatComplex(c complex128, bitSize int) string {
+	bitSize /= 2
+	re := strconv.FormatFloat(real(c), 'g', -1, bitSize)
+	im := strconv.FormatFloat(imag(c), 'g', -1, bitSize)
+	if im[0] != '-' && im[0] != '+' {
+		im = "+" + im
+	}
+	return re + im + "i"
+}
+
+// parseComplex parses strings like "1+2i", "-0.5e3-1i", "2i", "i" or "3",
+// optionally surrounded by parentheses.
+func parseComplex(s string) (complex128, error) {
+	orig := s
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && s[0] == '(' && s[len(s)-1] == ')' {
+		s = s[1 : len(s)-1]
+	}
+	if s == "" {
+		return 0, errors.New("invalid complex number " + strconv.Quote(orig))
+	}
+
+	reStr, imStr := s, ""
+	if s[len(s)-1] == 'i' {
+		body := s[:len(s)-1]
+		// Find the sign starting the imaginary part, skipping signs of
+		// exponents.
+		k := len(body) - 1
+		for ; k > 0; k-- {
+			if (body[k] == '+' || body[k] == '-') && body[k-1] != 'e' && body[k-1] != 'E' {
+				break
+			}
+		}
+		if k > 0 {
+			reStr, imStr = body[:k], body[k:]
+		} else {
+			reStr, imStr = "0", body
+		}
+		if imStr == "" || imStr == "+" || imStr == "-" {
+			imStr += "1"
+		}
+	}
+
+	re, err := strconv.ParseFloat(reStr, 64)
+	if err != nil {
+		return 0, errors.New("invalid complex number " + strconv.Quote(orig))
+	}
+	var im float64
+	if imStr != "" {
+		if im, err = strconv.ParseFloat(imStr, 64); err != nil {
+			return 0, errors.New("invalid complex number " + strconv.Quote(orig))
+		}
+	}
+	return complex(re, im), nil
+}
+
+// toComplex converts a value created by the parser to a complex number. Valid
+// values are numbers, strings accepted by parseComplex() and arrays containing
+// two numbers (the real and imaginary parts).
+func toComplex(v interface{}) (complex128, error) {
+	toFloat := func(v interface{}) (float64, bool) {
+		switch v := v.(type) {
+		case float64:
+			return v, true
+		case json.Number:
+			f, err := v.Float64()
+			return f, err == nil
+		}
+		return 0, false
+	}
+
+	switch v := v.(type) {
+	case string:
+		return parseComplex(v)
+	case []interface{}:
+		if len(v) == 2 {
+			re, ok1 := toFloat(v[0])
+			im, ok2 := toFloat(v[1])
+			if ok1 && ok2 {
+				return complex(re, im), nil
+			}
+		}
+		return 0, errors.New("an array must contain exactly two numbers to represent a complex number")
+	}
+	if f, ok := toFloat(v); ok {
+		return complex(f, 0), nil
+	}
+	return 0, errors.New("invalid complex number")
+}
+
+// readComplex reads a value for a destination of complex type. It returns nil
+// and stores the complex number in p.fixups.
+func (p *hjsonParser) readComplex() (interface{}, error) {
+	p.white()
+	start := p.at
+	val, err := p.readValue(reflect.Value{}, nil)
+	if err != nil || val == nil {
+		return nil, err
+	}
+	c, err := toComplex(val)
+	if err != nil {
+		// Report the position of the value.
+		p.at = start
+		return nil, p.errAt(MsgBadComplex, err.Error())
+	}
+	p.fixups = append(p.fixups, destFixup{
+		path:  append([]interface{}(nil), p.path...),
+		value: c,
+	})
+	return nil, nil
+}
+
+// applyFixup assigns value to the part of the destination rv found by
+// following path. Returns false if the path cannot be followed.
+func applyFixup(rv reflect.Value, path []interface{}, value interface{}) bool {
+	for a := 0; a < maxPointerDepth && (rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface); a++ {
+		if rv.Kind() == reflect.Interface {
+			if rv.IsNil() || rv.Elem().Kind() != reflect.Ptr {
+				if len(path) == 0 && rv.CanSet() {
+					// Replace the placeholder in an interface{}.
+					break
+				}
+				if !rv.IsNil() && (rv.Elem().Kind() == reflect.Map || rv.Elem().Kind() == reflect.Slice) {
+					// Elements of maps and slices can still be changed.
+					rv = rv.Elem()
+					break
+				}
+				return false
+			}
+			rv = rv.Elem()
+			continue
+		}
+		if rv.IsNil() {
+			if !rv.CanSet() {
+				return false
+			}
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		rv = rv.Elem()
+	}
+
+	if isOptionalType(rv.Type()) {
+		if !rv.CanSet() {
+			return false
+		}
+		// Present and not Null, even if the placeholder null was decoded.
+		rv.Field(1).SetBool(true)
+		rv.Field(2).SetBool(false)
+		return applyFixup(rv.Field(0), path, value)
+	}
+
+	if len(path) == 0 {
+		if !rv.CanSet() {
+			return false
+		}
+		if c, ok := value.(complex128); ok {
+			if !isComplexKind(rv.Type()) {
+				return false
+			}
+			rv.SetComplex(c)
+			return true
+		}
+		if f, ok := value.(float64); ok && (rv.Kind() == reflect.Float32 || rv.Kind() == reflect.Float64) {
+			// A non-finite number, see DecoderOptions.NonFiniteNumbers.
+			rv.SetFloat(f)
+			return true
+		}
+		vv := reflect.ValueOf(value)
+		if !vv.Type().AssignableTo(rv.Type()) {
+			return false
+		}
+		rv.Set(vv)
+		return true
+	}
+
+	switch key := path[0].(type) {
+	case int:
+		if (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) || key >= rv.Len() {
+			return false
+		}
+		return applyFixup(rv.Index(key), path[1:], value)
+
+	case string:
+		switch rv.Kind() {
+		case reflect.Struct:
+			sfi, ok := getStructFieldInfoMap(rv.Type()).getField(key)
+			if !ok {
+				return false
+			}
+			for i, index := range sfi.indexPath {
+				if i > 0 && rv.Kind() == reflect.Ptr {
+					if rv.IsNil() {
+						if !rv.CanSet() {
+							return false
+						}
+						rv.Set(reflect.New(rv.Type().Elem()))
+					}
+					rv = rv.Elem()
+				}
+				rv = rv.Field(index)
+			}
+			return applyFixup(rv, path[1:], value)
+
+		case reflect.Map:
+			if rv.IsNil() {
+				return false
+			}
+			kt := rv.Type().Key()
+			var kv reflect.Value
+			switch kt.Kind() {
+			case reflect.String:
+				kv = reflect.ValueOf(key).Convert(kt)
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				n, err := strconv.ParseInt(key, 10, 64)
+				if err != nil {
+					return false
+				}
+				kv = reflect.New(kt).Elem()
+				kv.SetInt(n)
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+				reflect.Uintptr:
+				n, err := strconv.ParseUint(key, 10, 64)
+				if err != nil {
+					return false
+				}
+				kv = reflect.New(kt).Elem()
+				kv.SetUint(n)
+			default:
+				return false
+			}
+			// Map elements are not addressable, so update a copy.
+			elem := reflect.New(rv.Type().Elem()).Elem()
+			if old := rv.MapIndex(kv); old.IsValid() {
+				elem.Set(old)
+			}
+			if !applyFixup(elem, path[1:], value) {
+				return false
+			}
+			rv.SetMapIndex(kv, elem)
+			return true
+		}
+	}
+
+	return false
+}
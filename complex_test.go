@@ -0,0 +1,166 @@
+package hjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseComplex(t *testing.T) {
+	for txt, expected := range map[string]complex128{
+		"1+2i":       1 + 2i,
+		"-1.5-0.5i":  -1.5 - 0.5i,
+		"(3-4i)":     3 - 4i,
+		"2i":         2i,
+		"-i":         -1i,
+		"7":          7,
+		"1e3+2e-1i":  1000 + 0.2i,
+		" 1.5E+2+i ": 150 + 1i,
+	} {
+		c, err := parseComplex(txt)
+		if err != nil {
+			t.Errorf("%q: %v", txt, err)
+		} else if c != expected {
+			t.Errorf("%q: expected %v, got %v", txt, expected, c)
+		}
+	}
+	for _, txt := range []string{"", "x", "1+2j", "1+xi", "()"} {
+		if _, err := parseComplex(txt); err == nil {
+			t.Errorf("%q: expected error", txt)
+		}
+	}
+}
+
+func TestComplex(t *testing.T) {
+	type sub struct {
+		Z complex64
+	}
+	type foo struct {
+		A complex128
+		B *complex128
+		C []complex128
+		D map[string]complex64
+		E sub
+		F *sub
+		G complex128
+		H int
+	}
+	var v foo
+	err := Unmarshal([]byte(`{
+  a: 1+2i
+  b: [3, -4]
+  c: [
+    5
+    "1e1-1i"
+    [0, 1]
+  ]
+  d: {
+    x: (1-1i)
+  }
+  e: {
+    z: 2i
+  }
+  f: {z: 0.5}
+  g: null
+  h: 3
+}`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := 3 - 4i
+	expected := foo{
+		A: 1 + 2i,
+		B: &b,
+		C: []complex128{5, 10 - 1i, 1i},
+		D: map[string]complex64{"x": 1 - 1i},
+		E: sub{2i},
+		F: &sub{0.5},
+		H: 3,
+	}
+	if !reflect.DeepEqual(v, expected) {
+		t.Errorf("Expected %#v, got %#v", expected, v)
+	}
+
+	h, err := Marshal(expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareStrings(t, h, `{
+  A: 1+2i
+  B: 3-4i
+  C: [
+    5+0i
+    10-1i
+    0+1i
+  ]
+  D: {
+    x: 1-1i
+  }
+  E: {
+    Z: 0+2i
+  }
+  F: {
+    Z: 0.5+0i
+  }
+  G: 0+0i
+  H: 3
+}`)
+	var v2 foo
+	if err = Unmarshal(h, &v2); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(v2, expected) {
+		t.Errorf("Expected %#v, got %#v", expected, v2)
+	}
+
+	var c complex64
+	if err = Unmarshal([]byte("[1.5, 2]"), &c); err != nil || c != 1.5+2i {
+		t.Errorf("Unexpected result %v, %v", c, err)
+	}
+
+	for _, txt := range []string{"{\na: [1, 2, 3]\n}", "{\na: x\n}", "{\na: true\n}", "{\na: {}\n}"} {
+		if err = Unmarshal([]byte(txt), &v); err == nil {
+			t.Errorf("%s: expected error", txt)
+		}
+	}
+	err = Unmarshal([]byte("{\n  h: 1\n  a: 1+xi\n}"), &v)
+	if err == nil || err.Error() != `Cannot unmarshal into complex number: invalid complex number "1+xi" at line 3,6 >>>   a: 1+xi` {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
+
+func TestRune(t *testing.T) {
+	type foo struct {
+		A int32  `hjson:",rune"`
+		B *int32 `json:"b" hjson:",rune"`
+		C int32  `hjson:",rune"`
+		D int32
+	}
+	var v foo
+	err := Unmarshal([]byte(`{
+  A: x
+  b: 名
+  C: 65
+  D: 66
+}`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.A != 'x' || v.B == nil || *v.B != '名' || v.C != 65 || v.D != 66 {
+		t.Errorf("Unexpected result %#v", v)
+	}
+
+	h, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareStrings(t, h, `{
+  A: x
+  b: 名
+  C: A
+  D: 66
+}`)
+
+	if err = Unmarshal([]byte("{A: xy}"), &v); err == nil {
+		t.Error("Expected error for a string with more than one character")
+	}
+}
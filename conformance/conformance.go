@@ -0,0 +1,262 @@
+// Package conformance runs the Hjson test corpus of this module against any
+// implementation of an Hjson decoder and encoder, so that alternative code
+// paths (for example streaming or Node based decoding) can be verified
+// against the same fixtures as hjson.Unmarshal() and hjson.Marshal().
+//
+// The corpus is read from the assets directory of the module. Each case
+// consists of an input file named like "name_test.hjson" (or
+// "name_test.json") and, unless the name starts with "fail", the expected
+// results in the subdirectories "sorted", "comments2" and "comments3".
+package conformance
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/bingoohuang/hjson"
+)
+
+// Interface is an Hjson implementation to be checked against the corpus.
+type Interface interface {
+	// Decode decodes data into a tree of interface{}, map[string]interface{},
+	// []interface{}, string, float64, bool and nil values, like
+	// hjson.Unmarshal() does for an *interface{} destination.
+	Decode(data []byte) (interface{}, error)
+	// Encode encodes v, a value returned by Decode(), like hjson.Marshal().
+	Encode(v interface{}) ([]byte, error)
+}
+
+// CommentsInterface is implemented by implementations that also keep
+// comments. Its method is only called for inputs that Decode() accepts.
+type CommentsInterface interface {
+	Interface
+	// Reformat decodes data with its comments and encodes it again, like
+	// hjson.Marshal() does for an hjson.Node decoded from data with
+	// DecoderOptions.WhitespaceAsComments set to whitespaceAsComments.
+	Reformat(data []byte, whitespaceAsComments bool) ([]byte, error)
+}
+
+// Case is a test case of the corpus.
+type Case struct {
+	// Name is the name of the case, like "comments" or "failJSON02".
+	Name string
+	// Input is the Hjson or JSON input.
+	Input []byte
+	// ShouldFail is true if Input is invalid and must be rejected.
+	ShouldFail bool
+	// JSON is the decoded value, indented by two spaces by
+	// json.MarshalIndent(), with a line feed at the end.
+	JSON []byte
+	// Hjson is the decoded value as encoded by hjson.Marshal(), with a line
+	// feed at the end.
+	Hjson []byte
+	// Comments is Input decoded with its comments, but without whitespace,
+	// and encoded again.
+	Comments []byte
+	// Whitespace is Input decoded with its comments and whitespace, and
+	// encoded again.
+	Whitespace []byte
+}
+
+// Dir returns the assets directory of the module, as found next to the
+// source of this package.
+func Dir() string {
+	_, file, _, ok := runtime.Caller(0)
+	if !ok {
+		return "assets"
+	}
+	return filepath.Join(filepath.Dir(file), "..", "assets")
+}
+
+// Load reads the cases listed in the file testlist.txt in dir. Cases that
+// test encoder options other than the defaults are left out.
+func Load(dir string) ([]Case, error) {
+	list, err := readFile(filepath.Join(dir, "testlist.txt"))
+	if err != nil {
+		return nil, err
+	}
+	var cases []Case
+	for _, file := range strings.Split(string(list), "\n") {
+		file = strings.TrimSpace(file)
+		if file == "" || strings.HasPrefix(file, "stringify/quotes") ||
+			strings.HasPrefix(file, "extra/") {
+
+			continue
+		}
+		c, err := loadCase(dir, file)
+		if err != nil {
+			return nil, err
+		}
+		cases = append(cases, c)
+	}
+	return cases, nil
+}
+
+func loadCase(dir, file string) (Case, error) {
+	name := strings.TrimSuffix(file, "_test"+filepath.Ext(file))
+	c := Case{Name: name, ShouldFail: strings.HasPrefix(name, "fail")}
+	var err error
+	if c.Input, err = readFile(filepath.Join(dir, file)); err != nil {
+		return c, err
+	}
+	if c.ShouldFail {
+		return c, nil
+	}
+	for _, r := range []struct {
+		dest *[]byte
+		path string
+	}{
+		{&c.JSON, filepath.Join(dir, "sorted", name+"_result.json")},
+		{&c.Hjson, filepath.Join(dir, "sorted", name+"_result.hjson")},
+		{&c.Comments, filepath.Join(dir, "comments2", name+"_result.hjson")},
+		{&c.Whitespace, filepath.Join(dir, "comments3", name+"_result.hjson")},
+	} {
+		if *r.dest, err = readFile(r.path); err != nil {
+			return c, err
+		}
+	}
+	return c, nil
+}
+
+// readFile reads a file of the corpus. The line feeds are converted to "\n"
+// in case git has converted them to "\r\n".
+func readFile(path string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.Replace(data, []byte("\r\n"), []byte("\n"), -1), nil
+}
+
+// RunConformance runs each case of the corpus found in Dir() as a subtest of
+// t, checking the results of impl. If impl implements CommentsInterface the
+// comments are checked too.
+func RunConformance(t *testing.T, impl Interface) {
+	cases, err := Load(Dir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			if err := Check(c, impl); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// Check returns an error describing the first result of impl that differs
+// from the expected result of c.
+func Check(c Case, impl Interface) error {
+	data, err := impl.Decode(c.Input)
+	if c.ShouldFail {
+		if err == nil {
+			return errors.New(c.Name + " should fail")
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	actualJSON, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := compare(c.Name, "json", c.JSON, append(fixJSON(actualJSON), '\n')); err != nil {
+		return err
+	}
+	actualHjson, err := impl.Encode(data)
+	if err != nil {
+		return err
+	}
+	if err := compare(c.Name, "hjson", c.Hjson, append(actualHjson, '\n')); err != nil {
+		return err
+	}
+
+	cm, ok := impl.(CommentsInterface)
+	if !ok {
+		return nil
+	}
+	for _, r := range []struct {
+		kind     string
+		expected []byte
+		ws       bool
+	}{
+		{"comments", c.Comments, false},
+		{"whitespace", c.Whitespace, true},
+	} {
+		actual, err := cm.Reformat(c.Input, r.ws)
+		if err != nil {
+			return err
+		}
+		if len(actual) > 0 && actual[len(actual)-1] != '\n' {
+			actual = append(actual, '\n')
+		}
+		if err := compare(c.Name, r.kind, r.expected, actual); err != nil {
+			return err
+		}
+		roundTrip, err := impl.Decode(actual)
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(data, roundTrip) {
+			return fmt.Errorf("%s: %s round trip failed", c.Name, r.kind)
+		}
+	}
+	return nil
+}
+
+func compare(name, kind string, expected, actual []byte) error {
+	if bytes.Equal(expected, actual) {
+		return nil
+	}
+	return fmt.Errorf("%s\n---%s expected\n%s\n---%s actual\n%s\n---", name, kind, expected, kind,
+		actual)
+}
+
+// fixJSON undoes the escaping of HTML characters by json.Marshal(), which
+// the expected results do not use.
+func fixJSON(data []byte) []byte {
+	data = bytes.Replace(data, []byte("\\u003c"), []byte("<"), -1)
+	data = bytes.Replace(data, []byte("\\u003e"), []byte(">"), -1)
+	data = bytes.Replace(data, []byte("\\u0026"), []byte("&"), -1)
+	data = bytes.Replace(data, []byte("\\u0008"), []byte("\\b"), -1)
+	data = bytes.Replace(data, []byte("\\u000c"), []byte("\\f"), -1)
+	return data
+}
+
+// Default is the implementation of this module: hjson.Unmarshal(),
+// hjson.Marshal() and hjson.Node for comments.
+var Default CommentsInterface = defaultImpl{}
+
+type defaultImpl struct{}
+
+func (defaultImpl) Decode(data []byte) (interface{}, error) {
+	var v interface{}
+	err := hjson.Unmarshal(data, &v)
+	return v, err
+}
+
+func (defaultImpl) Encode(v interface{}) ([]byte, error) {
+	return hjson.Marshal(v)
+}
+
+func (defaultImpl) Reformat(data []byte, whitespaceAsComments bool) ([]byte, error) {
+	var node hjson.Node
+	options := hjson.DefaultDecoderOptions()
+	options.WhitespaceAsComments = whitespaceAsComments
+	if err := hjson.UnmarshalWithOptions(data, &node, options); err != nil {
+		return nil, err
+	}
+	return hjson.Marshal(node)
+}
@@ -0,0 +1,74 @@
+package conformance
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/bingoohuang/hjson"
+)
+
+func TestDefault(t *testing.T) {
+	RunConformance(t, Default)
+}
+
+// streamImpl decodes with an hjson.Decoder, which must find exactly one
+// value in the input.
+type streamImpl struct{ defaultImpl }
+
+func (streamImpl) Decode(data []byte) (interface{}, error) {
+	dec := hjson.NewDecoder(bytes.NewReader(data))
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	var extra interface{}
+	if err := dec.Decode(&extra); err != io.EOF {
+		return nil, errTrailing
+	}
+	return v, nil
+}
+
+var errTrailing = errors.New("more than one value in the input")
+
+func TestStream(t *testing.T) {
+	RunConformance(t, streamImpl{})
+}
+
+// nodeImpl decodes with hjson.ParseNode() and converts the tree of Nodes.
+type nodeImpl struct{}
+
+func (nodeImpl) Decode(data []byte) (interface{}, error) {
+	node, err := hjson.ParseNode(data)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := json.Marshal(node)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	err = json.Unmarshal(buf, &v)
+	return v, err
+}
+
+func (nodeImpl) Encode(v interface{}) ([]byte, error) {
+	return hjson.Marshal(v)
+}
+
+func TestNode(t *testing.T) {
+	RunConformance(t, nodeImpl{})
+}
+
+func TestCheck(t *testing.T) {
+	c := Case{Name: "wrong", Input: []byte("a: 1"), JSON: []byte("{\n  \"a\": 2\n}\n")}
+	if err := Check(c, Default); err == nil {
+		t.Error("Expected an error for a wrong result")
+	}
+	c = Case{Name: "failWrong", Input: []byte("a: 1"), ShouldFail: true}
+	if err := Check(c, Default); err == nil {
+		t.Error("Expected an error for accepted input")
+	}
+}
@@ -0,0 +1,227 @@
+package hjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// ToJSON converts the Hjson document data to compact JSON. Unlike decoding
+// into interface{} and calling json.Marshal(), the keys of each object are
+// written in their original order, numbers are written with the digits of
+// their original text (so that big integers keep their precision) and
+// characters like < and & are not escaped. Comments are left out. Duplicate
+// keys are written as they are found, no tree of values is built.
+//
+// ToJSON is a shorthand for Transcode(data, FormatJSON).
+func ToJSON(data []byte) ([]byte, error) {
+	return Transcode(data, FormatJSON)
+}
+
+// FromJSON converts the JSON document data to Hjson, formatted like Marshal()
+// does using the default options. The keys of each object keep their order
+// and numbers keep the digits of their original text, so that no information
+// is lost. An error is returned if data is not valid JSON.
+func FromJSON(data []byte) ([]byte, error) {
+	var raw json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	return Transcode(data, FormatHjson)
+}
+
+// Transcode converts the Hjson (or JSON) document src to dstFormat, which
+// must be FormatJSON or FormatHjson. The events of the parser are written
+// directly to the output, without decoding src into Go values, which saves
+// memory and time for large documents. Numbers are written with the digits
+// of their original text and keys are written in their original order.
+// Comments are left out.
+//
+// FormatJSON gives compact JSON, like ToJSON(). FormatHjson gives the same
+// output as Marshal() would for the decoded document, using the default
+// options.
+func Transcode(src []byte, dstFormat Format) ([]byte, error) {
+	var w transcoder
+	switch dstFormat {
+	case FormatJSON:
+		w = &jsonWriter{}
+	case FormatHjson:
+		w = newHjsonWriter()
+	default:
+		return nil, fmt.Errorf("hjson: cannot transcode to %v", dstFormat)
+	}
+	options := DefaultDecoderOptions()
+	options.UseJSONNumber = true
+	if err := parseWithOptions(src, w, options); err != nil {
+		return nil, err
+	}
+	return w.Bytes(), nil
+}
+
+// transcoder is a Handler writing a document in another format.
+type transcoder interface {
+	Handler
+	Bytes() []byte
+}
+
+// jsonWriter is a Handler writing compact JSON.
+type jsonWriter struct {
+	BaseHandler
+	bytes.Buffer
+	// hasElems tells for each open object or array if anything has been
+	// written in it, so that the next value needs a comma.
+	hasElems []bool
+	afterKey bool
+}
+
+// sep writes a comma if needed before the next key, or value in an array.
+func (w *jsonWriter) sep() {
+	if w.afterKey {
+		w.afterKey = false
+		return
+	}
+	if n := len(w.hasElems); n > 0 {
+		if w.hasElems[n-1] {
+			w.WriteByte(',')
+		}
+		w.hasElems[n-1] = true
+	}
+}
+
+func (w *jsonWriter) start(delim byte) error {
+	w.sep()
+	w.WriteByte(delim)
+	w.hasElems = append(w.hasElems, false)
+	return nil
+}
+
+func (w *jsonWriter) end(delim byte) error {
+	w.WriteByte(delim)
+	w.hasElems = w.hasElems[:len(w.hasElems)-1]
+	return nil
+}
+
+func (w *jsonWriter) ObjectStart(pos Position) error { return w.start('{') }
+func (w *jsonWriter) ObjectEnd(pos Position) error   { return w.end('}') }
+func (w *jsonWriter) ArrayStart(pos Position) error  { return w.start('[') }
+func (w *jsonWriter) ArrayEnd(pos Position) error    { return w.end(']') }
+
+func (w *jsonWriter) Key(key string, pos Position) error {
+	w.sep()
+	w.writeString(key)
+	w.WriteByte(':')
+	w.afterKey = true
+	return nil
+}
+
+func (w *jsonWriter) Value(value interface{}, pos Position) error {
+	w.sep()
+	switch v := value.(type) {
+	case nil:
+		w.WriteString("null")
+	case bool:
+		if v {
+			w.WriteString("true")
+		} else {
+			w.WriteString("false")
+		}
+	case string:
+		w.writeString(v)
+	case json.Number:
+		w.WriteString(string(v))
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		w.Write(b)
+	}
+	return nil
+}
+
+func (w *jsonWriter) writeString(s string) {
+	var e hjsonEncoder
+	w.WriteString(`"` + e.quoteReplace(s) + `"`)
+}
+
+// hjsonWriter is a Handler writing Hjson, formatted like Marshal() does.
+type hjsonWriter struct {
+	BaseHandler
+	e hjsonEncoder
+	// elems holds, for each open object or array, the number of members or
+	// elements written in it. isObject tells which of them are objects.
+	elems    []int
+	isObject []bool
+	afterKey bool
+}
+
+func newHjsonWriter() *hjsonWriter {
+	return &hjsonWriter{e: hjsonEncoder{EncoderOptions: DefaultOptions()}}
+}
+
+func (w *hjsonWriter) Bytes() []byte {
+	return w.e.Bytes()
+}
+
+// inArray returns true if the next value is an element of an array.
+func (w *hjsonWriter) inArray() bool {
+	n := len(w.isObject)
+	return n > 0 && !w.isObject[n-1]
+}
+
+// beforeValue writes what precedes a value that is not an object member.
+func (w *hjsonWriter) beforeValue() {
+	if w.inArray() {
+		w.elems[len(w.elems)-1]++
+		w.e.writeIndent(len(w.elems))
+	}
+}
+
+func (w *hjsonWriter) start(delim string, isObject bool) error {
+	if w.afterKey {
+		w.afterKey = false
+		w.e.WriteString(" ")
+	} else {
+		w.beforeValue()
+	}
+	w.e.WriteString(delim)
+	w.elems = append(w.elems, 0)
+	w.isObject = append(w.isObject, isObject)
+	return nil
+}
+
+func (w *hjsonWriter) end(delim string) error {
+	n := len(w.elems)
+	if w.elems[n-1] > 0 {
+		w.e.writeIndent(n - 1)
+	}
+	w.e.WriteString(delim)
+	w.elems, w.isObject = w.elems[:n-1], w.isObject[:n-1]
+	return nil
+}
+
+func (w *hjsonWriter) ObjectStart(pos Position) error { return w.start("{", true) }
+func (w *hjsonWriter) ObjectEnd(pos Position) error   { return w.end("}") }
+func (w *hjsonWriter) ArrayStart(pos Position) error  { return w.start("[", false) }
+func (w *hjsonWriter) ArrayEnd(pos Position) error    { return w.end("]") }
+
+func (w *hjsonWriter) Key(key string, pos Position) error {
+	w.elems[len(w.elems)-1]++
+	w.e.writeIndent(len(w.elems))
+	w.e.WriteString(w.e.quoteName(key) + ":")
+	w.afterKey = true
+	return nil
+}
+
+func (w *hjsonWriter) Value(value interface{}, pos Position) error {
+	w.e.indent = len(w.elems)
+	rv := reflect.ValueOf(value)
+	if w.afterKey {
+		w.afterKey = false
+		return w.e.str(rv, false, " ", false, true, Comments{})
+	}
+	isRoot := len(w.elems) == 0
+	w.beforeValue()
+	return w.e.str(rv, true, "", isRoot, false, Comments{})
+}
@@ -0,0 +1,123 @@
+package hjson
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestToJSON(t *testing.T) {
+	testCases := []struct {
+		in, out string
+	}{
+		{"", "{}"},
+		{"3", "3"},
+		{"null", "null"},
+		{"[true, false, null]", "[true,false,null]"},
+		{"# comment\nz: 1\na: 12345678901234567890\nm: <b> & c\n", `{"z":1,"a":12345678901234567890,"m":"<b> & c"}`},
+		{"{\n  b: [\n    1.5e3\n    {}\n    []\n  ]\n  q: '''\n    x\n    \"y\"\n    '''\n}", `{"b":[1.5e3,{},[]],"q":"x\n\"y\""}`},
+		{"a: 1\na: 2", `{"a":1,"a":2}`},
+		{"\"\u2028😀\"", `"\u2028😀"`},
+	}
+	for _, tc := range testCases {
+		out, err := ToJSON([]byte(tc.in))
+		if err != nil {
+			t.Errorf("Input %q: %v", tc.in, err)
+			continue
+		}
+		if string(out) != tc.out {
+			t.Errorf("Input %q, expected:\n%s\nGot:\n%s", tc.in, tc.out, out)
+		}
+		if !json.Valid(out) {
+			t.Errorf("Invalid JSON for input %q: %s", tc.in, out)
+		}
+	}
+
+	if _, err := ToJSON([]byte("{a: 1")); err == nil {
+		t.Error("Expected an error for invalid input")
+	}
+	if _, err := ToJSON([]byte("1\n2")); err == nil {
+		t.Error("Expected an error for trailing characters")
+	}
+}
+
+func TestTranscode(t *testing.T) {
+	inputs := []string{
+		"",
+		"3",
+		"null",
+		"[]",
+		"{}",
+		"x y z",
+		"# comment\nz: 1\na: 12345678901234567890\nm: <b> & c\n",
+		"{\n  b: [\n    1.5e3\n    {}\n    []\n    [[1], {c: null}]\n    text\n  ]\n  q: '''\n    x\n    \"y\"\n    '''\n  \"a key\": \"007\"\n  e: {}\n}",
+		"[\n  '''\n  multi\n  line\n  '''\n  true\n  \"\"\n]",
+	}
+	opt := DefaultDecoderOptions()
+	opt.UseJSONNumber = true
+	encOpt := DefaultOptions()
+	encOpt.Comments = false
+	for _, in := range inputs {
+		var node *Node
+		if err := UnmarshalWithOptions([]byte(in), &node, opt); err != nil {
+			t.Fatal(err)
+		}
+		expected, err := MarshalWithOptions(node, encOpt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := Transcode([]byte(in), FormatHjson)
+		if err != nil {
+			t.Errorf("Input %q: %v", in, err)
+			continue
+		}
+		if string(out) != string(expected) {
+			t.Errorf("Input %q, expected:\n%s\nGot:\n%s", in, expected, out)
+		}
+	}
+
+	if _, err := Transcode([]byte("a: 1"), FormatJSON5); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+}
+
+func TestFromJSON(t *testing.T) {
+	in := `{"zeta": 1, "alpha": {"n": 12345678901234567890, "s": "two\nlines", "e": []}, "list": ["a b", "1", true]}`
+	expected := `{
+  zeta: 1
+  alpha: {
+    n: 12345678901234567890
+    s:
+      '''
+      two
+      lines
+      '''
+    e: []
+  }
+  list: [
+    a b
+    "1"
+    true
+  ]
+}`
+	out, err := FromJSON([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, out)
+	}
+
+	back, err := ToJSON(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(back) != `{"zeta":1,"alpha":{"n":12345678901234567890,"s":"two\nlines","e":[]},"list":["a b","1",true]}` {
+		t.Errorf("Unexpected round trip: %s", back)
+	}
+
+	for _, in := range []string{"", "{a: 1}", `{"a": 1,}`, "[1] [2]"} {
+		if _, err := FromJSON([]byte(in)); err == nil {
+			t.Errorf("Expected an error for %q", in)
+		}
+	}
+}
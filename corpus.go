@@ -0,0 +1,156 @@
+package hjson
+
+import (
+	"math/rand"
+	"strconv"
+)
+
+// CorpusSpec describes the synthetic documents created by GenerateCorpus().
+// Zero fields are replaced by the defaults noted for each field.
+type CorpusSpec struct {
+	// Documents is the number of documents to create. Default 1.
+	Documents int
+	// Depth is the max nesting depth of objects and arrays, where the root
+	// object has depth 1. Default 3.
+	Depth int
+	// Width is the max number of members of each object and elements of each
+	// array. The number is chosen at random between 1 and Width. Default 8.
+	Width int
+	// MinStringLen and MaxStringLen are the limits of the length in bytes of
+	// string values, chosen at random with a uniform distribution. Defaults 1
+	// and 16.
+	MinStringLen int
+	MaxStringLen int
+	// MultilineRatio is the share of string values, from 0 to 1, that can
+	// contain line feeds and are then written as multiline strings.
+	MultilineRatio float64
+	// Comments causes a comment to be written before every object member.
+	Comments bool
+	// Seed seeds the random numbers, so that the same spec always creates
+	// the same documents.
+	Seed int64
+}
+
+// GenerateCorpus creates synthetic Hjson documents with the shape described by
+// spec, for benchmarks and for validating the throughput of applications. The
+// root value of each document is an object, written by Marshal() with the
+// default options. Each object member and array element holds an object, an
+// array, a string, a number, a boolean or null, with objects and arrays only
+// used up to the max depth.
+func GenerateCorpus(spec CorpusSpec) [][]byte {
+	if spec.Documents <= 0 {
+		spec.Documents = 1
+	}
+	if spec.Depth <= 0 {
+		spec.Depth = 3
+	}
+	if spec.Width <= 0 {
+		spec.Width = 8
+	}
+	if spec.MinStringLen <= 0 {
+		spec.MinStringLen = 1
+	}
+	if spec.MaxStringLen <= 0 {
+		spec.MaxStringLen = 16
+	}
+	if spec.MaxStringLen < spec.MinStringLen {
+		spec.MaxStringLen = spec.MinStringLen
+	}
+
+	g := corpusGenerator{spec: spec, rnd: rand.New(rand.NewSource(spec.Seed))}
+	docs := make([][]byte, spec.Documents)
+	for i := range docs {
+		// Marshal() cannot fail for the values created here.
+		docs[i], _ = Marshal(g.object(1))
+	}
+	return docs
+}
+
+type corpusGenerator struct {
+	spec CorpusSpec
+	rnd  *rand.Rand
+}
+
+func (g *corpusGenerator) object(depth int) *Node {
+	om := NewOrderedMap()
+	for n := 1 + g.rnd.Intn(g.spec.Width); om.Len() < n; {
+		key := g.word(3 + g.rnd.Intn(8))
+		if _, ok := om.Map[key]; ok {
+			continue
+		}
+		node := g.value(depth + 1)
+		if g.spec.Comments {
+			node.Cm.Before = "# " + g.word(4) + " " + g.word(6) + "\n"
+		}
+		om.Set(key, node)
+	}
+	return &Node{Value: om}
+}
+
+func (g *corpusGenerator) array(depth int) *Node {
+	n := 1 + g.rnd.Intn(g.spec.Width)
+	arr := make([]interface{}, n)
+	for i := range arr {
+		arr[i] = g.value(depth + 1)
+	}
+	return &Node{Value: arr}
+}
+
+func (g *corpusGenerator) value(depth int) *Node {
+	kinds := 6
+	if depth > g.spec.Depth {
+		// No more objects or arrays.
+		kinds = 4
+	}
+	switch g.rnd.Intn(kinds) {
+	case 0:
+		return &Node{Value: g.string()}
+	case 1:
+		if g.rnd.Intn(2) == 0 {
+			return &Node{Value: float64(g.rnd.Intn(100000))}
+		}
+		f, _ := strconv.ParseFloat(strconv.FormatFloat(g.rnd.Float64()*1000, 'f', 3, 64), 64)
+		return &Node{Value: f}
+	case 2:
+		return &Node{Value: g.rnd.Intn(2) == 0}
+	case 3:
+		if g.rnd.Intn(4) == 0 {
+			return &Node{Value: nil}
+		}
+		return &Node{Value: g.string()}
+	case 4:
+		return g.object(depth)
+	}
+	return g.array(depth)
+}
+
+const corpusLetters = "abcdefghijklmnopqrstuvwxyz"
+
+// word returns n random lowercase letters.
+func (g *corpusGenerator) word(n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = corpusLetters[g.rnd.Intn(len(corpusLetters))]
+	}
+	return string(b)
+}
+
+// string returns a string of random words with a length chosen from the spec.
+func (g *corpusGenerator) string() string {
+	n := g.spec.MinStringLen + g.rnd.Intn(g.spec.MaxStringLen-g.spec.MinStringLen+1)
+	multiline := g.rnd.Float64() < g.spec.MultilineRatio
+	b := make([]byte, n)
+	for i := range b {
+		switch {
+		case i == 0 || i == n-1 || b[i-1] == ' ' || b[i-1] == '\n':
+			b[i] = corpusLetters[g.rnd.Intn(len(corpusLetters))]
+		case g.rnd.Intn(6) > 0:
+			b[i] = corpusLetters[g.rnd.Intn(len(corpusLetters))]
+		case multiline && g.rnd.Intn(2) == 0:
+			b[i] = '\n'
+		default:
+			b[i] = ' '
+		}
+	}
+	return string(b)
+}
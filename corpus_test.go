@@ -0,0 +1,82 @@
+package hjson
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGenerateCorpus(t *testing.T) {
+	spec := CorpusSpec{
+		Documents:      5,
+		Depth:          4,
+		Width:          6,
+		MinStringLen:   8,
+		MaxStringLen:   20,
+		MultilineRatio: 0.5,
+		Comments:       true,
+		Seed:           42,
+	}
+	docs := GenerateCorpus(spec)
+	if len(docs) != 5 {
+		t.Fatalf("Expected 5 documents, got %d", len(docs))
+	}
+	again := GenerateCorpus(spec)
+	for i, doc := range docs {
+		if !bytes.Equal(doc, again[i]) {
+			t.Errorf("Document %d differs for the same seed", i)
+		}
+		if !bytes.Contains(doc, []byte("# ")) {
+			t.Errorf("Expected comments in document %d:\n%s", i, doc)
+		}
+		root, err := ParseNode(doc)
+		if err != nil {
+			t.Fatalf("%v\n%s", err, doc)
+		}
+		var check func(node *Node, depth int)
+		check = func(node *Node, depth int) {
+			switch v := node.Value.(type) {
+			case *OrderedMap:
+				if depth > spec.Depth || v.Len() < 1 || v.Len() > spec.Width {
+					t.Errorf("Unexpected object with %d members at depth %d", v.Len(), depth)
+				}
+				for _, key := range v.Keys {
+					check(v.Map[key].(*Node), depth+1)
+				}
+			case []interface{}:
+				if depth > spec.Depth || len(v) < 1 || len(v) > spec.Width {
+					t.Errorf("Unexpected array with %d elements at depth %d", len(v), depth)
+				}
+				for _, elem := range v {
+					check(elem.(*Node), depth+1)
+				}
+			case string:
+				if len(v) < spec.MinStringLen || len(v) > spec.MaxStringLen {
+					t.Errorf("Unexpected string length %d: %q", len(v), v)
+				}
+			}
+		}
+		check(root, 1)
+	}
+
+	if docs := GenerateCorpus(CorpusSpec{}); len(docs) != 1 || len(docs[0]) == 0 {
+		t.Errorf("Unexpected documents for the default spec: %q", docs)
+	}
+}
+
+func BenchmarkUnmarshalCorpus(b *testing.B) {
+	docs := GenerateCorpus(CorpusSpec{Documents: 20, Depth: 5, Width: 10, Comments: true})
+	size := 0
+	for _, doc := range docs {
+		size += len(doc)
+	}
+	b.SetBytes(int64(size))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, doc := range docs {
+			var v interface{}
+			if err := Unmarshal(doc, &v); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
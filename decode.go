@@ -7,7 +7,11 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
 )
 
 const maxPointerDepth = 512
@@ -15,6 +19,29 @@ const maxPointerDepth = 512
 // This limits the max nesting depth to prevent stack overflow.
 const maxNestingDepth = 10000
 
+// The max nesting depth used by HardenedDecoderOptions().
+const hardenedMaxDepth = 100
+
+// The allocation budget used by HardenedDecoderOptions().
+const hardenedAllocBudget = 64 << 20
+
+// The input size and string and container limits used by
+// HardenedDecoderOptions().
+const (
+	hardenedMaxBytes     = 16 << 20
+	hardenedMaxStringLen = 1 << 20
+	hardenedMaxElements  = 100000
+)
+
+// Estimated sizes used for AllocBudget.
+const (
+	sizeInterface  = 16
+	sizeSlice      = 24
+	sizeMap        = 48
+	sizeMapElement = 32
+	sizeNode       = 320 // Including the snapshot used by Node.Lit
+)
+
 type commentInfo struct {
 	hasComment bool
 	cmStart    int
@@ -40,6 +67,12 @@ type DecoderOptions struct {
 	// UseJSONNumber causes the Decoder to unmarshal a number into an interface{} as a
 	// json.Number instead of as a float64.
 	UseJSONNumber bool
+	// UseInt64 causes the Decoder to unmarshal a number that is written as an
+	// integer and fits in an int64 into an interface{} as an int64 instead of
+	// as a float64, so that large integers like IDs above 2^53 keep their
+	// precision. Other numbers are still unmarshaled as float64. UseJSONNumber
+	// takes precedence over UseInt64.
+	UseInt64 bool
 	// DisallowUnknownFields causes an error to be returned when the destination
 	// is a struct and the input contains object keys which do not match any
 	// non-ignored, exported fields in the destination.
@@ -49,6 +82,12 @@ type DecoderOptions struct {
 	// to false, later values will silently overwrite previous values for the
 	// same key.
 	DisallowDuplicateKeys bool
+	// DisallowNullIntoNonPointer causes an error to be returned if the Hjson
+	// input contains null for a destination that cannot be nil, like a bool, a
+	// number or a struct, instead of leaving the destination unchanged.
+	// Pointers, interfaces, maps and slices can still be set to nil. A
+	// quoteless null for a string destination is the string "null", as usual.
+	DisallowNullIntoNonPointer bool
 	// WhitespaceAsComments only has any effect when an hjson.Node struct (or
 	// an *hjson.Node pointer) is used as target for Unmarshal. If
 	// WhitespaceAsComments is set to true, all whitespace and comments are stored
@@ -56,6 +95,172 @@ type DecoderOptions struct {
 	// WhitespaceAsComments instead is set to false, only actual comments are
 	// stored as comments in Node structs.
 	WhitespaceAsComments bool
+	// MaxDepth is the max nesting depth of objects and arrays in the Hjson
+	// input. If MaxDepth is 0, the default limit of 10000 levels is used.
+	// Nested objects and arrays are read without recursion, on a stack that
+	// grows by one small frame per level. MaxDepth also limits the nesting of
+	// $include directives and of $ref directives referring to values with
+	// $ref directives.
+	MaxDepth int
+	// StrictNumbers causes an error to be returned if the Hjson input contains
+	// a quoteless value that is formatted as a number but is too large to be
+	// represented as a float64 (for example 1e400). If StrictNumbers is set to
+	// false, such values are treated as quoteless strings.
+	StrictNumbers bool
+	// NonFiniteNumbers causes the quoteless values NaN, Infinity, +Infinity
+	// and -Infinity to be decoded as the float64 values NaN, +Inf and -Inf, if
+	// the destination is a float or an interface{}, instead of as strings. In
+	// the JSON5 dialect they are accepted instead of reported as errors.
+	NonFiniteNumbers bool
+	// ExtendedNumbers causes quoteless integers with a base prefix, like 0xFF,
+	// 0o755 or 0b1010, optionally negative, and numbers with underscores
+	// separating digits, like 1_000_000 or 0xFF_FF, to be decoded as numbers
+	// instead of as strings.
+	ExtendedNumbers bool
+	// AllocBudget is the max estimated size in bytes of all values created when
+	// decoding the Hjson input. If the budget is exceeded a *LimitError is
+	// returned. The estimate covers the values that are created by the parser
+	// (strings, numbers, slices, maps and Node structs), not any memory
+	// allocated when those values are assigned to the destination. If
+	// AllocBudget is 0 there is no limit.
+	AllocBudget int
+	// MaxBytes is the max size in bytes of the Hjson input, or of each value
+	// read by a Decoder. Larger input is rejected with a *LimitError before it
+	// is parsed. If MaxBytes is 0 there is no limit.
+	MaxBytes int
+	// MaxStringLen is the max length in bytes of each string value and key
+	// name in the Hjson input, after escape sequences have been replaced. If a
+	// longer string is found a *LimitError is returned. If MaxStringLen is 0
+	// there is no limit.
+	MaxStringLen int
+	// MaxElements is the max number of elements of each array and members of
+	// each object in the Hjson input. If an array or object has more a
+	// *LimitError is returned. If MaxElements is 0 there is no limit.
+	MaxElements int
+	// MapKeyParser, if not nil, is called with the key type and the text of
+	// each key of an object that is decoded into a map, unless the key type is
+	// string. It returns the key to use, for example a Level for the text
+	// "debug" in a map[Level]Config. If it returns nil the key is decoded like
+	// by json.Unmarshal(), so map key types that are not handled can be left
+	// alone. The returned key must be convertible to the key type, which must
+	// be of a string or integer kind, or implement both encoding.TextMarshaler
+	// and encoding.TextUnmarshaler.
+	MapKeyParser func(keyType reflect.Type, key string) (interface{}, error)
+	// NumberFunc, if not nil, is called with the text of each number in the
+	// Hjson input, for example to decode numbers into decimal.Decimal, *big.Int
+	// or types with units. The returned value is used if the destination of
+	// the number is an interface{} or if the value can be assigned to the
+	// destination. If NumberFunc returns nil, or a value that cannot be used,
+	// the number is decoded as usual. If it returns an error a *ParseError is
+	// returned.
+	NumberFunc func(literal string) (interface{}, error)
+	// CaseSensitive causes object keys to only match struct fields with
+	// exactly the same name. By default a key is matched case-insensitively
+	// if no field has exactly the same name, like "userid" to the field
+	// UserID. Keys that only differ in case from a field are treated as
+	// unknown fields.
+	CaseSensitive bool
+	// KeyMatcher, if not nil, converts the names of struct fields without a
+	// name in a tag, so that keys in the Hjson input following a naming
+	// convention are matched to them, like "max_conns" to the field MaxConns
+	// with SnakeCase. Keys matching the field names themselves are still
+	// accepted. The paths in FieldStates then hold the names of the fields
+	// instead of the matched keys.
+	KeyMatcher KeyNamer
+	// DecodeHook, if not nil, is called with each string, number and boolean
+	// in the Hjson input that has a known destination, before the value is
+	// assigned, for example to convert strings to enums or net.IP values
+	// without implementing encoding.TextUnmarshaler on every type. from is
+	// the type of v, which is a string, a bool or a number (float64, or
+	// json.Number or int64 if UseJSONNumber or UseInt64 is set), and to is the
+	// type of the destination, without pointers. The returned value is
+	// assigned to the destination if it can be, otherwise it is decoded like
+	// a value of that type found in the input, so that a hook can for example
+	// convert a string to a number. Return v unchanged for values that are not
+	// to be converted. If DecodeHook returns an error a *ParseError is
+	// returned.
+	DecodeHook func(from reflect.Type, to reflect.Type, v interface{}) (interface{}, error)
+	// MultilineIndent controls how much indentation is removed from the lines
+	// of multiline strings (strings in triple quotes). The default,
+	// MultilineIndentQuotes, follows the Hjson specification.
+	MultilineIndent MultilineIndentMode
+	// KeepComments causes comments to be kept when the destination is a
+	// pointer to an interface{} or a pointer to a map with string keys and
+	// interface{} values (for example *map[string]interface{}), so that they
+	// are written again when the result is passed to Marshal(). An interface{}
+	// destination is then set to a tree of *hjson.Node, just like when a
+	// **hjson.Node is used as destination. The values of a map destination are
+	// set to *hjson.Node, each containing the comments of its key/value pair.
+	// Comments outside of the root object are lost for map destinations.
+	// KeepComments has no effect on other types of destinations.
+	KeepComments bool
+	// CollectErrors causes the parser to continue after syntax errors, by
+	// skipping the rest of the line containing the error, so that all errors
+	// in the input can be reported at once. If any error is found, an
+	// ErrorList containing all errors is returned, sorted by position, and
+	// the destination is not changed. Errors that are found because of earlier
+	// errors are not always meaningful.
+	CollectErrors bool
+	// Messages, if not nil, builds the messages of the errors returned, so
+	// that they can be localized or rephrased. DefaultMessages is used if
+	// Messages is nil.
+	Messages Messages
+	// InvalidUTF8 defines how invalid UTF-8 byte sequences in the input are
+	// handled. The default, InvalidUTF8Replace, replaces them with U+FFFD.
+	InvalidUTF8 InvalidUTF8Policy
+	// OnInvalidUTF8, if not nil, is called with the offset in the input of each
+	// invalid UTF-8 byte sequence that is replaced because of InvalidUTF8Replace.
+	OnInvalidUTF8 func(offset int)
+	// Literals are tried, in order, on quoteless values that are not keywords
+	// or numbers, unless the destination is a string. Like a number, a value
+	// recognized by a Literal ends at a comma, a closing bracket or a comment.
+	// The value returned by the Literal is assigned as it is to interface{}
+	// destinations and to destinations of its own type. For other
+	// destinations it is converted like by json.Unmarshal(), except for
+	// destinations implementing encoding.TextUnmarshaler, which are given the
+	// text instead.
+	Literals []Literal
+	// Dialect is the syntax of the input. The default, FormatUnknown, and
+	// FormatHjson both mean Hjson. If Dialect is FormatJSON5 the input must be
+	// JSON5: strings can be in single quotes but not quoteless or multiline,
+	// keys without quotes must be identifiers (which can contain '$'), numbers
+	// can be hexadecimal, start with '+' or '.' or end with '.', commas are
+	// required between members and elements but trailing commas are allowed,
+	// only // and /* */ comments are allowed, and the root object must have
+	// braces. Infinity and NaN are reported as errors because they cannot be
+	// represented in JSON, unless NonFiniteNumbers is set. If Dialect is
+	// FormatJSONC the input must be JSON, except that // and /* */ comments and
+	// trailing commas are allowed, like in the settings files of VS Code.
+	// Literals are not used for JSON5 or JSONC input.
+	Dialect Format
+	// FieldStates, if not nil, is filled with the state of every object member
+	// found in the input, so that members explicitly set to null can be told
+	// apart from members that are absent.
+	FieldStates FieldStates
+	// Include, if not nil, enables $include directives: an object member with
+	// the key "$include" and a file name (or an array of file names) as value
+	// is replaced by the members of the objects in those files, which are
+	// read with Include. See IncludeResolver.
+	Include IncludeResolver
+	// ResolveRefs enables $ref directives: an object with a member "$ref"
+	// whose value is a JSON Pointer (RFC 6901) prefixed with "#", like
+	// "#/defaults/db", is replaced by the value it refers to in the same
+	// document. The other members of the object override those of the
+	// referenced object. References are resolved after $include directives.
+	// A value referenced several times counts against MaxBytes and
+	// AllocBudget each time, already while the references are resolved.
+	ResolveRefs bool
+
+	// includeName is the name of the file being decoded, relative to which
+	// the names in $include directives are resolved.
+	includeName string
+	// unusedKeys, if not nil, is filled with the paths of the object members
+	// that do not match any field of a struct destination, see
+	// UnmarshalWithReport().
+	unusedKeys *[]string
+	// valuePath, if not nil, is the path of the only value to decode, see
+	// UnmarshalPath().
+	valuePath []string
 }
 
 // DefaultDecoderOptions returns the default decoding options.
@@ -68,15 +273,60 @@ func DefaultDecoderOptions() DecoderOptions {
 	}
 }
 
+// HardenedDecoderOptions returns decoding options suitable for Hjson input
+// from untrusted sources, for example network clients. Compared to
+// DefaultDecoderOptions() the max nesting depth is much lower, the size of the
+// input, of strings and of containers is limited, duplicate keys are not
+// allowed and numbers that cannot be represented as float64 are rejected
+// instead of being treated as strings.
+//
+// The limits also apply to the expansion of $include and $ref directives, if
+// Include or ResolveRefs is set: the expanded document counts against MaxBytes
+// and AllocBudget, and directives may be nested at most MaxDepth levels.
+func HardenedDecoderOptions() DecoderOptions {
+	opt := DefaultDecoderOptions()
+	opt.DisallowDuplicateKeys = true
+	opt.MaxDepth = hardenedMaxDepth
+	opt.StrictNumbers = true
+	opt.AllocBudget = hardenedAllocBudget
+	opt.MaxBytes = hardenedMaxBytes
+	opt.MaxStringLen = hardenedMaxStringLen
+	opt.MaxElements = hardenedMaxElements
+	return opt
+}
+
+// StrictDecoderOptions returns decoding options for validating configs
+// written by users, where mistakes should be reported instead of ignored.
+// Compared to DefaultDecoderOptions() unknown fields, duplicate keys and null
+// for destinations that cannot be nil are reported as errors.
+func StrictDecoderOptions() DecoderOptions {
+	opt := DefaultDecoderOptions()
+	opt.DisallowUnknownFields = true
+	opt.DisallowDuplicateKeys = true
+	opt.DisallowNullIntoNonPointer = true
+	return opt
+}
+
 type hjsonParser struct {
 	DecoderOptions
 	data              []byte
 	at                int  // The index of the current character
 	ch                byte // The current character
 	structTypeCache   map[reflect.Type]structFieldMap
+	matchedNames      map[reflect.Type]map[string]structFieldInfo // See matchKey()
 	willMarshalToJSON bool
 	nodeDestination   bool
 	nestingDepth      int
+	allocated         int           // Estimated size of all values created so far
+	lineStarts        []int         // Offsets of all lines, used by position()
+	path              []interface{} // Keys and indexes leading to the current value
+	fixups            []destFixup   // Values to assign after json.Unmarshal()
+	errs              ErrorList     // Errors found if CollectErrors is true
+	valueStart        int           // Offset of the last value read by readValue()
+	valueEnd          int           // Offset after the last value read by readValue()
+	// If true, a root object without braces ends at a document separator, see
+	// separatorAt().
+	documentSeparators bool
 }
 
 var unmarshalerText = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
@@ -100,33 +350,68 @@ func (p *hjsonParser) setComment2(pCm *string, ciA, ciB commentInfo) {
 func (p *hjsonParser) resetAt() {
 	p.at = 0
 	p.nestingDepth = 0
+	p.allocated = 0
+	p.path = p.path[:0]
+	p.fixups = nil
+	p.errs = nil
+	if p.unusedKeys != nil {
+		*p.unusedKeys = (*p.unusedKeys)[:0]
+	}
 	p.next()
 }
 
+func (o DecoderOptions) maxDepth() int {
+	if o.MaxDepth > 0 {
+		return o.MaxDepth
+	}
+	return maxNestingDepth
+}
+
 func isPunctuatorChar(c byte) bool {
 	return c == '{' || c == '}' || c == '[' || c == ']' || c == ',' || c == ':'
 }
 
-func (p *hjsonParser) errAt(message string) error {
-	if p.at <= len(p.data) {
-		var i int
-		col := 0
-		line := 1
-		for i = p.at - 1; i > 0 && p.data[i] != '\n'; i-- {
-			col++
-		}
-		for ; i > 0; i-- {
-			if p.data[i] == '\n' {
-				line++
-			}
-		}
-		samEnd := p.at - col + 20
-		if samEnd > len(p.data) {
-			samEnd = len(p.data)
-		}
-		return fmt.Errorf("%s at line %d,%d >>> %s", message, line, col, string(p.data[p.at-col:samEnd]))
+// errAt returns a *ParseError for the current position, with the message
+// identified by id (one of the Msg constants).
+func (p *hjsonParser) errAt(id string, args ...interface{}) error {
+	// p.at is the index of the character after the current character p.ch.
+	return p.errAtOffset(p.at-1, id, args...)
+}
+
+// errAtOffset returns a *ParseError for the given offset, with the message
+// identified by id.
+func (p *hjsonParser) errAtOffset(offset int, id string, args ...interface{}) *ParseError {
+	err := newParseError(p.data, offset, message(p.Messages, id, args...))
+	err.Kind = id
+	err.Code = CodeOf(id)
+	return err
+}
+
+// collect adds err to p.errs if CollectErrors is true and err is a
+// *ParseError. An error at the same offset as the previous error is not
+// added, because it was caused by the previous error (for example when the
+// end of the input is found inside nested objects).
+func (p *hjsonParser) collect(err error) bool {
+	pe, ok := err.(*ParseError)
+	if !p.CollectErrors || !ok {
+		return false
 	}
-	return errors.New(message)
+	if len(p.errs) == 0 || p.errs[len(p.errs)-1].Offset != pe.Offset {
+		p.errs = append(p.errs, pe)
+	}
+	return true
+}
+
+// recoverFrom collects err (see collect()) and skips the rest of the current
+// line, so that parsing can continue. Returns false if err was not collected.
+func (p *hjsonParser) recoverFrom(err error) bool {
+	if !p.collect(err) {
+		return false
+	}
+	for p.ch != '\n' && p.ch != 0 {
+		p.next()
+	}
+	return true
 }
 
 func (p *hjsonParser) next() bool {
@@ -192,15 +477,42 @@ func unravelDestination(dest reflect.Value, t reflect.Type) (reflect.Value, refl
 	return dest, t
 }
 
+// readHex4 reads the 4 hex digits following \u in a string. A lone
+// surrogate is returned as is, and written as U+FFFD by WriteRune().
+func (p *hjsonParser) readHex4() (rune, error) {
+	uffff := 0
+	for i := 0; i < 4; i++ {
+		p.next()
+		var hex int
+		if p.ch >= '0' && p.ch <= '9' {
+			hex = int(p.ch - '0')
+		} else if p.ch >= 'a' && p.ch <= 'f' {
+			hex = int(p.ch - 'a' + 0xa)
+		} else if p.ch >= 'A' && p.ch <= 'F' {
+			hex = int(p.ch - 'A' + 0xa)
+		} else {
+			return 0, p.errAt(MsgBadUnicodeEscape, string(p.ch))
+		}
+		uffff = uffff*16 + hex
+	}
+	return rune(uffff), nil
+}
+
 func (p *hjsonParser) readString(allowML bool) (string, error) {
 
 	// Parse a string value.
 	res := new(bytes.Buffer)
 
 	// callers make sure that (ch === '"' || ch === "'")
+	if p.ch == '\'' && p.Dialect == FormatJSONC {
+		return "", p.dialectError()
+	}
 	// When parsing for string values, we must look for " and \ characters.
 	exitCh := p.ch
 	for p.next() {
+		if err := p.checkStringLen(res.Len()); err != nil {
+			return "", err
+		}
 		if p.ch == exitCh {
 			p.next()
 			if allowML && exitCh == '\'' && p.ch == '\'' && res.Len() == 0 {
@@ -214,34 +526,44 @@ func (p *hjsonParser) readString(allowML bool) (string, error) {
 		if p.ch == '\\' {
 			p.next()
 			if p.ch == 'u' {
-				uffff := 0
-				for i := 0; i < 4; i++ {
+				r, err := p.readHex4()
+				if err != nil {
+					return "", err
+				}
+				if utf16.IsSurrogate(r) && p.peek(0) == '\\' && p.peek(1) == 'u' {
+					// Combine a surrogate pair, like \ud83d\ude00.
+					at := p.at
 					p.next()
-					var hex int
-					if p.ch >= '0' && p.ch <= '9' {
-						hex = int(p.ch - '0')
-					} else if p.ch >= 'a' && p.ch <= 'f' {
-						hex = int(p.ch - 'a' + 0xa)
-					} else if p.ch >= 'A' && p.ch <= 'F' {
-						hex = int(p.ch - 'A' + 0xa)
+					p.next()
+					r2, err := p.readHex4()
+					if err != nil {
+						return "", err
+					}
+					if pair := utf16.DecodeRune(r, r2); pair != utf8.RuneError {
+						r = pair
 					} else {
-						return "", p.errAt("Bad \\u char " + string(p.ch))
+						// Not a pair, read the second escape on its own.
+						p.at = at
+						p.ch = p.data[at-1]
 					}
-					uffff = uffff*16 + hex
 				}
-				res.WriteRune(rune(uffff))
+				res.WriteRune(r)
 			} else if ech, ok := escapee[p.ch]; ok {
 				res.WriteByte(ech)
+			} else if p.json5() {
+				if err := p.readJSON5Escape(res); err != nil {
+					return "", err
+				}
 			} else {
-				return "", p.errAt("Bad escape \\" + string(p.ch))
+				return "", p.errAt(MsgBadEscape, string(p.ch))
 			}
 		} else if p.ch == '\n' || p.ch == '\r' {
-			return "", p.errAt("Bad string containing newline")
+			return "", p.errAt(MsgNewlineInString)
 		} else {
 			res.WriteByte(p.ch)
 		}
 	}
-	return "", p.errAt("Bad string")
+	return "", p.errAt(MsgUnterminatedString)
 }
 
 func (p *hjsonParser) readMLString() (value string, err error) {
@@ -259,6 +581,9 @@ func (p *hjsonParser) readMLString() (value string, err error) {
 		}
 		indent++
 	}
+	if p.MultilineIndent != MultilineIndentQuotes {
+		indent = 0
+	}
 
 	skipIndent := func() {
 		skip := indent
@@ -272,6 +597,7 @@ func (p *hjsonParser) readMLString() (value string, err error) {
 	for p.ch > 0 && p.ch <= ' ' && p.ch != '\n' {
 		p.next()
 	}
+	firstOnOpening := p.ch != '\n'
 	if p.ch == '\n' {
 		p.next()
 		skipIndent()
@@ -281,16 +607,28 @@ func (p *hjsonParser) readMLString() (value string, err error) {
 	lastLf := false
 	for {
 		if p.ch == 0 {
-			return "", p.errAt("Bad multiline string")
+			return "", p.errAt(MsgUnterminatedMLString)
 		} else if p.ch == '\'' {
 			triple++
 			p.next()
 			if triple == 3 {
 				sres := res.Bytes()
 				if lastLf {
-					return string(sres[0 : len(sres)-1]), nil // remove last EOL
+					sres = sres[0 : len(sres)-1] // remove last EOL
+				}
+				value = string(sres)
+				switch p.MultilineIndent {
+				case MultilineIndentCommon:
+					value = dedentCommon(trimClosingIndent(value), firstOnOpening)
+				case MultilineIndentNone:
+					value = trimClosingIndent(value)
+				}
+				// The length is checked at the end because the indentation is
+				// only removed here.
+				if err := p.checkStringLen(len(value)); err != nil {
+					return "", err
 				}
-				return string(sres), nil
+				return value, nil
 			}
 			continue
 		} else {
@@ -323,6 +661,9 @@ func (p *hjsonParser) readKeyname() (string, error) {
 	if p.ch == '"' || p.ch == '\'' {
 		return p.readString(false)
 	}
+	if p.strictDialect() {
+		return p.readIdentifier()
+	}
 
 	name := new(bytes.Buffer)
 	start := p.at
@@ -330,22 +671,25 @@ func (p *hjsonParser) readKeyname() (string, error) {
 	for {
 		if p.ch == ':' {
 			if name.Len() == 0 {
-				return "", p.errAt("Found ':' but no key name (for an empty key name use quotes)")
+				return "", p.errAt(MsgEmptyKey)
 			} else if space >= 0 && space != name.Len() {
 				p.at = start + space
-				return "", p.errAt("Found whitespace in your key name (use quotes to include)")
+				return "", p.errAt(MsgWhitespaceInKey)
 			}
 			return name.String(), nil
 		} else if p.ch <= ' ' {
 			if p.ch == 0 {
-				return "", p.errAt("Found EOF while looking for a key name (check your syntax)")
+				return "", p.errAt(MsgEOFInKey)
 			}
 			if space < 0 {
 				space = name.Len()
 			}
 		} else {
 			if isPunctuatorChar(p.ch) {
-				return "", p.errAt("Found '" + string(p.ch) + "' where a key name was expected (check your syntax or use quotes if the key name includes {}[],: or whitespace)")
+				return "", p.errAt(MsgPunctuatorInKey, string(p.ch))
+			}
+			if err := p.checkStringLen(name.Len() + 1); err != nil {
+				return "", err
 			}
 			name.WriteByte(p.ch)
 		}
@@ -376,7 +720,7 @@ func (p *hjsonParser) commonWhite(onlyAfter bool) (commentInfo, bool) {
 			p.next()
 		}
 		// Hjson allows comments
-		if p.ch == '#' || p.ch == '/' && p.peek(0) == '/' {
+		if p.ch == '#' && !p.strictDialect() || p.ch == '/' && p.peek(0) == '/' {
 			ci.hasComment = p.nodeDestination
 			for p.ch > 0 && p.ch != '\n' {
 				p.next()
@@ -428,7 +772,80 @@ func (p *hjsonParser) getCommentAfter() commentInfo {
 	return ci
 }
 
+// checkStringLen returns a *LimitError if n, the length of the string or key
+// name being read, exceeds MaxStringLen.
+func (p *hjsonParser) checkStringLen(n int) error {
+	if p.MaxStringLen > 0 && n > p.MaxStringLen {
+		return &LimitError{
+			Limit:  "MaxStringLen",
+			Max:    p.MaxStringLen,
+			Offset: p.at - 1,
+		}
+	}
+	return nil
+}
+
+// checkElements returns a *LimitError if n, the number of elements of the
+// array or members of the object being read, exceeds MaxElements.
+func (p *hjsonParser) checkElements(n int) error {
+	if p.MaxElements > 0 && n > p.MaxElements {
+		return &LimitError{
+			Limit:  "MaxElements",
+			Max:    p.MaxElements,
+			Offset: p.at - 1,
+		}
+	}
+	return nil
+}
+
+// charge adds the estimated size of v to the total size of all created values
+// and returns an error if DecoderOptions.AllocBudget is exceeded.
+func (p *hjsonParser) charge(v interface{}) error {
+	if p.AllocBudget <= 0 {
+		return nil
+	}
+
+	size := allocSize(v)
+	if p.nodeDestination {
+		size += sizeNode
+	}
+
+	p.allocated += size
+	if p.allocated > p.AllocBudget {
+		return &LimitError{
+			Limit:  "AllocBudget",
+			Max:    p.AllocBudget,
+			Offset: p.at - 1,
+		}
+	}
+
+	return nil
+}
+
+// allocSize returns the estimated size of the value v for AllocBudget, not
+// including the elements of arrays and the values of object members.
+func allocSize(v interface{}) int {
+	size := sizeInterface
+	switch cont := v.(type) {
+	case string:
+		size += len(cont)
+	case json.Number:
+		size += len(cont)
+	case []interface{}:
+		size += sizeSlice + len(cont)*sizeInterface
+	case *OrderedMap:
+		size += sizeMap + sizeSlice
+		for _, key := range cont.Keys {
+			size += 2*len(key) + sizeMapElement
+		}
+	}
+	return size
+}
+
 func (p *hjsonParser) maybeWrapNode(n *Node, v interface{}) (interface{}, error) {
+	if err := p.charge(v); err != nil {
+		return nil, err
+	}
 	if p.nodeDestination {
 		n.Value = v
 		return n, nil
@@ -436,6 +853,48 @@ func (p *hjsonParser) maybeWrapNode(n *Node, v interface{}) (interface{}, error)
 	return v, nil
 }
 
+// position returns the position of offset in p.data.
+func (p *hjsonParser) position(offset int) Position {
+	if p.lineStarts == nil {
+		p.lineStarts = []int{0}
+		for i, c := range p.data {
+			if c == '\n' {
+				p.lineStarts = append(p.lineStarts, i+1)
+			}
+		}
+	}
+	line := sort.SearchInts(p.lineStarts, offset+1) - 1
+	return Position{
+		Offset: offset,
+		Line:   line + 1,
+		Column: offset - p.lineStarts[line] + 1,
+	}
+}
+
+// setSource sets Node.Lit, Node.Pos and Node.End if v is a *Node, for a value
+// starting at offset start and ending at the current position. Whitespace at
+// the end of quoteless values is not included.
+func (p *hjsonParser) setSource(v interface{}, start int) {
+	node, ok := v.(*Node)
+	if !ok {
+		return
+	}
+	end := p.at - 1
+	if end > len(p.data) {
+		end = len(p.data)
+	}
+	lit := p.data[start:end]
+	switch node.Value.(type) {
+	case []interface{}, *OrderedMap:
+	default:
+		lit = bytes.TrimRight(lit, " \t\r\n")
+		end = start + len(lit)
+	}
+	node.Lit = string(lit)
+	node.Pos = p.position(start)
+	node.End = p.position(end)
+}
+
 func (p *hjsonParser) readTfnns(dest reflect.Value, t reflect.Type) (interface{}, error) {
 
 	// Hjson strings can be quoteless
@@ -443,7 +902,18 @@ func (p *hjsonParser) readTfnns(dest reflect.Value, t reflect.Type) (interface{}
 	// Or wraps the value in a Node.
 
 	if isPunctuatorChar(p.ch) {
-		return nil, p.errAt("Found a punctuator character '" + string(p.ch) + "' when expecting a quoteless string (check your syntax)")
+		return nil, p.errAt(MsgPunctuatorInValue, string(p.ch))
+	}
+	if p.strictDialect() {
+		var newT reflect.Type
+		if !p.nodeDestination {
+			_, newT = unravelDestination(dest, t)
+		}
+		v, err := p.readDialectScalar(newT)
+		if err != nil {
+			return nil, err
+		}
+		return p.maybeWrapNode(&Node{}, v)
 	}
 	chf := p.ch
 	var node Node
@@ -475,9 +945,14 @@ func (p *hjsonParser) readTfnns(dest reflect.Value, t reflect.Type) (interface{}
 
 				return p.maybeWrapNode(&node, nil)
 			}
-			if (newT == nil || newT.Kind() != reflect.String) &&
-				(t == nil || !(t.Implements(unmarshalerText) ||
-					dest.CanAddr() && dest.Addr().Type().Implements(unmarshalerText))) {
+			textDest := t != nil && (t.Implements(unmarshalerText) ||
+				dest.CanAddr() && dest.Addr().Type().Implements(unmarshalerText))
+			if isBigNumberType(newT) && (chf == '-' || chf >= '0' && chf <= '9') {
+				if n, err := tryParseNumber(value.Bytes(), false, true); err == nil {
+					return p.parseBigNumber(string(n.(json.Number)), newT)
+				}
+			}
+			if (newT == nil || newT.Kind() != reflect.String) && !textDest {
 
 				switch chf {
 				case 'f':
@@ -494,16 +969,44 @@ func (p *hjsonParser) readTfnns(dest reflect.Value, t reflect.Type) (interface{}
 					}
 				default:
 					if chf == '-' || chf >= '0' && chf <= '9' {
+						if p.ExtendedNumbers {
+							if n, ok := p.readExtendedNumber(strings.TrimSpace(value.String())); ok {
+								return p.maybeWrapNode(&node, n)
+							}
+						}
+						if p.NumberFunc != nil {
+							if n, err := tryParseNumber(value.Bytes(), false, true); err == nil {
+								v, ok, err := p.callNumberFunc(string(n.(json.Number)), newT)
+								if err != nil {
+									return nil, err
+								}
+								if ok {
+									return p.maybeWrapNode(&node, v)
+								}
+							}
+						}
 						// Always use json.Number if we will marshal to JSON.
-						if n, err := tryParseNumber(
-							value.Bytes(),
-							false,
-							p.willMarshalToJSON || p.DecoderOptions.UseJSONNumber,
-						); err == nil {
+						if n, err := p.parseNumber(value.Bytes()); err == nil {
+							if p.StrictNumbers && isNumberOutOfRange(n) {
+								return nil, p.errAt(MsgNumberOutOfRange)
+							}
 							return p.maybeWrapNode(&node, n)
+						} else if p.StrictNumbers && isNumberOutOfRange(err) {
+							return nil, p.errAt(MsgNumberOutOfRange)
 						}
 					}
 				}
+				if p.NonFiniteNumbers {
+					if f, ok := parseNonFinite(strings.TrimSpace(value.String())); ok && isFloatDest(newT) {
+						return p.maybeWrapNode(&node, p.nonFiniteValue(f, newT))
+					}
+				}
+
+			}
+			if len(p.Literals) > 0 && (newT == nil || newT.Kind() != reflect.String) {
+				if v, ok := p.parseLiteral(strings.TrimSpace(value.String()), newT, textDest); ok {
+					return p.maybeWrapNode(&node, v)
+				}
 			}
 
 			if isEol {
@@ -511,6 +1014,9 @@ func (p *hjsonParser) readTfnns(dest reflect.Value, t reflect.Type) (interface{}
 				return p.maybeWrapNode(&node, strings.TrimSpace(value.String()))
 			}
 		}
+		if err := p.checkStringLen(value.Len() + 1); err != nil {
+			return nil, err
+		}
 		value.WriteByte(p.ch)
 	}
 }
@@ -552,77 +1058,249 @@ func getElemTyperType(rv reflect.Value, t reflect.Type) reflect.Type {
 	return elemType
 }
 
-func (p *hjsonParser) readArray(dest reflect.Value, t reflect.Type) (value interface{}, err error) {
-	var node Node
+// A readStep is returned by a containerReader, either to request the next
+// member value of the container, or with the container value when the
+// container has been read completely.
+type readStep struct {
+	member bool
+	// The destination of the member value to read.
+	dest reflect.Value
+	t    reflect.Type
+	// The container value, if member is false.
+	value interface{}
+	err   error
+}
+
+func readMember(dest reflect.Value, t reflect.Type) readStep {
+	return readStep{member: true, dest: dest, t: t}
+}
+
+func readDone(value interface{}, err error) readStep {
+	return readStep{value: value, err: err}
+}
+
+// A containerReader reads an object or an array one member at a time, so that
+// nested objects and arrays can be read without recursion, see
+// readContainer().
+type containerReader interface {
+	// step continues reading the container after a member value has been read
+	// as val (or failed with err).
+	step(p *hjsonParser, val interface{}, err error) readStep
+}
+
+// A valueFrame is an object or an array read by readValue(), whose members
+// are being read.
+type valueFrame struct {
+	reader   containerReader
+	t        reflect.Type
+	ciBefore commentInfo
+	start    int
+}
+
+// readContainer reads the members of the container read by r, s being the
+// first step returned by r, and returns the container value. Objects and
+// arrays nested in the container are read using an explicit stack instead of
+// recursion, so the stack of the goroutine does not grow with the nesting
+// depth of the input.
+func (p *hjsonParser) readContainer(r containerReader, s readStep) (interface{}, error) {
+	var frames []valueFrame
+	for {
+		if s.member {
+			f, first := p.beginValue(s.dest, s.t)
+			if f.reader != nil {
+				frames = append(frames, f)
+				s = first
+				continue
+			}
+			s = first
+		} else if len(frames) > 0 {
+			// A nested container has been read, which is a member value of the
+			// container below it.
+			f := frames[len(frames)-1]
+			frames = frames[:len(frames)-1]
+			s = readDone(p.endValue(f, s.value, s.err))
+		} else {
+			return s.value, s.err
+		}
+		parent := r
+		if len(frames) > 0 {
+			parent = frames[len(frames)-1].reader
+		}
+		s = parent.step(p, s.value, s.err)
+	}
+}
+
+// An arrayReader reads the elements of an array.
+type arrayReader struct {
+	node     Node
+	array    []interface{}
+	elemType reflect.Type
+	ciBefore commentInfo
+}
 
-	if p.nestingDepth > maxNestingDepth {
-		return nil, p.errAt(fmt.Sprintf("Exceeded max depth (%d)", maxNestingDepth))
+func (p *hjsonParser) readArray(dest reflect.Value, t reflect.Type) (interface{}, error) {
+	r := &arrayReader{}
+	return p.readContainer(r, r.begin(p, dest, t))
+}
+
+// begin reads the array up to its first element.
+func (r *arrayReader) begin(p *hjsonParser, dest reflect.Value, t reflect.Type) readStep {
+	if maxDepth := p.maxDepth(); p.nestingDepth > maxDepth {
+		return readDone(nil, p.errAt(MsgMaxDepth, maxDepth))
 	}
 
-	array := make([]interface{}, 0, 1)
+	r.array = make([]interface{}, 0, 1)
 
 	// Skip '['.
 	p.next()
 	ciBefore := p.getCommentAfter()
-	p.setComment1(&node.Cm.InsideFirst, ciBefore)
-	ciBefore = p.white()
+	p.setComment1(&r.node.Cm.InsideFirst, ciBefore)
+	r.ciBefore = p.white()
 
 	if p.ch == ']' {
-		p.setComment1(&node.Cm.InsideLast, ciBefore)
+		p.setComment1(&r.node.Cm.InsideLast, r.ciBefore)
 		p.next()
-		return p.maybeWrapNode(&node, array) // empty array
+		return readDone(p.maybeWrapNode(&r.node, r.array)) // empty array
 	}
 
-	var elemType reflect.Type
 	if !p.nodeDestination {
-		elemType = getElemTyperType(dest, t)
+		r.elemType = getElemTyperType(dest, t)
 
 		dest, t = unravelDestination(dest, t)
 
 		// All elements in any existing slice/array will be removed, so we only care
 		// about the type of the new elements that will be created.
-		if elemType == nil && t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
-			elemType = t.Elem()
+		if r.elemType == nil && t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			r.elemType = t.Elem()
 		}
 	}
 
-	for p.ch > 0 {
-		var elemNode *Node
-		var val interface{}
-		if val, err = p.readValue(reflect.Value{}, elemType); err != nil {
-			return nil, err
+	return r.nextElem(p)
+}
+
+// nextElem reads the array up to its next element.
+func (r *arrayReader) nextElem(p *hjsonParser) readStep {
+	if p.ch > 0 {
+		if p.ch == ']' {
+			// After recovering from an error.
+			p.setComment1(&r.node.Cm.InsideLast, r.ciBefore)
+			p.next()
+			return readDone(p.maybeWrapNode(&r.node, r.array))
 		}
-		if p.nodeDestination {
-			var ok bool
-			if elemNode, ok = val.(*Node); ok {
-				p.setComment1(&elemNode.Cm.Before, ciBefore)
-			}
+		if err := p.checkElements(len(r.array) + 1); err != nil {
+			return readDone(nil, err)
 		}
-		// Check white before comma because comma might be on other line.
-		ciAfter := p.white()
-		// in Hjson the comma is optional and trailing commas are allowed
-		if p.ch == ',' {
-			p.next()
-			ciAfterComma := p.whiteAfterComma()
-			if elemNode != nil {
-				existingAfter := elemNode.Cm.After
-				p.setComment2(&elemNode.Cm.After, ciAfter, ciAfterComma)
-				elemNode.Cm.After = existingAfter + elemNode.Cm.After
-			}
-			// Any comments starting on the line after the comma.
-			ciAfter = p.white()
+		p.path = append(p.path, len(r.array))
+		return readMember(reflect.Value{}, r.elemType)
+	}
+
+	err := p.errAt(MsgUnterminatedArray)
+	if p.recoverFrom(err) {
+		return readDone(p.maybeWrapNode(&r.node, r.array))
+	}
+	return readDone(nil, err)
+}
+
+func (r *arrayReader) step(p *hjsonParser, val interface{}, err error) readStep {
+	p.path = p.path[:len(p.path)-1]
+	if err != nil {
+		if p.recoverFrom(err) {
+			r.ciBefore = p.white()
+			return r.nextElem(p)
 		}
-		if p.ch == ']' {
-			p.setComment1(&node.Cm.InsideLast, ciAfter)
-			array = append(array, val)
-			p.next()
-			return p.maybeWrapNode(&node, array)
+		return readDone(nil, err)
+	}
+	var elemNode *Node
+	if p.nodeDestination {
+		var ok bool
+		if elemNode, ok = val.(*Node); ok {
+			p.setComment1(&elemNode.Cm.Before, r.ciBefore)
+		}
+	}
+	// Check white before comma because comma might be on other line.
+	ciAfter := p.white()
+	// in Hjson the comma is optional and trailing commas are allowed
+	hasComma := p.ch == ','
+	if hasComma {
+		p.next()
+		ciAfterComma := p.whiteAfterComma()
+		if elemNode != nil {
+			existingAfter := elemNode.Cm.After
+			p.setComment2(&elemNode.Cm.After, ciAfter, ciAfterComma)
+			elemNode.Cm.After = existingAfter + elemNode.Cm.After
+		}
+		// Any comments starting on the line after the comma.
+		ciAfter = p.white()
+	}
+	if p.ch == ']' {
+		p.setComment1(&r.node.Cm.InsideLast, ciAfter)
+		r.array = append(r.array, val)
+		p.next()
+		return readDone(p.maybeWrapNode(&r.node, r.array))
+	}
+	if !hasComma && p.strictDialect() && p.ch > 0 {
+		err = p.commaError()
+		if !p.recoverFrom(err) {
+			return readDone(nil, err)
 		}
-		array = append(array, val)
-		ciBefore = ciAfter
+		ciAfter = p.white()
 	}
+	r.array = append(r.array, val)
+	r.ciBefore = ciAfter
+	return r.nextElem(p)
+}
+
+// An objectReader reads the members of an object.
+type objectReader struct {
+	withoutBraces bool
+	dest          reflect.Value
+	t             reflect.Type
+	node          Node
+	object        *OrderedMap
+
+	// Decoding into a slice of Entry[T], that keeps duplicate keys.
+	entryValueType reflect.Type
+	entries        []interface{}
+	// Members not matching any field of a struct destination with a field
+	// tagged with the "remain" option.
+	remain      *OrderedMap
+	remainField structFieldInfo
+	// Members of fields ignored by json.Unmarshal(), see fieldFixup().
+	ignored *OrderedMap
+	// The required fields of a struct destination, the fields with default
+	// values and the fields found.
+	required, defaults []structFieldInfo
+	found              map[string]bool
+	structType         reflect.Type
+	objectStart        int
+
+	stm        structFieldMap
+	mapKeyType reflect.Type
+	elemType   reflect.Type
+	ciBefore   commentInfo
+
+	// The member whose value is being read.
+	member objectMember
+}
 
-	return nil, p.errAt("End of input while parsing an array (did you forget a closing ']'?)")
+// objectMember is the state of an object member kept while its value is read.
+type objectMember struct {
+	key       string
+	keyOffset int
+	keyLit    string
+	ciKey     commentInfo
+	target    *OrderedMap
+	// The key passed to json.Unmarshal(), and whether the value must
+	// instead be assigned after it.
+	objKey        string
+	ignoredByJSON bool
+	isRune        bool
+	bytesFormat   string
+	intFormat     string
+	decoder       string
+	enum          []string
+	parentPath    []interface{}
+	fixupAt       int
 }
 
 func (p *hjsonParser) readObject(
@@ -630,52 +1308,56 @@ func (p *hjsonParser) readObject(
 	dest reflect.Value,
 	t reflect.Type,
 	ciBefore commentInfo,
-) (value interface{}, err error) {
-	// Parse an object value.
-	var node Node
-	var elemNode *Node
+) (interface{}, error) {
+	r := &objectReader{}
+	return p.readContainer(r, r.begin(p, withoutBraces, dest, t, ciBefore))
+}
 
-	if p.nestingDepth > maxNestingDepth {
-		return nil, p.errAt(fmt.Sprintf("Exceeded max depth (%d)", maxNestingDepth))
+// begin reads the object up to the value of its first member.
+func (r *objectReader) begin(
+	p *hjsonParser,
+	withoutBraces bool,
+	dest reflect.Value,
+	t reflect.Type,
+	ciBefore commentInfo,
+) readStep {
+	// Parse an object value.
+	if maxDepth := p.maxDepth(); p.nestingDepth > maxDepth {
+		return readDone(nil, p.errAt(MsgMaxDepth, maxDepth))
 	}
 
-	object := NewOrderedMap()
-
-	// If withoutBraces == true we use the input argument ciBefore as
-	// Before-comment on the first element of this obj, or as InnerLast-comment
-	// on this obj if it doesn't contain any elements. If withoutBraces == false
-	// we ignore the input ciBefore.
-
-	if !withoutBraces {
-		// assuming ch == '{'
-		p.next()
-		ciInsideFirst := p.getCommentAfter()
-		p.setComment1(&node.Cm.InsideFirst, ciInsideFirst)
-		ciBefore = p.white()
-		if p.ch == '}' {
-			p.setComment1(&node.Cm.InsideLast, ciBefore)
-			p.next()
-			return p.maybeWrapNode(&node, object) // empty object
-		}
+	r.withoutBraces = withoutBraces
+	r.object = NewOrderedMap()
+	r.entries = []interface{}{}
+	if !p.nodeDestination {
+		r.entryValueType = getEntryValueType(dest, t)
 	}
+	r.objectStart = p.at - 1
 
-	var stm structFieldMap
-
-	var elemType reflect.Type
-	if !p.nodeDestination {
-		elemType = getElemTyperType(dest, t)
+	if r.entryValueType != nil {
+		r.elemType = r.entryValueType
+	} else if !p.nodeDestination {
+		r.elemType = getElemTyperType(dest, t)
 
 		dest, t = unravelDestination(dest, t)
 
-		if elemType == nil && t != nil {
+		if r.elemType == nil && t != nil {
 			switch t.Kind() {
 			case reflect.Struct:
-				var ok bool
-				stm, ok = p.structTypeCache[t]
+				stm, ok := p.structTypeCache[t]
 				if !ok {
 					stm = getStructFieldInfoMap(t)
 					p.structTypeCache[t] = stm
 				}
+				r.stm = stm
+				if sfi, ok := stm.remainField(); ok {
+					r.remain, r.remainField = NewOrderedMap(), sfi
+				}
+				r.structType = t
+				r.required, r.defaults = stm.requiredFields(), stm.defaultFields(t)
+				if r.required != nil || r.defaults != nil {
+					r.found = map[string]bool{}
+				}
 
 			case reflect.Map:
 				// For any key that we find in our loop here below, the new value fully
@@ -683,37 +1365,186 @@ func (p *hjsonParser) readObject(
 				// (This is because we are decoding into a map. If we were decoding into
 				// a struct we would need to dig down into a tree, to match the behavior
 				// of Golang's JSON decoder.)
-				elemType = t.Elem()
+				r.elemType = t.Elem()
+				r.mapKeyType = t.Key()
 			}
 		}
 	}
+	r.dest, r.t = dest, t
+
+	// If withoutBraces == true we use the input argument ciBefore as
+	// Before-comment on the first element of this obj, or as InnerLast-comment
+	// on this obj if it doesn't contain any elements. If withoutBraces == false
+	// we ignore the input ciBefore.
+	r.ciBefore = ciBefore
+
+	if !withoutBraces {
+		// assuming ch == '{'
+		p.next()
+		ciInsideFirst := p.getCommentAfter()
+		p.setComment1(&r.node.Cm.InsideFirst, ciInsideFirst)
+		r.ciBefore = p.white()
+		if p.ch == '}' {
+			p.setComment1(&r.node.Cm.InsideLast, r.ciBefore)
+			p.next()
+			return r.finish(p) // empty object
+		}
+	}
+
+	return r.nextMember(p)
+}
+
+// finish returns the object read, after checking required fields and setting
+// default values.
+func (r *objectReader) finish(p *hjsonParser) readStep {
+	if r.entryValueType != nil {
+		return readDone(r.entries, nil)
+	}
+	if r.required != nil {
+		err := p.checkRequired(r.required, r.found, r.objectStart)
+		if err != nil && !p.collect(err) {
+			return readDone(nil, err)
+		}
+	}
+	for _, sfi := range r.defaults {
+		if r.found[sfi.name] {
+			continue
+		}
+		fixupAt := len(p.fixups)
+		val, err := p.defaultValue(sfi, r.structType, r.objectStart)
+		if err == nil {
+			err = p.setMember(r.object, sfi, r.structType, val, fixupAt)
+		}
+		if err != nil && !p.collect(err) {
+			return readDone(nil, err)
+		}
+	}
+	if r.remain != nil && r.remain.Len() > 0 {
+		err := p.setMember(r.object, r.remainField, r.structType, r.remain, len(p.fixups))
+		if err != nil {
+			return readDone(nil, err)
+		}
+	}
+	return readDone(p.maybeWrapNode(&r.node, r.object))
+}
 
+// nextMember reads the object up to the value of its next member.
+func (r *objectReader) nextMember(p *hjsonParser) readStep {
 	for p.ch > 0 {
-		var key string
-		if key, err = p.readKeyname(); err != nil {
-			return nil, err
+		if r.withoutBraces && p.documentSeparators {
+			if n, _ := separatorAt(p.data, p.at-1, true); n > 0 {
+				return r.finish(p)
+			}
+		}
+		if p.ch == '}' && !r.withoutBraces {
+			// After recovering from an error.
+			p.setComment1(&r.node.Cm.InsideLast, r.ciBefore)
+			p.next()
+			return r.finish(p)
+		}
+		members := r.object.Len()
+		if r.entryValueType != nil {
+			members = len(r.entries)
+		}
+		if err := p.checkElements(members + 1); err != nil {
+			return readDone(nil, err)
+		}
+		keyOffset := p.at - 1
+		key, err := p.readKeyname()
+		keyLit := string(p.data[keyOffset : p.at-1])
+		if err == nil && r.mapKeyType != nil {
+			key, err = p.parseMapKey(r.mapKeyType, key, keyOffset)
+		}
+		if err != nil {
+			if p.recoverFrom(err) {
+				r.ciBefore = p.white()
+				continue
+			}
+			return readDone(nil, err)
 		}
 		ciKey := p.white()
 		if p.ch != ':' {
-			return nil, p.errAt("Expected ':' instead of '" + string(p.ch) + "'")
+			err = p.errAt(MsgMissingColon, string(p.ch))
+			if p.recoverFrom(err) {
+				r.ciBefore = p.white()
+				continue
+			}
+			return readDone(nil, err)
 		}
 		p.next()
 
+		m := objectMember{
+			keyOffset: keyOffset,
+			keyLit:    keyLit,
+			ciKey:     ciKey,
+			target:    r.object,
+		}
 		var newDest reflect.Value
-		var newDestType reflect.Type
-		if stm != nil {
-			sfi, ok := stm.getField(key)
+		if r.stm != nil {
+			// Unknown fields have no destination type.
+			r.elemType = nil
+			var sfi structFieldInfo
+			var ok bool
+			if p.KeyMatcher != nil {
+				if sfi, ok = p.matchKey(r.t, r.stm, key); ok {
+					// Use the name of the field, so that json.Unmarshal() finds it.
+					key = sfi.name
+				}
+			}
+			m.objKey = key
+			shadowed := false
+			if !ok {
+				sfi, ok = r.stm.getField(key)
+				if ok && p.CaseSensitive && sfi.name != key {
+					ok, shadowed = false, true
+				}
+			}
+			if ok && sfi.remain {
+				ok = false
+			}
+			if !ok && r.remain != nil {
+				m.target = r.remain
+			} else if !ok && p.unusedKeys != nil {
+				*p.unusedKeys = append(*p.unusedKeys, joinPath(p.path, key))
+			}
+			if !ok && !shadowed {
+				shadowed = shadowedByJSON(r.t, key)
+			}
+			if shadowed && m.target == r.object {
+				// json.Unmarshal() would assign the member to the field anyway,
+				// so it is left out.
+				m.target = NewOrderedMap()
+				if p.DisallowUnknownFields {
+					err = p.errAtOffset(keyOffset, MsgUnknownField, key)
+					if !p.collect(err) {
+						return readDone(nil, err)
+					}
+				}
+			}
 			if ok {
+				if r.found != nil {
+					r.found[sfi.name] = true
+				}
+				if sfi.jsonName != sfi.name {
+					m.objKey = sfi.jsonName
+					m.ignoredByJSON = m.objKey == ""
+				}
+				m.isRune = sfi.rune
+				m.bytesFormat = sfi.bytes
+				m.intFormat = sfi.intFormat
+				m.decoder = sfi.decoder
+				m.enum = sfi.enum
 				// The field might be found on the root struct or in embedded structs.
-				newDest, newDestType = dest, t
+				var newDestType reflect.Type
+				newDest, newDestType = r.dest, r.t
 				for _, i := range sfi.indexPath {
 					newDest, newDestType = unravelDestination(newDest, newDestType)
 
 					if newDestType == nil {
-						return nil, p.errAt("Internal error")
+						return readDone(nil, p.errAt(MsgInternalError))
 					}
 					newDestType = newDestType.Field(i).Type
-					elemType = newDestType
+					r.elemType = newDestType
 
 					if newDest.IsValid() {
 						if newDest.Kind() == reflect.Struct {
@@ -728,81 +1559,213 @@ func (p *hjsonParser) readObject(
 					}
 				}
 			}
+		} else {
+			m.objKey = key
 		}
+		m.key = key
 
 		// duplicate keys overwrite the previous value
-		var val interface{}
-		if val, err = p.readValue(newDest, elemType); err != nil {
-			return nil, err
+		m.parentPath = p.path[:len(p.path):len(p.path)]
+		if r.entryValueType != nil {
+			p.path = append(p.path, len(r.entries), "Value")
+		} else {
+			p.path = append(p.path, key)
 		}
-		if p.nodeDestination {
-			var ok bool
-			if elemNode, ok = val.(*Node); ok {
-				p.setComment1(&elemNode.Cm.Key, ciKey)
-				elemNode.Cm.Key += elemNode.Cm.Before
-				elemNode.Cm.Before = ""
-				p.setComment1(&elemNode.Cm.Before, ciBefore)
-			}
+		m.fixupAt = len(p.fixups)
+		r.member = m
+		return readMember(newDest, r.elemType)
+	}
+
+	if r.withoutBraces {
+		p.setComment1(&r.node.Cm.InsideLast, r.ciBefore)
+		return r.finish(p)
+	}
+	err := p.errAt(MsgUnterminatedObject)
+	if p.recoverFrom(err) {
+		return r.finish(p)
+	}
+	return readDone(nil, err)
+}
+
+func (r *objectReader) step(p *hjsonParser, val interface{}, err error) readStep {
+	m := &r.member
+	key := m.key
+	if err == nil && p.FieldStates != nil {
+		p.recordFieldState(m.parentPath, key)
+	}
+	p.path = p.path[:len(p.path)-1]
+	if r.entryValueType != nil {
+		p.path = p.path[:len(p.path)-1]
+	}
+	if err != nil {
+		if p.recoverFrom(err) {
+			r.ciBefore = p.white()
+			return r.nextMember(p)
 		}
-		// Check white before comma because comma might be on other line.
-		ciAfter := p.white()
-		// in Hjson the comma is optional and trailing commas are allowed
-		if p.ch == ',' {
-			p.next()
-			ciAfterComma := p.whiteAfterComma()
-			if elemNode != nil {
-				existingAfter := elemNode.Cm.After
-				p.setComment2(&elemNode.Cm.After, ciAfter, ciAfterComma)
-				elemNode.Cm.After = existingAfter + elemNode.Cm.After
-			}
-			ciAfter = p.white()
-		}
-		if p.ch == '}' && !withoutBraces {
-			p.setComment1(&node.Cm.InsideLast, ciAfter)
-			oldValue, isDuplicate := object.Set(key, val)
-			if isDuplicate && p.DisallowDuplicateKeys {
-				return nil, p.errAt(fmt.Sprintf("Found duplicate values ('%#v' and '%#v') for key '%v'",
-					oldValue, val, key))
-			}
+		return readDone(nil, err)
+	}
+	if err = p.checkEnum(m.enum, key, val); err != nil && !p.collect(err) {
+		return readDone(nil, err)
+	}
+	if m.decoder != "" {
+		if val, err = p.decodeField(m.decoder, key, r.elemType, val); err != nil && !p.collect(err) {
+			return readDone(nil, err)
+		}
+	}
+	if m.intFormat != "" {
+		val = p.decodeIntField(val)
+	}
+	if m.bytesFormat != "" {
+		if val, err = p.decodeBytesField(m.bytesFormat, val); err != nil && !p.collect(err) {
+			return readDone(nil, err)
+		}
+	}
+	if s, ok := val.(string); ok && m.isRune && utf8.RuneCountInString(s) == 1 {
+		c, _ := utf8.DecodeRuneInString(s)
+		val = json.Number(strconv.Itoa(int(c)))
+	}
+	target := m.target
+	if m.ignoredByJSON {
+		if err = p.fieldFixup(key, r.elemType, val, m.fixupAt); err != nil {
+			return readDone(nil, err)
+		}
+		if r.ignored == nil {
+			r.ignored = NewOrderedMap()
+		}
+		target = r.ignored
+	}
+	var elemNode *Node
+	if p.nodeDestination {
+		var ok bool
+		if elemNode, ok = val.(*Node); ok {
+			elemNode.keyLit = m.keyLit
+			p.setComment1(&elemNode.Cm.Key, m.ciKey)
+			elemNode.Cm.Key += elemNode.Cm.Before
+			elemNode.Cm.Before = ""
+			p.setComment1(&elemNode.Cm.Before, r.ciBefore)
+		}
+	}
+	// Check white before comma because comma might be on other line.
+	ciAfter := p.white()
+	// in Hjson the comma is optional and trailing commas are allowed
+	hasComma := p.ch == ','
+	if hasComma {
+		p.next()
+		ciAfterComma := p.whiteAfterComma()
+		if elemNode != nil {
+			existingAfter := elemNode.Cm.After
+			p.setComment2(&elemNode.Cm.After, ciAfter, ciAfterComma)
+			elemNode.Cm.After = existingAfter + elemNode.Cm.After
+		}
+		ciAfter = p.white()
+	}
+	if !hasComma && p.strictDialect() && p.ch > 0 && p.ch != '}' {
+		err = p.commaError()
+		if !p.recoverFrom(err) {
+			return readDone(nil, err)
+		}
+		ciAfter = p.white()
+	}
+	if p.ch == '}' && !r.withoutBraces {
+		p.setComment1(&r.node.Cm.InsideLast, ciAfter)
+		if r.entryValueType != nil {
+			r.entries = append(r.entries, map[string]interface{}{"Key": key, "Value": val})
 			p.next()
-			return p.maybeWrapNode(&node, object)
+			return r.finish(p)
 		}
-		oldValue, isDuplicate := object.Set(key, val)
+		oldValue, isDuplicate := target.Set(m.objKey, val)
 		if isDuplicate && p.DisallowDuplicateKeys {
-			return nil, p.errAt(fmt.Sprintf("Found duplicate values ('%#v' and '%#v') for key '%v'",
-				oldValue, val, key))
+			err = p.errAtOffset(m.keyOffset, MsgDuplicateKey, oldValue, val, key)
+			if !p.collect(err) {
+				return readDone(nil, err)
+			}
 		}
-		ciBefore = ciAfter
+		p.next()
+		return r.finish(p)
 	}
-
-	if withoutBraces {
-		p.setComment1(&node.Cm.InsideLast, ciBefore)
-		return p.maybeWrapNode(&node, object)
+	if r.entryValueType != nil {
+		r.entries = append(r.entries, map[string]interface{}{"Key": key, "Value": val})
+		r.ciBefore = ciAfter
+		return r.nextMember(p)
+	}
+	oldValue, isDuplicate := target.Set(m.objKey, val)
+	if isDuplicate && p.DisallowDuplicateKeys {
+		err = p.errAtOffset(m.keyOffset, MsgDuplicateKey, oldValue, val, key)
+		if !p.collect(err) {
+			return readDone(nil, err)
+		}
 	}
-	return nil, p.errAt("End of input while parsing an object (did you forget a closing '}'?)")
+	r.ciBefore = ciAfter
+	return r.nextMember(p)
 }
 
 // dest and t must not have been unraveled yet here. In readTfnns we need
 // to check if the original type (or a pointer to it) implements
 // encoding.TextUnmarshaler.
-func (p *hjsonParser) readValue(dest reflect.Value, t reflect.Type) (ret interface{}, err error) {
-	ciBefore := p.white()
+func (p *hjsonParser) readValue(dest reflect.Value, t reflect.Type) (interface{}, error) {
+	f, s := p.beginValue(dest, t)
+	if f.reader == nil {
+		return s.value, s.err
+	}
+	ret, err := p.readContainer(f.reader, s)
+	return p.endValue(f, ret, err)
+}
+
+// beginValue starts reading a value like readValue(). For an object or an
+// array it returns a frame with the reader of the container and its first
+// step, any other value is read completely and returned in the step.
+func (p *hjsonParser) beginValue(dest reflect.Value, t reflect.Type) (f valueFrame, s readStep) {
+	if !p.nodeDestination && t != nil {
+		if _, ut := unravelDestination(dest, t); isOptionalType(ut) {
+			// Read the value like for a *T, so that null is recognized.
+			dest, t = reflect.Value{}, reflect.PtrTo(ut.Field(0).Type)
+		}
+		_, ut := unravelDestination(dest, t)
+		if isComplexKind(ut) {
+			return f, readDone(p.readComplex())
+		}
+		if ut == rawMessageType {
+			return f, readDone(p.readRaw())
+		}
+		if ut == durationType {
+			return f, readDone(p.readDuration())
+		}
+	}
+
+	f.t = t
+	f.ciBefore = p.white()
+	f.start = p.at - 1
+	var ret interface{}
+	var err error
 	// Parse an Hjson value. It could be an object, an array, a string, a number or a word.
 	switch p.ch {
 	case '{':
 		p.nestingDepth++
-		ret, err = p.readObject(false, dest, t, ciBefore)
-		p.nestingDepth--
+		if it := p.interfaceDest(dest, t); it != nil {
+			ret, err = p.readRegisteredType(it, f.ciBefore)
+			p.nestingDepth--
+			break
+		}
+		r := &objectReader{}
+		f.reader = r
+		return f, r.begin(p, false, dest, t, f.ciBefore)
 	case '[':
 		p.nestingDepth++
-		ret, err = p.readArray(dest, t)
-		p.nestingDepth--
+		r := &arrayReader{}
+		f.reader = r
+		return f, r.begin(p, dest, t)
 	case '"', '\'':
-		s, err := p.readString(true)
+		if p.strictDialect() && p.ch == '\'' && p.peek(0) == '\'' && p.peek(1) == '\'' {
+			return f, readDone(nil, p.dialectError())
+		}
+		str, err := p.readString(!p.strictDialect())
 		if err != nil {
-			return nil, err
+			return f, readDone(nil, err)
+		}
+		ret, err = p.maybeWrapNode(&Node{}, str)
+		if err != nil {
+			return f, readDone(nil, err)
 		}
-		ret, err = p.maybeWrapNode(&Node{}, s)
 	default:
 		ret, err = p.readTfnns(dest, t)
 		// Make sure that any comment will include preceding whitespace.
@@ -813,15 +1776,42 @@ func (p *hjsonParser) readValue(dest reflect.Value, t reflect.Type) (ret interfa
 		}
 	}
 
+	return f, readDone(p.endValue(f, ret, err))
+}
+
+// endValue finishes reading the value started by beginValue(), ret being the
+// value read.
+func (p *hjsonParser) endValue(f valueFrame, ret interface{}, err error) (interface{}, error) {
+	if f.reader != nil {
+		p.nestingDepth--
+	}
+
+	p.valueStart, p.valueEnd = f.start, p.at-1
+	p.setSource(ret, f.start)
+
+	t := f.t
+	if ret == nil && err == nil && p.DisallowNullIntoNonPointer && !p.nodeDestination &&
+		t != nil && !canBeNil(t) {
+
+		return nil, p.errAtOffset(f.start, MsgNullIntoNonPointer, t)
+	}
+	if ret != nil && err == nil && p.DecodeHook != nil && p.willMarshalToJSON &&
+		!p.nodeDestination && t != nil {
+
+		if ret, err = p.callDecodeHook(t, ret); err != nil {
+			return nil, err
+		}
+	}
+
 	ciAfter := p.getCommentAfter()
 	if p.nodeDestination {
 		if node, ok := ret.(*Node); ok {
-			p.setComment1(&node.Cm.Before, ciBefore)
+			p.setComment1(&node.Cm.Before, f.ciBefore)
 			p.setComment1(&node.Cm.After, ciAfter)
 		}
 	}
 
-	return
+	return ret, err
 }
 
 func (p *hjsonParser) rootValue(dest reflect.Value) (ret interface{}, err error) {
@@ -833,16 +1823,33 @@ func (p *hjsonParser) rootValue(dest reflect.Value) (ret interface{}, err error)
 	dest = dest.Elem()
 	t := dest.Type()
 
+	if _, ut := unravelDestination(dest, t); !p.nodeDestination && isComplexKind(ut) {
+		if ret, err = p.readComplex(); err == nil {
+			_, err = p.checkTrailing()
+		}
+		return
+	}
+
+	if p.strictDialect() {
+		return p.dialectRoot(dest, t)
+	}
+
 	var errSyntax error
 	var ciAfter commentInfo
 	ciBefore := p.white()
 
+	start := p.at - 1
 	switch p.ch {
 	case '{':
-		ret, err = p.readObject(false, dest, t, ciBefore)
+		if it := p.interfaceDest(dest, t); it != nil {
+			ret, err = p.readRegisteredType(it, ciBefore)
+		} else {
+			ret, err = p.readObject(false, dest, t, ciBefore)
+		}
 		if err != nil {
 			return
 		}
+		p.setSource(ret, start)
 		ciAfter, err = p.checkTrailing()
 		if err != nil {
 			return
@@ -859,6 +1866,7 @@ func (p *hjsonParser) rootValue(dest reflect.Value) (ret interface{}, err error)
 		if err != nil {
 			return
 		}
+		p.setSource(ret, start)
 		ciAfter, err = p.checkTrailing()
 		if err != nil {
 			return
@@ -874,6 +1882,22 @@ func (p *hjsonParser) rootValue(dest reflect.Value) (ret interface{}, err error)
 
 	// Assume we have a root object without braces.
 	ret, errSyntax = p.readObject(true, dest, t, ciBefore)
+	bracelessErrs := p.errs
+	collected := errSyntax == nil && len(bracelessErrs) > 0
+	if collected {
+		errSyntax = bracelessErrs
+	}
+	if errSyntax == nil {
+		// Any comments before the first key belong to the first key, so they are
+		// included in the source of the object.
+		p.setSource(ret, ciBefore.cmStart)
+	} else if !isSyntaxError(errSyntax) {
+		// The input is an object, but its values do not fit the destination.
+		if collected {
+			return nil, nil
+		}
+		return nil, errSyntax
+	}
 	ciAfter, err = p.checkTrailing()
 	if errSyntax != nil || err != nil {
 		// Syntax error, or maybe a single JSON value.
@@ -894,7 +1918,7 @@ func (p *hjsonParser) rootValue(dest reflect.Value) (ret interface{}, err error)
 	if err == nil {
 		ciAfter, err = p.checkTrailing()
 	}
-	if err == nil {
+	if err == nil && len(p.errs) == 0 {
 		if p.nodeDestination {
 			if node, ok := ret.(*Node); ok {
 				// ciBefore has been read again and set on the node inside the
@@ -903,6 +1927,9 @@ func (p *hjsonParser) rootValue(dest reflect.Value) (ret interface{}, err error)
 				p.setComment1(&node.Cm.After, ciAfter)
 				if node.Cm.After != "" {
 					existingAfter += "\n"
+				} else if existingAfter == "" && p.WhitespaceAsComments {
+					// Only whitespace (like a final line feed) after the value.
+					existingAfter = string(p.data[node.End.Offset:])
 				}
 				node.Cm.After = existingAfter + node.Cm.After
 			}
@@ -912,16 +1939,52 @@ func (p *hjsonParser) rootValue(dest reflect.Value) (ret interface{}, err error)
 	}
 
 	if errSyntax != nil {
+		// Report the errors found when parsing the input as an object.
+		p.errs = bracelessErrs
+		if collected {
+			return nil, nil
+		}
 		return nil, errSyntax
 	}
 
 	return
 }
 
+// valueErrorKinds are the kinds of errors about values that do not fit the
+// destination, as opposed to syntax errors.
+var valueErrorKinds = map[string]bool{
+	MsgNotInEnum:          true,
+	MsgMissingRequired:    true,
+	MsgUnknownField:       true,
+	MsgBadDefault:         true,
+	MsgBadFieldValue:      true,
+	MsgDecodeHook:         true,
+	MsgBadDuration:        true,
+	MsgBadBytes:           true,
+	MsgBadMapKey:          true,
+	MsgNullIntoNonPointer: true,
+}
+
+// isSyntaxError returns false if err is or holds an error about a value that
+// does not fit the destination.
+func isSyntaxError(err error) bool {
+	switch err := err.(type) {
+	case *ParseError:
+		return !valueErrorKinds[err.Kind]
+	case ErrorList:
+		for _, pe := range err {
+			if valueErrorKinds[pe.Kind] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 func (p *hjsonParser) checkTrailing() (commentInfo, error) {
 	ci := p.white()
 	if p.ch > 0 {
-		return ci, p.errAt("Syntax error, found trailing characters")
+		return ci, p.errAt(MsgTrailingCharacters)
 	}
 	return ci, nil
 }
@@ -934,6 +1997,25 @@ func Unmarshal(data []byte, v interface{}) error {
 	return UnmarshalWithOptions(data, v, DefaultDecoderOptions())
 }
 
+// UnmarshalStrict parses the Hjson-encoded data using StrictDecoderOptions()
+// and stores the result in the value pointed to by v. Unknown fields,
+// duplicate keys and null for destinations that cannot be nil are reported as
+// errors.
+//
+// See UnmarshalWithOptions.
+func UnmarshalStrict(data []byte, v interface{}) error {
+	return UnmarshalWithOptions(data, v, StrictDecoderOptions())
+}
+
+// canBeNil returns true if null can be assigned to a destination of type t.
+func canBeNil(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		return true
+	}
+	return false
+}
+
 func orderedUnmarshal(
 	data []byte,
 	v interface{},
@@ -942,11 +2024,27 @@ func orderedUnmarshal(
 	nodeDestination bool,
 ) (
 	interface{},
+	[]destFixup,
 	error,
 ) {
 	rv := reflect.ValueOf(v)
 	if rv.Kind() != reflect.Ptr || rv.IsNil() {
-		return nil, fmt.Errorf("cannot unmarshal into non-pointer %v", reflect.TypeOf(v))
+		return nil, nil, fmt.Errorf("cannot unmarshal into non-pointer %v", reflect.TypeOf(v))
+	}
+
+	if err := checkDialect(options.Dialect); err != nil {
+		return nil, nil, err
+	}
+	if options.MaxBytes > 0 && len(data) > options.MaxBytes {
+		return nil, nil, &LimitError{
+			Limit:  "MaxBytes",
+			Max:    options.MaxBytes,
+			Offset: options.MaxBytes,
+		}
+	}
+	data, err := checkUTF8(data, options)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	parser := &hjsonParser{
@@ -960,12 +2058,31 @@ func orderedUnmarshal(
 		nestingDepth:      0,
 	}
 	parser.resetAt()
-	value, err := parser.rootValue(rv)
+	var value interface{}
+	if options.valuePath != nil {
+		value, err = parser.pathValue(rv, options.valuePath)
+	} else {
+		value, err = parser.rootValue(rv)
+	}
+	if parser.CollectErrors && (len(parser.errs) > 0 || err != nil) {
+		if !parser.collect(err) && err != nil {
+			return nil, nil, err
+		}
+		sort.SliceStable(parser.errs, func(i, j int) bool {
+			return parser.errs[i].Offset < parser.errs[j].Offset
+		})
+		return nil, nil, parser.errs
+	}
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	if nodeDestination {
+		if node, ok := value.(*Node); ok {
+			node.snapshot()
+		}
 	}
 
-	return value, nil
+	return value, parser.fixups, nil
 }
 
 // UnmarshalWithOptions parses the Hjson-encoded data and stores the result
@@ -981,11 +2098,27 @@ func orderedUnmarshal(
 //	**hjson.Node
 //
 // Comments can be read from the Hjson-encoded data, but only if the input
-// argument v is of type *hjson.Node or **hjson.Node.
+// argument v is of type *hjson.Node or **hjson.Node, or if
+// DecoderOptions.KeepComments is true and v is a pointer to an interface{} or
+// a map.
 //
 // For more details about the output from this function, see the documentation
 // for json.Unmarshal().
 func UnmarshalWithOptions(data []byte, v interface{}, options DecoderOptions) error {
+	if options.Include != nil || options.ResolveRefs {
+		return unmarshalExpanded(data, v, options)
+	}
+
+	if options.KeepComments {
+		if ok, err := unmarshalKeepComments(data, v, options); ok {
+			return err
+		}
+	}
+
+	if raw, ok := v.(*RawMessage); ok && options.valuePath == nil {
+		return unmarshalRaw(data, raw, options)
+	}
+
 	inOM, destinationIsOrderedMap := v.(*OrderedMap)
 	if !destinationIsOrderedMap {
 		pInOM, ok := v.(**OrderedMap)
@@ -1006,7 +2139,7 @@ func UnmarshalWithOptions(data []byte, v interface{}, options DecoderOptions) er
 		}
 	}
 
-	value, err := orderedUnmarshal(data, v, options, !(destinationIsOrderedMap ||
+	value, fixups, err := orderedUnmarshal(data, v, options, !(destinationIsOrderedMap ||
 		destinationIsNode), destinationIsNode)
 	if err != nil {
 		return err
@@ -1037,7 +2170,7 @@ func UnmarshalWithOptions(data []byte, v interface{}, options DecoderOptions) er
 	}
 
 	dec := json.NewDecoder(bytes.NewBuffer(buf))
-	if options.UseJSONNumber {
+	if options.UseJSONNumber || options.UseInt64 {
 		dec.UseNumber()
 	}
 	if options.DisallowUnknownFields {
@@ -1048,6 +2181,58 @@ func UnmarshalWithOptions(data []byte, v interface{}, options DecoderOptions) er
 	if err != nil {
 		return err
 	}
+	if options.UseInt64 && !options.UseJSONNumber {
+		replaceJSONNumbers(reflect.ValueOf(v), 0)
+	}
+
+	for _, f := range fixups {
+		if !applyFixup(reflect.ValueOf(v), f.path, f.value) && !f.ignorable {
+			return fmt.Errorf("cannot assign %v to %v", f.describe(), reflect.TypeOf(v))
+		}
+	}
 
 	return err
 }
+
+// unmarshalKeepComments handles the destinations supported by
+// DecoderOptions.KeepComments. It returns false if v is not such a
+// destination.
+func unmarshalKeepComments(data []byte, v interface{}, options DecoderOptions) (bool, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return false, nil
+	}
+	t := rv.Type().Elem()
+	isMap := t.Kind() == reflect.Map && t.Key().Kind() == reflect.String &&
+		t.Elem().Kind() == reflect.Interface && t.Elem().NumMethod() == 0
+	if !isMap && (t.Kind() != reflect.Interface || t.NumMethod() != 0) {
+		return false, nil
+	}
+
+	var node *Node
+	if err := UnmarshalWithOptions(data, &node, options); err != nil {
+		return true, err
+	}
+
+	if !isMap {
+		rv.Elem().Set(reflect.ValueOf(node))
+		return true, nil
+	}
+
+	if node.Value == nil {
+		rv.Elem().Set(reflect.Zero(t))
+		return true, nil
+	}
+	om, ok := node.Value.(*OrderedMap)
+	if !ok {
+		return true, fmt.Errorf("cannot unmarshal %T into %v", node.Value, t)
+	}
+	if rv.Elem().IsNil() {
+		rv.Elem().Set(reflect.MakeMapWithSize(t, om.Len()))
+	}
+	for i, key := range om.Keys {
+		rv.Elem().SetMapIndex(reflect.ValueOf(key).Convert(t.Key()),
+			reflect.ValueOf(om.AtIndex(i)))
+	}
+	return true, nil
+}
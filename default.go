@@ -0,0 +1,100 @@
+package hjson
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// defaultFieldsCache holds the result of defaultFields() for each struct type.
+var defaultFieldsCache sync.Map
+
+// defaultFields returns the fields with a default tag, and the fields of
+// struct types containing such fields, in the order of the struct.
+func (s structFieldMap) defaultFields(structType reflect.Type) []structFieldInfo {
+	if cached, ok := defaultFieldsCache.Load(structType); ok {
+		return cached.([]structFieldInfo)
+	}
+	var sfis []structFieldInfo
+	for _, arr := range s {
+		for _, sfi := range arr {
+			if sfi.defaultValue != "" ||
+				hasDefaults(fieldType(structType, sfi.indexPath), map[reflect.Type]bool{}) {
+
+				sfis = append(sfis, sfi)
+			}
+		}
+	}
+	sort.Sort(byIndex(sfis))
+	defaultFieldsCache.Store(structType, sfis)
+	return sfis
+}
+
+// fieldType returns the type of the field of structType found by following
+// indexPath through embedded structs.
+func fieldType(structType reflect.Type, indexPath []int) reflect.Type {
+	t := structType
+	for _, i := range indexPath {
+		_, t = unravelDestination(reflect.Value{}, t)
+		t = t.Field(i).Type
+	}
+	return t
+}
+
+// hasDefaults returns true if t is a struct (not a pointer to a struct) with
+// fields that have a default tag, directly or in nested structs.
+func hasDefaults(t reflect.Type, visited map[reflect.Type]bool) bool {
+	if t.Kind() != reflect.Struct || visited[t] {
+		return false
+	}
+	visited[t] = true
+	for _, sfi := range getStructFieldInfoSlice(t) {
+		if sfi.defaultValue != "" || hasDefaults(fieldType(t, sfi.indexPath), visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultValue decodes the default value of the struct field sfi of
+// structType, which was not found in the object starting at offset. The
+// default is read as an Hjson value, so strings can be quoteless. A nested
+// struct without a default tag is read as {}, so that the defaults of its own
+// fields are applied.
+func (p *hjsonParser) defaultValue(
+	sfi structFieldInfo,
+	structType reflect.Type,
+	offset int,
+) (interface{}, error) {
+	text := sfi.defaultValue
+	if text == "" {
+		text = "{}"
+	}
+	options := p.DecoderOptions
+	options.CollectErrors = false
+	options.FieldStates = nil
+	options.unusedKeys = nil
+	sub := &hjsonParser{
+		DecoderOptions:    options,
+		data:              []byte(text),
+		ch:                ' ',
+		structTypeCache:   p.structTypeCache,
+		willMarshalToJSON: p.willMarshalToJSON,
+		nestingDepth:      p.nestingDepth,
+	}
+	sub.resetAt()
+	sub.path = append(append(sub.path, p.path...), sfi.name)
+	val, err := sub.readValue(reflect.Value{}, fieldType(structType, sfi.indexPath))
+	if err == nil {
+		_, err = sub.checkTrailing()
+	}
+	if err != nil {
+		reason := err.Error()
+		if pe, ok := err.(*ParseError); ok {
+			reason = pe.Message
+		}
+		return nil, p.errAtOffset(offset, MsgBadDefault, sfi.defaultValue, sfi.name, reason)
+	}
+	p.fixups = append(p.fixups, sub.fixups...)
+	return val, nil
+}
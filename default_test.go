@@ -0,0 +1,56 @@
+package hjson
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDefaultTag(t *testing.T) {
+	type db struct {
+		Host    string        `json:"host" default:"localhost"`
+		Port    int           `json:"port" default:"5432"`
+		Timeout time.Duration `json:"timeout" default:"5s"`
+	}
+	type config struct {
+		Name  string   `json:"name" default:"my app"`
+		Debug *bool    `json:"debug" default:"true"`
+		Tags  []string `json:"tags" default:"[\"a\", \"b\"]"`
+		Ratio float64  `json:"ratio" default:"0.5"`
+		DB    db       `json:"db"`
+	}
+
+	var c config
+	if err := Unmarshal([]byte("{\n  ratio: 2\n}"), &c); err != nil {
+		t.Fatal(err)
+	}
+	debug := true
+	expected := config{
+		Name:  "my app",
+		Debug: &debug,
+		Tags:  []string{"a", "b"},
+		Ratio: 2,
+		DB:    db{Host: "localhost", Port: 5432, Timeout: 5 * time.Second},
+	}
+	if !reflect.DeepEqual(c, expected) {
+		t.Errorf("Expected:\n%#v\nGot:\n%#v", expected, c)
+	}
+
+	// null is not absent.
+	c = config{}
+	if err := Unmarshal([]byte("{\n  debug: null\n  db: {\n    port: 1\n  }\n}"), &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Debug != nil || c.DB.Port != 1 || c.DB.Host != "localhost" || c.Name != "my app" {
+		t.Errorf("Unexpected result: %#v", c)
+	}
+
+	type bad struct {
+		Port int `json:"port" default:"[1"`
+	}
+	var b bad
+	err := Unmarshal([]byte("{}"), &b)
+	if pe, ok := err.(*ParseError); !ok || pe.Kind != MsgBadDefault {
+		t.Errorf("Unexpected error: %#v", err)
+	}
+}
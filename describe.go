@@ -0,0 +1,147 @@
+package hjson
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// The types reported in PathInfo.Type.
+const (
+	TypeObject = "object"
+	TypeArray  = "array"
+	TypeString = "string"
+	TypeNumber = "number"
+	TypeBool   = "bool"
+	TypeNull   = "null"
+)
+
+// describePreviewLen is the max number of characters of PathInfo.Preview.
+const describePreviewLen = 40
+
+// PathInfo describes a value found by Describe().
+type PathInfo struct {
+	// Path is the dot-separated list of object keys and array indexes leading
+	// to the value, like in RoundTripDiff. Empty for the root value.
+	Path string `json:"path"`
+	// Type is one of TypeObject, TypeArray, TypeString, TypeNumber, TypeBool
+	// and TypeNull.
+	Type string `json:"type"`
+	// Preview is a short description of the value: the value as JSON for
+	// scalars, shortened to 40 characters, or the number of members or
+	// elements for objects and arrays, like "{3 members}" or "[2 elements]".
+	Preview string `json:"preview"`
+	// Pos is the position of the value, or of the first key of a root object
+	// without braces.
+	Pos Position `json:"pos"`
+}
+
+// Describe returns every value of the Hjson document data with its path, its
+// type, a preview and its position, in document order, starting with the
+// root value. It is meant for quick audits of configs, for generating tables
+// of the settings in documentation and for grouping diffs by path.
+//
+// Like Parse(), Describe does not decode the document into Go values. An
+// error is returned if data is not a valid Hjson document.
+func Describe(data []byte) ([]PathInfo, error) {
+	h := describeHandler{infos: []PathInfo{}}
+	if err := Parse(data, &h); err != nil {
+		return nil, err
+	}
+	return h.infos, nil
+}
+
+// describeFrame is an object or array that is being described.
+type describeFrame struct {
+	// info is the index of the PathInfo of the object or array.
+	info int
+	// count is the number of members or elements found so far.
+	count int
+	// key is the key of the next member, if the frame is an object.
+	key string
+}
+
+type describeHandler struct {
+	BaseHandler
+	infos []PathInfo
+	stack []describeFrame
+}
+
+// add appends the PathInfo of a value found at pos.
+func (h *describeHandler) add(typ, preview string, pos Position) {
+	path := ""
+	if n := len(h.stack); n > 0 {
+		f := &h.stack[n-1]
+		name := f.key
+		if h.infos[f.info].Type == TypeArray {
+			name = strconv.Itoa(f.count)
+		}
+		f.count++
+		path = name
+		if n > 1 {
+			path = h.infos[f.info].Path + "." + name
+		}
+	}
+	h.infos = append(h.infos, PathInfo{Path: path, Type: typ, Preview: preview, Pos: pos})
+}
+
+func (h *describeHandler) ObjectStart(pos Position) error {
+	h.add(TypeObject, "", pos)
+	h.stack = append(h.stack, describeFrame{info: len(h.infos) - 1})
+	return nil
+}
+
+func (h *describeHandler) ArrayStart(pos Position) error {
+	h.add(TypeArray, "", pos)
+	h.stack = append(h.stack, describeFrame{info: len(h.infos) - 1})
+	return nil
+}
+
+func (h *describeHandler) ObjectEnd(pos Position) error {
+	h.end("{", "member", "}")
+	return nil
+}
+
+func (h *describeHandler) ArrayEnd(pos Position) error {
+	h.end("[", "element", "]")
+	return nil
+}
+
+// end sets the preview of the innermost object or array and removes it from
+// the stack.
+func (h *describeHandler) end(open, noun, close string) {
+	f := h.stack[len(h.stack)-1]
+	h.stack = h.stack[:len(h.stack)-1]
+	if f.count != 1 {
+		noun += "s"
+	}
+	h.infos[f.info].Preview = open + strconv.Itoa(f.count) + " " + noun + close
+}
+
+func (h *describeHandler) Key(key string, pos Position) error {
+	h.stack[len(h.stack)-1].key = key
+	return nil
+}
+
+func (h *describeHandler) Value(value interface{}, pos Position) error {
+	typ := TypeNull
+	switch value.(type) {
+	case string:
+		typ = TypeString
+	case float64:
+		typ = TypeNumber
+	case bool:
+		typ = TypeBool
+	}
+	h.add(typ, shortenPreview(diffValue(value)), pos)
+	return nil
+}
+
+// shortenPreview cuts s to describePreviewLen characters, ending with "...".
+func shortenPreview(s string) string {
+	if utf8.RuneCountInString(s) <= describePreviewLen {
+		return s
+	}
+	runes := []rune(s)
+	return strings.TrimRight(string(runes[:describePreviewLen-3]), " ") + "..."
+}
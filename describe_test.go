@@ -0,0 +1,71 @@
+package hjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDescribe(t *testing.T) {
+	txt := `# Server settings
+name: web
+db: {
+  host: localhost
+  ports: [80, 443]
+  tls: true
+}
+notes: This is a very long text that does not fit into the preview
+owner: null
+`
+	infos, err := Describe([]byte(txt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []PathInfo{
+		{"", TypeObject, "{4 members}", Position{18, 2, 1}},
+		{"name", TypeString, `"web"`, Position{24, 2, 7}},
+		{"db", TypeObject, "{3 members}", Position{32, 3, 5}},
+		{"db.host", TypeString, `"localhost"`, Position{42, 4, 9}},
+		{"db.ports", TypeArray, "[2 elements]", Position{61, 5, 10}},
+		{"db.ports.0", TypeNumber, "80", Position{62, 5, 11}},
+		{"db.ports.1", TypeNumber, "443", Position{66, 5, 15}},
+		{"db.tls", TypeBool, "true", Position{78, 6, 8}},
+		{"notes", TypeString, `"This is a very long text that does n...`, Position{92, 8, 8}},
+		{"owner", TypeNull, "null", Position{159, 9, 8}},
+	}
+	if !reflect.DeepEqual(infos, expected) {
+		t.Errorf("Expected:\n%#v\nGot:\n%#v", expected, infos)
+	}
+
+	infos, err = Describe([]byte("[[], {a: 1}]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []PathInfo{
+		{"", TypeArray, "[2 elements]", Position{0, 1, 1}},
+		{"0", TypeArray, "[0 elements]", Position{1, 1, 2}},
+		{"1", TypeObject, "{1 member}", Position{5, 1, 6}},
+		{"1.a", TypeNumber, "1", Position{9, 1, 10}},
+	}
+	if !reflect.DeepEqual(infos, expected) {
+		t.Errorf("Expected:\n%#v\nGot:\n%#v", expected, infos)
+	}
+
+	if _, err := Describe([]byte("{a: 1")); err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestDescribeInvalidUTF8(t *testing.T) {
+	// The positions are those in the input, not in the repaired text.
+	infos, err := Describe([]byte("# caf\xe9\nname: x\nport: 80\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Position{{Offset: 7, Line: 2, Column: 1}, {Offset: 13, Line: 2, Column: 7},
+		{Offset: 21, Line: 3, Column: 7}}
+	for i, info := range infos {
+		if info.Pos != expected[i] {
+			t.Errorf("%q: expected position %+v, got %+v", info.Path, expected[i], info.Pos)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package hjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// checkDialect returns an error if dialect cannot be used as
+// DecoderOptions.Dialect.
+func checkDialect(dialect Format) error {
+	switch dialect {
+	case FormatUnknown, FormatHjson, FormatJSON5, FormatJSONC:
+		return nil
+	}
+	return fmt.Errorf("hjson: unsupported dialect %v", dialect)
+}
+
+// json5 returns true if the input is decoded as JSON5.
+func (p *hjsonParser) json5() bool {
+	return p.Dialect == FormatJSON5
+}
+
+// strictDialect returns true if the input is decoded as JSON5 or JSONC, which
+// both require commas and braces and do not allow quoteless strings.
+func (p *hjsonParser) strictDialect() bool {
+	return p.Dialect == FormatJSON5 || p.Dialect == FormatJSONC
+}
+
+// dialectError returns the error for Hjson syntax that is not allowed by
+// p.Dialect, found at the current position instead of a value, a key name or
+// a comma.
+func (p *hjsonParser) dialectError() error {
+	switch {
+	case p.ch == '#':
+		return p.errAt(MsgNotInDialect, "# comments", p.Dialect)
+	case p.ch == '\'' && p.peek(0) == '\'' && p.peek(1) == '\'':
+		return p.errAt(MsgNotInDialect, "multiline strings", p.Dialect)
+	case p.ch == '\'':
+		return p.errAt(MsgNotInDialect, "single-quoted strings", p.Dialect)
+	case p.ch == 0:
+		return p.errAt(MsgEOFInValue)
+	}
+	return p.errAt(MsgNotInDialect, "quoteless strings", p.Dialect)
+}
+
+// commaError returns the error for a missing comma between two members or
+// elements, at the current position.
+func (p *hjsonParser) commaError() error {
+	if p.ch == '#' {
+		return p.dialectError()
+	}
+	return p.errAt(MsgMissingComma, string(p.ch))
+}
+
+// readIdentifier reads a key name without quotes, which must be an
+// identifier in JSON5 and is not allowed in JSONC.
+func (p *hjsonParser) readIdentifier() (string, error) {
+	if p.Dialect == FormatJSONC && p.ch != '#' && p.ch != 0 {
+		return "", p.errAt(MsgNotInDialect, "key names without quotes", p.Dialect)
+	}
+	if !isIdentifierChar(p.ch, true) {
+		if p.ch == '#' || p.ch == 0 {
+			return "", p.dialectError()
+		}
+		return "", p.errAt(MsgUnexpectedInKey, string(p.ch))
+	}
+	start := p.at - 1
+	for isIdentifierChar(p.ch, false) {
+		p.next()
+	}
+	return string(p.data[start : p.at-1]), nil
+}
+
+// readDialectScalar reads a keyword or a number in the syntax of p.Dialect.
+// Anything else would be a quoteless string in Hjson and is an error. t is the
+// type of the destination, after unravelDestination().
+func (p *hjsonParser) readDialectScalar(t reflect.Type) (interface{}, error) {
+	start := p.at - 1
+	for p.ch > ' ' && !isPunctuatorChar(p.ch) && p.ch != '#' &&
+		!(p.ch == '/' && (p.peek(0) == '/' || p.peek(0) == '*')) {
+
+		p.next()
+	}
+	lit := string(p.data[start : p.at-1])
+	switch lit {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	case "Infinity", "-Infinity", "+Infinity", "NaN", "-NaN", "+NaN":
+		if p.json5() && p.NonFiniteNumbers && isFloatDest(t) {
+			f, _ := parseNonFinite(lit)
+			return p.nonFiniteValue(f, t), nil
+		}
+		if p.json5() {
+			return nil, p.errAtOffset(start, MsgNotFiniteNumber, lit)
+		}
+	}
+	// The other keywords have been handled, so only a number can be valid.
+	text, ok := lit, lit != "" && lit[0] != '"' && json.Valid([]byte(lit))
+	if p.json5() {
+		text, ok = json5Number(lit)
+	}
+	if !ok {
+		p.at, p.ch = start+1, p.data[start]
+		return nil, p.dialectError()
+	}
+	if isBigNumberType(t) {
+		return p.parseBigNumber(text, t)
+	}
+	if v, ok, err := p.callNumberFunc(lit, t); err != nil || ok {
+		return v, err
+	}
+	n, err := p.parseNumber([]byte(text))
+	if err != nil || p.StrictNumbers && isNumberOutOfRange(n) {
+		return nil, p.errAtOffset(start, MsgNumberOutOfRange)
+	}
+	return n, nil
+}
+
+// json5Number converts lit, a number in JSON5 syntax, to JSON syntax.
+// Returns false if lit is not a number.
+func json5Number(lit string) (string, bool) {
+	if lit == "" || !isJSON5Literal([]byte(lit)) {
+		return "", false
+	}
+	sign := ""
+	switch lit[0] {
+	case '-':
+		sign = "-"
+		lit = lit[1:]
+	case '+':
+		lit = lit[1:]
+	}
+	if len(lit) > 2 && lit[0] == '0' && (lit[1] == 'x' || lit[1] == 'X') {
+		n, ok := new(big.Int).SetString(lit[2:], 16)
+		if !ok {
+			return "", false
+		}
+		if n.Sign() == 0 {
+			sign = ""
+		}
+		return sign + n.String(), true
+	}
+	mant, exp := lit, ""
+	if i := strings.IndexAny(lit, "eE"); i >= 0 {
+		mant, exp = lit[:i], lit[i:]
+	}
+	if strings.HasPrefix(mant, ".") {
+		mant = "0" + mant
+	}
+	mant = strings.TrimSuffix(mant, ".")
+	if len(mant) > 1 && mant[0] == '0' && mant[1] != '.' {
+		// Leading zeros are not allowed, like in JSON.
+		return "", false
+	}
+	return sign + mant + exp, true
+}
+
+// readJSON5Escape reads the escape sequences of JSON5 that are not valid in
+// Hjson, with the character after the backslash in p.ch, and writes the
+// escaped text to res.
+func (p *hjsonParser) readJSON5Escape(res *bytes.Buffer) error {
+	switch {
+	case p.ch == 'v':
+		res.WriteByte('\v')
+	case p.ch == '0':
+		if p.peek(0) >= '0' && p.peek(0) <= '9' {
+			p.next()
+			return p.errAt(MsgBadEscape, string(p.ch))
+		}
+		res.WriteByte(0)
+	case p.ch == 'x':
+		var hex [2]byte
+		for i := range hex {
+			p.next()
+			if !isHexDigit(p.ch) {
+				return p.errAt(MsgBadEscape, "x"+string(hex[:i])+string(p.ch))
+			}
+			hex[i] = p.ch
+		}
+		n, _ := strconv.ParseUint(string(hex[:]), 16, 8)
+		res.WriteRune(rune(n))
+	case p.ch == '\r':
+		// Line continuation.
+		if p.peek(0) == '\n' {
+			p.next()
+		}
+	case p.ch == '\n':
+	case p.ch >= '1' && p.ch <= '9' || p.ch == 0:
+		return p.errAt(MsgBadEscape, string(p.ch))
+	default:
+		// Any other character stands for itself.
+		res.WriteByte(p.ch)
+	}
+	return nil
+}
+
+func isHexDigit(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+// dialectRoot reads the root value of JSON5 or JSONC input, which must not be
+// an object without braces.
+func (p *hjsonParser) dialectRoot(dest reflect.Value, t reflect.Type) (interface{}, error) {
+	ret, err := p.readValue(dest, t)
+	if err != nil {
+		return nil, err
+	}
+	ci, err := p.checkTrailing()
+	if err != nil {
+		return nil, err
+	}
+	if node, ok := ret.(*Node); ok && p.nodeDestination {
+		// Comments on the lines after the value.
+		var after string
+		p.setComment1(&after, ci)
+		node.Cm.After += after
+	}
+	return ret, nil
+}
@@ -0,0 +1,144 @@
+package hjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJSON5Dialect(t *testing.T) {
+	options := DefaultDecoderOptions()
+	options.Dialect = FormatJSON5
+
+	txt := `// JSON5 input
+{
+  unquoted: 'single \'quoted\'',
+  $id_1: 0x1F,
+  neg: -0XfF,
+  plus: +1,
+  lead: .5,
+  trail: 5.,
+  exp: 5.e2,
+  "quoted": "line \
+continued \x41\v",
+  list: [1, 2, /* c */ 3,],
+  empty: '',
+}
+`
+	var v map[string]interface{}
+	if err := UnmarshalWithOptions([]byte(txt), &v, options); err != nil {
+		t.Fatal(err)
+	}
+	exp := map[string]interface{}{
+		"unquoted": "single 'quoted'",
+		"$id_1":    31.0,
+		"neg":      -255.0,
+		"plus":     1.0,
+		"lead":     0.5,
+		"trail":    5.0,
+		"exp":      500.0,
+		"quoted":   "line continued A\v",
+		"list":     []interface{}{1.0, 2.0, 3.0},
+		"empty":    "",
+	}
+	if !reflect.DeepEqual(v, exp) {
+		t.Errorf("Expected\n%#v\ngot\n%#v", exp, v)
+	}
+
+	var s struct {
+		A uint8 `json:"a"`
+	}
+	if err := UnmarshalWithOptions([]byte("{a: 0xff}"), &s, options); err != nil || s.A != 255 {
+		t.Errorf("Unexpected result: %v %v", s.A, err)
+	}
+
+	var node *Node
+	if err := UnmarshalWithOptions([]byte("[0x10, 'x'] // end"), &node, options); err != nil {
+		t.Fatal(err)
+	}
+	if n := node.NI(0); n.Value != 16.0 || n.Lit != "0x10" || node.Cm.After != " // end" {
+		t.Errorf("Unexpected node: %#v", node)
+	}
+}
+
+func TestJSON5DialectErrors(t *testing.T) {
+	options := DefaultDecoderOptions()
+	options.Dialect = FormatJSON5
+
+	for txt, kind := range map[string]string{
+		"{a: text}":                   MsgNotInDialect,
+		"{a: 1 # comment\n}":          MsgNotInDialect,
+		"{a: '''\nml\n'''}":           MsgNotInDialect,
+		"{\"a\": 1\n\"b\": 2}":        MsgMissingComma,
+		"[1 2]":                       MsgMissingComma,
+		"a: 1":                        MsgNotInDialect,
+		"{x-y: 1}":                    MsgMissingColon,
+		"{1a: 1}":                     MsgUnexpectedInKey,
+		"[1,,2]":                      MsgPunctuatorInValue,
+		"[Infinity]":                  MsgNotFiniteNumber,
+		"[007]":                       MsgNotInDialect,
+		"['\\1']":                     MsgBadEscape,
+		"{a: 1} trailing":             MsgTrailingCharacters,
+		"{a: 1,\n  # comment\n b: 2}": MsgNotInDialect,
+	} {
+		var v interface{}
+		err := UnmarshalWithOptions([]byte(txt), &v, options)
+		if pe, ok := err.(*ParseError); !ok || pe.Kind != kind {
+			t.Errorf("Expected %s for %q, got %v", kind, txt, err)
+		}
+	}
+
+	options.Dialect = FormatJSON
+	var v interface{}
+	if err := UnmarshalWithOptions([]byte("{}"), &v, options); err == nil {
+		t.Error("Expected an error for an unsupported dialect")
+	}
+}
+
+func TestJSONCDialect(t *testing.T) {
+	options := DefaultDecoderOptions()
+	options.Dialect = FormatJSONC
+
+	txt := `// settings.json
+{
+  /* Editor */
+  "editor.fontSize": 14,
+  "files.exclude": {"**/.git": true,},
+  "list": [1.5e3, -2, null, "x\"y"],
+}
+`
+	var v map[string]interface{}
+	if err := UnmarshalWithOptions([]byte(txt), &v, options); err != nil {
+		t.Fatal(err)
+	}
+	exp := map[string]interface{}{
+		"editor.fontSize": 14.0,
+		"files.exclude":   map[string]interface{}{"**/.git": true},
+		"list":            []interface{}{1500.0, -2.0, nil, "x\"y"},
+	}
+	if !reflect.DeepEqual(v, exp) {
+		t.Errorf("Expected\n%#v\ngot\n%#v", exp, v)
+	}
+
+	for _, txt := range []string{
+		"{a: 1}",
+		"{'a': 1}",
+		`{"a": 'x'}`,
+		`{"a": 0x1F}`,
+		`{"a": .5}`,
+		`{"a": +1}`,
+		`{"a": Infinity}`,
+		`{"a": text}`,
+		"{\"a\": 1 # comment\n}",
+	} {
+		var v interface{}
+		err := UnmarshalWithOptions([]byte(txt), &v, options)
+		if pe, ok := err.(*ParseError); !ok || pe.Kind != MsgNotInDialect {
+			t.Errorf("Expected %s for %q, got %v", MsgNotInDialect, txt, err)
+		}
+	}
+	var s interface{}
+	err := UnmarshalWithOptions([]byte("[1\n2]"), &s, options)
+	if pe, ok := err.(*ParseError); !ok || pe.Kind != MsgMissingComma {
+		t.Errorf("Expected %s, got %v", MsgMissingComma, err)
+	}
+}
@@ -0,0 +1,212 @@
+package hjson
+
+import (
+	"bytes"
+	"errors"
+	"unicode/utf8"
+)
+
+// Document is an Hjson document together with its tree of Nodes, for tools
+// like editors that keep a document open and change it in small steps. The
+// tree is kept in sync with the text by ApplyEdit(), which only parses again
+// the part of the document that was changed.
+//
+// The Nodes returned by Root() must not be changed directly, because that
+// would make the tree and the text of the Document differ.
+type Document struct {
+	data []byte
+	root *Node
+}
+
+// ParseDocument parses data into a Document. The Document keeps a copy of
+// data, in which invalid UTF-8 byte sequences are replaced with U+FFFD like
+// Unmarshal() does by default, so that the positions of the Nodes match the
+// text of the Document.
+func ParseDocument(data []byte) (*Document, error) {
+	if utf8.Valid(data) {
+		data = append([]byte(nil), data...)
+	} else {
+		data = toValidUTF8(data, nil)
+	}
+	root, err := ParseNode(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Document{data: data, root: root}, nil
+}
+
+// Bytes returns the current text of the document. The returned slice must not
+// be modified.
+func (d *Document) Bytes() []byte {
+	return d.data
+}
+
+// Root returns the root Node of the document. The Nodes contain the original
+// text and the position of each value in the current text of the document.
+func (d *Document) Root() *Node {
+	return d.root
+}
+
+// ApplyEdit replaces the removed bytes starting at offset in the text of the
+// document with inserted, and updates the tree of Nodes. If the edit is inside
+// the braces or brackets of an object or array, only the innermost such value
+// is parsed again. The Nodes outside of it are kept, with their positions
+// moved to match the new text. Otherwise the whole document is parsed again.
+//
+// Invalid UTF-8 byte sequences in inserted are replaced with U+FFFD, like in
+// ParseDocument(). If the new text cannot be parsed the error is returned and
+// the Document is not changed.
+func (d *Document) ApplyEdit(offset, removed int, inserted []byte) error {
+	if offset < 0 || removed < 0 || offset+removed > len(d.data) {
+		return errors.New("hjson: edit out of range")
+	}
+	if !utf8.Valid(inserted) {
+		inserted = toValidUTF8(inserted, nil)
+	}
+	oldEnd := offset + removed
+	delta := len(inserted) - removed
+
+	newData := make([]byte, 0, len(d.data)+delta)
+	newData = append(newData, d.data[:offset]...)
+	newData = append(newData, inserted...)
+	newData = append(newData, d.data[oldEnd:]...)
+
+	target, ancestors := d.editTarget(offset, oldEnd)
+	if target == nil {
+		return d.reparse(newData)
+	}
+	sub, err := parseSubtree(newData, target.Pos, target.End.Offset+delta)
+	if err != nil {
+		// The edit might have changed where the value ends, for example by
+		// starting a string or a comment, so let the whole document decide.
+		return d.reparse(newData)
+	}
+
+	// Move the positions of all Nodes after the edit.
+	oldEndPos := Position{
+		Offset: oldEnd,
+		Line:   target.Pos.Line + bytes.Count(d.data[target.Pos.Offset:oldEnd], []byte("\n")),
+		Column: oldEnd - (bytes.LastIndexByte(d.data[:oldEnd], '\n') + 1) + 1,
+	}
+	newEnd := offset + len(inserted)
+	newEndPos := Position{
+		Offset: newEnd,
+		Line:   target.Pos.Line + bytes.Count(newData[target.Pos.Offset:newEnd], []byte("\n")),
+		Column: newEnd - (bytes.LastIndexByte(newData[:newEnd], '\n') + 1) + 1,
+	}
+	move := func(pos *Position) {
+		if pos.Offset < oldEnd {
+			return
+		}
+		if pos.Line == oldEndPos.Line {
+			pos.Column += newEndPos.Column - oldEndPos.Column
+		}
+		pos.Line += newEndPos.Line - oldEndPos.Line
+		pos.Offset += delta
+	}
+	_ = d.root.Walk(func(path []string, node *Node) error {
+		if node == target {
+			return SkipChildren
+		}
+		move(&node.Pos)
+		move(&node.End)
+		return nil
+	})
+
+	text := string(newData)
+	for _, node := range ancestors {
+		node.Lit = text[node.Pos.Offset:node.End.Offset]
+	}
+
+	cm := target.Cm
+	cm.InsideFirst, cm.InsideLast = sub.Cm.InsideFirst, sub.Cm.InsideLast
+	target.Value, target.Lit, target.End = sub.Value, sub.Lit, sub.End
+	target.Cm = cm
+	target.snapshot()
+
+	d.data = newData
+	return nil
+}
+
+// editTarget returns the innermost array or object that contains the range
+// from start to end inside its brackets, and its ancestors. Returns nil if
+// there is no such value.
+func (d *Document) editTarget(start, end int) (*Node, []*Node) {
+	inside := func(node *Node) bool {
+		switch node.Value.(type) {
+		case []interface{}, *OrderedMap:
+		default:
+			return false
+		}
+		return node.Lit != "" && (node.Lit[0] == '{' || node.Lit[0] == '[') &&
+			node.Pos.Offset < start && end < node.End.Offset
+	}
+	child := func(node *Node) *Node {
+		switch cont := node.Value.(type) {
+		case []interface{}:
+			for _, elem := range cont {
+				if child, ok := elem.(*Node); ok && inside(child) {
+					return child
+				}
+			}
+		case *OrderedMap:
+			for _, key := range cont.Keys {
+				if child, ok := cont.Map[key].(*Node); ok && inside(child) {
+					return child
+				}
+			}
+		}
+		return nil
+	}
+
+	var nodes []*Node
+	node := d.root
+	if !inside(node) {
+		// The members of a root object without braces can still be edited on
+		// their own.
+		nodes = append(nodes, node)
+		node = child(node)
+	}
+	for ; node != nil; node = child(node) {
+		nodes = append(nodes, node)
+	}
+	if len(nodes) == 0 || !inside(nodes[len(nodes)-1]) {
+		return nil, nil
+	}
+	return nodes[len(nodes)-1], nodes[:len(nodes)-1]
+}
+
+// parseSubtree parses the array or object found from pos to end in data on
+// its own, with the positions of the Nodes set as if data had been parsed as
+// a whole.
+func parseSubtree(data []byte, pos Position, end int) (*Node, error) {
+	// Keep the column of the value, because the indentation of multiline
+	// strings depends on it.
+	lineStart := pos.Offset - (pos.Column - 1)
+	text := append(bytes.Repeat([]byte{' '}, pos.Column-1), data[pos.Offset:end]...)
+	sub, err := ParseNode(text)
+	if err != nil {
+		return nil, err
+	}
+	if sub.Pos.Offset != pos.Column-1 || sub.End.Offset != len(text) {
+		return nil, errors.New("hjson: the value does not fill the text")
+	}
+	_ = sub.Walk(func(path []string, node *Node) error {
+		for _, p := range []*Position{&node.Pos, &node.End} {
+			p.Offset += lineStart
+			p.Line += pos.Line - 1
+		}
+		return nil
+	})
+	return sub, nil
+}
+
+// reparse replaces the whole tree of d by parsing data.
+func (d *Document) reparse(data []byte) error {
+	root, err := ParseNode(data)
+	if err != nil {
+		return err
+	}
+	d.data, d.root = data, root
+	return nil
+}
@@ -0,0 +1,163 @@
+package hjson
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// compareNodes returns the path to the first difference between a and b,
+// comparing values, comments, original text and positions.
+func compareNodes(path string, a, b *Node) string {
+	if a.Lit != b.Lit || a.Pos != b.Pos || a.End != b.End || a.Cm != b.Cm {
+		return path
+	}
+	switch av := a.Value.(type) {
+	case []interface{}:
+		bv, ok := b.Value.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return path
+		}
+		for i := range av {
+			if diff := compareNodes(path+"/"+string(rune('0'+i)), av[i].(*Node), bv[i].(*Node)); diff != "" {
+				return diff
+			}
+		}
+	case *OrderedMap:
+		bv, ok := b.Value.(*OrderedMap)
+		if !ok || !reflect.DeepEqual(av.Keys, bv.Keys) {
+			return path
+		}
+		for _, key := range av.Keys {
+			if diff := compareNodes(path+"/"+key, av.Map[key].(*Node), bv.Map[key].(*Node)); diff != "" {
+				return diff
+			}
+		}
+	default:
+		if !reflect.DeepEqual(a.Value, b.Value) {
+			return path
+		}
+	}
+	return ""
+}
+
+func TestDocumentApplyEdit(t *testing.T) {
+	txt := `# config
+server: {
+  host: localhost
+  ports: [80, 443]
+  tls: {cert: "a.pem", key: "a.key"}
+}
+text:
+  '''
+  two
+  lines
+  '''
+last: 1
+`
+	testCases := []struct {
+		find, replace string
+	}{
+		{"localhost", "example.com"},
+		{"80", "8080"},
+		{", 443", ""},
+		{"\"a.pem\"", "'b.pem'\n    "},
+		{"tls", "ssl"},
+		{"  host: localhost\n", ""},
+		{"ports: [80, 443]", "ports: [\n    80\n    443\n  ]"},
+		{"two", "2"},
+		{"last: 1", "last: 2\nmore: 3"},
+		{"# config", "// settings"},
+		{"[80, 443]", "[80, /* unterminated ]"},
+		{"\"a.key\"", "\"a.key"},
+	}
+	for _, tc := range testCases {
+		doc, err := ParseDocument([]byte(txt))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Keep references to check that Nodes outside of the edit are kept.
+		last := doc.Root().NK("last")
+
+		offset := strings.Index(txt, tc.find)
+		newTxt := txt[:offset] + tc.replace + txt[offset+len(tc.find):]
+		expected, expErr := ParseNode([]byte(newTxt))
+
+		err = doc.ApplyEdit(offset, len(tc.find), []byte(tc.replace))
+		if (err != nil) != (expErr != nil) {
+			t.Errorf("Replacing %q, expected error %v, got %v", tc.find, expErr, err)
+			continue
+		}
+		if err != nil {
+			if string(doc.Bytes()) != txt {
+				t.Errorf("Replacing %q, the document was changed by a failed edit", tc.find)
+			}
+			continue
+		}
+		if string(doc.Bytes()) != newTxt {
+			t.Errorf("Replacing %q, unexpected text:\n%s", tc.find, doc.Bytes())
+		}
+		if diff := compareNodes("", doc.Root(), expected); diff != "" {
+			t.Errorf("Replacing %q, the trees differ at %q", tc.find, diff)
+		}
+		if tc.find == "80" && doc.Root().NK("last") != last {
+			t.Errorf("Replacing %q, a Node outside of the edit was parsed again", tc.find)
+		}
+
+		out, err := MarshalWithOptions(doc.Root(), func() EncoderOptions {
+			opt := DefaultOptions()
+			opt.PreserveFormatting = true
+			return opt
+		}())
+		if err != nil {
+			t.Error(err)
+		} else if strings.TrimSpace(string(out)) != strings.TrimSpace(newTxt) {
+			t.Errorf("Replacing %q, expected the new text to be encoded, got:\n%s", tc.find, out)
+		}
+	}
+
+	doc, _ := ParseDocument([]byte("a: 1"))
+	if err := doc.ApplyEdit(2, 5, nil); err == nil {
+		t.Error("Expected an error for an edit out of range")
+	}
+}
+
+func TestDocumentInvalidUTF8(t *testing.T) {
+	src := []byte("# caf\xe9\nname: x\nport: 80\n")
+	doc, err := ParseDocument(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = doc.Set("/port", 81); err != nil {
+		t.Fatal(err)
+	}
+	if err = doc.Set("/host", "h"); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "# caf�\nname: x\nport: 81\nhost: h\n"; string(doc.Bytes()) != expected {
+		t.Errorf("Expected:\n%q\nGot:\n%q", expected, doc.Bytes())
+	}
+
+	out, err := Edit(src, "port", 82)
+	if err != nil || string(out) != "# caf�\nname: x\nport: 82\n" {
+		t.Errorf("Unexpected result of Edit(): %q, %v", out, err)
+	}
+	out, err = MergePatch(src, []byte("name: y"))
+	if err != nil || string(out) != "# caf�\nname: y\nport: 80\n" {
+		t.Errorf("Unexpected result of MergePatch(): %q, %v", out, err)
+	}
+	out, err = Merge(src, []byte("port: 443"))
+	if err != nil || string(out) != "# caf�\nname: x\nport: 443\n" {
+		t.Errorf("Unexpected result of Merge(): %q, %v", out, err)
+	}
+
+	if err = doc.ApplyEdit(0, 0, []byte("# \xff\n")); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(doc.Bytes()), "# �\n# caf�\n") {
+		t.Errorf("Unexpected text %q", doc.Bytes())
+	}
+	if n, err := doc.Get("/name"); err != nil || string(doc.Bytes()[n.Pos.Offset:n.End.Offset]) != "x" {
+		t.Errorf("Unexpected node %+v, %v", n, err)
+	}
+}
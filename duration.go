@@ -0,0 +1,37 @@
+package hjson
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// readDuration reads a value for a destination of type time.Duration. Strings
+// like "1h30m" or "500ms" are parsed by time.ParseDuration() and returned as
+// a number of nanoseconds, which is what json.Unmarshal() expects. Numbers
+// are returned as they are, so that durations written as nanoseconds keep
+// working.
+func (p *hjsonParser) readDuration() (interface{}, error) {
+	p.white()
+	start := p.at
+	val, err := p.readValue(reflect.Value{}, nil)
+	s, ok := val.(string)
+	if err != nil || !ok {
+		return val, err
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		// Report the position of the value.
+		p.at = start
+		return nil, p.errAt(MsgBadDuration, s)
+	}
+	return json.Number(strconv.FormatInt(int64(d), 10)), nil
+}
+
+// formatDuration returns the text of the time.Duration value, like "1h30m0s".
+func formatDuration(value reflect.Value) string {
+	return time.Duration(value.Int()).String()
+}
@@ -0,0 +1,75 @@
+package hjson
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDuration(t *testing.T) {
+	type config struct {
+		Timeout  time.Duration
+		Interval *time.Duration
+		Retries  []time.Duration
+		Nanos    time.Duration
+		Limits   map[string]time.Duration
+	}
+	txt := `{
+  timeout: 1h30m
+  interval: "500ms"
+  retries: [
+    1s
+    2.5s
+    0
+  ]
+  nanos: 1500
+  limits: {
+    read: 5s
+  }
+}`
+	var c config
+	if err := Unmarshal([]byte(txt), &c); err != nil {
+		t.Fatal(err)
+	}
+	interval := 500 * time.Millisecond
+	exp := config{
+		Timeout:  90 * time.Minute,
+		Interval: &interval,
+		Retries:  []time.Duration{time.Second, 2500 * time.Millisecond, 0},
+		Nanos:    1500,
+		Limits:   map[string]time.Duration{"read": 5 * time.Second},
+	}
+	if !reflect.DeepEqual(c, exp) {
+		t.Errorf("Expected\n%#v\ngot\n%#v", exp, c)
+	}
+
+	b, err := Marshal(exp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expTxt := `{
+  Timeout: 1h30m0s
+  Interval: 500ms
+  Retries: [
+    1s
+    2.5s
+    0s
+  ]
+  Nanos: 1.5µs
+  Limits: {
+    read: 5s
+  }
+}`
+	if string(b) != expTxt {
+		t.Errorf("Expected\n%s\ngot\n%s", expTxt, b)
+	}
+	var c2 config
+	if err := Unmarshal(b, &c2); err != nil || !reflect.DeepEqual(c2, exp) {
+		t.Errorf("Round trip failed: %v\n%#v", err, c2)
+	}
+
+	err = Unmarshal([]byte("{timeout: 5 minutes\n}"), &c)
+	if pe, ok := err.(*ParseError); !ok || pe.Kind != MsgBadDuration || pe.Column != 11 {
+		t.Errorf("Unexpected error: %#v", err)
+	}
+}
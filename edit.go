@@ -0,0 +1,34 @@
+package hjson
+
+import "strings"
+
+// Edit returns a copy of the Hjson document src with the value at path
+// replaced by newValue, which can be any value supported by Marshal(). Only
+// the bytes of the replaced value are rewritten: all other values, comments,
+// whitespace and the order of keys are kept exactly as in src.
+//
+// path is a dot-separated list of object keys and array indexes, like
+// "servers.0.port", or "" to replace the whole document. Like for
+// Document.Set(), the last key can name a new member of an object, which is
+// added on a new line after the other members, and "-" appends an element to
+// an array. A *PathNotFoundError is returned if the object or array that
+// would hold the value does not exist.
+func Edit(src []byte, path string, newValue interface{}) ([]byte, error) {
+	doc, err := ParseDocument(src)
+	if err != nil {
+		return nil, err
+	}
+	var tokens []string
+	if path != "" {
+		tokens = strings.Split(path, ".")
+	}
+	if err = doc.set(tokens, newValue, joinDotted); err != nil {
+		return nil, err
+	}
+	return doc.Bytes(), nil
+}
+
+// joinDotted joins the keys and indexes of a path with dots.
+func joinDotted(tokens []string) string {
+	return strings.Join(tokens, ".")
+}
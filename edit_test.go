@@ -0,0 +1,109 @@
+package hjson
+
+import (
+	"testing"
+)
+
+func TestEdit(t *testing.T) {
+	src := `// Service config.
+{
+  name: api   // Aligned comment.
+
+  servers: [
+    {
+      host: "a.example.com"
+      port: 80 # Plain HTTP.
+    }
+  ]
+  limits: {rps: 10, burst: 20}
+}
+`
+	testCases := []struct {
+		path     string
+		value    interface{}
+		expected string
+	}{
+		{"servers.0.port", 443, `// Service config.
+{
+  name: api   // Aligned comment.
+
+  servers: [
+    {
+      host: "a.example.com"
+      port: 443 # Plain HTTP.
+    }
+  ]
+  limits: {rps: 10, burst: 20}
+}
+`},
+		{"limits.burst", 50, `// Service config.
+{
+  name: api   // Aligned comment.
+
+  servers: [
+    {
+      host: "a.example.com"
+      port: 80 # Plain HTTP.
+    }
+  ]
+  limits: {rps: 10, burst: 50}
+}
+`},
+		{"servers.0", map[string]interface{}{"host": "b"}, `// Service config.
+{
+  name: api   // Aligned comment.
+
+  servers: [
+    {
+      host: b
+    }
+  ]
+  limits: {rps: 10, burst: 20}
+}
+`},
+		{"debug", true, `// Service config.
+{
+  name: api   // Aligned comment.
+
+  servers: [
+    {
+      host: "a.example.com"
+      port: 80 # Plain HTTP.
+    }
+  ]
+  limits: {rps: 10, burst: 20}
+  debug: true
+}
+`},
+	}
+	for _, tc := range testCases {
+		out, err := Edit([]byte(src), tc.path, tc.value)
+		if err != nil {
+			t.Errorf("%s: %v", tc.path, err)
+		} else if string(out) != tc.expected {
+			t.Errorf("%s: expected:\n%s\nGot:\n%s", tc.path, tc.expected, out)
+		}
+	}
+
+	// Strings followed by other text on their line are quoted.
+	out, err := Edit([]byte("a: {b: 1}\nc: 2 # Two.\nd: 3\n"), "a.b", "x")
+	if err == nil {
+		out, err = Edit(out, "c", "y")
+	}
+	if err == nil {
+		out, err = Edit(out, "d", "z")
+	}
+	if err != nil {
+		t.Fatal(err)
+	} else if string(out) != "a: {b: \"x\"}\nc: \"y\" # Two.\nd: z\n" {
+		t.Errorf("Unexpected result:\n%s", out)
+	}
+
+	_, err = Edit([]byte(src), "servers.1.port", 1)
+	if pe, ok := err.(*PathNotFoundError); !ok || pe.Path != "servers.1" {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if _, err = Edit([]byte("a: ["), "a", 1); err == nil {
+		t.Error("Expected a syntax error")
+	}
+}
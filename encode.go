@@ -12,12 +12,15 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"unicode/utf16"
 	"unicode/utf8"
 )
 
 // EncoderOptions defines options for encoding to Hjson.
 type EncoderOptions struct {
-	// End of line, should be either \n or \r\n
+	// End of line, should be either \n or \r\n. Line endings in comments and
+	// in original text written because of PreserveFormatting are converted to
+	// Eol too.
 	Eol string
 	// Place braces on the same line
 	BracesSameLine bool
@@ -27,6 +30,9 @@ type EncoderOptions struct {
 	QuoteAlways bool
 	// Place string in quotes if it could otherwise be a number, boolean or null
 	QuoteAmbiguousStrings bool
+	// QuotePolicy, if not QuotePolicyDefault, replaces QuoteAlways and
+	// QuoteAmbiguousStrings.
+	QuotePolicy QuotePolicy
 	// Indent string
 	IndentBy string
 	// Base indentation string
@@ -34,6 +40,66 @@ type EncoderOptions struct {
 	// Write comments, if any are found in hjson.Node structs or as tags on
 	// other structs.
 	Comments bool
+	// CommentStyle selects the comment markers used when writing comments. By
+	// default comments from struct field tags are written with "#" and
+	// comments from hjson.Node trees are written as they are.
+	CommentStyle CommentStyle
+	// Align the values of all members of an object in the same column, by
+	// padding with spaces after the keys. The display width of keys is
+	// calculated with East Asian wide characters taking up two columns and
+	// combining marks taking up none, so that the output looks aligned in a
+	// terminal or an editor using a monospace font.
+	AlignValues bool
+	// Write unchanged values in hjson.Node trees created by Unmarshal() using
+	// their original text from the decoded input (see Node.Lit), so that an
+	// unchanged node tree is written byte-for-byte identical to the input. The
+	// original text of arrays and objects is only used if Comments is true,
+	// because it includes any comments inside them. No original text is used
+	// if EnableColor is true. The keys of object members decoded into Nodes
+	// are written with or without quotes like in the input, also in objects
+	// that have changed, as long as the key itself has not changed.
+	PreserveFormatting bool
+	// MultilineIndent controls the layout of multiline strings (strings in
+	// triple quotes). Output written with any other mode than the default,
+	// MultilineIndentQuotes, must be decoded with the same mode set in
+	// DecoderOptions.MultilineIndent.
+	MultilineIndent MultilineIndentMode
+	// Sort the keys of all objects, including structs, hjson.OrderedMap and
+	// hjson.Node trees, which are otherwise written in their own order. Keys
+	// are compared as strings unless KeyLess is set. The keys of Go maps are
+	// always sorted.
+	SortKeys bool
+	// KeyLess, if not nil, reports whether the key a must be written before the
+	// key b. It is used for sorting the keys of Go maps, and the keys of all
+	// other objects if SortKeys is true.
+	KeyLess func(a, b string) bool
+	// Literals are asked, in order, to write each value as a custom quoteless
+	// literal, see Literal. The text is quoted if it could not be read back
+	// without quotes.
+	Literals []Literal
+	// NonFiniteNumbers causes the float values NaN, +Inf and -Inf to be written
+	// as the quoteless values NaN, Infinity and -Infinity, which can be read
+	// with DecoderOptions.NonFiniteNumbers. By default they are written as
+	// null, because they cannot be represented in JSON.
+	NonFiniteNumbers bool
+	// GroupDigits causes integers with more than four digits to be written
+	// with underscores separating groups of three digits, like 1_000_000,
+	// which is easier to read. Such numbers must be decoded with
+	// DecoderOptions.ExtendedNumbers.
+	GroupDigits bool
+	// KeyNamer, if not nil, converts the names of struct fields without a
+	// name in a tag to the keys written, for example to "max_conns" for the
+	// field MaxConns with SnakeCase. Decode such keys with
+	// DecoderOptions.KeyMatcher set to the same function.
+	KeyNamer KeyNamer
+	// FinalNewlines controls the line endings at the end of the output. If
+	// FinalNewlines is greater than 0 the output ends with exactly that number
+	// of line endings (Eol, or "\n" if Eol is empty), so 1 gives the final
+	// newline expected by POSIX tools. If FinalNewlines is less than 0 the
+	// output ends without any line ending. If FinalNewlines is 0 the output is
+	// left as it is, which normally means without a final line ending unless
+	// the root value is followed by a comment ending with one.
+	FinalNewlines int
 
 	// EnableColor enables colorized output
 	EnableColor bool
@@ -47,9 +113,18 @@ type EncoderOptions struct {
 // EmitRootBraces = true
 // QuoteAlways = false
 // QuoteAmbiguousStrings = true
+// QuotePolicy = QuotePolicyDefault
 // IndentBy = "  "
 // BaseIndentation = ""
 // Comments = true
+// CommentStyle = CommentStyleDefault
+// AlignValues = false
+// PreserveFormatting = false
+// MultilineIndent = MultilineIndentQuotes
+// SortKeys = false
+// KeyLess = nil
+// Literals = nil
+// FinalNewlines = 0
 func DefaultOptions() EncoderOptions {
 	return EncoderOptions{
 		Eol:                   "\n",
@@ -57,14 +132,45 @@ func DefaultOptions() EncoderOptions {
 		EmitRootBraces:        true,
 		QuoteAlways:           false,
 		QuoteAmbiguousStrings: true,
+		QuotePolicy:           QuotePolicyDefault,
 		IndentBy:              "  ",
 		BaseIndentation:       "",
 		Comments:              true,
+		CommentStyle:          CommentStyleDefault,
+		AlignValues:           false,
+		PreserveFormatting:    false,
+		MultilineIndent:       MultilineIndentQuotes,
+		SortKeys:              false,
+		KeyLess:               nil,
+		Literals:              nil,
 		EnableColor:           false,
 		ColorStyle:            TerminalStyle,
 	}
 }
 
+// QuotePolicy controls which string values are written in quotes, see
+// EncoderOptions.QuotePolicy. Strings that cannot be written without quotes,
+// like strings with leading whitespace, are always quoted.
+type QuotePolicy int
+
+const (
+	// QuotePolicyDefault quotes strings as specified by
+	// EncoderOptions.QuoteAlways and EncoderOptions.QuoteAmbiguousStrings.
+	QuotePolicyDefault QuotePolicy = iota
+	// QuotePolicyAlways quotes all strings, which some consumers prefer for
+	// safety.
+	QuotePolicyAlways
+	// QuotePolicyAmbiguous quotes strings that a reader could mistake for a
+	// number, boolean or null: strings starting with a digit (also after a
+	// sign or a dot) or with the word true, false or null in any case, like
+	// "1 apple" or "True story".
+	QuotePolicyAmbiguous
+	// QuotePolicyMinimal only quotes strings that would be decoded as
+	// something else than the same string without quotes, like "1" or "true"
+	// but not "1 apple".
+	QuotePolicyMinimal
+)
+
 // Start looking for circular references below this depth.
 const depthLimit = 1024
 
@@ -75,6 +181,9 @@ type hjsonEncoder struct {
 	pDepth          uint
 	parents         map[uintptr]struct{} // Starts to be filled after pDepth has reached depthLimit
 	structTypeCache map[reflect.Type][]structFieldInfo
+	// nodeWritten, if not nil, is called with each Node that is written and
+	// the offset in the output where its value starts.
+	nodeWritten func(node *Node, offset int)
 }
 
 var JSONNumberType = reflect.TypeOf(json.Number(""))
@@ -112,6 +221,10 @@ func (e *hjsonEncoder) quoteReplace(text string) string {
 			return c
 		}
 		r, _ := utf8.DecodeRune(a)
+		if r1, r2 := utf16.EncodeRune(r); r1 != utf8.RuneError {
+			// Never split a character outside of the BMP.
+			return []byte(fmt.Sprintf("\\u%04x\\u%04x", r1, r2))
+		}
 		return []byte(fmt.Sprintf("\\u%04x", r))
 	}))
 }
@@ -142,11 +255,7 @@ func (e *hjsonEncoder) quote(value, separator string, isRootObject bool,
 
 	if len(value) == 0 {
 		e.WriteString(separator + l + `""` + r)
-	} else if e.QuoteAlways ||
-		hasCommentAfter ||
-		needsQuotes.MatchString(value) ||
-		(e.QuoteAmbiguousStrings && (startsWithNumber([]byte(value)) ||
-			startsWithKeyword.MatchString(value))) {
+	} else if e.needsQuotes(value, hasCommentAfter) {
 
 		// If the string contains no control characters, no quote characters, and no
 		// backslash characters, then we can safely slap some quotes around it.
@@ -157,8 +266,8 @@ func (e *hjsonEncoder) quote(value, separator string, isRootObject bool,
 		if !needsEscape.MatchString(value) {
 
 			e.WriteString(separator + l + `"` + value + `"` + r)
-		} else if !needsEscapeML.MatchString(value) && !isRootObject {
-			e.mlString(value, separator, keyComment, l, r)
+		} else if !isRootObject && e.canWriteML(value) {
+			e.mlString(value, separator, keyComment, l, r, false)
 		} else {
 			e.WriteString(separator + l + `"` + e.quoteReplace(value) + `"` + r)
 		}
@@ -168,23 +277,94 @@ func (e *hjsonEncoder) quote(value, separator string, isRootObject bool,
 	}
 }
 
-func (e *hjsonEncoder) mlString(value, separator, keyComment, lColor, rColor string) {
+// needsQuotes returns true if value must be written in quotes, according to
+// the options of e.
+func (e *hjsonEncoder) needsQuotes(value string, hasCommentAfter bool) bool {
+	if hasCommentAfter || needsQuotes.MatchString(value) {
+		return true
+	}
+	if _, ok := parseNonFinite(value); ok && e.NonFiniteNumbers {
+		// Would be read as a number with DecoderOptions.NonFiniteNumbers.
+		return true
+	}
+	if _, ok := extendedNumber(value); ok && e.GroupDigits {
+		// Would be read as a number with DecoderOptions.ExtendedNumbers.
+		return true
+	}
+	switch e.QuotePolicy {
+	case QuotePolicyAlways:
+		return true
+	case QuotePolicyAmbiguous:
+		return looksAmbiguous(value) || !quotelessIsSafe(value)
+	case QuotePolicyMinimal:
+		return !quotelessIsSafe(value)
+	}
+	return e.QuoteAlways || (e.QuoteAmbiguousStrings &&
+		(startsWithNumber([]byte(value)) || startsWithKeyword.MatchString(value)))
+}
+
+// looksAmbiguous returns true if value could be mistaken for a number, a
+// boolean or null by a reader, see QuotePolicyAmbiguous.
+func looksAmbiguous(value string) bool {
+	isDigit := func(i int) bool {
+		return i < len(value) && value[i] >= '0' && value[i] <= '9'
+	}
+	if isDigit(0) || (strings.IndexByte("+-.", value[0]) >= 0 && isDigit(1)) {
+		return true
+	}
+	lower := strings.ToLower(value)
+	for _, word := range []string{"true", "false", "null"} {
+		if strings.HasPrefix(lower, word) {
+			rest := lower[len(word):]
+			if rest == "" || !(rest[0] >= 'a' && rest[0] <= 'z' || rest[0] >= '0' && rest[0] <= '9' ||
+				rest[0] == '_') {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// quotelessIsSafe returns true if value is decoded as the same string when
+// written without quotes.
+func quotelessIsSafe(value string) bool {
+	// Numbers out of the range of float64 are decoded as numbers too if the
+	// destination is not an interface{}.
+	p := &hjsonParser{data: []byte(value), ch: ' ', willMarshalToJSON: true}
+	p.resetAt()
+	v, err := p.readTfnns(reflect.Value{}, nil)
+	return err == nil && v == value
+}
+
+// canWriteML returns true if value can be written as a multiline string.
+func (e *hjsonEncoder) canWriteML(value string) bool {
+	return e.Eol != "" && !needsEscapeML.MatchString(value) &&
+		(e.MultilineIndent != MultilineIndentCommon || dedentCommon(value, false) == value)
+}
+
+// mlString writes value as a multiline string. Unless block is true, a value
+// without line feeds is written on a single line.
+func (e *hjsonEncoder) mlString(value, separator, keyComment, lColor, rColor string, block bool) {
 	a := strings.Split(value, "\n")
 
-	if len(a) == 1 {
+	if len(a) == 1 && !block {
 		// The string contains only a single line. We still use the multiline
 		// format as it avoids escaping the \ character (e.g. when used in a
 		// regex).
 		e.WriteString(separator + lColor + "'''")
 		e.WriteString(a[0])
 	} else {
-		if !strings.Contains(keyComment, "\n") {
+		if e.MultilineIndent == MultilineIndentCommon {
+			if !strings.Contains(keyComment, "\n") {
+				e.WriteString(separator)
+			}
+		} else if !strings.Contains(keyComment, "\n") {
 			e.writeIndent(e.indent + 1)
 		}
 		e.WriteString("'''")
 		for _, v := range a {
 			indent := e.indent + 1
-			if len(v) == 0 {
+			if len(v) == 0 || e.MultilineIndent == MultilineIndentNone {
 				indent = 0
 			}
 			e.writeIndent(indent)
@@ -209,6 +389,34 @@ func (e *hjsonEncoder) quoteName(name string) string {
 	return name
 }
 
+// memberName returns the text of the key name of an object member whose
+// value is field. If PreserveFormatting is true and the member was decoded
+// into a Node, the key is written with or without quotes like in the decoded
+// input, unless the key has been changed.
+func (e *hjsonEncoder) memberName(name string, field reflect.Value) string {
+	if e.PreserveFormatting {
+		if node := asNode(field); node != nil && node.keyLit != "" &&
+			keyLitMatches(node.keyLit, name) {
+
+			return node.keyLit
+		}
+	}
+	return e.quoteName(name)
+}
+
+// keyLitMatches returns true if lit, the original text of a key, is the text
+// of the key name.
+func keyLitMatches(lit, name string) bool {
+	switch lit[0] {
+	case '"':
+		var s string
+		return json.Unmarshal([]byte(lit), &s) == nil && s == name
+	case '\'':
+		return !strings.Contains(lit, "\\") && lit[1:len(lit)-1] == name
+	}
+	return lit == name
+}
+
 func (e *hjsonEncoder) bracesIndent(isObjElement, isEmpty bool, cm Comments,
 	separator string) {
 
@@ -233,6 +441,17 @@ func (s sortAlpha) Less(i, j int) bool {
 	return fmt.Sprintf("%v", s[i]) < fmt.Sprintf("%v", s[j])
 }
 
+// sortFields sorts fis by name, using EncoderOptions.KeyLess if set.
+func (e *hjsonEncoder) sortFields(fis []fieldInfo) {
+	less := e.KeyLess
+	if less == nil {
+		less = func(a, b string) bool { return a < b }
+	}
+	sort.SliceStable(fis, func(i, j int) bool {
+		return less(fis[i].name, fis[j].name)
+	})
+}
+
 func (e *hjsonEncoder) writeIndentNoEOL(indent int) {
 	e.WriteString(e.BaseIndentation)
 	for i := 0; i < indent; i++ {
@@ -260,7 +479,7 @@ func (e *hjsonEncoder) useMarshalerJSON(
 	decOpt := DefaultDecoderOptions()
 	decOpt.UseJSONNumber = true
 	var dummyDest interface{}
-	jsonRoot, err := orderedUnmarshal(b, &dummyDest, decOpt, false, false)
+	jsonRoot, _, err := orderedUnmarshal(b, &dummyDest, decOpt, false, false)
 	if err != nil {
 		return err
 	}
@@ -278,12 +497,12 @@ func (e *hjsonEncoder) unpackNode(value reflect.Value, cm Comments) (reflect.Val
 		if node, ok := value.Interface().(Node); ok {
 			value = reflect.ValueOf(node.Value)
 			if e.Comments {
-				cm = node.Cm
+				cm = e.CommentStyle.restyle(node.Cm)
 			}
 		} else if pNode, ok := value.Interface().(*Node); ok {
 			value = reflect.ValueOf(pNode.Value)
 			if e.Comments {
-				cm = pNode.Cm
+				cm = e.CommentStyle.restyle(pNode.Cm)
 			}
 		}
 	}
@@ -291,6 +510,77 @@ func (e *hjsonEncoder) unpackNode(value reflect.Value, cm Comments) (reflect.Val
 	return value, cm
 }
 
+// asNode returns the Node in value, if value is a Node or a non-nil *Node.
+func asNode(value reflect.Value) *Node {
+	if value.IsValid() && value.CanInterface() {
+		switch node := value.Interface().(type) {
+		case Node:
+			return &node
+		case *Node:
+			return node
+		}
+	}
+	return nil
+}
+
+// canWriteLit returns true if node.Lit can be written instead of encoding
+// node.Value, see EncoderOptions.PreserveFormatting.
+func (e *hjsonEncoder) canWriteLit(node *Node) bool {
+	if !e.PreserveFormatting || e.EnableColor || node.Style != nil || !node.unchanged() {
+		return false
+	}
+	switch node.Value.(type) {
+	case []interface{}, *OrderedMap:
+		// The original text of objects might not have sorted keys, and the
+		// comments in it are written as they are.
+		return e.Comments && !e.SortKeys && e.CommentStyle == CommentStyleDefault && (e.Eol != "" || !strings.Contains(node.Lit, "\n"))
+	case string:
+		switch node.Lit[0] {
+		case '"':
+		case '\'':
+			// The indentation of a multiline string depends on the column where it
+			// starts.
+			return !strings.HasPrefix(node.Lit, "'''") || !strings.Contains(node.Lit, "\n")
+		default:
+			// A quoteless string ends at the end of the line, so nothing else may
+			// follow it on the same line.
+			return e.Eol != "" && node.Cm.After == node.src.cm.After
+		}
+	}
+	return true
+}
+
+// applyStyle changes the options of e according to style, and returns a
+// function restoring them.
+func (e *hjsonEncoder) applyStyle(style *NodeStyle, cm *Comments) func() {
+	saved, savedIndent := e.EncoderOptions, e.indent
+
+	// Keep the current indentation, even if IndentBy is changed.
+	e.BaseIndentation += strings.Repeat(e.IndentBy, e.indent)
+	e.indent = 0
+
+	if style.IndentBy != "" {
+		e.IndentBy = style.IndentBy
+	}
+	if style.QuoteAlways {
+		e.QuoteAlways = true
+		e.QuotePolicy = QuotePolicyAlways
+	}
+	if style.Inline {
+		e.Eol, e.IndentBy, e.BaseIndentation = "", "", ""
+		e.BracesSameLine = true
+		e.QuoteAlways = true
+		e.QuotePolicy = QuotePolicyAlways
+		e.Comments = false
+		e.AlignValues = false
+		cm.InsideFirst, cm.InsideLast = "", ""
+	}
+
+	return func() {
+		e.EncoderOptions, e.indent = saved, savedIndent
+	}
+}
+
 func (e *hjsonEncoder) writeNull() {
 	l, r := "", ""
 	if e.EnableColor {
@@ -313,12 +603,26 @@ func (e *hjsonEncoder) str(
 	// Produce a string from value.
 
 	// Unpack *Node, possibly overwrite cm.
+	node := asNode(value)
 	value, cm = e.unpackNode(value, cm)
 
 	if cm.Key != "" {
 		separator = ""
 	}
 
+	if node != nil && e.nodeWritten != nil {
+		e.nodeWritten(node, e.Len()+len(separator))
+	}
+
+	if node != nil && node.Style != nil {
+		defer e.applyStyle(node.Style, &cm)()
+	}
+
+	if node != nil && e.canWriteLit(node) {
+		e.WriteString(separator + node.Lit)
+		return nil
+	}
+
 	kind := value.Kind()
 
 	switch kind {
@@ -352,6 +656,38 @@ func (e *hjsonEncoder) str(
 		return e.str(value.Elem(), noIndent, separator, isRootObject, isObjElement, cm)
 	}
 
+	if len(e.Literals) > 0 && e.writeLiteral(value, separator) {
+		return nil
+	}
+
+	// big.Int and big.Float only implement marshalerJSON with pointer
+	// receivers.
+	if ok, err := e.writeBigNumber(value, noIndent, separator, isRootObject, isObjElement,
+		cm); ok {
+
+		return err
+	}
+
+	// Optional implements marshalerJSON too, but its value is written as Hjson.
+	if isOptionalType(value.Type()) {
+		if !value.Field(1).Bool() || value.Field(2).Bool() {
+			e.WriteString(separator)
+			e.writeNull()
+			return nil
+		}
+		return e.str(value.Field(0), noIndent, separator, isRootObject, isObjElement, cm)
+	}
+
+	// RawMessage implements marshalerJSON too, but is written verbatim.
+	if value.Type() == rawMessageType {
+		return e.writeRaw(value.Interface().(RawMessage), noIndent, separator,
+			isRootObject, isObjElement)
+	}
+	if value.Type() == preformattedValueType {
+		return e.writePreformatted(value.Interface().(PreformattedValue), noIndent, separator,
+			isRootObject, isObjElement)
+	}
+
 	// Our internal orderedMap implements marshalerJSON. We must therefore place
 	// this check before checking marshalerJSON. Calling orderedMap.MarshalJSON()
 	// from this function would cause an infinite loop.
@@ -363,9 +699,22 @@ func (e *hjsonEncoder) str(
 				name:  key,
 			})
 		}
+		if e.SortKeys {
+			e.sortFields(fis)
+		}
 		return e.writeFields(fis, noIndent, separator, isRootObject, isObjElement, cm)
 	}
 
+	if specs, ok := hjsonFields(value); ok {
+		return e.writeHjsonFields(specs, noIndent, separator, isRootObject, isObjElement, cm)
+	}
+
+	// Number implements marshalerJSON too, but its text is written as it is.
+	if value.Type() == numberType {
+		e.writeNumberText(value.String(), separator)
+		return nil
+	}
+
 	if value.Type().Implements(marshalerJSON) {
 		return e.useMarshalerJSON(value, noIndent, separator, isRootObject, isObjElement)
 	}
@@ -380,21 +729,16 @@ func (e *hjsonEncoder) str(
 			isObjElement, cm)
 	}
 
+	if value.Type() == durationType {
+		e.quote(formatDuration(value), separator, isRootObject, cm.Key,
+			e.quoteForComment(cm.After))
+		return nil
+	}
+
 	switch kind {
 	case reflect.String:
 		if value.Type() == JSONNumberType {
-			n := value.String()
-			if n == "" {
-				n = "0"
-			}
-			e.WriteString(separator)
-
-			l, r := "", ""
-			if e.EnableColor {
-				l, r = e.ColorStyle.Number[0], e.ColorStyle.Number[1]
-			}
-			// without quotes
-			e.WriteString(l + n + r)
+			e.writeNumberText(value.String(), separator)
 		} else {
 			e.quote(value.String(), separator, isRootObject, cm.Key,
 				e.quoteForComment(cm.After))
@@ -406,7 +750,7 @@ func (e *hjsonEncoder) str(
 		if e.EnableColor {
 			l, r = e.ColorStyle.Number[0], e.ColorStyle.Number[1]
 		}
-		e.WriteString(l + strconv.FormatInt(value.Int(), 10) + r)
+		e.WriteString(l + e.groupDigits(strconv.FormatInt(value.Int(), 10)) + r)
 
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
 		reflect.Uintptr:
@@ -415,7 +759,7 @@ func (e *hjsonEncoder) str(
 		if e.EnableColor {
 			l, r = e.ColorStyle.Number[0], e.ColorStyle.Number[1]
 		}
-		e.WriteString(l + strconv.FormatUint(value.Uint(), 10) + r)
+		e.WriteString(l + e.groupDigits(strconv.FormatUint(value.Uint(), 10)) + r)
 
 	case reflect.Float32, reflect.Float64:
 		// JSON numbers must be finite. Encode non-finite numbers as null.
@@ -425,7 +769,9 @@ func (e *hjsonEncoder) str(
 		if e.EnableColor {
 			l, r = e.ColorStyle.Number[0], e.ColorStyle.Number[1]
 		}
-		if math.IsInf(number, 0) || math.IsNaN(number) {
+		if e.NonFiniteNumbers && (math.IsInf(number, 0) || math.IsNaN(number)) {
+			e.WriteString(l + formatNonFinite(number) + r)
+		} else if math.IsInf(number, 0) || math.IsNaN(number) {
 			e.writeNull()
 		} else if number == -0 {
 			e.WriteString(l + "0" + r)
@@ -437,9 +783,13 @@ func (e *hjsonEncoder) str(
 				val = strings.ToLower(exp)
 			}
 
-			e.WriteString(l + val + r)
+			e.WriteString(l + e.groupDigits(val) + r)
 		}
 
+	case reflect.Complex64, reflect.Complex128:
+		e.quote(formatComplex(value.Complex(), value.Type().Bits()), separator,
+			isRootObject, cm.Key, e.quoteForComment(cm.After))
+
 	case reflect.Bool:
 		e.WriteString(separator)
 		l, r := "", ""
@@ -456,6 +806,22 @@ func (e *hjsonEncoder) str(
 		}
 
 	case reflect.Slice, reflect.Array:
+		if et := value.Type().Elem(); et.Kind() == reflect.Struct && et.Implements(entryInterface) {
+			// A slice of Entry is written as an object.
+			var fis []fieldInfo
+			for i := 0; i < value.Len(); i++ {
+				elem := value.Index(i)
+				fis = append(fis, fieldInfo{
+					field: elem.Field(1),
+					name:  elem.Field(0).String(),
+				})
+			}
+			if e.SortKeys {
+				e.sortFields(fis)
+			}
+			return e.writeFields(fis, noIndent, separator, isRootObject, isObjElement, cm)
+		}
+
 		e.bracesIndent(isObjElement, value.Len() == 0, cm, separator)
 		e.WriteString("[" + cm.InsideFirst)
 
@@ -473,9 +839,19 @@ func (e *hjsonEncoder) str(
 		indent1 := e.indent
 		e.indent++
 
+		var align *alignment
+		var nodes []*Node
+		if e.Comments && e.Eol != "" {
+			for i := 0; i < value.Len(); i++ {
+				nodes = append(nodes, asNode(value.Index(i)))
+			}
+			align = newAlignment(nil, nodes)
+		}
+
 		// Join all of the element texts together, separated with newlines
 		for i := 0; i < value.Len(); i++ {
-			elem, elemCm := e.unpackNode(value.Index(i), Comments{})
+			elem := value.Index(i)
+			_, elemCm := e.unpackNode(elem, Comments{})
 
 			if elemCm.Before == "" && elemCm.Key == "" {
 				e.writeIndent(e.indent)
@@ -487,10 +863,14 @@ func (e *hjsonEncoder) str(
 				e.WriteString(", ")
 			}
 
+			start := e.column()
 			if err := e.str(elem, true, "", false, false, elemCm); err != nil {
 				return err
 			}
 
+			if align != nil {
+				elemCm.After = align.after(nil, i, nodes[i], elemCm.After, e.column()-start)
+			}
 			e.WriteString(elemCm.After)
 		}
 
@@ -524,6 +904,9 @@ func (e *hjsonEncoder) str(
 				name:  name,
 			})
 		}
+		if e.SortKeys || e.KeyLess != nil {
+			e.sortFields(fis)
+		}
 		return e.writeFields(fis, noIndent, separator, isRootObject, isObjElement, cm)
 
 	case reflect.Struct:
@@ -539,6 +922,8 @@ func (e *hjsonEncoder) str(
 		// Collect fields first, too see if any should be shown (considering
 		// "omitEmpty").
 		var fis []fieldInfo
+		// The map of the field tagged with the "remain" option, if any.
+		var remain reflect.Value
 	FieldLoop:
 		for _, sfi := range sfis {
 			// The field might be found on the root struct or in embedded structs.
@@ -553,7 +938,11 @@ func (e *hjsonEncoder) str(
 				fv = fv.Field(i)
 			}
 
-			if sfi.omitEmpty && isEmptyValue(fv) {
+			if sfi.omitEmpty && isEmptyValue(fv) || isUnsetOptional(fv) {
+				continue
+			}
+			if sfi.remain {
+				remain = fv
 				continue
 			}
 
@@ -561,11 +950,28 @@ func (e *hjsonEncoder) str(
 				field: fv,
 				name:  sfi.name,
 			}
+			if !sfi.tagged && e.KeyNamer != nil {
+				fi.name = e.KeyNamer(sfi.name)
+			}
 			if e.Comments {
 				fi.comment = sfi.comment
 			}
+			fi.multiline = sfi.multiline
+			fi.rune = sfi.rune
+			fi.bytes = sfi.bytes
+			fi.intFormat = sfi.intFormat
+			fi.encoder = sfi.encoder
 			fis = append(fis, fi)
 		}
+		if fi, ok := discriminatorField(value, sfis); ok {
+			fis = append([]fieldInfo{fi}, fis...)
+		}
+		if remain.IsValid() {
+			fis = remainFields(fis, remain)
+		}
+		if e.SortKeys {
+			e.sortFields(fis)
+		}
 		return e.writeFields(fis, noIndent, separator, isRootObject, isObjElement, cm)
 
 	default:
@@ -587,6 +993,8 @@ func isEmptyValue(v reflect.Value) bool {
 		return v.Uint() == 0
 	case reflect.Float32, reflect.Float64:
 		return v.Float() == 0
+	case reflect.Complex64, reflect.Complex128:
+		return v.Complex() == 0
 	case reflect.Interface, reflect.Ptr:
 		return v.IsNil()
 	default:
@@ -665,8 +1073,12 @@ func Marshal(v interface{}) ([]byte, error) {
 //
 // Comments can be set on struct fields using the "comment" key in the struct
 // field's tag. The comment will be written on the line before the field key,
-// prefixed with #. Or possible several lines prefixed by #, if there are line
-// breaks (\n) in the comment text.
+// prefixed with # (or the marker selected by EncoderOptions.CommentStyle). Or
+// possible several lines prefixed by #, if there are line breaks (\n) in the
+// comment text.
+//
+// The key "hjsonComment" can be used instead of "comment", to avoid clashes
+// with other packages reading the "comment" key.
 //
 // If both the "json" and the "comment" tag keys are used on a struct field
 // they should be separated by whitespace.
@@ -732,16 +1144,23 @@ func Marshal(v interface{}) ([]byte, error) {
 // but not the json.Marshaler interface, then the function MarshalText() is
 // called on it to get a text.
 //
-// Channel, complex, and function values cannot be encoded in Hjson, will
-// result in an error.
+// Channel and function values cannot be encoded in Hjson, will result in an
+// error.
 //
 // Hjson cannot represent cyclic data structures and Marshal does not handle
 // them. Passing cyclic structures to Marshal will result in an error.
 func MarshalWithOptions(v interface{}, options EncoderOptions) ([]byte, error) {
+	return marshal(v, options, nil)
+}
+
+// marshal is MarshalWithOptions(), calling nodeWritten (if not nil) for each
+// Node that is written, see hjsonEncoder.nodeWritten.
+func marshal(v interface{}, options EncoderOptions, nodeWritten func(node *Node, offset int)) ([]byte, error) {
 	e := &hjsonEncoder{
 		indent:          0,
 		EncoderOptions:  options,
 		structTypeCache: map[reflect.Type][]structFieldInfo{},
+		nodeWritten:     nodeWritten,
 	}
 
 	value := reflect.ValueOf(v)
@@ -755,5 +1174,38 @@ func MarshalWithOptions(v interface{}, options EncoderOptions) ([]byte, error) {
 
 	e.WriteString(cm.After)
 
-	return e.Bytes(), nil
+	out := e.Bytes()
+	if e.Eol != "\n" && e.Eol != "" {
+		// Comments and original text from decoded input may contain other line
+		// endings than Eol.
+		out = convertEol(out, e.Eol)
+	}
+	if !utf8.Valid(out) {
+		// Strings are always escaped, but comments are written as they are.
+		out = toValidUTF8(out, nil)
+	}
+
+	return applyFinalNewlines(out, e.FinalNewlines, e.Eol), nil
+}
+
+// applyFinalNewlines changes the line endings at the end of b according to
+// EncoderOptions.FinalNewlines n.
+func applyFinalNewlines(b []byte, n int, eol string) []byte {
+	if n == 0 {
+		return b
+	}
+	b = bytes.TrimRight(b, "\r\n")
+	if eol == "" {
+		eol = "\n"
+	}
+	for ; n > 0; n-- {
+		b = append(b, eol...)
+	}
+	return b
+}
+
+// convertEol replaces all line endings ("\n" or "\r\n") in b with eol.
+func convertEol(b []byte, eol string) []byte {
+	b = bytes.Replace(b, []byte("\r\n"), []byte("\n"), -1)
+	return bytes.Replace(b, []byte("\n"), []byte(eol), -1)
 }
@@ -875,3 +875,394 @@ func TestStructComment(t *testing.T) {
 		t.Errorf("Expected:\n%s\nGot:\n%s\n\n", expected, string(h))
 	}
 }
+
+func TestStructHjsonComment(t *testing.T) {
+	type foo struct {
+		A string `hjsonComment:"First comment"`
+		B int32  `hjsonComment:"Second comment"`
+		C string
+	}
+	h, err := Marshal(foo{A: "hi!", B: 3, C: "some text"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{
+  # First comment
+  A: hi!
+
+  # Second comment
+  B: 3
+
+  C: some text
+}`
+	if string(h) != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s\n\n", expected, string(h))
+	}
+}
+
+func TestStructMultiline(t *testing.T) {
+	type foo struct {
+		A string  `hjson:",multiline"`
+		B *string `json:"b" hjson:",multiline"`
+		C string  `hjson:",multiline"`
+		D string
+	}
+	b := "two\nlines"
+	a := foo{A: "echo $HOME", B: &b, D: "x"}
+	h, err := Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{
+  A:
+    '''
+    echo $HOME
+    '''
+  b:
+    '''
+    two
+    lines
+    '''
+  C: ""
+  D: x
+}`
+	if string(h) != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s\n\n", expected, string(h))
+	}
+
+	var a2 foo
+	if err = Unmarshal(h, &a2); err != nil {
+		t.Fatal(err)
+	}
+	if a2.A != a.A || *a2.B != b || a2.C != "" || a2.D != "x" {
+		t.Errorf("Unexpected result %#v", a2)
+	}
+}
+
+func TestDisplayWidth(t *testing.T) {
+	for txt, exp := range map[string]int{
+		"abc":                3,
+		"\u540d\u524d":       4,
+		"e\u0301":            1,
+		"\uff76\uff80":       2,
+		"\uff21\uff22":       4,
+		"\u200d\u00ad":       0,
+		"\ud55c\uad6d\uc5b4": 6,
+	} {
+		if w := displayWidth(txt); w != exp {
+			t.Errorf("Expected width %d for %q, got %d", exp, txt, w)
+		}
+	}
+}
+
+func TestAlignValues(t *testing.T) {
+	om := NewOrderedMapFromSlice([]KeyValue{
+		{"a", 1},
+		{"名前", "x"},
+		{"cafe\u0301", true},
+		{"sub", map[string]int{"x": 1, "yyy": 2}},
+	})
+
+	opt := DefaultOptions()
+	opt.AlignValues = true
+	opt.EmitRootBraces = false
+	b, err := MarshalWithOptions(om, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareStrings(t, b, "a:    1\n名前: x\ncafe\u0301: true\nsub:  {\n  x:   1\n  yyy: 2\n}")
+}
+
+func TestSortKeys(t *testing.T) {
+	type S struct {
+		B int
+		A map[string]int
+	}
+	om := NewOrderedMapFromSlice([]KeyValue{{"z", 1}, {"y", &S{B: 2, A: map[string]int{"b": 1, "a": 2}}}})
+
+	opt := DefaultOptions()
+	opt.SortKeys = true
+	out, err := MarshalWithOptions(om, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := `{
+  y: {
+    A: {
+      a: 2
+      b: 1
+    }
+    B: 2
+  }
+  z: 1
+}`
+	if string(out) != exp {
+		t.Errorf("Expected:\n%s\nGot:\n%s", exp, out)
+	}
+
+	// KeyLess alone only applies to Go maps.
+	opt = DefaultOptions()
+	opt.KeyLess = func(a, b string) bool { return a > b }
+	out, err = MarshalWithOptions(om, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp = `{
+  z: 1
+  y: {
+    B: 2
+    A: {
+      b: 1
+      a: 2
+    }
+  }
+}`
+	if string(out) != exp {
+		t.Errorf("Expected:\n%s\nGot:\n%s", exp, out)
+	}
+
+	// The original text of objects is not used, because it is not sorted.
+	node, err := ParseNode([]byte("{\n  b: 1 # one\n  a: 2\n}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opt = DefaultOptions()
+	opt.SortKeys = true
+	opt.PreserveFormatting = true
+	out, err = MarshalWithOptions(node, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp = "{\n  a: 2\n  b: 1 # one\n}"
+	if string(out) != exp {
+		t.Errorf("Expected:\n%s\nGot:\n%s", exp, out)
+	}
+}
+
+func TestIndentBy(t *testing.T) {
+	opt := DefaultOptions()
+	opt.IndentBy = "\t"
+	out, err := MarshalWithOptions(map[string]interface{}{
+		"a": []int{1},
+		"b": map[string]int{"c": 2},
+	}, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := "{\n\ta: [\n\t\t1\n\t]\n\tb: {\n\t\tc: 2\n\t}\n}"
+	if string(out) != exp {
+		t.Errorf("Expected:\n%s\nGot:\n%s", exp, out)
+	}
+}
+
+func TestEol(t *testing.T) {
+	node, err := ParseNode([]byte("# c1\r\n# c2\n{\n  a: 1 # x\n  b: [\n    2\n  ]\n}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opt := DefaultOptions()
+	opt.Eol = "\r\n"
+	out, err := MarshalWithOptions(node, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := "# c1\r\n# c2\r\n{\r\n  a: 1 # x\r\n  b: [\r\n    2\r\n  ]\r\n}"
+	if string(out) != exp {
+		t.Errorf("Expected:\n%q\nGot:\n%q", exp, out)
+	}
+}
+
+func TestQuotePolicy(t *testing.T) {
+	value := []string{"text", "1 apple", "True story", "1", "true", "null # x", "-", "1e999"}
+	exps := map[QuotePolicy]string{
+		QuotePolicyDefault:   "[\n  text\n  1 apple\n  True story\n  \"1\"\n  \"true\"\n  \"null # x\"\n  -\n  1e999\n]",
+		QuotePolicyAlways:    "[\n  \"text\"\n  \"1 apple\"\n  \"True story\"\n  \"1\"\n  \"true\"\n  \"null # x\"\n  \"-\"\n  \"1e999\"\n]",
+		QuotePolicyAmbiguous: "[\n  text\n  \"1 apple\"\n  \"True story\"\n  \"1\"\n  \"true\"\n  \"null # x\"\n  -\n  \"1e999\"\n]",
+		QuotePolicyMinimal:   "[\n  text\n  1 apple\n  True story\n  \"1\"\n  \"true\"\n  \"null # x\"\n  -\n  \"1e999\"\n]",
+	}
+	for policy, exp := range exps {
+		opt := DefaultOptions()
+		opt.QuotePolicy = policy
+		out, err := MarshalWithOptions(value, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != exp {
+			t.Errorf("Policy %d, expected:\n%s\nGot:\n%s", policy, exp, out)
+		}
+		var back []string
+		if err := Unmarshal(out, &back); err != nil {
+			t.Fatal(err)
+		}
+		for i, elem := range back {
+			if elem != value[i] {
+				t.Errorf("Policy %d, expected %q, got %q", policy, value[i], elem)
+			}
+		}
+	}
+}
+
+func TestBracesSameLine(t *testing.T) {
+	value := map[string]interface{}{
+		"a": map[string]int{"b": 1},
+		"c": []int{2},
+	}
+	exps := map[bool]string{
+		true:  "{\n  a: {\n    b: 1\n  }\n  c: [\n    2\n  ]\n}",
+		false: "{\n  a:\n  {\n    b: 1\n  }\n  c:\n  [\n    2\n  ]\n}",
+	}
+	for sameLine, exp := range exps {
+		opt := DefaultOptions()
+		opt.BracesSameLine = sameLine
+		out, err := MarshalWithOptions(value, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != exp {
+			t.Errorf("BracesSameLine %v, expected:\n%s\nGot:\n%s", sameLine, exp, out)
+		}
+	}
+}
+
+func TestCommentStyle(t *testing.T) {
+	type T struct {
+		A int    `comment:"First line\nsecond line"`
+		B string `comment:"Not */ a block"`
+	}
+	expected := map[CommentStyle]string{
+		CommentStyleDefault: "{\n  # First line\n  # second line\n  A: 1\n\n  # Not */ a block\n  B: x\n}",
+		CommentStyleHash:    "{\n  # First line\n  # second line\n  A: 1\n\n  # Not */ a block\n  B: x\n}",
+		CommentStyleSlash:   "{\n  // First line\n  // second line\n  A: 1\n\n  // Not */ a block\n  B: x\n}",
+		CommentStyleBlock:   "{\n  /* First line */\n  /* second line */\n  A: 1\n\n  # Not */ a block\n  B: x\n}",
+	}
+	for style, exp := range expected {
+		opt := DefaultOptions()
+		opt.CommentStyle = style
+		out, err := MarshalWithOptions(T{1, "x"}, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != exp {
+			t.Errorf("Style %d, expected:\n%s\n\nGot:\n%s", style, exp, out)
+		}
+	}
+
+	in := `// head
+{
+  # a
+  a: 1 /* after a */
+  /* b
+   * two */
+  b: /* inline */ 2
+  c: [ // first
+    3
+    /* last */
+  ]
+}`
+	var node Node
+	if err := Unmarshal([]byte(in), &node); err != nil {
+		t.Fatal(err)
+	}
+	expected = map[CommentStyle]string{
+		CommentStyleDefault: in,
+		CommentStyleHash: `# head
+{
+  # a
+  a: 1 # after a
+  # b
+  # two
+  b: /* inline */ 2
+  c: [ # first
+    3
+    # last
+  ]
+}`,
+		CommentStyleSlash: `// head
+{
+  // a
+  a: 1 // after a
+  // b
+  // two
+  b: /* inline */ 2
+  c: [ // first
+    3
+    // last
+  ]
+}`,
+		CommentStyleBlock: `/* head */
+{
+  /* a */
+  a: 1 /* after a */
+  /* b
+   * two */
+  b: /* inline */ 2
+  c: [ /* first */
+    3
+    /* last */
+  ]
+}`,
+	}
+	for style, exp := range expected {
+		opt := DefaultOptions()
+		opt.CommentStyle = style
+		opt.PreserveFormatting = true
+		out, err := MarshalWithOptions(node, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != exp {
+			t.Errorf("Style %d, expected:\n%s\n\nGot:\n%s", style, exp, out)
+		}
+		var roundTrip Node
+		if err := Unmarshal(out, &roundTrip); err != nil {
+			t.Errorf("Style %d: %v", style, err)
+		}
+	}
+}
+
+func TestFinalNewlines(t *testing.T) {
+	v := map[string]int{"a": 1}
+	for _, c := range []struct {
+		n   int
+		eol string
+		exp string
+	}{
+		{0, "\n", "{\n  a: 1\n}"},
+		{1, "\n", "{\n  a: 1\n}\n"},
+		{2, "\r\n", "{\r\n  a: 1\r\n}\r\n\r\n"},
+		{1, "", "{  a: 1}\n"},
+		{-1, "\n", "{\n  a: 1\n}"},
+	} {
+		opt := DefaultOptions()
+		opt.FinalNewlines = c.n
+		opt.Eol = c.eol
+		out, err := MarshalWithOptions(v, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != c.exp {
+			t.Errorf("FinalNewlines %d: expected %q, got %q", c.n, c.exp, out)
+		}
+	}
+
+	// A comment after the root value can end with line feeds.
+	node := &Node{Value: 1, Cm: Comments{After: " # end\n\n"}}
+	opt := DefaultOptions()
+	for _, c := range []struct {
+		n   int
+		exp string
+	}{
+		{0, "1 # end\n\n"},
+		{-1, "1 # end"},
+		{1, "1 # end\n"},
+	} {
+		opt.FinalNewlines = c.n
+		out, err := MarshalWithOptions(node, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != c.exp {
+			t.Errorf("FinalNewlines %d: expected %q, got %q", c.n, c.exp, out)
+		}
+	}
+}
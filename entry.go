@@ -0,0 +1,24 @@
+//go:build go1.18
+
+package hjson
+
+// Entry is a key and its value in an object. A slice of Entry can be used as
+// destination for Unmarshal() to decode an object while keeping the order of
+// its keys and any duplicate keys, for example for HTTP headers or routing
+// tables:
+//
+//	var headers []hjson.Entry[string]
+//	err := hjson.Unmarshal([]byte(`{
+//	  Accept: text/html
+//	  Cookie: a=1
+//	  Cookie: b=2
+//	}`), &headers)
+//
+// Each value is decoded into a T like any other value. Marshal() writes a
+// slice of Entry as an object, with the keys in the order of the slice.
+type Entry[T any] struct {
+	Key   string
+	Value T
+}
+
+func (Entry[T]) isEntry() {}
@@ -0,0 +1,76 @@
+//go:build go1.18
+
+package hjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEntry(t *testing.T) {
+	var headers []Entry[string]
+	err := Unmarshal([]byte(`{
+  Accept: text/html
+  Cookie: a=1
+  Cookie: b=2
+}`), &headers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []Entry[string]{
+		{"Accept", "text/html"},
+		{"Cookie", "a=1"},
+		{"Cookie", "b=2"},
+	}
+	if !reflect.DeepEqual(headers, exp) {
+		t.Errorf("Expected %v, got %v", exp, headers)
+	}
+
+	out, err := Marshal(headers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expOut := `{
+  Accept: text/html
+  Cookie: a=1
+  Cookie: b=2
+}`
+	if string(out) != expOut {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expOut, out)
+	}
+
+	type route struct {
+		Handler string
+		Weight  complex128
+	}
+	var cfg struct {
+		Routes []Entry[route]
+		Empty  []Entry[int]
+	}
+	err = Unmarshal([]byte(`{
+  routes: {
+    /b: {handler: "b", weight: "1+1i"}
+    /a: {handler: "a"}
+    /b: {handler: "c"}
+  }
+  empty: {}
+}`), &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expRoutes := []Entry[route]{
+		{"/b", route{"b", 1 + 1i}},
+		{"/a", route{"a", 0}},
+		{"/b", route{"c", 0}},
+	}
+	if !reflect.DeepEqual(cfg.Routes, expRoutes) {
+		t.Errorf("Expected %v, got %v", expRoutes, cfg.Routes)
+	}
+	if cfg.Empty == nil || len(cfg.Empty) != 0 {
+		t.Errorf("Expected an empty slice, got %#v", cfg.Empty)
+	}
+
+	if err := Unmarshal([]byte(`[1, 2]`), &headers); err == nil {
+		t.Error("Expected an error for an array")
+	}
+}
@@ -0,0 +1,20 @@
+package hjson
+
+import "strings"
+
+// checkEnum returns an error if val, the value of the struct field key, is a
+// string that is not one of the values listed in the enum tag of the field,
+// like `enum:"debug|info|warn|error"`. The error is reported at the position
+// of the value and lists the allowed values.
+func (p *hjsonParser) checkEnum(enum []string, key string, val interface{}) error {
+	s, ok := val.(string)
+	if !ok || enum == nil {
+		return nil
+	}
+	for _, v := range enum {
+		if v == s {
+			return nil
+		}
+	}
+	return p.errAtOffset(p.valueStart, MsgNotInEnum, s, key, strings.Join(enum, ", "))
+}
@@ -0,0 +1,58 @@
+package hjson
+
+import (
+	"testing"
+)
+
+func TestEnumTag(t *testing.T) {
+	type Level string
+	type config struct {
+		Level  Level   `json:"level" enum:"debug|info|warn|error"`
+		Format *string `enum:"text|json"`
+		Name   string
+	}
+
+	var c config
+	if err := Unmarshal([]byte("level: warn\nformat: json\nname: x"), &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Level != "warn" || c.Format == nil || *c.Format != "json" || c.Name != "x" {
+		t.Errorf("Unexpected result: %#v", c)
+	}
+
+	err := Unmarshal([]byte("name: x\nlevel: verbose\n"), &c)
+	pe, ok := err.(*ParseError)
+	if !ok || pe.Kind != MsgNotInEnum || pe.Line != 2 || pe.Column != 8 {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+	exp := "Invalid value 'verbose' for 'level', expected one of: debug, info, warn, error"
+	if pe.Message != exp {
+		t.Errorf("Expected %q, got %q", exp, pe.Message)
+	}
+
+	// A root object without braces on a single line.
+	for _, txt := range []string{"level: c", "format:c"} {
+		err = Unmarshal([]byte(txt), &c)
+		if pe, ok = err.(*ParseError); !ok || pe.Kind != MsgNotInEnum || pe.Line != 1 || pe.Column != 8 {
+			t.Errorf("%q: unexpected error %#v", txt, err)
+		}
+	}
+
+	// null is not checked.
+	c = config{}
+	if err := Unmarshal([]byte("format: null"), &c); err != nil || c.Format != nil {
+		t.Errorf("Unexpected result: %v %#v", err, c)
+	}
+
+	options := DefaultDecoderOptions()
+	options.CollectErrors = true
+	err = UnmarshalWithOptions([]byte("level: trace\nformat: yaml\n"), &c, options)
+	if list, ok := err.(ErrorList); !ok || len(list) != 2 ||
+		list[0].Kind != MsgNotInEnum || list[1].Kind != MsgNotInEnum {
+		t.Errorf("Unexpected error: %#v", err)
+	}
+	err = UnmarshalWithOptions([]byte("level: c"), &c, options)
+	if list, ok := err.(ErrorList); !ok || len(list) != 1 || list[0].Kind != MsgNotInEnum {
+		t.Errorf("Unexpected error: %#v", err)
+	}
+}
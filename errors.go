@@ -0,0 +1,122 @@
+package hjson
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// LimitError is returned by the Unmarshal functions when the Hjson input
+// exceeds one of the limits set in DecoderOptions.
+type LimitError struct {
+	// Limit is the name of the exceeded option in DecoderOptions, for example
+	// "AllocBudget".
+	Limit string
+	// Max is the value of the exceeded option.
+	Max int
+	// Offset is the byte offset in the input where the limit was exceeded.
+	Offset int
+}
+
+func (e *LimitError) Error() string {
+	return DefaultMessages.Message(MsgLimitExceeded, e.Limit, e.Max, e.Offset)
+}
+
+// Code returns the stable code of the error, see Codes().
+func (e *LimitError) Code() string {
+	return CodeOf(MsgLimitExceeded)
+}
+
+// PathNotFoundError is returned by UnmarshalPath() and by the methods of
+// Document taking a JSON Pointer if the document has no value at the
+// requested path.
+type PathNotFoundError struct {
+	// Path is the part of the requested path that was not found, for example
+	// "server.ports" (or "/server/ports" for a JSON Pointer) if the document
+	// has no member "ports" in "server".
+	Path string
+}
+
+func (e *PathNotFoundError) Error() string {
+	return DefaultMessages.Message(MsgPathNotFound, e.Path)
+}
+
+// Code returns the stable code of the error, see Codes().
+func (e *PathNotFoundError) Code() string {
+	return CodeOf(MsgPathNotFound)
+}
+
+// ParseError is returned by the Unmarshal functions when the Hjson input
+// contains a syntax error, or a value that cannot be stored in the
+// destination.
+//
+// When returned by a Decoder, Offset, Line and Column are counted from the
+// start of the stream. LineText then only holds the part of a very long line
+// that is still buffered by the Decoder.
+type ParseError struct {
+	// Message describes the error, without any position. It is built by
+	// DecoderOptions.Messages.
+	Message string
+	// Kind identifies the kind of error, it is one of the Msg constants (for
+	// example MsgDuplicateKey). Kind is empty for errors that do not come from
+	// the Hjson parser.
+	Kind string
+	// Code is the stable code of Kind, for example "HJ001", see Codes().
+	Code string
+	// Offset is the byte offset of the error in the input, starting at 0.
+	Offset int
+	// Line is the line number of the error, starting at 1.
+	Line int
+	// Column is the byte offset of the error on its line, starting at 1.
+	Column int
+	// LineText is the text of the line containing the error, without line
+	// feed.
+	LineText string
+}
+
+func (e *ParseError) Error() string {
+	sample := e.LineText
+	if len(sample) > 20 {
+		sample = sample[:20]
+	}
+	return fmt.Sprintf("%s at line %d,%d >>> %s", e.Message, e.Line, e.Column, sample)
+}
+
+// newParseError returns a *ParseError for the given offset in data.
+func newParseError(data []byte, offset int, message string) *ParseError {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	lineStart := bytes.LastIndexByte(data[:offset], '\n') + 1
+	lineEnd := bytes.IndexByte(data[offset:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(data)
+	} else {
+		lineEnd += offset
+	}
+	return &ParseError{
+		Message:  message,
+		Offset:   offset,
+		Line:     bytes.Count(data[:lineStart], []byte{'\n'}) + 1,
+		Column:   offset - lineStart + 1,
+		LineText: strings.TrimSuffix(string(data[lineStart:lineEnd]), "\r"),
+	}
+}
+
+// ErrorList is returned by the Unmarshal functions if
+// DecoderOptions.CollectErrors is true and the Hjson input contains one or
+// more syntax errors.
+type ErrorList []*ParseError
+
+func (l ErrorList) Error() string {
+	switch len(l) {
+	case 0:
+		return "no errors"
+	case 1:
+		return l[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", l[0], len(l)-1)
+}
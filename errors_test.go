@@ -0,0 +1,205 @@
+package hjson
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAllocBudget(t *testing.T) {
+	txt := []byte(`{
+  a: [` + strings.Repeat("1,", 100) + `]
+  b: ` + strings.Repeat("x", 1000) + `
+}`)
+
+	var v interface{}
+	if err := Unmarshal(txt, &v); err != nil {
+		t.Error(err)
+	}
+
+	opt := DefaultDecoderOptions()
+	opt.AllocBudget = 10000
+	if err := UnmarshalWithOptions(txt, &v, opt); err != nil {
+		t.Error(err)
+	}
+
+	opt.AllocBudget = 1000
+	err := UnmarshalWithOptions(txt, &v, opt)
+	limitErr, ok := err.(*LimitError)
+	if !ok {
+		t.Fatalf("Expected *LimitError, got %#v", err)
+	}
+	if limitErr.Limit != "AllocBudget" || limitErr.Max != 1000 {
+		t.Errorf("Unexpected error: %v", limitErr)
+	}
+
+	var node Node
+	opt.AllocBudget = 2500
+	if err = UnmarshalWithOptions(txt, &node, opt); err == nil {
+		t.Error("Should have failed because Node structs are larger")
+	}
+}
+
+func TestParseError(t *testing.T) {
+	var v interface{}
+	err := Unmarshal([]byte("{\n  a: 1\n  b: [1, 2}\n}"), &v)
+	pe, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("Expected *ParseError, got %#v", err)
+	}
+	expected := ParseError{
+		Message:  "Found a punctuator character '}' when expecting a quoteless string (check your syntax)",
+		Kind:     MsgPunctuatorInValue,
+		Code:     "HJ012",
+		Offset:   19,
+		Line:     3,
+		Column:   11,
+		LineText: "  b: [1, 2}",
+	}
+	if *pe != expected {
+		t.Errorf("Expected %#v, got %#v", expected, *pe)
+	}
+	if pe.Error() != expected.Message+" at line 3,11 >>>   b: [1, 2}" {
+		t.Errorf("Unexpected message: %s", pe.Error())
+	}
+
+	err = Unmarshal([]byte("[}"), &v)
+	if pe, ok = err.(*ParseError); !ok || pe.Line != 1 || pe.Column != 2 || pe.Offset != 1 {
+		t.Errorf("Unexpected error %#v", err)
+	}
+
+	err = Unmarshal([]byte("{\r\n  a: [\r\n"), &v)
+	if pe, ok = err.(*ParseError); !ok || pe.Line != 3 || pe.Column != 1 || pe.LineText != "" {
+		t.Errorf("Unexpected error %#v", err)
+	}
+
+	dec := NewDecoder(strings.NewReader("1\n2\n[3, }]\n"))
+	for i := 0; i < 2; i++ {
+		if err = dec.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = dec.Decode(&v)
+	if pe, ok = err.(*ParseError); !ok || pe.Offset != 8 {
+		t.Errorf("Unexpected error %#v", err)
+	}
+}
+
+func TestCollectErrors(t *testing.T) {
+	txt := []byte(`{
+  a: 1
+  b: }
+  c: [
+    1
+    :
+  ]
+  d 4
+  a: 5
+  e: {
+    x: 1
+  }
+  f: 6
+}
+`)
+	opt := DefaultDecoderOptions()
+	opt.DisallowDuplicateKeys = true
+	var v map[string]interface{}
+	err := UnmarshalWithOptions(txt, &v, opt)
+	if _, ok := err.(*ParseError); !ok {
+		t.Fatalf("Expected *ParseError, got %#v", err)
+	}
+
+	opt.CollectErrors = true
+	err = UnmarshalWithOptions(txt, &v, opt)
+	list, ok := err.(ErrorList)
+	if !ok {
+		t.Fatalf("Expected ErrorList, got %#v", err)
+	}
+	var lines []int
+	for _, pe := range list {
+		lines = append(lines, pe.Line)
+	}
+	if !reflect.DeepEqual(lines, []int{3, 6, 8, 9}) {
+		t.Errorf("Unexpected errors on lines %v: %v", lines, list)
+	}
+	if v != nil {
+		t.Errorf("Destination should not have been changed: %v", v)
+	}
+	if !strings.HasSuffix(list.Error(), " (and 3 more errors)") {
+		t.Errorf("Unexpected message: %s", list.Error())
+	}
+
+	// The end of the input in nested objects is only reported once.
+	err = UnmarshalWithOptions([]byte("{\n  a: {\n    b: [\n"), &v, opt)
+	if list, ok = err.(ErrorList); !ok || len(list) != 1 {
+		t.Errorf("Unexpected error %#v", err)
+	}
+
+	// Valid input, including a root value that is not an object.
+	if err = UnmarshalWithOptions([]byte("a: 1\nb: 2"), &v, opt); err != nil || len(v) != 2 {
+		t.Errorf("Unexpected result %v, %v", v, err)
+	}
+	var n int
+	if err = UnmarshalWithOptions([]byte("5"), &n, opt); err != nil || n != 5 {
+		t.Errorf("Unexpected result %v, %v", n, err)
+	}
+	if err = UnmarshalWithOptions([]byte("a: 1\nb 2\nc: 3\n"), &v, opt); err == nil {
+		t.Error("Expected error")
+	} else if list, ok = err.(ErrorList); !ok || len(list) != 1 || list[0].Line != 2 {
+		t.Errorf("Unexpected error %#v", err)
+	}
+}
+
+func TestInputLimits(t *testing.T) {
+	txt := []byte(`{
+  a: [1, 2, 3]
+  b: "` + strings.Repeat("x", 100) + `"
+  c: ` + strings.Repeat("y", 100) + `
+  d: '''
+    ` + strings.Repeat("z", 100) + `
+    '''
+}`)
+
+	opt := DefaultDecoderOptions()
+	opt.MaxBytes = len(txt)
+	opt.MaxStringLen = 100
+	opt.MaxElements = 4
+	var v interface{}
+	if err := UnmarshalWithOptions(txt, &v, opt); err != nil {
+		t.Error(err)
+	}
+
+	for _, c := range []struct {
+		limit string
+		set   func(*DecoderOptions)
+		txt   []byte
+	}{
+		{"MaxBytes", func(o *DecoderOptions) { o.MaxBytes = len(txt) - 1 }, txt},
+		{"MaxStringLen", func(o *DecoderOptions) { o.MaxStringLen = 99 }, txt},
+		{"MaxStringLen", func(o *DecoderOptions) { o.MaxStringLen = 99 }, []byte(`{"` + strings.Repeat("z", 100) + `": 1}`)},
+		{"MaxStringLen", func(o *DecoderOptions) { o.MaxStringLen = 9 }, []byte(`{` + strings.Repeat("k", 10) + `: 1}`)},
+		{"MaxElements", func(o *DecoderOptions) { o.MaxElements = 3 }, txt},
+		{"MaxElements", func(o *DecoderOptions) { o.MaxElements = 2 }, []byte("[1, 2, 3]")},
+	} {
+		opt := DefaultDecoderOptions()
+		c.set(&opt)
+		err := UnmarshalWithOptions(c.txt, &v, opt)
+		if limitErr, ok := err.(*LimitError); !ok || limitErr.Limit != c.limit {
+			t.Errorf("Expected %s to be exceeded for %q, got %v", c.limit, c.txt, err)
+		}
+	}
+
+	opt = DefaultDecoderOptions()
+	opt.MaxBytes = 10
+	dec := NewDecoderWithOptions(strings.NewReader("[1, 2]\n[3, 4]\n\"a very long string\""), opt)
+	for i := 0; i < 2; i++ {
+		if err := dec.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := dec.Decode(&v); err == nil {
+		t.Error("Expected MaxBytes to be exceeded")
+	} else if _, ok := err.(*LimitError); !ok {
+		t.Errorf("Expected *LimitError, got %#v", err)
+	}
+}
@@ -0,0 +1,200 @@
+package hjson
+
+import (
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// The options of the hjson struct tag that cause an integer field to be
+// written in another base. Such fields are decoded from that base also if
+// DecoderOptions.ExtendedNumbers is false.
+const (
+	// intHex writes the integer like 0xff. It is the same option as bytesHex,
+	// which applies to []byte fields.
+	intHex = "hex"
+	// intOctal writes the integer like 0o755.
+	intOctal = "octal"
+	// intBinary writes the integer like 0b1010.
+	intBinary = "binary"
+)
+
+func isIntFormat(opt string) bool {
+	return opt == intHex || opt == intOctal || opt == intBinary
+}
+
+func isIntType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Uintptr:
+		return true
+	}
+	return false
+}
+
+// parseExtendedInt converts an integer literal with a base prefix, like 0xFF,
+// 0o755 or 0b1010, optionally negative, to decimal text. Returns false if lit
+// is not such a literal.
+func parseExtendedInt(lit string) (string, bool) {
+	negative := strings.HasPrefix(lit, "-")
+	lit = strings.TrimPrefix(lit, "-")
+	if len(lit) < 3 || lit[0] != '0' {
+		return "", false
+	}
+	var base int
+	switch lit[1] {
+	case 'x', 'X':
+		base = 16
+	case 'o', 'O':
+		base = 8
+	case 'b', 'B':
+		base = 2
+	default:
+		return "", false
+	}
+	digits := lit[2:]
+	if digits[0] == '+' || digits[0] == '-' {
+		return "", false
+	}
+	n, ok := new(big.Int).SetString(digits, base)
+	if !ok {
+		return "", false
+	}
+	if negative {
+		n.Neg(n)
+	}
+	return n.String(), true
+}
+
+// stripDigitSeparators removes the underscores separating digits in a number
+// literal like 1_000_000 or 0xFF_FF. Returns false if lit contains no
+// underscores or if any underscore is not between two digits.
+func stripDigitSeparators(lit string) (string, bool) {
+	if !strings.Contains(lit, "_") {
+		return "", false
+	}
+	isDigit := isDecimalDigit
+	if _, ok := parseExtendedInt(strings.Replace(lit, "_", "", -1)); ok {
+		isDigit = isHexDigit
+	}
+	for i := 0; i < len(lit); i++ {
+		if lit[i] == '_' && (i == 0 || i == len(lit)-1 || !isDigit(lit[i-1]) || !isDigit(lit[i+1])) {
+			return "", false
+		}
+	}
+	return strings.Replace(lit, "_", "", -1), true
+}
+
+func isDecimalDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// extendedNumber returns the decimal text of a number literal that is only
+// valid with DecoderOptions.ExtendedNumbers: an integer with a base prefix,
+// see parseExtendedInt(), or a number with digit separators, see
+// stripDigitSeparators(). Returns false if lit is not such a literal.
+func extendedNumber(lit string) (string, bool) {
+	stripped, hasSeparators := stripDigitSeparators(lit)
+	if hasSeparators {
+		lit = stripped
+	}
+	if text, ok := parseExtendedInt(lit); ok {
+		return text, true
+	}
+	if _, err := tryParseNumber([]byte(lit), false, true); err != nil || !hasSeparators {
+		// Not a number, or a plain number that is read as usual.
+		return "", false
+	}
+	return lit, true
+}
+
+// readExtendedNumber returns the value of lit, see extendedNumber(), in the
+// same form as other numbers.
+func (p *hjsonParser) readExtendedNumber(lit string) (interface{}, bool) {
+	text, ok := extendedNumber(lit)
+	if !ok {
+		return nil, false
+	}
+	n, err := p.parseNumber([]byte(text))
+	return n, err == nil
+}
+
+// decodeIntField converts val, the value read for an integer struct field
+// tagged with the "hex", "octal" or "binary" option, to a number if it is a
+// string holding an integer literal with a base prefix. Other values are
+// returned unchanged.
+func (p *hjsonParser) decodeIntField(val interface{}) interface{} {
+	if s, ok := val.(string); ok {
+		if n, ok := p.readExtendedNumber(s); ok {
+			return n
+		}
+	}
+	return val
+}
+
+// writeIntField writes an integer struct field tagged with the "hex",
+// "octal" or "binary" option in that base. Returns false if nothing was
+// written because the field does not contain an integer.
+func (e *hjsonEncoder) writeIntField(value reflect.Value, separator, format string) bool {
+	value, _ = e.unpackNode(value, Comments{})
+	for value.IsValid() && (value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface) {
+		if value.IsNil() {
+			return false
+		}
+		value = value.Elem()
+	}
+	if !value.IsValid() || !isIntType(value.Type()) {
+		return false
+	}
+
+	base, prefix := 16, "0x"
+	switch format {
+	case intOctal:
+		base, prefix = 8, "0o"
+	case intBinary:
+		base, prefix = 2, "0b"
+	}
+	var text string
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n := value.Int()
+		if n < 0 {
+			text = "-" + prefix + strings.TrimPrefix(strconv.FormatInt(n, base), "-")
+		} else {
+			text = prefix + strconv.FormatInt(n, base)
+		}
+	default:
+		text = prefix + strconv.FormatUint(value.Uint(), base)
+	}
+
+	l, r := "", ""
+	if e.EnableColor {
+		l, r = e.ColorStyle.Number[0], e.ColorStyle.Number[1]
+	}
+	e.WriteString(separator + l + text + r)
+	return true
+}
+
+// groupDigits separates the digits of the integer text in groups of three
+// with underscores, like 1_000_000, if e.GroupDigits is true and text has
+// more than four digits. Any other text is returned unchanged.
+func (e *hjsonEncoder) groupDigits(text string) string {
+	digits := strings.TrimPrefix(text, "-")
+	if !e.GroupDigits || len(digits) <= 4 || strings.Trim(digits, "0123456789") != "" {
+		return text
+	}
+	var b strings.Builder
+	b.WriteString(text[:len(text)-len(digits)])
+	for i := 0; i < len(digits); i++ {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('_')
+		}
+		b.WriteByte(digits[i])
+	}
+	return b.String()
+}
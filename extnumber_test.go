@@ -0,0 +1,152 @@
+package hjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtendedNumbers(t *testing.T) {
+	options := DefaultDecoderOptions()
+	options.ExtendedNumbers = true
+
+	var v map[string]interface{}
+	txt := "{\n  hex: 0xFF\n  oct: 0o755\n  bin: 0b1010\n  neg: -0x10\n  big: 0xFFFFFFFFFFFFFFFF\n  bad: 0xZZ\n  list: [0x1, 0B11]\n}"
+	if err := UnmarshalWithOptions([]byte(txt), &v, options); err != nil {
+		t.Fatal(err)
+	}
+	exp := map[string]interface{}{
+		"hex":  255.0,
+		"oct":  493.0,
+		"bin":  10.0,
+		"neg":  -16.0,
+		"big":  18446744073709551615.0,
+		"bad":  "0xZZ",
+		"list": []interface{}{1.0, 3.0},
+	}
+	if !reflect.DeepEqual(v, exp) {
+		t.Errorf("Expected\n%#v\ngot\n%#v", exp, v)
+	}
+
+	var s struct {
+		Mode  uint32
+		Big   uint64
+		Small int8
+	}
+	if err := UnmarshalWithOptions([]byte("{mode: 0o644\nbig: 0xFFFFFFFFFFFFFFFF\nsmall: -0b101\n}"), &s, options); err != nil {
+		t.Fatal(err)
+	}
+	if s.Mode != 0644 || s.Big != 1<<64-1 || s.Small != -5 {
+		t.Errorf("Unexpected result: %#v", s)
+	}
+
+	var node *Node
+	if err := UnmarshalWithOptions([]byte("[0x10]"), &node, options); err != nil {
+		t.Fatal(err)
+	}
+	if n := node.NI(0); n.Value != 16.0 || n.Lit != "0x10" {
+		t.Errorf("Unexpected node: %#v", n)
+	}
+
+	// Without the option the literals are strings.
+	v = nil
+	if err := Unmarshal([]byte("a: 0xFF"), &v); err != nil || v["a"] != "0xFF" {
+		t.Errorf("Unexpected result: %v %#v", err, v)
+	}
+}
+
+func TestIntFieldFormats(t *testing.T) {
+	type file struct {
+		Mode  uint32  `hjson:",octal"`
+		Color *int    `json:"color" hjson:",hex"`
+		Flags uint8   `hjson:",binary"`
+		Delta int     `hjson:",hex"`
+		Name  string  `hjson:",hex"`
+		Sizes []int64 `hjson:",hex"`
+	}
+	color := 0xff8800
+	f := file{Mode: 0755, Color: &color, Flags: 5, Delta: -31, Name: "x", Sizes: []int64{1}}
+	b, err := Marshal(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := `{
+  Mode: 0o755
+  color: 0xff8800
+  Flags: 0b101
+  Delta: -0x1f
+  Name: x
+  Sizes: [
+    1
+  ]
+}`
+	if string(b) != exp {
+		t.Errorf("Expected\n%s\ngot\n%s", exp, b)
+	}
+
+	// Tagged fields are decoded without ExtendedNumbers.
+	var f2 file
+	if err := Unmarshal(b, &f2); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(f, f2) {
+		t.Errorf("Expected\n%#v\ngot\n%#v", f, f2)
+	}
+	if err := Unmarshal([]byte("{Mode: 420\n}"), &f2); err != nil || f2.Mode != 420 {
+		t.Errorf("Unexpected result: %v %#v", err, f2)
+	}
+}
+
+func TestDigitSeparators(t *testing.T) {
+	options := DefaultDecoderOptions()
+	options.ExtendedNumbers = true
+
+	var v map[string]interface{}
+	txt := "{\n  a: 1_000_000\n  b: -12_345.6_7\n  c: 0xFF_FF\n  d: 0b1010_1010\n  e: 1__0\n  f: _1\n  g: 1_\n  h: 1_e5\n  i: 1_000abc\n}"
+	if err := UnmarshalWithOptions([]byte(txt), &v, options); err != nil {
+		t.Fatal(err)
+	}
+	exp := map[string]interface{}{
+		"a": 1000000.0,
+		"b": -12345.67,
+		"c": 65535.0,
+		"d": 170.0,
+		"e": "1__0",
+		"f": "_1",
+		"g": "1_",
+		"h": "1_e5",
+		"i": "1_000abc",
+	}
+	if !reflect.DeepEqual(v, exp) {
+		t.Errorf("Expected\n%#v\ngot\n%#v", exp, v)
+	}
+
+	var s struct{ N int64 }
+	if err := UnmarshalWithOptions([]byte("{n: 9_007_199_254_740_993\n}"), &s, options); err != nil || s.N != 9007199254740993 {
+		t.Errorf("Unexpected result: %v %#v", err, s)
+	}
+
+	encOptions := DefaultOptions()
+	encOptions.GroupDigits = true
+	b, err := MarshalWithOptions([]interface{}{1234, -12345, uint64(1000000), 12345678.0, 1.5e3, 123456.5, "1_000", "0x10", "1_x"}, encOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expTxt := `[
+  1234
+  -12_345
+  1_000_000
+  12_345_678
+  1500
+  123456.5
+  "1_000"
+  "0x10"
+  1_x
+]`
+	if string(b) != expTxt {
+		t.Errorf("Expected\n%s\ngot\n%s", expTxt, b)
+	}
+	var arr []interface{}
+	if err := UnmarshalWithOptions(b, &arr, options); err != nil || arr[3] != 12345678.0 || arr[6] != "1_000" {
+		t.Errorf("Unexpected result: %v %#v", err, arr)
+	}
+}
@@ -0,0 +1,69 @@
+package hjson
+
+import (
+	"reflect"
+)
+
+// FieldSpec is a member of an object written for a type that implements
+// HjsonFielder.
+type FieldSpec struct {
+	// Name is the key of the member.
+	Name string
+	// Value is encoded like any other value. It can hold the content of
+	// unexported fields.
+	Value interface{}
+	// Comment is written before the member if EncoderOptions.Comments is
+	// true, like the comment tag of a struct field.
+	Comment string
+}
+
+// If a type implements HjsonFielder, Marshal() writes a value of that type as
+// an object with the members returned by HjsonFields(), in that order, instead
+// of looking at its fields or calling json.Marshaler or
+// encoding.TextMarshaler. This lets internal types expose unexported state,
+// for example to dump it for diagnostics, without exporting any fields or
+// writing a full marshaler. Unmarshal() does not use HjsonFielder.
+type HjsonFielder interface {
+	HjsonFields() []FieldSpec
+}
+
+var hjsonFielderType = reflect.TypeOf((*HjsonFielder)(nil)).Elem()
+
+// hjsonFields returns the members of value if its type (or a pointer to it,
+// if value is addressable) implements HjsonFielder.
+func hjsonFields(value reflect.Value) ([]FieldSpec, bool) {
+	if value.Type().Implements(hjsonFielderType) && value.CanInterface() {
+		return value.Interface().(HjsonFielder).HjsonFields(), true
+	}
+	if value.CanAddr() && value.Addr().Type().Implements(hjsonFielderType) &&
+		value.Addr().CanInterface() {
+
+		return value.Addr().Interface().(HjsonFielder).HjsonFields(), true
+	}
+	return nil, false
+}
+
+// writeHjsonFields writes the members returned by HjsonFielder.HjsonFields().
+func (e *hjsonEncoder) writeHjsonFields(
+	specs []FieldSpec,
+	noIndent bool,
+	separator string,
+	isRootObject,
+	isObjElement bool,
+	cm Comments,
+) error {
+	fis := make([]fieldInfo, len(specs))
+	for i, spec := range specs {
+		fis[i] = fieldInfo{
+			field: reflect.ValueOf(spec.Value),
+			name:  spec.Name,
+		}
+		if e.Comments {
+			fis[i].comment = spec.Comment
+		}
+	}
+	if e.SortKeys {
+		e.sortFields(fis)
+	}
+	return e.writeFields(fis, noIndent, separator, isRootObject, isObjElement, cm)
+}
@@ -0,0 +1,80 @@
+package hjson
+
+import (
+	"testing"
+)
+
+type testConnPool struct {
+	name    string
+	open    int
+	waiting []string
+	parent  *testConnPool
+}
+
+func (p *testConnPool) HjsonFields() []FieldSpec {
+	fields := []FieldSpec{
+		{Name: "name", Value: p.name},
+		{Name: "open", Value: p.open, Comment: "Open connections"},
+		{Name: "waiting", Value: p.waiting},
+	}
+	if p.parent != nil {
+		fields = append(fields, FieldSpec{Name: "parent", Value: p.parent})
+	}
+	return fields
+}
+
+// MarshalJSON is not used by Marshal(), because HjsonFields() is implemented.
+func (p *testConnPool) MarshalJSON() ([]byte, error) {
+	return []byte(`"pool"`), nil
+}
+
+func TestHjsonFielder(t *testing.T) {
+	pool := testConnPool{
+		name:    "db",
+		open:    3,
+		waiting: []string{"a"},
+		parent:  &testConnPool{name: "root"},
+	}
+	options := DefaultOptions()
+	options.Comments = true
+	b, err := MarshalWithOptions(struct{ Pool *testConnPool }{&pool}, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := `{
+  Pool: {
+    name: db
+    # Open connections
+    open: 3
+
+    waiting: [
+      a
+    ]
+    parent: {
+      name: root
+      # Open connections
+      open: 0
+
+      waiting: []
+    }
+  }
+}`
+	if string(b) != exp {
+		t.Errorf("Expected\n%s\ngot\n%s", exp, b)
+	}
+
+	options.Comments = false
+	options.SortKeys = true
+	b, err = MarshalWithOptions(&testConnPool{name: "x"}, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp = `{
+  name: x
+  open: 0
+  waiting: []
+}`
+	if string(b) != exp {
+		t.Errorf("Expected\n%s\ngot\n%s", exp, b)
+	}
+}
@@ -0,0 +1,67 @@
+package hjson
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// FieldState tells how an object member was given in the Hjson input, see
+// DecoderOptions.FieldStates.
+type FieldState int
+
+const (
+	// FieldAbsent means that the member was not found in the input.
+	FieldAbsent FieldState = iota
+	// FieldNull means that the member was explicitly set to null.
+	FieldNull
+	// FieldSet means that the member was set to a value other than null.
+	FieldSet
+)
+
+func (s FieldState) String() string {
+	switch s {
+	case FieldAbsent:
+		return "absent"
+	case FieldNull:
+		return "null"
+	case FieldSet:
+		return "set"
+	}
+	return fmt.Sprintf("FieldState(%d)", int(s))
+}
+
+// FieldStates records the state of every object member found in the Hjson
+// input, so that a member that was explicitly set to null can be told apart
+// from a member that was left out, even if both leave the destination
+// unchanged. The keys are paths from the root of the input, like
+// "db.password" or "servers.0.host": the object keys and array indexes
+// leading to the member, joined by ".".
+type FieldStates map[string]FieldState
+
+// State returns the state of the member found at path, FieldAbsent if the
+// member was not found in the input.
+func (s FieldStates) State(path string) FieldState {
+	return s[path]
+}
+
+// recordFieldState records the state of the member key of the object found
+// at path, after its value has been read.
+func (p *hjsonParser) recordFieldState(path []interface{}, key string) {
+	state := FieldSet
+	if string(bytes.TrimSpace(p.data[p.valueStart:p.valueEnd])) == "null" {
+		state = FieldNull
+	}
+	p.FieldStates[joinPath(path, key)] = state
+}
+
+// joinPath returns the path of the member key of the object found at path,
+// with the keys and indexes joined by ".".
+func joinPath(path []interface{}, key string) string {
+	parts := make([]string, 0, len(path)+1)
+	for _, elem := range path {
+		parts = append(parts, fmt.Sprint(elem))
+	}
+	parts = append(parts, key)
+	return strings.Join(parts, ".")
+}
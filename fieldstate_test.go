@@ -0,0 +1,64 @@
+package hjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFieldStates(t *testing.T) {
+	type Server struct {
+		Host string
+		Port *int
+	}
+	type Config struct {
+		Name    *string
+		Timeout *int
+		Servers []Server
+		Z       complex128
+	}
+	txt := `{
+  Name: null
+  Timeout: 5 # seconds
+  Servers: [
+    {Host: "a", Port: null, Extra: "null"}
+    {
+      Host: null
+    }
+  ]
+  Z: null
+}`
+	opt := DefaultDecoderOptions()
+	opt.FieldStates = FieldStates{}
+	var c Config
+	if err := UnmarshalWithOptions([]byte(txt), &c, opt); err != nil {
+		t.Fatal(err)
+	}
+	expected := FieldStates{
+		"Name":            FieldNull,
+		"Timeout":         FieldSet,
+		"Servers":         FieldSet,
+		"Servers.0.Host":  FieldSet,
+		"Servers.0.Port":  FieldNull,
+		"Servers.0.Extra": FieldSet,
+		"Servers.1.Host":  FieldNull,
+		"Z":               FieldNull,
+	}
+	if !reflect.DeepEqual(opt.FieldStates, expected) {
+		t.Errorf("Expected:\n%v\nGot:\n%v", expected, opt.FieldStates)
+	}
+	if s := opt.FieldStates.State("Servers.1.Port"); s != FieldAbsent {
+		t.Errorf("Expected %v, got %v", FieldAbsent, s)
+	}
+	if FieldNull.String() != "null" {
+		t.Errorf("Unexpected string %q", FieldNull.String())
+	}
+
+	opt.FieldStates = FieldStates{}
+	var v interface{}
+	if err := UnmarshalWithOptions([]byte("a: null\nb: 1\n"), &v, opt); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(opt.FieldStates, FieldStates{"a": FieldNull, "b": FieldSet}) {
+		t.Errorf("Unexpected states %v", opt.FieldStates)
+	}
+}
@@ -0,0 +1,164 @@
+package hjson
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Flatten decodes the Hjson document data and returns its values in a flat
+// map, where each key is the path to a value: the object keys and array
+// indexes leading to the value, joined by sep. For example, with sep ".":
+//
+//	{
+//	  db: {host: "localhost", ports: [80, 443]}
+//	}
+//
+// becomes
+//
+//	map[string]interface{}{
+//	  "db.host":    "localhost",
+//	  "db.ports.0": 80.0,
+//	  "db.ports.1": 443.0,
+//	}
+//
+// This is useful for bridging to configuration systems using flat keys, like
+// environment variables or Java properties files. Empty objects and arrays are
+// kept as values of type map[string]interface{} and []interface{}, so that
+// Unflatten() can restore them. If the root of the document is not an object
+// or an array, the returned map contains the root value for the key "".
+func Flatten(data []byte, sep string) (map[string]interface{}, error) {
+	if sep == "" {
+		return nil, errors.New("hjson: empty separator given to Flatten")
+	}
+	var v interface{}
+	if err := Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	flat := map[string]interface{}{}
+	flatten(flat, "", sep, v)
+	return flat, nil
+}
+
+func flatten(flat map[string]interface{}, prefix, sep string, v interface{}) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + sep + key
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 && prefix != "" {
+			flat[prefix] = v
+		}
+		for key, elem := range v {
+			flatten(flat, join(key), sep, elem)
+		}
+	case []interface{}:
+		if len(v) == 0 && prefix != "" {
+			flat[prefix] = v
+		}
+		for i, elem := range v {
+			flatten(flat, join(strconv.Itoa(i)), sep, elem)
+		}
+	default:
+		flat[prefix] = v
+	}
+}
+
+// Unflatten is the inverse of Flatten(). It splits each key in flat by sep,
+// builds the nested objects and arrays described by the keys and returns them
+// encoded as Hjson, using default options. Objects in which the keys are
+// exactly the indexes 0, 1, 2, ... are converted to arrays. An error is
+// returned if a key is used both for a value and as the prefix of other keys.
+func Unflatten(flat map[string]interface{}, sep string) ([]byte, error) {
+	if sep == "" {
+		return nil, errors.New("hjson: empty separator given to Unflatten")
+	}
+	if v, ok := flat[""]; ok && len(flat) == 1 {
+		return Marshal(v)
+	}
+
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	root := map[string]interface{}{}
+	for _, key := range keys {
+		parts := strings.Split(key, sep)
+		m := root
+		for i, part := range parts[:len(parts)-1] {
+			switch child := m[part].(type) {
+			case nil:
+				newChild := map[string]interface{}{}
+				m[part] = newChild
+				m = newChild
+			case map[string]interface{}:
+				m = child
+			case []interface{}:
+				if len(child) > 0 {
+					return nil, fmt.Errorf("hjson: key %q conflicts with key %q",
+						key, strings.Join(parts[:i+1], sep))
+				}
+				// An empty array that is given elements.
+				newChild := map[string]interface{}{}
+				m[part] = newChild
+				m = newChild
+			default:
+				return nil, fmt.Errorf("hjson: key %q conflicts with key %q",
+					key, strings.Join(parts[:i+1], sep))
+			}
+		}
+		last := parts[len(parts)-1]
+		if _, exists := m[last]; exists {
+			return nil, fmt.Errorf("hjson: key %q conflicts with other keys", key)
+		}
+		// Other keys may add values to a map value, so copy it.
+		m[last] = copyMaps(flat[key])
+	}
+
+	return Marshal(unflattenArrays(root))
+}
+
+// copyMaps returns v, with all maps of type map[string]interface{} in v
+// copied.
+func copyMaps(v interface{}) interface{} {
+	orig, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+	m := make(map[string]interface{}, len(orig))
+	for key, elem := range orig {
+		m[key] = copyMaps(elem)
+	}
+	return m
+}
+
+// unflattenArrays replaces all maps in the tree v that have the keys "0", "1",
+// "2" and so on with arrays.
+func unflattenArrays(v interface{}) interface{} {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+	for key, elem := range m {
+		m[key] = unflattenArrays(elem)
+	}
+	if len(m) == 0 {
+		return m
+	}
+	arr := make([]interface{}, len(m))
+	for i := range arr {
+		elem, ok := m[strconv.Itoa(i)]
+		if !ok {
+			return m
+		}
+		arr[i] = elem
+	}
+	return arr
+}
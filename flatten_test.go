@@ -0,0 +1,94 @@
+package hjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFlatten(t *testing.T) {
+	txt := `{
+  db: {
+    host: localhost
+    ports: [80, 443]
+  }
+  empty: {}
+  list: []
+  debug: true
+}`
+	flat, err := Flatten([]byte(txt), ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"db.host":    "localhost",
+		"db.ports.0": 80.0,
+		"db.ports.1": 443.0,
+		"empty":      map[string]interface{}{},
+		"list":       []interface{}{},
+		"debug":      true,
+	}
+	if !reflect.DeepEqual(flat, expected) {
+		t.Errorf("Expected %#v, got %#v", expected, flat)
+	}
+
+	out, err := Unflatten(flat, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareStrings(t, out, `{
+  db: {
+    host: localhost
+    ports: [
+      80
+      443
+    ]
+  }
+  debug: true
+  empty: {}
+  list: []
+}`)
+
+	if flat, err = Flatten([]byte("5"), "_"); err != nil || !reflect.DeepEqual(flat, map[string]interface{}{"": 5.0}) {
+		t.Errorf("Unexpected result %#v, %v", flat, err)
+	}
+	if out, err = Unflatten(flat, "_"); err != nil || string(out) != "5" {
+		t.Errorf("Unexpected result %s, %v", out, err)
+	}
+}
+
+func TestUnflatten(t *testing.T) {
+	in := map[string]interface{}{
+		"A__B":    1,
+		"A__C__1": "y",
+		"A__C__0": "x",
+		"D":       map[string]interface{}{"E": 2},
+		"D__F":    3,
+	}
+	out, err := Unflatten(in, "__")
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareStrings(t, out, `{
+  A: {
+    B: 1
+    C: [
+      x
+      y
+    ]
+  }
+  D: {
+    E: 2
+    F: 3
+  }
+}`)
+	if len(in["D"].(map[string]interface{})) != 1 {
+		t.Error("The input map should not have been changed")
+	}
+
+	if _, err = Unflatten(map[string]interface{}{"a": 1, "a.b": 2}, "."); err == nil {
+		t.Error("Expected error for conflicting keys")
+	}
+	if _, err = Unflatten(map[string]interface{}{"a": 1}, ""); err == nil {
+		t.Error("Expected error for empty separator")
+	}
+}
@@ -0,0 +1,318 @@
+package hjson
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Format identifies a text format that can be decoded by this package.
+type Format int
+
+const (
+	// FormatUnknown is returned by Sniff() for empty input.
+	FormatUnknown Format = iota
+	// FormatJSON is strict JSON as defined in RFC 8259.
+	FormatJSON
+	// FormatJSON5 is JSON5 (https://json5.org), i.e. JSON extended with
+	// comments, trailing commas, single quoted strings, unquoted keys and
+	// more number formats.
+	FormatJSON5
+	// FormatHjson is Hjson (https://hjson.github.io).
+	FormatHjson
+	// FormatJSONC is JSON with // and /* */ comments and trailing commas, as
+	// used for example by the settings files of VS Code. It is never returned
+	// by Sniff(), which reports such input as FormatJSON5.
+	FormatJSONC
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatJSON:
+		return "JSON"
+	case FormatJSON5:
+		return "JSON5"
+	case FormatHjson:
+		return "Hjson"
+	case FormatJSONC:
+		return "JSONC"
+	}
+	return "unknown"
+}
+
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// Sniff returns the format of data. Valid JSON is reported as FormatJSON.
+// Input that is not valid JSON but only uses syntax that is valid in JSON5
+// is reported as FormatJSON5. Input that uses any Hjson-only syntax (for
+// example quoteless strings, multiline strings, # comments, missing commas
+// or a root object without braces) is reported as FormatHjson, as is any
+// input that is not valid in any of the formats. FormatUnknown is returned
+// if data only contains whitespace.
+//
+// Sniff only looks at the syntax of data, it does not decode any values.
+func Sniff(data []byte) Format {
+	data = bytes.TrimPrefix(data, utf8BOM)
+	if len(bytes.TrimSpace(data)) == 0 {
+		return FormatUnknown
+	}
+	if json.Valid(data) {
+		return FormatJSON
+	}
+
+	s := sniffer{data: data}
+	if !s.value() {
+		return FormatHjson
+	}
+	s.white()
+	if s.at < len(s.data) || s.hjson {
+		return FormatHjson
+	}
+	return FormatJSON5
+}
+
+// sniffer walks through JSON5 syntax. As soon as any syntax is found that is
+// not valid JSON5, hjson is set to true and the walk is aborted.
+type sniffer struct {
+	data  []byte
+	at    int
+	hjson bool
+}
+
+func (s *sniffer) peek(offs int) byte {
+	if s.at+offs < len(s.data) {
+		return s.data[s.at+offs]
+	}
+	return 0
+}
+
+// white skips whitespace and comments.
+func (s *sniffer) white() {
+	for s.at < len(s.data) {
+		c := s.data[s.at]
+		switch {
+		case c <= ' ':
+			s.at++
+		case c == '#':
+			s.hjson = true
+			return
+		case c == '/' && s.peek(1) == '/':
+			for s.at < len(s.data) && s.data[s.at] != '\n' {
+				s.at++
+			}
+		case c == '/' && s.peek(1) == '*':
+			end := bytes.Index(s.data[s.at+2:], []byte("*/"))
+			if end < 0 {
+				s.at = len(s.data)
+			} else {
+				s.at += end + 4
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (s *sniffer) fail() bool {
+	s.hjson = true
+	return false
+}
+
+func isIdentifierChar(c byte, first bool) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' ||
+		!first && c >= '0' && c <= '9' || c >= 0x80
+}
+
+func (s *sniffer) value() bool {
+	if s.white(); s.hjson {
+		return false
+	}
+	switch s.peek(0) {
+	case '{':
+		return s.object()
+	case '[':
+		return s.array()
+	case '"', '\'':
+		return s.string()
+	}
+	// A number or a keyword, or else it is a quoteless string (or a root object
+	// without braces).
+	start := s.at
+	for s.at < len(s.data) {
+		c := s.data[s.at]
+		if c <= ' ' || c == ',' || c == '}' || c == ']' || c == ':' ||
+			c == '#' || c == '/' && (s.peek(1) == '/' || s.peek(1) == '*') {
+			break
+		}
+		s.at++
+	}
+	if !isJSON5Literal(s.data[start:s.at]) {
+		return s.fail()
+	}
+	return true
+}
+
+func isJSON5Literal(lit []byte) bool {
+	switch string(lit) {
+	case "true", "false", "null", "Infinity", "-Infinity", "+Infinity",
+		"NaN", "-NaN", "+NaN":
+		return true
+	}
+	if len(lit) > 0 && (lit[0] == '+' || lit[0] == '-') {
+		lit = lit[1:]
+	}
+	if len(lit) > 2 && lit[0] == '0' && (lit[1] == 'x' || lit[1] == 'X') {
+		for _, c := range lit[2:] {
+			if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+				return false
+			}
+		}
+		return true
+	}
+	digits := 0
+	i := 0
+	for ; i < len(lit) && lit[i] >= '0' && lit[i] <= '9'; i++ {
+		digits++
+	}
+	if i < len(lit) && lit[i] == '.' {
+		for i++; i < len(lit) && lit[i] >= '0' && lit[i] <= '9'; i++ {
+			digits++
+		}
+	}
+	if digits == 0 {
+		return false
+	}
+	if i < len(lit) && (lit[i] == 'e' || lit[i] == 'E') {
+		i++
+		if i < len(lit) && (lit[i] == '+' || lit[i] == '-') {
+			i++
+		}
+		expDigits := 0
+		for ; i < len(lit) && lit[i] >= '0' && lit[i] <= '9'; i++ {
+			expDigits++
+		}
+		if expDigits == 0 {
+			return false
+		}
+	}
+	return i == len(lit)
+}
+
+func (s *sniffer) string() bool {
+	quote := s.data[s.at]
+	if quote == '\'' && s.peek(1) == '\'' && s.peek(2) == '\'' {
+		// Multiline string.
+		return s.fail()
+	}
+	for s.at++; s.at < len(s.data); s.at++ {
+		switch s.data[s.at] {
+		case '\\':
+			s.at++
+		case quote:
+			s.at++
+			return true
+		case '\n':
+			return s.fail()
+		}
+	}
+	return s.fail()
+}
+
+func (s *sniffer) key() bool {
+	if s.white(); s.hjson {
+		return false
+	}
+	switch c := s.peek(0); {
+	case c == '"' || c == '\'':
+		if !s.string() {
+			return false
+		}
+	case isIdentifierChar(c, true):
+		for s.at < len(s.data) && isIdentifierChar(s.data[s.at], false) {
+			s.at++
+		}
+	default:
+		return s.fail()
+	}
+	if s.white(); s.hjson || s.peek(0) != ':' {
+		return s.fail()
+	}
+	s.at++
+	return true
+}
+
+// afterElement checks that the element just read is followed by a comma or
+// the closing character. Returns true if the closing character was found.
+func (s *sniffer) afterElement(closing byte) (bool, bool) {
+	s.white()
+	if s.hjson {
+		return false, false
+	}
+	switch s.peek(0) {
+	case ',':
+		s.at++
+		if s.white(); s.hjson {
+			return false, false
+		}
+		if s.peek(0) == closing {
+			s.at++
+			return true, true
+		}
+		return false, true
+	case closing:
+		s.at++
+		return true, true
+	}
+	// Missing comma, or something that is not valid in any format.
+	return false, s.fail()
+}
+
+func (s *sniffer) object() bool {
+	s.at++
+	if s.white(); s.peek(0) == '}' {
+		s.at++
+		return !s.hjson
+	}
+	for s.at < len(s.data) {
+		if !s.key() || !s.value() {
+			return false
+		}
+		done, ok := s.afterElement('}')
+		if !ok || done {
+			return ok
+		}
+	}
+	return s.fail()
+}
+
+func (s *sniffer) array() bool {
+	s.at++
+	if s.white(); s.peek(0) == ']' {
+		s.at++
+		return !s.hjson
+	}
+	for s.at < len(s.data) {
+		if !s.value() {
+			return false
+		}
+		done, ok := s.afterElement(']')
+		if !ok || done {
+			return ok
+		}
+	}
+	return s.fail()
+}
+
+// UnmarshalDetect works like UnmarshalWithOptions, but also returns the
+// format of data as reported by Sniff(). JSON, JSON5 and Hjson input can all
+// be decoded, so that tools can read directories containing a mix of
+// configuration files in different formats using a single code path.
+//
+// JSON5 input is decoded with DecoderOptions.Dialect set to FormatJSON5, so
+// that for example hexadecimal numbers are decoded as numbers.
+func UnmarshalDetect(data []byte, v interface{}, options DecoderOptions) (Format, error) {
+	format := Sniff(data)
+	if format == FormatJSON5 {
+		options.Dialect = FormatJSON5
+	}
+	return format, UnmarshalWithOptions(bytes.TrimPrefix(data, utf8BOM), v, options)
+}
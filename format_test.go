@@ -0,0 +1,46 @@
+package hjson
+
+import "testing"
+
+func TestSniff(t *testing.T) {
+	for txt, exp := range map[string]Format{
+		"":                                  FormatUnknown,
+		" \n ":                              FormatUnknown,
+		`{"a": [1, 2.5e3, "x"], "b": null}`: FormatJSON,
+		"\xef\xbb\xbf[true]":                FormatJSON,
+		`"str"`:                             FormatJSON,
+		`{a: 1, 'b': 'x',}`:                 FormatJSON5,
+		"// comment\n{\"a\": 0x1F}":         FormatJSON5,
+		"[+1, .5, 5., Infinity, NaN]":       FormatJSON5,
+		"{$id_1: 1 /* c */}":                FormatJSON5,
+		"a: 1":                              FormatHjson,
+		"{a: text}":                         FormatHjson,
+		"{\"a\": 1\n\"b\": 2}":              FormatHjson,
+		"{\"a\": 1 # comment\n}":            FormatHjson,
+		"{a: '''\nml\n'''}":                 FormatHjson,
+		"{x-y: 1}":                          FormatHjson,
+		"[1, 2":                             FormatHjson,
+	} {
+		if f := Sniff([]byte(txt)); f != exp {
+			t.Errorf("Expected %v for %q, got %v", exp, txt, f)
+		}
+	}
+}
+
+func TestUnmarshalDetect(t *testing.T) {
+	var v map[string]interface{}
+	f, err := UnmarshalDetect([]byte("\xef\xbb\xbf{a: 'x', b: [1, 2,],}"), &v, DefaultDecoderOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f != FormatJSON5 || v["a"] != "x" || len(v["b"].([]interface{})) != 2 {
+		t.Errorf("Unexpected result: %v %v", f, v)
+	}
+	f, err = UnmarshalDetect([]byte("a: x\nb: 2"), &v, DefaultDecoderOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f != FormatHjson || v["a"] != "x" || v["b"] != 2.0 {
+		t.Errorf("Unexpected result: %v %v", f, v)
+	}
+}
@@ -0,0 +1,157 @@
+package hjson
+
+import (
+	"bytes"
+	"io"
+)
+
+// Position is a location in an Hjson document.
+type Position struct {
+	// Offset is the byte offset, starting at 0.
+	Offset int
+	// Line is the line number, starting at 1.
+	Line int
+	// Column is the byte offset on the line, starting at 1.
+	Column int
+}
+
+// Handler receives events from Parse(). The position passed to each method is
+// the position of the first character of the delimiter, key, value or
+// comment. If a method returns an error, parsing stops and Parse() returns
+// that error.
+//
+// A root object without braces generates ObjectStart at the position of its
+// first key and ObjectEnd at the end of the document.
+type Handler interface {
+	ObjectStart(pos Position) error
+	ObjectEnd(pos Position) error
+	ArrayStart(pos Position) error
+	ArrayEnd(pos Position) error
+	// Key is called for each object key, before the events for its value.
+	Key(key string, pos Position) error
+	// Value is called for each scalar value, which can be a string, a float64,
+	// a bool or nil.
+	Value(value interface{}, pos Position) error
+	// Comment is called for each comment, including its markers (#, // or
+	// /* */).
+	Comment(comment string, pos Position) error
+}
+
+// BaseHandler implements all methods of Handler, doing nothing. It can be
+// embedded in structs that only need to implement some of the methods.
+type BaseHandler struct{}
+
+// ObjectStart implements Handler.
+func (BaseHandler) ObjectStart(pos Position) error { return nil }
+
+// ObjectEnd implements Handler.
+func (BaseHandler) ObjectEnd(pos Position) error { return nil }
+
+// ArrayStart implements Handler.
+func (BaseHandler) ArrayStart(pos Position) error { return nil }
+
+// ArrayEnd implements Handler.
+func (BaseHandler) ArrayEnd(pos Position) error { return nil }
+
+// Key implements Handler.
+func (BaseHandler) Key(key string, pos Position) error { return nil }
+
+// Value implements Handler.
+func (BaseHandler) Value(value interface{}, pos Position) error { return nil }
+
+// Comment implements Handler.
+func (BaseHandler) Comment(comment string, pos Position) error { return nil }
+
+// positionTracker converts offsets to positions. Offsets must be increasing.
+type positionTracker struct {
+	data      []byte
+	at        int
+	line      int
+	lineStart int
+}
+
+func (pt *positionTracker) position(offset int) Position {
+	for ; pt.at < offset; pt.at++ {
+		if pt.data[pt.at] == '\n' {
+			pt.line++
+			pt.lineStart = pt.at + 1
+		}
+	}
+	return Position{
+		Offset: offset,
+		Line:   pt.line + 1,
+		Column: offset - pt.lineStart + 1,
+	}
+}
+
+// Parse parses data as a single Hjson document and calls the methods of
+// handler for each delimiter, key, scalar value and comment found, in
+// document order. An empty document is reported as an empty object, like in
+// Unmarshal(). Unlike Unmarshal(), Parse does not create any tree of
+// values, so it can be used to build an index over a very large document
+// without keeping the whole document in memory as Go values.
+func Parse(data []byte, handler Handler) error {
+	return parseWithOptions(data, handler, DefaultDecoderOptions())
+}
+
+// parseWithOptions is like Parse(), but decodes scalar values using the given
+// options.
+func parseWithOptions(data []byte, handler Handler, options DecoderOptions) error {
+	dec := NewDecoderWithOptions(bytes.NewReader(data), options)
+	pt := positionTracker{data: data}
+	done := false
+	for {
+		prev := int(dec.InputOffset())
+		tok, err := dec.Token()
+		if err == io.EOF {
+			if !done {
+				// An empty document is an empty root object without braces.
+				pos := pt.position(len(data))
+				if err = handler.ObjectStart(pos); err != nil {
+					return err
+				}
+				return handler.ObjectEnd(pos)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		start := prev + len(data[prev:]) - len(bytes.TrimLeft(data[prev:], " \t\r\n,"))
+		pos := pt.position(start)
+
+		if _, ok := tok.(Comment); !ok {
+			if done {
+				p := &hjsonParser{DecoderOptions: options, data: data}
+				return p.errAtOffset(start, MsgTrailingCharacters)
+			}
+			if len(dec.tokenStack) == 0 {
+				done = true
+			}
+		}
+
+		switch tok := tok.(type) {
+		case Delim:
+			switch tok {
+			case '{':
+				err = handler.ObjectStart(pos)
+			case '}':
+				err = handler.ObjectEnd(pos)
+			case '[':
+				err = handler.ArrayStart(pos)
+			case ']':
+				err = handler.ArrayEnd(pos)
+			}
+		case Key:
+			err = handler.Key(string(tok), pos)
+		case Comment:
+			err = handler.Comment(string(tok), pos)
+		default:
+			err = handler.Value(tok, pos)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package hjson
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type recordingHandler struct {
+	events []string
+}
+
+func (h *recordingHandler) add(name string, v interface{}, pos Position) error {
+	h.events = append(h.events, fmt.Sprintf("%s %v %d:%d:%d", name, v, pos.Offset, pos.Line, pos.Column))
+	return nil
+}
+
+func (h *recordingHandler) ObjectStart(pos Position) error { return h.add("ObjectStart", "{", pos) }
+func (h *recordingHandler) ObjectEnd(pos Position) error   { return h.add("ObjectEnd", "}", pos) }
+func (h *recordingHandler) ArrayStart(pos Position) error  { return h.add("ArrayStart", "[", pos) }
+func (h *recordingHandler) ArrayEnd(pos Position) error    { return h.add("ArrayEnd", "]", pos) }
+func (h *recordingHandler) Key(key string, pos Position) error {
+	return h.add("Key", key, pos)
+}
+func (h *recordingHandler) Value(value interface{}, pos Position) error {
+	return h.add("Value", value, pos)
+}
+func (h *recordingHandler) Comment(comment string, pos Position) error {
+	return h.add("Comment", comment, pos)
+}
+
+func TestParse(t *testing.T) {
+	txt := `{
+  # comment
+  a: [1, "two"]
+  b: true
+}
+`
+	expected := []string{
+		"ObjectStart { 0:1:1",
+		"Comment # comment 4:2:3",
+		"Key a 16:3:3",
+		"ArrayStart [ 19:3:6",
+		"Value 1 20:3:7",
+		"Value two 23:3:10",
+		"ArrayEnd ] 28:3:15",
+		"Key b 32:4:3",
+		"Value true 35:4:6",
+		"ObjectEnd } 40:5:1",
+	}
+	h := &recordingHandler{}
+	if err := Parse([]byte(txt), h); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(h.events, expected) {
+		t.Errorf("Expected:\n%#v\nGot:\n%#v", expected, h.events)
+	}
+}
+
+func TestParseWithoutBraces(t *testing.T) {
+	expected := []string{
+		"ObjectStart { 1:2:1",
+		"Key a 1:2:1",
+		"Value x 4:2:4",
+		"ObjectEnd } 6:3:1",
+	}
+	h := &recordingHandler{}
+	if err := Parse([]byte("\na: x\n"), h); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(h.events, expected) {
+		t.Errorf("Expected:\n%#v\nGot:\n%#v", expected, h.events)
+	}
+
+	h = &recordingHandler{}
+	if err := Parse([]byte("# only a comment"), h); err != nil {
+		t.Fatal(err)
+	}
+	if len(h.events) != 3 || h.events[1] != "ObjectStart { 16:1:17" {
+		t.Errorf("Unexpected events %#v", h.events)
+	}
+}
+
+type stopHandler struct {
+	BaseHandler
+	keys int
+}
+
+var errStop = errors.New("stop")
+
+func (h *stopHandler) Key(key string, pos Position) error {
+	h.keys++
+	if key == "b" {
+		return errStop
+	}
+	return nil
+}
+
+func TestParseErrors(t *testing.T) {
+	h := &stopHandler{}
+	if err := Parse([]byte("{a: 1, b: 2, c: 3}"), h); err != errStop {
+		t.Errorf("Expected errStop, got %v", err)
+	}
+	if h.keys != 2 {
+		t.Errorf("Expected 2 keys, got %d", h.keys)
+	}
+
+	for _, txt := range []string{"[1] [2]", "{a: 1", "[1}"} {
+		if err := Parse([]byte(txt), BaseHandler{}); err == nil {
+			t.Errorf("Expected error for %q", txt)
+		}
+	}
+}
+
+func TestParseErrorPositions(t *testing.T) {
+	// An error after many buffer refills.
+	long := "{\n" + strings.Repeat("  key: value\n", 300) + "  bad\n}\n"
+
+	for _, tc := range []struct {
+		input        string
+		kind         string
+		line, column int
+	}{
+		{long, MsgPunctuatorInKey, 303, 1},
+		{"{a: 1", MsgUnterminatedObject, 1, 6},
+		{"[1, 2", MsgUnterminatedArray, 1, 6},
+		{"{\n  a: [\n    1\n", MsgUnterminatedArray, 4, 1},
+		{"{}\nx", MsgTrailingCharacters, 2, 1},
+	} {
+		err := Parse([]byte(tc.input), BaseHandler{})
+		pe, ok := err.(*ParseError)
+		if !ok {
+			t.Errorf("%.20q: expected a *ParseError, got %v", tc.input, err)
+			continue
+		}
+		if pe.Kind != tc.kind || pe.Line != tc.line || pe.Column != tc.column {
+			t.Errorf("%.20q: expected %s at %d,%d, got %s at %d,%d", tc.input,
+				tc.kind, tc.line, tc.column, pe.Kind, pe.Line, pe.Column)
+		}
+		// Unmarshal reports the same position.
+		var v interface{}
+		if ue, ok := Unmarshal([]byte(tc.input), &v).(*ParseError); !ok || ue.Line != pe.Line || ue.Column != pe.Column {
+			t.Errorf("%.20q: Unmarshal() reports %v", tc.input, ue)
+		}
+	}
+}
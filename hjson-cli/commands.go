@@ -0,0 +1,313 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// command is a subcommand of the CLI. The options of the CLI are the flags
+// of flag.CommandLine, so together they make up the command tree used to
+// generate shell completions and documentation.
+type command struct {
+	name    string
+	args    []string // The values accepted as the first argument.
+	usage   string
+	summary string
+	run     func(w io.Writer, args []string) error
+}
+
+var commands []command
+
+func init() {
+	// Set in init() because the functions refer to commands themselves.
+	commands = []command{
+		{
+			name:    "completion",
+			args:    []string{"bash", "zsh", "fish", "powershell"},
+			usage:   "completion bash|zsh|fish|powershell",
+			summary: "Print a script that adds completion of options and arguments to a shell.",
+			run:     runCompletion,
+		},
+		{
+			name:    "docs",
+			args:    []string{"man", "markdown"},
+			usage:   "docs [man|markdown]",
+			summary: "Print this documentation as a man page (the default) or as Markdown.",
+			run:     runDocs,
+		},
+	}
+}
+
+const (
+	cliName    = "hjson-cli"
+	cliSummary = "hjson can be used to convert JSON from/to Hjson."
+	cliDetails = "hjson will read the given JSON/Hjson input file or read from stdin. " +
+		"A first argument naming a command is read as an input file if a file with that name exists."
+)
+
+// commandArg returns the subcommand named by the first argument in args, or
+// nil. An existing file with the same name is an input file instead, so that
+// the commands do not break conversions of files named like them.
+func commandArg(args []string) *command {
+	if len(args) == 0 {
+		return nil
+	}
+	c := findCommand(args[0])
+	if c == nil {
+		return nil
+	}
+	if _, err := os.Stat(args[0]); err == nil {
+		return nil
+	}
+	return c
+}
+
+// findCommand returns the subcommand called name, or nil.
+func findCommand(name string) *command {
+	for i := range commands {
+		if commands[i].name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+// cliFlag is an option of the CLI.
+type cliFlag struct {
+	name     string
+	valueArg string // The name of the value, or "" for boolean flags.
+	usage    string
+	defValue string // The default value, or "" if it is the zero value.
+}
+
+// cliFlags returns the options defined in fs, sorted by name.
+func cliFlags(fs *flag.FlagSet) []cliFlag {
+	var flags []cliFlag
+	fs.VisitAll(func(f *flag.Flag) {
+		valueArg, usage := flag.UnquoteUsage(f)
+		cf := cliFlag{name: f.Name, valueArg: valueArg, usage: usage}
+		switch f.DefValue {
+		case "", "0", "false":
+		default:
+			cf.defValue = f.DefValue
+		}
+		flags = append(flags, cf)
+	})
+	return flags
+}
+
+func runCompletion(w io.Writer, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: %s %s", cliName, findCommand("completion").usage)
+	}
+	flags := cliFlags(flag.CommandLine)
+	switch args[0] {
+	case "bash":
+		writeBashCompletion(w, flags)
+	case "zsh":
+		writeZshCompletion(w, flags)
+	case "fish":
+		writeFishCompletion(w, flags)
+	case "powershell":
+		writePowerShellCompletion(w, flags)
+	default:
+		return fmt.Errorf("unsupported shell %q", args[0])
+	}
+	return nil
+}
+
+func writeBashCompletion(w io.Writer, flags []cliFlag) {
+	var names, valueNames []string
+	for _, f := range flags {
+		names = append(names, "-"+f.name)
+		if f.valueArg != "" {
+			valueNames = append(valueNames, "-"+f.name)
+		}
+	}
+	fmt.Fprintf(w, "# bash completion for %s\n", cliName)
+	fmt.Fprintf(w, "_hjson_cli() {\n")
+	fmt.Fprintf(w, "    local cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	fmt.Fprintf(w, "    if [ \"$COMP_CWORD\" -eq 2 ]; then\n")
+	fmt.Fprintf(w, "        case \"$prev\" in\n")
+	for _, c := range commands {
+		fmt.Fprintf(w, "        %s) COMPREPLY=($(compgen -W \"%s\" -- \"$cur\")); return ;;\n",
+			c.name, strings.Join(c.args, " "))
+	}
+	fmt.Fprintf(w, "        esac\n")
+	fmt.Fprintf(w, "    fi\n")
+	fmt.Fprintf(w, "    case \"$prev\" in\n")
+	fmt.Fprintf(w, "    %s) return ;;\n", strings.Join(valueNames, "|"))
+	fmt.Fprintf(w, "    esac\n")
+	fmt.Fprintf(w, "    case \"$cur\" in\n")
+	fmt.Fprintf(w, "    -*) COMPREPLY=($(compgen -W \"%s\" -- \"$cur\")) ;;\n", strings.Join(names, " "))
+	fmt.Fprintf(w, "    *)\n")
+	fmt.Fprintf(w, "        COMPREPLY=($(compgen -f -- \"$cur\"))\n")
+	fmt.Fprintf(w, "        if [ \"$COMP_CWORD\" -eq 1 ]; then\n")
+	fmt.Fprintf(w, "            COMPREPLY+=($(compgen -W \"%s\" -- \"$cur\"))\n", strings.Join(commandNames(), " "))
+	fmt.Fprintf(w, "        fi\n")
+	fmt.Fprintf(w, "        ;;\n")
+	fmt.Fprintf(w, "    esac\n")
+	fmt.Fprintf(w, "}\n")
+	fmt.Fprintf(w, "complete -o filenames -F _hjson_cli %s\n", cliName)
+}
+
+func writeZshCompletion(w io.Writer, flags []cliFlag) {
+	escape := strings.NewReplacer("'", "'\\''", "[", "\\[", "]", "\\]", ":", "\\:").Replace
+	fmt.Fprintf(w, "#compdef %s\n\n", cliName)
+	fmt.Fprintf(w, "if (( CURRENT == 3 )); then\n")
+	fmt.Fprintf(w, "    case $words[2] in\n")
+	for _, c := range commands {
+		fmt.Fprintf(w, "    %s) _values '%s' %s; return ;;\n", c.name, c.name, strings.Join(c.args, " "))
+	}
+	fmt.Fprintf(w, "    esac\n")
+	fmt.Fprintf(w, "fi\n")
+	fmt.Fprintf(w, "_arguments \\\n")
+	for _, f := range flags {
+		if f.valueArg != "" {
+			fmt.Fprintf(w, "    '-%s[%s]:%s: ' \\\n", f.name, escape(f.usage), f.valueArg)
+		} else {
+			fmt.Fprintf(w, "    '-%s[%s]' \\\n", f.name, escape(f.usage))
+		}
+	}
+	fmt.Fprintf(w, "    '1:command or input file:_alternative \"commands:command:(%s)\" \"files:file:_files\"' \\\n",
+		strings.Join(commandNames(), " "))
+	fmt.Fprintf(w, "    '*:input file:_files'\n")
+}
+
+func writeFishCompletion(w io.Writer, flags []cliFlag) {
+	escape := strings.NewReplacer("\\", "\\\\", "'", "\\'").Replace
+	fmt.Fprintf(w, "# fish completion for %s\n", cliName)
+	for _, c := range commands {
+		fmt.Fprintf(w, "complete -c %s -n '__fish_use_subcommand' -a %s -d '%s'\n",
+			cliName, c.name, escape(c.summary))
+		fmt.Fprintf(w, "complete -c %s -n '__fish_seen_subcommand_from %s' -f -a '%s'\n",
+			cliName, c.name, strings.Join(c.args, " "))
+	}
+	for _, f := range flags {
+		requires := ""
+		if f.valueArg != "" {
+			requires = " -r"
+		}
+		fmt.Fprintf(w, "complete -c %s -o %s%s -d '%s'\n", cliName, f.name, requires, escape(f.usage))
+	}
+}
+
+func writePowerShellCompletion(w io.Writer, flags []cliFlag) {
+	escape := strings.NewReplacer("'", "''").Replace
+	fmt.Fprintf(w, "# PowerShell completion for %s\n", cliName)
+	fmt.Fprintf(w, "Register-ArgumentCompleter -Native -CommandName '%s' -ScriptBlock {\n", cliName)
+	fmt.Fprintf(w, "    param($wordToComplete, $commandAst, $cursorPosition)\n")
+	fmt.Fprintf(w, "    $words = @($commandAst.CommandElements | ForEach-Object { $_.ToString() })\n")
+	fmt.Fprintf(w, "    $candidates = @(\n")
+	for i, f := range flags {
+		sep := ","
+		if i == len(flags)-1 {
+			sep = ""
+		}
+		fmt.Fprintf(w, "        @('-%s', 'ParameterName', '%s')%s\n", f.name, escape(f.usage), sep)
+	}
+	fmt.Fprintf(w, "    )\n")
+	fmt.Fprintf(w, "    if ($words.Count -ge 2 -and ($words.Count -gt 2 -or $wordToComplete -eq '')) {\n")
+	fmt.Fprintf(w, "        switch ($words[1]) {\n")
+	for _, c := range commands {
+		var args []string
+		for _, arg := range c.args {
+			args = append(args, fmt.Sprintf("@('%s', 'ParameterValue', '%s')", arg, arg))
+		}
+		fmt.Fprintf(w, "            '%s' { $candidates = @(%s) }\n", c.name, strings.Join(args, ", "))
+	}
+	fmt.Fprintf(w, "        }\n")
+	fmt.Fprintf(w, "    } else {\n")
+	for _, c := range commands {
+		fmt.Fprintf(w, "        $candidates += ,@('%s', 'Command', '%s')\n", c.name, escape(c.summary))
+	}
+	fmt.Fprintf(w, "    }\n")
+	fmt.Fprintf(w, "    $candidates | Where-Object { $_[0] -like \"$wordToComplete*\" } | ForEach-Object {\n")
+	fmt.Fprintf(w, "        [System.Management.Automation.CompletionResult]::new($_[0], $_[0], $_[1], $_[2])\n")
+	fmt.Fprintf(w, "    }\n")
+	fmt.Fprintf(w, "}\n")
+}
+
+func commandNames() []string {
+	var names []string
+	for _, c := range commands {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+func runDocs(w io.Writer, args []string) error {
+	format := "man"
+	if len(args) > 1 {
+		return fmt.Errorf("usage: %s %s", cliName, findCommand("docs").usage)
+	} else if len(args) == 1 {
+		format = args[0]
+	}
+	flags := cliFlags(flag.CommandLine)
+	switch format {
+	case "man":
+		writeManPage(w, flags)
+	case "markdown":
+		writeMarkdown(w, flags)
+	default:
+		return fmt.Errorf("unsupported format %q", format)
+	}
+	return nil
+}
+
+// roff escapes text for a man page.
+var roff = strings.NewReplacer("\\", "\\e", "-", "\\-", "'", "\\(aq").Replace
+
+func writeManPage(w io.Writer, flags []cliFlag) {
+	fmt.Fprintf(w, ".TH %s 1\n", strings.ToUpper(cliName))
+	fmt.Fprintf(w, ".SH NAME\n%s \\- convert JSON from/to Hjson\n", roff(cliName))
+	fmt.Fprintf(w, ".SH SYNOPSIS\n")
+	fmt.Fprintf(w, ".B %s\n[\\fIOPTIONS\\fR] [\\fIINPUT\\fR...]\n", roff(cliName))
+	for _, c := range commands {
+		fmt.Fprintf(w, ".br\n.B %s\n%s\n", roff(cliName), roff(c.usage))
+	}
+	fmt.Fprintf(w, ".SH DESCRIPTION\n%s\n.PP\n%s\n", roff(cliSummary), roff(cliDetails))
+	fmt.Fprintf(w, ".SH OPTIONS\n")
+	for _, f := range flags {
+		fmt.Fprintf(w, ".TP\n.B \\-%s", roff(f.name))
+		if f.valueArg != "" {
+			fmt.Fprintf(w, " \\fI%s\\fR", roff(f.valueArg))
+		}
+		fmt.Fprintf(w, "\n%s", roff(f.usage))
+		if f.defValue != "" {
+			fmt.Fprintf(w, " (default %s)", roff(fmt.Sprintf("%q", f.defValue)))
+		}
+		fmt.Fprintf(w, "\n")
+	}
+	fmt.Fprintf(w, ".SH COMMANDS\n")
+	for _, c := range commands {
+		fmt.Fprintf(w, ".TP\n.B %s\n%s\n", roff(c.usage), roff(c.summary))
+	}
+}
+
+func writeMarkdown(w io.Writer, flags []cliFlag) {
+	fmt.Fprintf(w, "# %s\n\n%s\n\n%s\n\n", cliName, cliSummary, cliDetails)
+	fmt.Fprintf(w, "## Usage\n\n```\n%s [OPTIONS] [INPUT...]\n", cliName)
+	for _, c := range commands {
+		fmt.Fprintf(w, "%s %s\n", cliName, c.usage)
+	}
+	fmt.Fprintf(w, "```\n\n## Options\n\n")
+	for _, f := range flags {
+		fmt.Fprintf(w, "- `-%s", f.name)
+		if f.valueArg != "" {
+			fmt.Fprintf(w, " %s", f.valueArg)
+		}
+		fmt.Fprintf(w, "`: %s", f.usage)
+		if f.defValue != "" {
+			fmt.Fprintf(w, " (default `%q`)", f.defValue)
+		}
+		fmt.Fprintf(w, "\n")
+	}
+	fmt.Fprintf(w, "\n## Commands\n\n")
+	for _, c := range commands {
+		fmt.Fprintf(w, "- `%s`: %s\n", c.usage, c.summary)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"os"
 	"runtime/debug"
+	"sync"
 
 	"github.com/bingoohuang/hjson"
 )
@@ -25,31 +26,71 @@ func fixJSON(data []byte) []byte {
 	return data
 }
 
+// withFinalNewlines makes data end with exactly n line feeds, like
+// hjson.EncoderOptions.FinalNewlines does for Hjson output.
+func withFinalNewlines(data []byte, n int) []byte {
+	data = bytes.TrimRight(data, "\r\n")
+	for ; n > 0; n-- {
+		data = append(data, '\n')
+	}
+	return data
+}
+
 func main() {
 
 	flag.Usage = func() {
-		fmt.Println("usage: hjson-cli [OPTIONS] [INPUT]")
-		fmt.Println("hjson can be used to convert JSON from/to Hjson.")
+		fmt.Println("usage: hjson-cli [OPTIONS] [INPUT...]")
+		for _, c := range commands {
+			fmt.Println("       hjson-cli " + c.usage)
+		}
+		fmt.Println(cliSummary)
 		fmt.Println("")
-		fmt.Println("hjson will read the given JSON/Hjson input file or read from stdin.")
+		fmt.Println(cliDetails)
 		fmt.Println("")
 		fmt.Println("Options:")
 		flag.PrintDefaults()
+		fmt.Println("")
+		fmt.Println("Commands:")
+		for _, c := range commands {
+			fmt.Printf("  %s\n    \t%s\n", c.name, c.summary)
+		}
 	}
 
 	var help = flag.Bool("h", false, "Show this screen.")
 	var showJSON = flag.Bool("j", false, "Output as formatted JSON.")
 	var showCompact = flag.Bool("c", false, "Output as JSON.")
+	var showProperties = flag.Bool("properties", false, "Output as flat .properties lines.")
+	var showDotenv = flag.Bool("dotenv", false, "Output as flat .env lines.")
 
 	var indentBy = flag.String("indentBy", "  ", "The indent string.")
 	var bracesSameLine = flag.Bool("bracesSameLine", false, "Print braces on the same line.")
 	var omitRootBraces = flag.Bool("omitRootBraces", false, "Omit braces at the root.")
 	var quoteAlways = flag.Bool("quoteAlways", false, "Always quote string values.")
+	var finalNewlines = flag.Int("finalNewlines", 1, "The number of line feeds at the end of the output.")
 	var showVersion = flag.Bool("v", false, "Show version.")
 	var preserveKeyOrder = flag.Bool("preserveKeyOrder", false, "Preserve key order in objects/maps.")
+	var sortKeys = flag.Bool("sortKeys", false, "Sort the keys of all objects/maps, also with -preserveKeyOrder.")
+
+	var write = flag.Bool("w", false, "Write the result to the input file(s) instead of stdout, keeping their comments and formatting.")
+	var dryRun = flag.Bool("dryRun", false, "With -w, only list the files that would be changed.")
+	var parallelism = flag.Int("parallelism", 0, "With -w, the max number of files to process at the same time.")
+
+	var strict = flag.Bool("strict", false, "Fail on lint problems, like mixed tabs and spaces in indentation or duplicate keys.")
+	var fixIndent = flag.Bool("fixIndent", false, "Replace tabs by spaces where tabs and spaces are mixed in indentation.")
+	var tabWidth = flag.Int("tabWidth", 4, "With -fixIndent, the number of columns per tab.")
+
+	var reportFile = flag.String("report", "", "Write a JSON report of lossy transformations (dropped comments, reformatted numbers, reordered keys) to this file.")
+
+	if c := commandArg(os.Args[1:]); c != nil {
+		if err := c.run(os.Stdout, os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
 
 	flag.Parse()
-	if *help || flag.NArg() > 1 {
+	if *help || (flag.NArg() > 1 && !*write) {
 		flag.Usage()
 		os.Exit(1)
 	}
@@ -65,10 +106,152 @@ func main() {
 		os.Exit(0)
 	}
 
+	// The reports of all inputs, by file name ("-" for stdin).
+	var reportsMu sync.Mutex
+	reports := map[string]*hjson.ConvertReport{}
+
+	convert := func(name string, data []byte) ([]byte, []hjson.Diagnostic, error) {
+		if *fixIndent {
+			data = hjson.FixIndentation(data, *tabWidth)
+		}
+		if *strict {
+			if diags := hjson.Lint(data); len(diags) > 0 {
+				return nil, diags, fmt.Errorf("found %d lint problem(s)", len(diags))
+			}
+		}
+
+		if *showProperties || *showDotenv {
+			if *reportFile != "" {
+				return nil, nil, fmt.Errorf("-report cannot be used with -properties or -dotenv")
+			}
+			toFlat := hjson.ToProperties
+			if *showDotenv {
+				toFlat = hjson.ToDotenv
+			}
+			out, err := toFlat(data)
+			return withFinalNewlines(out, *finalNewlines), nil, err
+		}
+
+		var err error
+		var value interface{}
+
+		if *preserveKeyOrder || *write {
+			var node *hjson.Node
+			err = hjson.Unmarshal(data, &node)
+			value = node
+		} else {
+			err = hjson.Unmarshal(data, &value)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var out []byte
+		if *showCompact {
+			out, err = json.Marshal(value)
+			if err != nil {
+				return nil, nil, err
+			}
+			out = withFinalNewlines(fixJSON(out), *finalNewlines)
+		} else if *showJSON {
+			out, err = json.MarshalIndent(value, "", *indentBy)
+			if err != nil {
+				return nil, nil, err
+			}
+			out = withFinalNewlines(fixJSON(out), *finalNewlines)
+		} else {
+			opt := hjson.DefaultOptions()
+			opt.IndentBy = *indentBy
+			opt.BracesSameLine = *bracesSameLine
+			opt.EmitRootBraces = !*omitRootBraces
+			opt.QuoteAlways = *quoteAlways
+			opt.SortKeys = *sortKeys
+			opt.Comments = false
+			if *write {
+				// Files are rewritten in place, so everything that has not
+				// changed is kept as it is, including comments.
+				opt.Comments = true
+				opt.PreserveFormatting = true
+			}
+			opt.FinalNewlines = *finalNewlines
+			if opt.FinalNewlines == 0 {
+				opt.FinalNewlines = -1
+			}
+			out, err = hjson.MarshalWithOptions(value, opt)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		if *reportFile != "" {
+			report, err := hjson.CheckConversion(data, out)
+			if err != nil {
+				return nil, nil, err
+			}
+			reportsMu.Lock()
+			reports[name] = report
+			reportsMu.Unlock()
+		}
+
+		return out, nil, nil
+	}
+
+	writeReport := func() {
+		if *reportFile == "" {
+			return
+		}
+		b, err := json.MarshalIndent(reports, "", "  ")
+		if err == nil {
+			err = ioutil.WriteFile(*reportFile, append(b, '\n'), 0644)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	if *write {
+		if flag.NArg() == 0 {
+			flag.Usage()
+			os.Exit(1)
+		}
+		if *showJSON || *showCompact || *showProperties || *showDotenv || *sortKeys {
+			fmt.Fprintln(os.Stderr, "-w keeps the comments and formatting of the files, "+
+				"it cannot be used with -j, -c, -properties, -dotenv or -sortKeys")
+			os.Exit(1)
+		}
+		res, err := hjson.ProcessFiles(flag.Args(), func(path string, data []byte) ([]byte, []hjson.Diagnostic, error) {
+			return convert(path, data)
+		}, hjson.BatchOptions{
+			Parallelism: *parallelism,
+			DryRun:      *dryRun,
+		})
+		for _, path := range res.Changed() {
+			fmt.Println(path)
+		}
+		for _, diag := range res.Diagnostics() {
+			fmt.Fprintln(os.Stderr, diag)
+		}
+		failed := false
+		for _, f := range res.Files {
+			if f.Err != nil {
+				fmt.Fprintln(os.Stderr, f.Err)
+				failed = true
+			}
+		}
+		writeReport()
+		if err != nil || failed {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	var err error
 	var data []byte
+	name := "-"
 	if flag.NArg() == 1 {
-		data, err = ioutil.ReadFile(flag.Arg(0))
+		name = flag.Arg(0)
+		data, err = ioutil.ReadFile(name)
 	} else {
 		data, err = ioutil.ReadAll(os.Stdin)
 	}
@@ -76,44 +259,18 @@ func main() {
 		panic(err)
 	}
 
-	var value interface{}
-
-	if *preserveKeyOrder {
-		var node *hjson.Node
-		err = hjson.Unmarshal(data, &node)
-		value = node
-	} else {
-		err = hjson.Unmarshal(data, &value)
+	out, diags, err := convert(name, data)
+	for _, diag := range diags {
+		fmt.Fprintln(os.Stderr, diag)
 	}
 	if err != nil {
-		panic(err)
-	}
-
-	var out []byte
-	if *showCompact {
-		out, err = json.Marshal(value)
-		if err != nil {
-			panic(err)
-		}
-		out = fixJSON(out)
-	} else if *showJSON {
-		out, err = json.MarshalIndent(value, "", *indentBy)
-		if err != nil {
-			panic(err)
-		}
-		out = fixJSON(out)
-	} else {
-		opt := hjson.DefaultOptions()
-		opt.IndentBy = *indentBy
-		opt.BracesSameLine = *bracesSameLine
-		opt.EmitRootBraces = !*omitRootBraces
-		opt.QuoteAlways = *quoteAlways
-		opt.Comments = false
-		out, err = hjson.MarshalWithOptions(value, opt)
-		if err != nil {
-			panic(err)
+		if len(diags) > 0 {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
+		panic(err)
 	}
 
-	fmt.Println(string(out))
+	writeReport()
+	os.Stdout.Write(out)
 }
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// mainEnv is set when the test binary is run as the CLI, see TestMain.
+const mainEnv = "HJSON_CLI_RUN_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(mainEnv) == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runCLI runs the CLI with args and returns its stdout and exit code.
+func runCLI(t *testing.T, args ...string) (string, int) {
+	t.Helper()
+	return runCLIIn(t, "", args...)
+}
+
+// runCLIIn runs the CLI like runCLI, in the directory dir.
+func runCLIIn(t *testing.T, dir string, args ...string) (string, int) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), mainEnv+"=1")
+	out, err := cmd.Output()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return string(out), exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return string(out), 0
+}
+
+func writeTemp(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestWriteInPlace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hjson-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Comments, key order and formatting are kept.
+	content := "# important comment\nzeta: 1\nalpha: 2 // note\n"
+	path := writeTemp(t, dir, "kept.hjson", content)
+	if out, code := runCLI(t, "-w", path); code != 0 || out != "" {
+		t.Errorf("Unexpected output %q, exit code %d", out, code)
+	}
+	if got := readFile(t, path); got != content {
+		t.Errorf("Expected the file to be unchanged, got %q", got)
+	}
+
+	// The final newline is fixed, everything else is kept.
+	path = writeTemp(t, dir, "newline.hjson", "{\n  # comment\n  b: 1\n  a: 2\n}")
+	if out, code := runCLI(t, "-w", path); code != 0 || out != path+"\n" {
+		t.Errorf("Unexpected output %q, exit code %d", out, code)
+	}
+	if got := readFile(t, path); got != "{\n  # comment\n  b: 1\n  a: 2\n}\n" {
+		t.Errorf("Unexpected content %q", got)
+	}
+
+	// With -dryRun the file is listed but not written.
+	content = "a: 1 # comment"
+	path = writeTemp(t, dir, "dry.hjson", content)
+	if out, code := runCLI(t, "-w", "-dryRun", path); code != 0 || out != path+"\n" {
+		t.Errorf("Unexpected output %q, exit code %d", out, code)
+	}
+	if got := readFile(t, path); got != content {
+		t.Errorf("Expected the file to be unchanged, got %q", got)
+	}
+
+	// Output formats that cannot keep comments are refused.
+	for _, flag := range []string{"-j", "-c", "-properties", "-dotenv", "-sortKeys"} {
+		if _, code := runCLI(t, "-w", flag, path); code != 1 {
+			t.Errorf("%s: expected exit code 1, got %d", flag, code)
+		}
+		if got := readFile(t, path); got != content {
+			t.Errorf("%s: expected the file to be unchanged, got %q", flag, got)
+		}
+	}
+
+	// Errors are reported and the file is not written.
+	content = "{\n  a: 1\n"
+	path = writeTemp(t, dir, "invalid.hjson", content)
+	if _, code := runCLI(t, "-w", path); code != 1 {
+		t.Errorf("Expected exit code 1, got %d", code)
+	}
+	if got := readFile(t, path); got != content {
+		t.Errorf("Expected the file to be unchanged, got %q", got)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	for shell, want := range map[string][]string{
+		"bash":       {"complete -o filenames -F _hjson_cli hjson-cli", "compgen -W \"man markdown\"", "-preserveKeyOrder"},
+		"zsh":        {"#compdef hjson-cli", "_values 'docs' man markdown", "'-indentBy[The indent string.]:string: '"},
+		"fish":       {"# fish completion for hjson-cli", "-a 'bash zsh fish powershell'", "complete -c hjson-cli -o tabWidth -r"},
+		"powershell": {"-CommandName 'hjson-cli'", "@('-w', 'ParameterName'", "$candidates += ,@('docs', 'Command'"},
+	} {
+		out, code := runCLI(t, "completion", shell)
+		if code != 0 {
+			t.Errorf("%s: expected exit code 0, got %d", shell, code)
+		}
+		for _, s := range want {
+			if !strings.Contains(out, s) {
+				t.Errorf("%s: expected %q in the output:\n%s", shell, s, out)
+			}
+		}
+	}
+
+	for _, args := range [][]string{{"completion"}, {"completion", "tcsh"}} {
+		if out, code := runCLI(t, args...); code != 1 || out != "" {
+			t.Errorf("%v: unexpected output %q, exit code %d", args, out, code)
+		}
+	}
+}
+
+func TestDocs(t *testing.T) {
+	man, code := runCLI(t, "docs")
+	if code != 0 || !strings.HasPrefix(man, ".TH HJSON-CLI 1\n") {
+		t.Errorf("Unexpected man page, exit code %d:\n%s", code, man)
+	}
+	for _, s := range []string{".B \\-preserveKeyOrder\n", ".B \\-indentBy \\fIstring\\fR\n", ".B docs [man|markdown]\n"} {
+		if !strings.Contains(man, s) {
+			t.Errorf("Expected %q in the man page:\n%s", s, man)
+		}
+	}
+	if out, _ := runCLI(t, "docs", "man"); out != man {
+		t.Errorf("Expected the man page, got:\n%s", out)
+	}
+
+	md, code := runCLI(t, "docs", "markdown")
+	if code != 0 || !strings.HasPrefix(md, "# hjson-cli\n") {
+		t.Errorf("Unexpected Markdown, exit code %d:\n%s", code, md)
+	}
+	for _, s := range []string{"- `-indentBy string`: The indent string. (default `\"  \"`)\n", "- `completion bash|zsh|fish|powershell`: "} {
+		if !strings.Contains(md, s) {
+			t.Errorf("Expected %q in the Markdown:\n%s", s, md)
+		}
+	}
+
+	if out, code := runCLI(t, "docs", "html"); code != 1 || out != "" {
+		t.Errorf("Unexpected output %q, exit code %d", out, code)
+	}
+}
+
+func TestCommandNamedFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hjson-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A file named like a command is converted.
+	writeTemp(t, dir, "docs", "a: 1")
+	for _, args := range [][]string{{"docs"}, {"--", "docs"}, {"-j", "docs"}} {
+		want := "{\n  a: 1\n}\n"
+		if args[0] == "-j" {
+			want = "{\n  \"a\": 1\n}\n"
+		}
+		if out, code := runCLIIn(t, dir, args...); code != 0 || out != want {
+			t.Errorf("%v: unexpected output %q, exit code %d", args, out, code)
+		}
+	}
+
+	// Without such a file the command is run.
+	if out, code := runCLIIn(t, dir, "completion", "bash"); code != 0 || !strings.HasPrefix(out, "# bash completion") {
+		t.Errorf("Unexpected output %q, exit code %d", out, code)
+	}
+}
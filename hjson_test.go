@@ -11,6 +11,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"runtime/debug"
 	"strings"
 	"testing"
 )
@@ -1691,6 +1692,47 @@ FieLd: 3
 	}
 }
 
+func TestCaseSensitive(t *testing.T) {
+	type tsA struct {
+		UserID string
+		Name   string
+		Extra  map[string]interface{} `hjson:",remain"`
+	}
+	text := []byte("{\n  UserID: a\n  userid: b\n  name: c\n}")
+
+	var sA tsA
+	if err := Unmarshal(text, &sA); err != nil {
+		t.Fatal(err)
+	} else if sA.UserID != "b" || sA.Name != "c" {
+		t.Errorf("Unexpected struct values:\n%#v\n", sA)
+	}
+
+	options := DefaultDecoderOptions()
+	options.CaseSensitive = true
+	sA = tsA{}
+	if err := UnmarshalWithOptions(text, &sA, options); err != nil {
+		t.Fatal(err)
+	}
+	expected := tsA{UserID: "a", Extra: map[string]interface{}{"userid": "b", "name": "c"}}
+	if !reflect.DeepEqual(sA, expected) {
+		t.Errorf("Expected:\n%#v\nGot:\n%#v", expected, sA)
+	}
+
+	type tsB struct {
+		UserID string
+	}
+	var sB tsB
+	text = []byte("{\n  UserID: a\n  userid: b\n}")
+	if err := UnmarshalWithOptions(text, &sB, options); err != nil || sB.UserID != "a" {
+		t.Errorf("Unexpected result: %#v, %v", sB, err)
+	}
+	options.DisallowUnknownFields = true
+	err := UnmarshalWithOptions(text, &sB, options)
+	if pe, ok := err.(*ParseError); !ok || pe.Kind != MsgUnknownField || pe.Line != 3 {
+		t.Errorf("Unexpected error: %#v", err)
+	}
+}
+
 type itsJ struct {
 	anon string
 }
@@ -1793,3 +1835,151 @@ j: null, k: "another text", l: null
 		t.Error("Should have failed, should not be possible to call pointer method UnmarshalText() on the map elements because they are not addressable.")
 	}
 }
+
+func TestHardenedDecoderOptions(t *testing.T) {
+	opt := HardenedDecoderOptions()
+
+	var v interface{}
+	if err := UnmarshalWithOptions([]byte("a: 1\nb: [1, 2]"), &v, opt); err != nil {
+		t.Error(err)
+	}
+
+	deep := strings.Repeat("[", hardenedMaxDepth+2) + strings.Repeat("]", hardenedMaxDepth+2)
+	if err := Unmarshal([]byte(deep), &v); err != nil {
+		t.Error(err)
+	}
+	if err, ok := UnmarshalWithOptions([]byte(deep), &v, opt).(*ParseError); !ok || err.Kind != MsgMaxDepth {
+		t.Errorf("Should have failed because of the max depth, got %v", err)
+	}
+
+	if err := UnmarshalWithOptions([]byte("a: 1\na: 2"), &v, opt); err == nil {
+		t.Error("Should have failed because of duplicate keys")
+	}
+
+	var node Node
+	if err := Unmarshal([]byte("{a: 1e400\n}"), &node); err != nil {
+		t.Error(err)
+	} else if val, _, _ := node.AtKey("a"); val != "1e400" {
+		t.Errorf("Unexpected value: %#v", val)
+	}
+	if err := UnmarshalWithOptions([]byte("{a: 1e400\n}"), &node, opt); err == nil {
+		t.Error("Should have failed because of number out of range")
+	}
+	if err := UnmarshalWithOptions([]byte("{a: 1e400\n}"), &v, opt); err == nil {
+		t.Error("Should have failed because of number out of range")
+	}
+
+	// Each file includes the next one.
+	files := map[string][]byte{}
+	for i := 0; i <= hardenedMaxDepth+1; i++ {
+		files[fmt.Sprintf("f%d.hjson", i)] = []byte(fmt.Sprintf("{\"$include\": \"f%d.hjson\", f%d: 1}", i+1, i))
+	}
+	opt.Include = func(name string) ([]byte, error) {
+		if data, ok := files[name]; ok {
+			return data, nil
+		}
+		return []byte("{}"), nil
+	}
+	err := UnmarshalWithOptions(files["f0.hjson"], &v, opt)
+	if err, ok := err.(*LimitError); !ok || err.Limit != "MaxDepth" {
+		t.Errorf("Should have failed because of nested includes, got %v", err)
+	}
+	opt.Include = nil
+
+	// Each value refers to the next one, or twice to the previous one.
+	var chain, doubling strings.Builder
+	doubling.WriteString("r0: {x: 1}\n")
+	for i := 1; i <= hardenedMaxDepth+1; i++ {
+		fmt.Fprintf(&chain, "r%d: {$ref: \"#/r%d\"}\n", i, i+1)
+		if i <= 32 {
+			fmt.Fprintf(&doubling, "r%d: {a: {$ref: \"#/r%d\"}, b: {$ref: \"#/r%d\"}}\n", i, i-1, i-1)
+		}
+	}
+	fmt.Fprintf(&chain, "r%d: {x: 1}\n", hardenedMaxDepth+2)
+	opt.ResolveRefs = true
+	err = UnmarshalWithOptions([]byte(chain.String()), &v, opt)
+	if err, ok := err.(*LimitError); !ok || err.Limit != "MaxDepth" {
+		t.Errorf("Should have failed because of nested references, got %v", err)
+	}
+	err = UnmarshalWithOptions([]byte(doubling.String()), &v, opt)
+	if err, ok := err.(*LimitError); !ok || err.Limit != "MaxBytes" && err.Limit != "AllocBudget" {
+		t.Errorf("Should have failed because of the size of the resolved references, got %v", err)
+	}
+}
+
+func TestDeepNesting(t *testing.T) {
+	// Deeply nested input must not be read recursively, which would exceed
+	// this max stack size.
+	defer debug.SetMaxStack(debug.SetMaxStack(1 << 20))
+
+	const depth = 100000
+	data := "a: " + strings.Repeat("[", depth) + strings.Repeat("]", depth)
+	var om OrderedMap
+	if err := UnmarshalWithOptions([]byte(data), &om, DecoderOptions{MaxDepth: depth + 1}); err != nil {
+		t.Fatal(err)
+	}
+	levels := 0
+	for val := om.Map["a"]; ; levels++ {
+		arr, ok := val.([]interface{})
+		if !ok || len(arr) == 0 {
+			break
+		}
+		val = arr[0]
+	}
+	if levels != depth-1 {
+		t.Errorf("Unexpected depth: %d", levels)
+	}
+}
+
+func TestUnmarshalStrict(t *testing.T) {
+	type strictConfig struct {
+		Name    string
+		Port    int
+		Tags    []string
+		Timeout *int
+		Extra   interface{}
+	}
+
+	var c strictConfig
+	err := UnmarshalStrict([]byte(`{
+  Name: x
+  Port: 80
+  Tags: null
+  Timeout: null
+  Extra: null
+}`), &c)
+	if err != nil {
+		t.Error(err)
+	} else if c.Name != "x" || c.Port != 80 {
+		t.Errorf("Unexpected value: %#v", c)
+	}
+
+	if err = UnmarshalStrict([]byte("{\n  Name: x\n  Nmae: y\n}"), &c); err == nil {
+		t.Error("Should have failed because of an unknown field")
+	}
+	if err = UnmarshalStrict([]byte("{\n  Name: x\n  Name: y\n}"), &c); err == nil {
+		t.Error("Should have failed because of duplicate keys")
+	}
+
+	err = UnmarshalStrict([]byte("{\n  Port: null\n}"), &c)
+	if pe, ok := err.(*ParseError); !ok || pe.Kind != MsgNullIntoNonPointer ||
+		pe.Line != 2 || pe.Column != 9 {
+		t.Errorf("Unexpected error: %#v", err)
+	}
+	if err = Unmarshal([]byte("{\n  Port: null\n}"), &c); err != nil {
+		t.Error(err)
+	}
+
+	var n int
+	if err = UnmarshalStrict([]byte("null"), &n); err == nil {
+		t.Error("Should have failed because of null for an int")
+	}
+	var s string
+	if err = UnmarshalStrict([]byte("null"), &s); err != nil || s != "null" {
+		t.Errorf("Unexpected result: %q, %v", s, err)
+	}
+	var list []int
+	if err = UnmarshalStrict([]byte("[1, null]"), &list); err == nil {
+		t.Error("Should have failed because of null for an array element")
+	}
+}
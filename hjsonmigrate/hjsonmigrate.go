@@ -0,0 +1,230 @@
+// Package hjsonmigrate upgrades Hjson config files from one version of an
+// application's config schema to the next.
+//
+// Every migration step is a function that changes a tree of hjson.Node from
+// version N to version N+1, typically by renaming keys, moving sections or
+// changing the format of values. Because the steps work on the Node tree,
+// the comments of the config file are kept:
+//
+//	hjsonmigrate.Register(1, func(root *hjson.Node) error {
+//	  return hjsonmigrate.Rename(root, "server.addr", "address")
+//	})
+//	hjsonmigrate.Register(2, func(root *hjson.Node) error {
+//	  return hjsonmigrate.Move(root, "server.timeout", "limits.timeout")
+//	})
+//
+//	root, err := hjson.ParseNode(data)
+//	...
+//	err = hjsonmigrate.Migrate(root, 1, 3)
+//	...
+//	data, err = hjson.Marshal(root)
+//
+// Paths passed to the helper functions are dot-separated lists of object
+// keys, like "server.addr".
+package hjsonmigrate
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/bingoohuang/hjson"
+)
+
+// Func is a migration step, changing root from version N to version N+1.
+type Func func(root *hjson.Node) error
+
+// Migrations holds the migration steps of a config schema. The zero value is
+// ready to use. It is safe for concurrent use.
+type Migrations struct {
+	mu    sync.RWMutex
+	steps map[int]Func
+}
+
+// defaultMigrations holds the steps used by Register() and Migrate().
+var defaultMigrations Migrations
+
+// Register adds fn as the step that migrates a document from version from to
+// version from+1. Panics if fn is nil or if a step from that version is
+// already registered.
+func (m *Migrations) Register(from int, fn Func) {
+	if fn == nil {
+		panic("hjsonmigrate: Register of nil migration")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, dup := m.steps[from]; dup {
+		panic(fmt.Sprintf("hjsonmigrate: Register called twice for version %d", from))
+	}
+	if m.steps == nil {
+		m.steps = map[int]Func{}
+	}
+	m.steps[from] = fn
+}
+
+// Migrate applies the steps from version fromVer up to version toVer to doc,
+// in order. Does nothing if fromVer == toVer. An error is returned if toVer is
+// lower than fromVer, if a step is missing or if a step returns an error, in
+// which case doc may have been changed by the steps that were applied.
+func (m *Migrations) Migrate(doc *hjson.Node, fromVer, toVer int) error {
+	if doc == nil {
+		return fmt.Errorf("hjsonmigrate: Node is nil")
+	}
+	if toVer < fromVer {
+		return fmt.Errorf("hjsonmigrate: cannot migrate from version %d down to version %d",
+			fromVer, toVer)
+	}
+	m.mu.RLock()
+	steps := make([]Func, 0, toVer-fromVer)
+	for v := fromVer; v < toVer; v++ {
+		fn, ok := m.steps[v]
+		if !ok {
+			m.mu.RUnlock()
+			return fmt.Errorf("hjsonmigrate: no migration from version %d to version %d", v, v+1)
+		}
+		steps = append(steps, fn)
+	}
+	m.mu.RUnlock()
+
+	for i, fn := range steps {
+		v := fromVer + i
+		if err := fn(doc); err != nil {
+			return fmt.Errorf("hjsonmigrate: migrating from version %d to version %d: %v",
+				v, v+1, err)
+		}
+	}
+	return nil
+}
+
+// Register adds fn to the default Migrations, see Migrations.Register().
+func Register(from int, fn Func) {
+	defaultMigrations.Register(from, fn)
+}
+
+// Migrate applies the steps of the default Migrations to doc, see
+// Migrations.Migrate().
+func Migrate(doc *hjson.Node, fromVer, toVer int) error {
+	return defaultMigrations.Migrate(doc, fromVer, toVer)
+}
+
+// Rename changes the key of the object member found at path to newKey,
+// keeping its position, its value and its comments. Does nothing if path is
+// not found. An error is returned if the object already has a member named
+// newKey.
+func Rename(root *hjson.Node, path, newKey string) error {
+	om, key, err := parent(root, path, false)
+	if err != nil || om == nil {
+		return err
+	}
+	elem, ok := om.Map[key]
+	if !ok || key == newKey {
+		return nil
+	}
+	if _, ok := om.Map[newKey]; ok {
+		return fmt.Errorf("cannot rename %q: the key %q already exists", path, newKey)
+	}
+	for i, k := range om.Keys {
+		if k == key {
+			om.Keys[i] = newKey
+			break
+		}
+	}
+	delete(om.Map, key)
+	om.Map[newKey] = elem
+	return nil
+}
+
+// Move removes the object member found at path from and adds it with its
+// comments as the last member of the object at path to, using the last key
+// of to. The objects leading to to are created if needed. Does nothing if from
+// is not found. An error is returned if to already exists.
+func Move(root *hjson.Node, from, to string) error {
+	src, srcKey, err := parent(root, from, false)
+	if err != nil || src == nil {
+		return err
+	}
+	elem, ok := src.Map[srcKey]
+	if !ok || from == to {
+		return nil
+	}
+	dst, dstKey, err := parent(root, to, true)
+	if err != nil {
+		return err
+	}
+	if _, ok := dst.Map[dstKey]; ok {
+		return fmt.Errorf("cannot move %q: %q already exists", from, to)
+	}
+	src.DeleteKey(srcKey)
+	dst.Set(dstKey, elem)
+	return nil
+}
+
+// Delete removes the object member found at path, including its comments.
+// Does nothing if path is not found.
+func Delete(root *hjson.Node, path string) error {
+	om, key, err := parent(root, path, false)
+	if err != nil || om == nil {
+		return err
+	}
+	om.DeleteKey(key)
+	return nil
+}
+
+// Convert replaces the value found at path by the result of fn, keeping the
+// comments of the value. fn gets the value like Node.Value, so objects and
+// arrays are passed as *hjson.OrderedMap and []interface{}. Does nothing if
+// path is not found.
+func Convert(root *hjson.Node, path string, fn func(value interface{}) (interface{}, error)) error {
+	om, key, err := parent(root, path, false)
+	if err != nil || om == nil {
+		return err
+	}
+	node, ok := om.Map[key].(*hjson.Node)
+	if !ok {
+		return nil
+	}
+	value, err := fn(node.Value)
+	if err != nil {
+		return fmt.Errorf("cannot convert %q: %v", path, err)
+	}
+	node.Value = value
+	return nil
+}
+
+// parent returns the object containing the member at path, and the last key
+// of path. If create is true, missing objects are created. Otherwise nil is
+// returned if an object on the way is missing or is not an object.
+func parent(root *hjson.Node, path string, create bool) (*hjson.OrderedMap, string, error) {
+	if root == nil {
+		return nil, "", fmt.Errorf("Node is nil")
+	}
+	keys := strings.Split(path, ".")
+	node := root
+	for i, key := range keys[:len(keys)-1] {
+		next := node.NK(key)
+		if next == nil && create {
+			next = node.NKC(key)
+		}
+		if next == nil {
+			if create {
+				return nil, "", fmt.Errorf("%q is not an object",
+					strings.Join(keys[:i], "."))
+			}
+			return nil, "", nil
+		}
+		node = next
+	}
+	if node.Value == nil && create {
+		node.Value = hjson.NewOrderedMap()
+	}
+	om, ok := node.Value.(*hjson.OrderedMap)
+	if !ok {
+		if !create {
+			return nil, "", nil
+		}
+		return nil, "", fmt.Errorf("%q is not an object, but %v",
+			strings.Join(keys[:len(keys)-1], "."), reflect.TypeOf(node.Value))
+	}
+	return om, keys[len(keys)-1], nil
+}
@@ -0,0 +1,105 @@
+package hjsonmigrate
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bingoohuang/hjson"
+)
+
+func TestMigrate(t *testing.T) {
+	var m Migrations
+	m.Register(1, func(root *hjson.Node) error {
+		return Rename(root, "server.addr", "address")
+	})
+	m.Register(2, func(root *hjson.Node) error {
+		if err := Move(root, "server.timeout", "limits.timeout"); err != nil {
+			return err
+		}
+		return Convert(root, "limits.timeout", func(v interface{}) (interface{}, error) {
+			return fmt.Sprintf("%vs", v), nil
+		})
+	})
+	m.Register(3, func(root *hjson.Node) error {
+		return Delete(root, "legacy")
+	})
+
+	root, err := hjson.ParseNode([]byte(`{
+  # The server.
+  server: {
+    # Where to listen.
+    addr: localhost
+    timeout: 30 # Seconds.
+  }
+  legacy: true
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = m.Migrate(root, 1, 4); err != nil {
+		t.Fatal(err)
+	}
+	out, err := hjson.Marshal(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# Where to listen.\n    address: localhost",
+		`timeout: "30s" # Seconds.`,
+		"# The server.",
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("Missing %q in:\n%s", want, out)
+		}
+	}
+	if root.NK("legacy") != nil || root.NK("server").NK("timeout") != nil {
+		t.Errorf("Unexpected result:\n%s", out)
+	}
+	if root.NK("limits").NK("timeout") == nil {
+		t.Errorf("Missing limits.timeout in:\n%s", out)
+	}
+}
+
+func TestMigrateErrors(t *testing.T) {
+	var m Migrations
+	m.Register(1, func(root *hjson.Node) error {
+		return Rename(root, "a", "b")
+	})
+	root, err := hjson.ParseNode([]byte("{\n  a: 1\n  b: 2\n}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = m.Migrate(root, 1, 2)
+	if err == nil || !strings.Contains(err.Error(), `"b" already exists`) {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	err = m.Migrate(root, 2, 3)
+	if err == nil || !strings.Contains(err.Error(), "no migration from version 2") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err = m.Migrate(root, 2, 1); err == nil {
+		t.Error("Expected an error for a downgrade")
+	}
+	if err = m.Migrate(root, 5, 5); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err = Move(root, "a", "b.c"); err == nil {
+		t.Error("Expected an error for moving into a number")
+	}
+	if err = Rename(root, "x.y", "z"); err != nil {
+		t.Errorf("Unexpected error for a missing path: %v", err)
+	}
+}
+
+func TestRegisterTwice(t *testing.T) {
+	var m Migrations
+	fn := func(root *hjson.Node) error { return nil }
+	m.Register(1, fn)
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic")
+		}
+	}()
+	m.Register(1, fn)
+}
@@ -0,0 +1,132 @@
+package hjson
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// The options of the hjson struct tag that flatten the fields of a struct
+// field into the parent object, like the embedded structs of encoding/json.
+const (
+	tagInline = "inline"
+	tagSquash = "squash"
+)
+
+// isInline returns true if the options of an hjson struct tag include
+// "inline" or "squash".
+func isInline(opts []string) bool {
+	for _, opt := range opts {
+		if opt == tagInline || opt == tagSquash {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonOnlyNamesCache holds the result of jsonOnlyNames() for each struct
+// type.
+var jsonOnlyNamesCache sync.Map
+
+// jsonOnlyNames returns the lowercase names that json.Unmarshal() uses for
+// fields of the struct type t, including embedded structs, that are named
+// differently, ignored or inlined by the hjson tag.
+func jsonOnlyNames(t reflect.Type) map[string]bool {
+	if cached, ok := jsonOnlyNamesCache.Load(t); ok {
+		return cached.(map[string]bool)
+	}
+	names := map[string]bool{}
+	visited := map[reflect.Type]bool{}
+	var add func(t reflect.Type)
+	add = func(t reflect.Type) {
+		if visited[t] {
+			return
+		}
+		visited[t] = true
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			jsonName := strings.Split(sf.Tag.Get("json"), ",")[0]
+			hjsonTag := strings.Split(sf.Tag.Get("hjson"), ",")
+			hjsonName := hjsonTag[0]
+			if isInline(hjsonTag[1:]) && !(sf.Anonymous && jsonName == "") {
+				// json.Unmarshal() sees the field itself.
+				hjsonName = "-"
+			}
+			if sf.Anonymous && jsonName == "" && hjsonName == "" {
+				if ft := sf.Type; ft.Kind() == reflect.Struct {
+					add(ft)
+				} else if ft.Kind() == reflect.Ptr && ft.Elem().Kind() == reflect.Struct {
+					add(ft.Elem())
+				}
+				continue
+			}
+			if hjsonName == "" || jsonName == "-" || sf.PkgPath != "" {
+				continue
+			}
+			if jsonName == "" {
+				jsonName = sf.Name
+			}
+			if jsonName != hjsonName {
+				names[strings.ToLower(jsonName)] = true
+			}
+		}
+	}
+	add(t)
+	jsonOnlyNamesCache.Store(t, names)
+	return names
+}
+
+// shadowedByJSON returns true if json.Unmarshal() would assign the member key,
+// which does not match any field of the struct type t, to a field that is
+// named differently or ignored by the hjson tag, so that key must not be
+// passed on to json.Unmarshal().
+func shadowedByJSON(t reflect.Type, key string) bool {
+	return jsonOnlyNames(t)[strings.ToLower(key)]
+}
+
+// setMember adds val as the value of the struct field sfi of structType to
+// object, using the name that json.Unmarshal() expects for the field. If
+// json.Unmarshal() ignores the field, val is instead assigned after it, see
+// fieldFixup().
+func (p *hjsonParser) setMember(
+	object *OrderedMap,
+	sfi structFieldInfo,
+	structType reflect.Type,
+	val interface{},
+	fixupAt int,
+) error {
+	if sfi.jsonName != "" {
+		object.Set(sfi.jsonName, val)
+		return nil
+	}
+	return p.fieldFixup(sfi.name, fieldType(structType, sfi.indexPath), val, fixupAt)
+}
+
+// fieldFixup converts val to the type t of the struct field name, in the
+// object found at p.path, for a field that is named by the hjson tag but
+// ignored by json.Unmarshal() because of the json tag "-". The result is
+// assigned after json.Unmarshal(), before the fixups found inside val, which
+// start at the index fixupAt. A nil val, which can also be a placeholder for
+// such a fixup, leaves the field unchanged.
+func (p *hjsonParser) fieldFixup(name string, t reflect.Type, val interface{}, fixupAt int) error {
+	if val == nil {
+		return nil
+	}
+	data, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+	_, ut := unravelDestination(reflect.Value{}, t)
+	ptr := reflect.New(ut)
+	if err = json.Unmarshal(data, ptr.Interface()); err != nil {
+		return err
+	}
+	p.fixups = append(p.fixups, destFixup{})
+	copy(p.fixups[fixupAt+1:], p.fixups[fixupAt:])
+	p.fixups[fixupAt] = destFixup{
+		path:  append(append([]interface{}(nil), p.path...), name),
+		value: ptr.Elem().Interface(),
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package hjson
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHjsonTag(t *testing.T) {
+	type config struct {
+		ID       string        `json:"id,omitempty" hjson:"identifier"`
+		Secret   string        `json:"secret" hjson:"-"`
+		Internal int           `json:"-" hjson:"internal,omitempty"`
+		Timeout  time.Duration `json:"-" hjson:"timeout"`
+		Tags     []string      `json:"-" hjson:"tags"`
+		Name     string        `json:"name" hjson:",omitempty"`
+		Plain    int
+	}
+
+	c := config{Secret: "s", Internal: 3, Timeout: time.Second, Tags: []string{"a"}, Plain: 1}
+	out, err := Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  identifier: ""
+  internal: 3
+  timeout: 1s
+  tags: [
+    a
+  ]
+  Plain: 1
+}`
+	if string(out) != want {
+		t.Errorf("Expected:\n%s\nGot:\n%s", want, out)
+	}
+
+	var c2 config
+	err = Unmarshal([]byte(`{
+  identifier: x
+  secret: s
+  internal: 4
+  timeout: 2m
+  tags: [
+    b
+    c
+  ]
+  name: n
+  Plain: 2
+}`), &c2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := config{ID: "x", Internal: 4, Timeout: 2 * time.Minute,
+		Tags: []string{"b", "c"}, Name: "n", Plain: 2}
+	if !reflect.DeepEqual(c2, expected) {
+		t.Errorf("Expected:\n%#v\nGot:\n%#v", expected, c2)
+	}
+
+	// The JSON names are not used for Hjson.
+	c2 = config{}
+	options := DefaultDecoderOptions()
+	options.DisallowUnknownFields = true
+	err = UnmarshalWithOptions([]byte("{\n  id: x\n}"), &c2, options)
+	if pe, ok := err.(*ParseError); !ok || pe.Kind != MsgUnknownField {
+		t.Errorf("Unexpected error: %#v", err)
+	}
+	if err = Unmarshal([]byte("{\n  id: x\n}"), &c2); err != nil || c2.ID != "" {
+		t.Errorf("Unexpected result: %#v, %v", c2, err)
+	}
+}
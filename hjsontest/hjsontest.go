@@ -0,0 +1,159 @@
+// Package hjsontest provides helpers for tests that compare values with
+// golden files written in Hjson.
+//
+// Golden files are compared semantically: comments, whitespace, the quoting
+// of strings, the formatting of numbers and the order of object keys do not
+// matter, so that golden files can be edited and commented by hand. Run the
+// tests with the environment variable HJSON_UPDATE_GOLDEN=1 to write the
+// current values to the golden files instead of comparing them.
+package hjsontest
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bingoohuang/hjson"
+)
+
+// UpdateEnv is the name of the environment variable that causes
+// AssertMatchesGolden() to update the golden files, if set to anything else
+// than "" or "0".
+const UpdateEnv = "HJSON_UPDATE_GOLDEN"
+
+// AssertMatchesGolden reports an error through t if got does not match the
+// Hjson document in the file goldenPath, listing every difference. got is
+// encoded with hjson.Marshal(), except that a []byte or a string is used as
+// Hjson text. A missing golden file is reported as an error.
+//
+// If the environment variable HJSON_UPDATE_GOLDEN is set, see UpdateEnv, got
+// is written to the golden file instead. A string or []byte is then written
+// as it is, so its comments are kept.
+func AssertMatchesGolden(t testing.TB, got interface{}, goldenPath string) {
+	t.Helper()
+
+	text, isText := got.([]byte)
+	if s, ok := got.(string); ok {
+		text, isText = []byte(s), true
+	}
+	if !isText {
+		var err error
+		if text, err = hjson.Marshal(got); err != nil {
+			t.Fatalf("hjsontest: cannot encode the value for %s: %v", goldenPath, err)
+			return
+		}
+	}
+
+	if update := os.Getenv(UpdateEnv); update != "" && update != "0" {
+		v := got
+		if isText {
+			v = hjson.PreformattedValue(text)
+		}
+		if err := hjson.WriteFileAtomic(goldenPath, v, 0644); err != nil {
+			t.Fatalf("hjsontest: cannot update %s: %v", goldenPath, err)
+		}
+		return
+	}
+
+	golden, err := ioutil.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("hjsontest: %v (run the test with %s=1 to create the golden file)",
+			err, UpdateEnv)
+		return
+	}
+	diffs, err := Diff(golden, text)
+	if err != nil {
+		t.Fatalf("hjsontest: %s: %v", goldenPath, err)
+		return
+	}
+	if len(diffs) > 0 {
+		lines := make([]string, len(diffs))
+		for i, d := range diffs {
+			lines[i] = "  " + d.String()
+		}
+		t.Errorf("hjsontest: the value does not match %s (run the test with %s=1 to update it):\n%s",
+			goldenPath, UpdateEnv, strings.Join(lines, "\n"))
+	}
+}
+
+// Diff decodes the Hjson documents want and got and returns the semantic
+// differences between them, in the order of want (with object keys sorted),
+// followed by the values only found in got. Before is the value in want and
+// After the value in got. An empty slice is returned if the documents
+// represent the same value.
+func Diff(want, got []byte) ([]hjson.RoundTripDiff, error) {
+	var w, g interface{}
+	if err := hjson.Unmarshal(want, &w); err != nil {
+		return nil, err
+	}
+	if err := hjson.Unmarshal(got, &g); err != nil {
+		return nil, err
+	}
+	diffs := []hjson.RoundTripDiff{}
+	diff(&diffs, nil, w, g)
+	return diffs, nil
+}
+
+func diff(diffs *[]hjson.RoundTripDiff, path []string, w, g interface{}) {
+	child := func(key string) []string {
+		return append(path[:len(path):len(path)], key)
+	}
+
+	switch wv := w.(type) {
+	case map[string]interface{}:
+		gv, ok := g.(map[string]interface{})
+		if !ok {
+			break
+		}
+		for _, key := range sortedKeys(wv) {
+			if gval, ok := gv[key]; ok {
+				diff(diffs, child(key), wv[key], gval)
+			} else {
+				*diffs = append(*diffs, hjson.RoundTripDiff{Path: strings.Join(child(key), "."),
+					Before: wv[key], Missing: true})
+			}
+		}
+		for _, key := range sortedKeys(gv) {
+			if _, ok := wv[key]; !ok {
+				*diffs = append(*diffs, hjson.RoundTripDiff{Path: strings.Join(child(key), "."),
+					After: gv[key], Added: true})
+			}
+		}
+		return
+	case []interface{}:
+		gv, ok := g.([]interface{})
+		if !ok {
+			break
+		}
+		for i := 0; i < len(wv) || i < len(gv); i++ {
+			p := strings.Join(child(strconv.Itoa(i)), ".")
+			switch {
+			case i >= len(gv):
+				*diffs = append(*diffs, hjson.RoundTripDiff{Path: p, Before: wv[i], Missing: true})
+			case i >= len(wv):
+				*diffs = append(*diffs, hjson.RoundTripDiff{Path: p, After: gv[i], Added: true})
+			default:
+				diff(diffs, child(strconv.Itoa(i)), wv[i], gv[i])
+			}
+		}
+		return
+	default:
+		if reflect.DeepEqual(w, g) {
+			return
+		}
+	}
+	*diffs = append(*diffs, hjson.RoundTripDiff{Path: strings.Join(path, "."), Before: w, After: g})
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,115 @@
+package hjsontest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// recorder records the messages of a test, instead of failing it.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+}
+
+type db struct {
+	Host string `json:"host"`
+	User string `json:"user"`
+}
+
+type config struct {
+	Name  string `json:"name"`
+	Ports []int  `json:"ports"`
+	DB    db     `json:"db"`
+}
+
+func TestAssertMatchesGolden(t *testing.T) {
+	cfg := config{Name: "web", Ports: []int{80, 443}, DB: db{Host: "localhost", User: "admin"}}
+	AssertMatchesGolden(t, cfg, "testdata/config.hjson")
+	AssertMatchesGolden(t, "db: {\nhost: localhost\nuser: admin\n}\nports: [80, 443]\nname: web", "testdata/config.hjson")
+
+	r := &recorder{TB: t}
+	cfg.Ports = []int{80}
+	cfg.DB.User = "root"
+	cfg.Name = "api"
+	AssertMatchesGolden(r, cfg, "testdata/config.hjson")
+	if len(r.errors) != 1 {
+		t.Fatalf("Expected one error, got %v", r.errors)
+	}
+	for _, s := range []string{
+		"db.user: value changed from \"admin\" to \"root\"",
+		"name: value changed from \"web\" to \"api\"",
+		"ports.1: value 443 is missing",
+		UpdateEnv,
+	} {
+		if !strings.Contains(r.errors[0], s) {
+			t.Errorf("Expected %q in the error:\n%s", s, r.errors[0])
+		}
+	}
+
+	r = &recorder{TB: t}
+	AssertMatchesGolden(r, cfg, "testdata/missing.hjson")
+	if len(r.errors) != 1 || !strings.Contains(r.errors[0], UpdateEnv) {
+		t.Errorf("Unexpected errors: %v", r.errors)
+	}
+}
+
+func TestAssertMatchesGoldenUpdate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hjsontest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Unsetenv(UpdateEnv)
+	os.Setenv(UpdateEnv, "1")
+
+	path := filepath.Join(dir, "golden.hjson")
+	AssertMatchesGolden(t, map[string]int{"a": 1}, path)
+	if b, err := ioutil.ReadFile(path); err != nil || string(b) != "{\n  a: 1\n}\n" {
+		t.Errorf("Unexpected golden file: %q %v", b, err)
+	}
+	AssertMatchesGolden(t, "# Comment\nb: 2\n", path)
+	if b, err := ioutil.ReadFile(path); err != nil || string(b) != "{\n  # Comment\n  b: 2\n}\n" {
+		t.Errorf("Unexpected golden file: %q %v", b, err)
+	}
+
+	os.Setenv(UpdateEnv, "0")
+	AssertMatchesGolden(t, map[string]int{"b": 2}, path)
+}
+
+func TestDiff(t *testing.T) {
+	diffs, err := Diff([]byte("a: [1, 2]\nb: {c: 1}\nd: x"), []byte("{\"b\": {\"c\": 1.0, \"e\": true}, \"a\": [1], \"d\": [\"x\"]}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	for _, d := range diffs {
+		lines = append(lines, d.String())
+	}
+	exp := []string{
+		"a.1: value 2 is missing",
+		"b.e: value true was added",
+		"d: value changed from \"x\" to [\"x\"]",
+	}
+	if !reflect.DeepEqual(lines, exp) {
+		t.Errorf("Expected\n%v\ngot\n%v", exp, lines)
+	}
+
+	if _, err := Diff([]byte("{"), []byte("{}")); err == nil {
+		t.Error("Expected an error")
+	}
+}
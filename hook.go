@@ -0,0 +1,54 @@
+package hjson
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// hookValue returns val, a string, number or boolean read from the input, as
+// it is passed to DecoderOptions.DecodeHook: numbers are passed as float64,
+// or as json.Number or int64 if UseJSONNumber or UseInt64 is set.
+func (p *hjsonParser) hookValue(val interface{}) interface{} {
+	n, ok := val.(json.Number)
+	if !ok || p.UseJSONNumber {
+		return val
+	}
+	if p.UseInt64 {
+		return jsonNumberValue(n)
+	}
+	f, _ := n.Float64()
+	return f
+}
+
+// callDecodeHook calls DecodeHook for val, the scalar value just read by
+// readValue() for a destination of type t. Returns the value to use instead
+// of val, which is nil if the result of the hook is assigned after
+// json.Unmarshal().
+func (p *hjsonParser) callDecodeHook(t reflect.Type, val interface{}) (interface{}, error) {
+	switch val.(type) {
+	case string, json.Number, bool:
+	default:
+		return val, nil
+	}
+	_, to := unravelDestination(reflect.Value{}, t)
+	from := p.hookValue(val)
+	v, err := p.DecodeHook(reflect.TypeOf(from), to, from)
+	if err != nil {
+		text := strings.TrimSpace(string(p.data[p.valueStart:p.valueEnd]))
+		return nil, p.errAtOffset(p.valueStart, MsgDecodeHook, text, to, err.Error())
+	}
+	if v == nil || reflect.TypeOf(v) == reflect.TypeOf(from) && v == from {
+		return val, nil
+	}
+	if to.Kind() != reflect.Interface && !reflect.TypeOf(v).AssignableTo(to) {
+		// Converted to another type, for example from a string to a number,
+		// which is assigned by json.Unmarshal().
+		return v, nil
+	}
+	p.fixups = append(p.fixups, destFixup{
+		path:  append([]interface{}(nil), p.path...),
+		value: v,
+	})
+	return nil, nil
+}
@@ -0,0 +1,87 @@
+package hjson
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestDecodeHook(t *testing.T) {
+	type level int
+	type config struct {
+		Addr    net.IP
+		Backup  *net.IP
+		Level   level
+		Port    int
+		Name    string
+		Enabled bool
+		Any     interface{}
+	}
+
+	levels := map[string]level{"debug": 1, "info": 2}
+	var calls []string
+	options := DefaultDecoderOptions()
+	options.DecodeHook = func(from, to reflect.Type, v interface{}) (interface{}, error) {
+		calls = append(calls, from.String()+"->"+to.String())
+		switch {
+		case to == reflect.TypeOf(net.IP{}) && from.Kind() == reflect.String:
+			ip := net.ParseIP(v.(string))
+			if ip == nil {
+				return nil, errors.New("not an IP address")
+			}
+			return ip, nil
+		case to == reflect.TypeOf(level(0)) && from.Kind() == reflect.String:
+			if l, ok := levels[v.(string)]; ok {
+				return l, nil
+			}
+			return nil, errors.New("unknown level")
+		case to.Kind() == reflect.Int && from.Kind() == reflect.String:
+			n, err := strconv.Atoi(v.(string))
+			return float64(n), err
+		}
+		return v, nil
+	}
+
+	var c config
+	err := UnmarshalWithOptions([]byte(`{
+  Addr: 10.0.0.1
+  Backup: "::1"
+  Level: info
+  Port: "8080"
+  Name: x
+  Enabled: true
+  Any: 1.5
+}`), &c, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backup := net.ParseIP("::1")
+	expected := config{
+		Addr:    net.ParseIP("10.0.0.1"),
+		Backup:  &backup,
+		Level:   2,
+		Port:    8080,
+		Name:    "x",
+		Enabled: true,
+		Any:     1.5,
+	}
+	if !reflect.DeepEqual(c, expected) {
+		t.Errorf("Expected:\n%#v\nGot:\n%#v", expected, c)
+	}
+	expCalls := []string{"string->net.IP", "string->net.IP", "string->hjson.level",
+		"string->int", "string->string", "bool->bool", "float64->interface {}"}
+	if !reflect.DeepEqual(calls, expCalls) {
+		t.Errorf("Expected %#v, got %#v", expCalls, calls)
+	}
+
+	err = UnmarshalWithOptions([]byte("{\n  Level: trace\n}"), &c, options)
+	pe, ok := err.(*ParseError)
+	if !ok || pe.Kind != MsgDecodeHook || pe.Line != 2 || pe.Column != 10 {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+	if exp := "Cannot convert trace to hjson.level: unknown level"; pe.Message != exp {
+		t.Errorf("Expected %q, got %q", exp, pe.Message)
+	}
+}
@@ -0,0 +1,420 @@
+package hjson
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// includeKey is the key of $include directives, see DecoderOptions.Include.
+const includeKey = "$include"
+
+// IncludeResolver returns the content of the file name, for $include
+// directives, see DecoderOptions.Include. name is the name found in the
+// directive, joined with the directory of the including file, using forward
+// slashes like io/fs. Names starting with a slash are relative to the root,
+// the directory of the decoded document. Names leading outside of the root,
+// like "../secret.hjson", are rejected before the resolver is called.
+//
+// The members of the included objects are added to the including object in
+// place of the directive, except for keys that the including object has
+// itself, so that it can override included values. If the directive is the
+// only member of an object and names a single file, the value in the file
+// can also be an array or a scalar, which then replaces the object. Included
+// files can include other files; an error is returned if a file includes
+// itself, directly or indirectly. The total size of the files read (each
+// time they are included) and of their values is limited by
+// DecoderOptions.MaxBytes and AllocBudget.
+type IncludeResolver func(name string) ([]byte, error)
+
+// IncludeDir returns an IncludeResolver reading the files of the directory
+// dir. Names leading outside of dir are rejected.
+func IncludeDir(dir string) IncludeResolver {
+	return func(name string) ([]byte, error) {
+		if !validIncludeName(name) || strings.ContainsAny(name, `\:`) {
+			return nil, fmt.Errorf("%s: invalid include name", name)
+		}
+		return ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	}
+}
+
+// validIncludeName returns true if name is a slash-separated path without
+// empty, "." or ".." elements, that does not start with a slash, like the
+// names accepted by io/fs.
+func validIncludeName(name string) bool {
+	for {
+		elem := name
+		i := strings.IndexByte(name, '/')
+		if i >= 0 {
+			elem = name[:i]
+		}
+		if elem == "" || elem == "." || elem == ".." {
+			return false
+		}
+		if i < 0 {
+			return true
+		}
+		name = name[i+1:]
+	}
+}
+
+// includeExpander expands the $include directives of a document.
+type includeExpander struct {
+	options  DecoderOptions
+	resolver IncludeResolver
+	// stack holds the names of the files being expanded, to detect cycles.
+	stack []string
+	// The total size of the parsed files, and of the values in them, which
+	// must stay within MaxBytes and AllocBudget.
+	total treeSize
+	// sources holds the file that each parsed Node was read from.
+	sources map[*Node]*source
+}
+
+// unmarshalExpanded decodes data after expanding its $include and $ref
+// directives. The expanded document is written as Hjson and decoded again
+// with options, whatever the dialect of data. The positions in errors are
+// changed back to those in data and in the included files.
+func unmarshalExpanded(data []byte, v interface{}, options DecoderOptions) error {
+	expanded, sources, err := expandDirectives(data, options)
+	if err != nil {
+		return err
+	}
+	options.Include = nil
+	options.ResolveRefs = false
+	options.Dialect = FormatHjson
+	return sources.mapError(UnmarshalWithOptions(expanded, v, options))
+}
+
+// expandDirectives returns data with all $include and $ref directives
+// expanded, written as Hjson, and the source map of the written document.
+func expandDirectives(data []byte, options DecoderOptions) ([]byte, sourceMap, error) {
+	e := includeExpander{
+		options:  expandOptions(options),
+		resolver: options.Include,
+		sources:  map[*Node]*source{},
+	}
+	root, err := e.parse(data, options.includeName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if options.ResolveRefs {
+		if err = resolveRefs(root, options); err != nil {
+			return nil, nil, err
+		}
+	}
+	var sources sourceMap
+	out, err := marshal(root, DefaultOptions(), func(node *Node, offset int) {
+		if src := e.sources[node]; src != nil {
+			sources = append(sources, sourceSpan{offset: offset, node: node, src: src})
+		}
+	})
+	return out, sources, err
+}
+
+// parse parses the content of the file name and expands its $include
+// directives, if $include is enabled.
+func (e *includeExpander) parse(data []byte, name string) (*Node, error) {
+	var root Node
+	if err := UnmarshalWithOptions(data, &root, e.options); err != nil {
+		if len(e.stack) > 0 {
+			// An included file.
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		return nil, err
+	}
+	// A file included several times is parsed each time, so that the total
+	// grows with the expanded document.
+	e.total.add(treeSize{bytes: len(data), alloc: sizeMeter{}.measure(&root).alloc})
+	e.addSource(&root, &source{name: name, data: data, included: len(e.stack) > 0})
+	if e.resolver == nil {
+		return &root, nil
+	}
+	e.stack = append(e.stack, name)
+	defer func() { e.stack = e.stack[:len(e.stack)-1] }()
+	if err := e.expand(&root, name); err != nil {
+		return nil, err
+	}
+	return &root, nil
+}
+
+// expand expands the directives in the tree of node, found in the file from.
+func (e *includeExpander) expand(node *Node, from string) error {
+	switch v := node.Value.(type) {
+	case []interface{}:
+		for _, elem := range v {
+			if n, ok := elem.(*Node); ok {
+				if err := e.expand(n, from); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	case *OrderedMap:
+		for _, key := range v.Keys {
+			if n, ok := v.Map[key].(*Node); ok && key != includeKey {
+				if err := e.expand(n, from); err != nil {
+					return err
+				}
+			}
+		}
+	default:
+		return nil
+	}
+
+	om := node.Value.(*OrderedMap)
+	directive, ok := om.Map[includeKey].(*Node)
+	if !ok {
+		return nil
+	}
+	names, err := includeNames(directive)
+	if err != nil {
+		return fmt.Errorf("%s%v", filePrefix(from), err)
+	}
+
+	result := NewOrderedMap()
+	for _, key := range om.Keys {
+		if key != includeKey {
+			result.Set(key, om.Map[key])
+			continue
+		}
+		for _, name := range names {
+			if path.IsAbs(name) {
+				name = path.Clean(name)[1:]
+			} else {
+				name = path.Join(path.Dir(from), name)
+			}
+			if !validIncludeName(name) {
+				return fmt.Errorf("%s%s: the included file is outside of the root directory",
+					filePrefix(from), name)
+			}
+			if len(e.stack) > e.options.maxDepth() {
+				return &LimitError{Limit: "MaxDepth", Max: e.options.maxDepth(), Offset: directive.Pos.Offset}
+			}
+			included, err := e.include(name)
+			if err != nil {
+				return err
+			}
+			if err = e.total.check(e.options, directive.Pos.Offset); err != nil {
+				return err
+			}
+			iom, ok := included.Value.(*OrderedMap)
+			if !ok {
+				if len(om.Keys) != 1 || len(names) != 1 {
+					return fmt.Errorf("%s: the included value must be an object", name)
+				}
+				node.Value = included.Value
+				return nil
+			}
+			for _, ikey := range iom.Keys {
+				if _, own := om.Map[ikey]; !own {
+					result.Set(ikey, iom.Map[ikey])
+				}
+			}
+		}
+	}
+	node.Value = result
+	return nil
+}
+
+// addSource records src as the file of all Nodes in the tree of node.
+func (e *includeExpander) addSource(node *Node, src *source) {
+	e.sources[node] = src
+	switch v := node.Value.(type) {
+	case []interface{}:
+		for _, elem := range v {
+			if n, ok := elem.(*Node); ok {
+				e.addSource(n, src)
+			}
+		}
+	case *OrderedMap:
+		for _, key := range v.Keys {
+			if n, ok := v.Map[key].(*Node); ok {
+				e.addSource(n, src)
+			}
+		}
+	}
+}
+
+// include reads, parses and expands the file name.
+func (e *includeExpander) include(name string) (*Node, error) {
+	for i, n := range e.stack {
+		if n == name {
+			return nil, fmt.Errorf("include cycle: %s -> %s",
+				strings.Join(e.stack[i:], " -> "), name)
+		}
+	}
+	data, err := e.resolver(name)
+	if err != nil {
+		return nil, err
+	}
+	return e.parse(data, name)
+}
+
+// includeNames returns the file names of the $include directive.
+func includeNames(directive *Node) ([]string, error) {
+	switch v := directive.Value.(type) {
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		names := make([]string, len(v))
+		for i, elem := range v {
+			n, _ := elem.(*Node)
+			name, ok := n.Value.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s must be a file name or an array of file names", includeKey)
+			}
+			names[i] = name
+		}
+		return names, nil
+	}
+	return nil, fmt.Errorf("%s must be a file name or an array of file names", includeKey)
+}
+
+// filePrefix returns "name: ", or "" for the decoded document itself.
+func filePrefix(name string) string {
+	if name == "" {
+		return ""
+	}
+	return name + ": "
+}
+
+// treeSize is the estimated size of a Node tree once it is written as Hjson
+// and decoded again: its length in bytes and the size of its values for
+// AllocBudget.
+type treeSize struct {
+	bytes int
+	alloc int
+}
+
+// maxTreeSize is the size at which a treeSize stops growing, so that it
+// cannot overflow.
+const maxTreeSize = math.MaxInt32
+
+// add adds o to s.
+func (s *treeSize) add(o treeSize) {
+	s.bytes = addTreeSize(s.bytes, o.bytes)
+	s.alloc = addTreeSize(s.alloc, o.alloc)
+}
+
+func addTreeSize(a, b int) int {
+	if a > maxTreeSize-b {
+		return maxTreeSize
+	}
+	return a + b
+}
+
+// check returns a *LimitError if s exceeds MaxBytes or AllocBudget, found at
+// offset.
+func (s treeSize) check(options DecoderOptions, offset int) error {
+	if options.MaxBytes > 0 && s.bytes > options.MaxBytes {
+		return &LimitError{Limit: "MaxBytes", Max: options.MaxBytes, Offset: offset}
+	}
+	if options.AllocBudget > 0 && s.alloc > options.AllocBudget {
+		return &LimitError{Limit: "AllocBudget", Max: options.AllocBudget, Offset: offset}
+	}
+	return nil
+}
+
+// sizeMeter measures Node trees, in which the same Node can be used several
+// times. Each Node is only measured once, but counted each time it is used,
+// so the trees must not be changed once they are measured.
+type sizeMeter map[*Node]treeSize
+
+// measure returns the size of the tree of node.
+func (m sizeMeter) measure(node *Node) treeSize {
+	if s, ok := m[node]; ok {
+		return s
+	}
+	s := treeSize{bytes: len(node.Lit), alloc: allocSize(node.Value)}
+	switch v := node.Value.(type) {
+	case []interface{}:
+		s.bytes = len("[]")
+		for _, elem := range v {
+			if n, ok := elem.(*Node); ok {
+				s.add(m.measure(n))
+			}
+			s.add(treeSize{bytes: len(",")})
+		}
+	case *OrderedMap:
+		s.bytes = len("{}")
+		for _, key := range v.Keys {
+			if n, ok := v.Map[key].(*Node); ok {
+				s.add(m.measure(n))
+			}
+			s.add(treeSize{bytes: len(key) + len(": ,")})
+		}
+	}
+	m[node] = s
+	return s
+}
+
+// source is a document read when expanding directives: the decoded document
+// or an included file.
+type source struct {
+	name     string
+	data     []byte
+	included bool
+}
+
+// sourceSpan is the start of the value of node in a written document.
+type sourceSpan struct {
+	offset int
+	node   *Node
+	src    *source
+}
+
+// sourceMap holds the spans of a written document, in the order of their
+// offsets, to find where its values were read from.
+type sourceMap []sourceSpan
+
+// position returns the document and the offset in it of the value written at
+// offset, or false if the value was not read from any document.
+func (m sourceMap) position(offset int) (*source, int, bool) {
+	i := sort.Search(len(m), func(i int) bool { return m[i].offset > offset }) - 1
+	if i < 0 {
+		return nil, 0, false
+	}
+	return m[i].src, m[i].node.Pos.Offset, true
+}
+
+// mapError changes the positions in err, found in the written document, to
+// the start of the values in the documents they were read from. The messages
+// of errors in included files start with the name of the file.
+func (m sourceMap) mapError(err error) error {
+	switch e := err.(type) {
+	case *ParseError:
+		return m.mapParseError(e)
+	case ErrorList:
+		list := make(ErrorList, len(e))
+		for i, pe := range e {
+			list[i] = m.mapParseError(pe)
+		}
+		return list
+	case *LimitError:
+		if src, offset, ok := m.position(e.Offset); ok && !src.included {
+			le := *e
+			le.Offset = offset
+			return &le
+		}
+	}
+	return err
+}
+
+func (m sourceMap) mapParseError(pe *ParseError) *ParseError {
+	src, offset, ok := m.position(pe.Offset)
+	if !ok {
+		return pe
+	}
+	message := pe.Message
+	if src.included {
+		message = filePrefix(src.name) + message
+	}
+	mapped := newParseError(src.data, offset, message)
+	mapped.Kind, mapped.Code = pe.Kind, pe.Code
+	return mapped
+}
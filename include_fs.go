@@ -0,0 +1,12 @@
+//go:build go1.16
+
+package hjson
+
+import "io/fs"
+
+// IncludeFS returns an IncludeResolver reading the files of fsys.
+func IncludeFS(fsys fs.FS) IncludeResolver {
+	return func(name string) ([]byte, error) {
+		return fs.ReadFile(fsys, name)
+	}
+}
@@ -0,0 +1,28 @@
+//go:build go1.16
+
+package hjson
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestIncludeFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"conf/base.hjson": {Data: []byte("port: 80\nhost: localhost")},
+		"conf/app.hjson":  {Data: []byte("$include: base.hjson\nport: 8080")},
+	}
+	options := DefaultDecoderOptions()
+	options.Include = IncludeFS(fsys)
+
+	var v struct {
+		Host string
+		Port int
+	}
+	if err := UnmarshalWithOptions([]byte("$include: /conf/app.hjson"), &v, options); err != nil {
+		t.Fatal(err)
+	}
+	if v.Host != "localhost" || v.Port != 8080 {
+		t.Errorf("got %+v", v)
+	}
+}
@@ -0,0 +1,177 @@
+package hjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInclude(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hjson-include")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"base.hjson":      "name: base\nport: 80\nbig: 12345678901234567890\n",
+		"db/db.hjson":     "host: localhost\n$include: user.hjson\n",
+		"db/user.hjson":   "user: admin\n",
+		"list.hjson":      "[1, 2]",
+		"cycle/a.hjson":   "$include: b.hjson",
+		"cycle/b.hjson":   "$include: /cycle/a.hjson",
+		"invalid.hjson":   "{",
+		"scalar.hjson":    "just text",
+		"bad-names.hjson": "$include: 1",
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	options := DefaultDecoderOptions()
+	options.Include = IncludeDir(dir)
+	options.UseJSONNumber = true
+	var v interface{}
+	err = UnmarshalWithOptions([]byte(`{
+  $include: base.hjson
+  # Overrides the included port.
+  port: 8080
+  db: {
+    $include: db/db.hjson
+  }
+  list: {
+    $include: list.hjson
+  }
+}`), &v, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"name": "base",
+		"big":  json.Number("12345678901234567890"),
+		"port": json.Number("8080"),
+		"db":   map[string]interface{}{"host": "localhost", "user": "admin"},
+		"list": []interface{}{json.Number("1"), json.Number("2")},
+	}
+	if !reflect.DeepEqual(v, expected) {
+		t.Errorf("Expected:\n%#v\nGot:\n%#v", expected, v)
+	}
+
+	// Files outside of the directory cannot be read.
+	secret := filepath.Join(filepath.Dir(dir), filepath.Base(dir)+"-secret.hjson")
+	if err = ioutil.WriteFile(secret, []byte("password: x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(secret)
+	for _, name := range []string{"../" + filepath.Base(secret), "db/../../" + filepath.Base(secret), "/", "a//b"} {
+		if data, err := IncludeDir(dir)(name); err == nil || data != nil {
+			t.Errorf("%q: expected an error, got %q", name, data)
+		}
+	}
+
+	// Without Include, $include is an ordinary key.
+	if err = Unmarshal([]byte("$include: base.hjson"), &v); err != nil ||
+		!reflect.DeepEqual(v, map[string]interface{}{"$include": "base.hjson"}) {
+
+		t.Errorf("Unexpected result %#v, %v", v, err)
+	}
+
+	for input, msg := range map[string]string{
+		"$include: cycle/a.hjson":                    "include cycle: cycle/a.hjson -> cycle/b.hjson -> cycle/a.hjson",
+		"$include: invalid.hjson":                    "invalid.hjson: ",
+		"$include: missing.hjson":                    "missing.hjson",
+		"$include: scalar.hjson\nother: 1":           "scalar.hjson: the included value must be an object",
+		"$include: bad-names.hjson":                  "bad-names.hjson: $include must be a file name",
+		"$include: [\"base.hjson\", \"list.hjson\"]": "list.hjson: the included value must be an object",
+		"$include: ../secret.hjson":                  "../secret.hjson: the included file is outside of the root directory",
+		"$include: db/../../secret.hjson":            "../secret.hjson: the included file is outside of the root directory",
+	} {
+		err = UnmarshalWithOptions([]byte(input), &v, options)
+		if err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("%q: expected an error containing %q, got %v", input, msg, err)
+		}
+	}
+}
+
+func TestIncludeLimits(t *testing.T) {
+	// Each level includes the previous one twice, so the expanded document
+	// doubles with each level.
+	files := map[string]string{
+		"l0.hjson": "text: " + strings.Repeat("x", 1000),
+	}
+	for i := 1; i <= 16; i++ {
+		files[fmt.Sprintf("l%d.hjson", i)] = fmt.Sprintf(
+			"a: {$include: \"l%d.hjson\"}\nb: {$include: \"l%[1]d.hjson\"}", i-1)
+	}
+	reads := 0
+	resolver := func(name string) ([]byte, error) {
+		reads++
+		return []byte(files[name]), nil
+	}
+
+	for _, limit := range []string{"MaxBytes", "AllocBudget"} {
+		reads = 0
+		options := DefaultDecoderOptions()
+		options.Include = resolver
+		if limit == "MaxBytes" {
+			options.MaxBytes = 1 << 20
+		} else {
+			options.AllocBudget = 1 << 20
+		}
+		var v interface{}
+		err := UnmarshalWithOptions([]byte("$include: l16.hjson"), &v, options)
+		if le, ok := err.(*LimitError); !ok || le.Limit != limit {
+			t.Errorf("Expected a %s *LimitError, got %v", limit, err)
+		}
+		// The expansion stops soon after the limit is exceeded.
+		if reads > 2000 {
+			t.Errorf("%s: %d files read", limit, reads)
+		}
+	}
+}
+
+func TestIncludeJSON5(t *testing.T) {
+	files := map[string]string{
+		"base.json5": "{\n  // The defaults.\n  name: 'base',\n  level: 'info',\n}",
+	}
+	options := DefaultDecoderOptions()
+	options.Dialect = FormatJSON5
+	options.Include = func(name string) ([]byte, error) {
+		return []byte(files[name]), nil
+	}
+	var v struct {
+		Name  string
+		Level string `enum:"debug|info"`
+		Mode  string `enum:"fast|safe"`
+	}
+	if err := UnmarshalWithOptions([]byte("{\n  $include: 'base.json5',\n  mode: 'safe',\n}"), &v, options); err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "base" || v.Level != "info" || v.Mode != "safe" {
+		t.Errorf("Unexpected result %+v", v)
+	}
+
+	// Errors found after the expansion point into the decoded document or the
+	// included file.
+	err := UnmarshalWithOptions([]byte("{\n  $include: 'base.json5',\n  // Not allowed.\n  mode: 'slow',\n}"), &v, options)
+	if pe, ok := err.(*ParseError); !ok || pe.Line != 4 || pe.Column != 9 {
+		t.Errorf("Expected an error at line 4, column 9, got %v", err)
+	}
+	files["base.json5"] = "{\n  level: 'trace',\n}"
+	err = UnmarshalWithOptions([]byte("{\n  $include: 'base.json5',\n}"), &v, options)
+	if pe, ok := err.(*ParseError); !ok || pe.Line != 2 || pe.Column != 10 ||
+		!strings.HasPrefix(pe.Message, "base.json5: ") {
+
+		t.Errorf("Expected an error in base.json5 at line 2, column 10, got %v", err)
+	}
+}
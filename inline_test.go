@@ -0,0 +1,62 @@
+package hjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+type inlineDB struct {
+	Host string `json:"host"`
+	Port int    `json:"port" default:"5432"`
+}
+
+type inlineLog struct {
+	Level string `json:"level"`
+}
+
+type inlineConfig struct {
+	Name string     `json:"name"`
+	DB   inlineDB   `json:"db" hjson:",inline"`
+	Log  *inlineLog `hjson:",squash"`
+}
+
+func TestInline(t *testing.T) {
+	c := inlineConfig{Name: "x", DB: inlineDB{Host: "h", Port: 1}, Log: &inlineLog{Level: "info"}}
+	out, err := Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  name: x
+  host: h
+  port: 1
+  level: info
+}`
+	if string(out) != want {
+		t.Errorf("Expected:\n%s\nGot:\n%s", want, out)
+	}
+
+	options := DefaultDecoderOptions()
+	options.DisallowUnknownFields = true
+	var c2 inlineConfig
+	if err = UnmarshalWithOptions(out, &c2, options); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(c, c2) {
+		t.Errorf("Expected:\n%#v\nGot:\n%#v", c, c2)
+	}
+
+	// The defaults of inlined fields are applied, and the nested key is unknown.
+	c2 = inlineConfig{}
+	if err = Unmarshal([]byte("{\n  name: y\n  db: {\n    host: z\n  }\n}"), &c2); err != nil {
+		t.Fatal(err)
+	}
+	expected := inlineConfig{Name: "y", DB: inlineDB{Port: 5432}}
+	if !reflect.DeepEqual(c2, expected) {
+		t.Errorf("Expected:\n%#v\nGot:\n%#v", expected, c2)
+	}
+	err = UnmarshalWithOptions([]byte("{\n  db: {}\n}"), &c2, options)
+	if pe, ok := err.(*ParseError); !ok || pe.Kind != MsgUnknownField {
+		t.Errorf("Unexpected error: %#v", err)
+	}
+}
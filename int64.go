@@ -0,0 +1,94 @@
+package hjson
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+)
+
+// parseNumber calls tryParseNumber() for text. If UseInt64 is set and the
+// value is not marshaled to JSON, integers that fit in an int64 are returned
+// as int64.
+func (p *hjsonParser) parseNumber(text []byte) (interface{}, error) {
+	useJSONNumber := p.willMarshalToJSON || p.UseJSONNumber
+	if p.UseInt64 && !useJSONNumber {
+		if n, err := tryParseNumber(text, false, true); err == nil {
+			if i, err := strconv.ParseInt(string(n.(json.Number)), 10, 64); err == nil {
+				return i, nil
+			}
+		}
+	}
+	return tryParseNumber(text, false, useJSONNumber)
+}
+
+// jsonNumberValue returns n as an int64 if it is an integer that fits in an
+// int64, otherwise as a float64.
+func jsonNumberValue(n json.Number) interface{} {
+	if i, err := n.Int64(); err == nil {
+		return i
+	}
+	f, _ := n.Float64()
+	return f
+}
+
+// replaceJSONNumbers replaces the json.Number values stored in interface{}
+// values in rv, as decoded by json.Unmarshal() when using UseInt64, by int64
+// or float64 values.
+func replaceJSONNumbers(rv reflect.Value, depth int) {
+	if depth > maxPointerDepth {
+		return
+	}
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if !rv.IsNil() {
+			replaceJSONNumbers(rv.Elem(), depth+1)
+		}
+	case reflect.Interface:
+		if rv.IsNil() {
+			return
+		}
+		if n, ok := rv.Interface().(json.Number); ok {
+			if rv.CanSet() && rv.Elem().Type() == JSONNumberType {
+				rv.Set(reflect.ValueOf(jsonNumberValue(n)))
+			}
+			return
+		}
+		replaceJSONNumbers(rv.Elem(), depth+1)
+	case reflect.Struct:
+		for i := 0; i < rv.NumField(); i++ {
+			if rv.Field(i).CanSet() {
+				replaceJSONNumbers(rv.Field(i), depth+1)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if !mayHoldInterface(rv.Type().Elem()) {
+			return
+		}
+		for i := 0; i < rv.Len(); i++ {
+			replaceJSONNumbers(rv.Index(i), depth+1)
+		}
+	case reflect.Map:
+		if !mayHoldInterface(rv.Type().Elem()) {
+			return
+		}
+		iter := rv.MapRange()
+		for iter.Next() {
+			// Map elements cannot be changed in place.
+			elem := reflect.New(rv.Type().Elem()).Elem()
+			elem.Set(iter.Value())
+			replaceJSONNumbers(elem, depth+1)
+			rv.SetMapIndex(iter.Key(), elem)
+		}
+	}
+}
+
+// mayHoldInterface returns false for types that cannot contain interface{}
+// values.
+func mayHoldInterface(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Interface, reflect.Ptr, reflect.Struct, reflect.Slice, reflect.Array,
+		reflect.Map:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package hjson
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUseInt64(t *testing.T) {
+	txt := []byte(`{
+  id: 9007199254740993
+  neg: -12
+  ratio: 1.5
+  exp: 1e3
+  huge: 123456789012345678901234567890
+  list: [1, 2.5]
+  text: 12 apples
+}`)
+	options := DefaultDecoderOptions()
+	options.UseInt64 = true
+
+	exp := map[string]interface{}{
+		"id":    int64(9007199254740993),
+		"neg":   int64(-12),
+		"ratio": 1.5,
+		"exp":   1000.0,
+		"huge":  1.2345678901234568e29,
+		"list":  []interface{}{int64(1), 2.5},
+		"text":  "12 apples",
+	}
+
+	var v interface{}
+	if err := UnmarshalWithOptions(txt, &v, options); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(v, exp) {
+		t.Errorf("Expected\n%#v\ngot\n%#v", exp, v)
+	}
+
+	var m map[string]interface{}
+	if err := UnmarshalWithOptions(txt, &m, options); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(m, exp) {
+		t.Errorf("Expected\n%#v\ngot\n%#v", exp, m)
+	}
+
+	var s struct {
+		ID     interface{} `json:"id"`
+		List   []interface{}
+		Number json.Number `json:"neg"`
+		Ratio  float64
+		Nested map[string]interface{}
+	}
+	txt2 := []byte(`{
+  id: 9007199254740993
+  neg: -12
+  ratio: 2
+  list: [3]
+  nested: {a: {b: [4]}}
+}`)
+	if err := UnmarshalWithOptions(txt2, &s, options); err != nil {
+		t.Fatal(err)
+	}
+	if s.ID != int64(9007199254740993) || s.List[0] != int64(3) || s.Number != "-12" ||
+		s.Ratio != 2 || !reflect.DeepEqual(s.Nested["a"],
+		map[string]interface{}{"b": []interface{}{int64(4)}}) {
+
+		t.Errorf("Unexpected result: %#v", s)
+	}
+
+	node, err := ParseNode(txt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node.NK("id").Value != 9007199254740993.0 {
+		t.Errorf("UseInt64 must only be used if set, got %#v", node.NK("id").Value)
+	}
+	node = &Node{}
+	if err := UnmarshalWithOptions(txt, node, options); err != nil {
+		t.Fatal(err)
+	}
+	if node.NK("id").Value != int64(9007199254740993) {
+		t.Errorf("Unexpected value: %#v", node.NK("id").Value)
+	}
+	out, err := Marshal(node)
+	if err != nil || !strings.Contains(string(out), "id: 9007199254740993\n") {
+		t.Errorf("Unexpected output: %s %v", out, err)
+	}
+
+	options.UseJSONNumber = true
+	if err := UnmarshalWithOptions(txt, &v, options); err != nil {
+		t.Fatal(err)
+	}
+	if n := v.(map[string]interface{})["id"]; n != json.Number("9007199254740993") {
+		t.Errorf("UseJSONNumber must take precedence, got %#v", n)
+	}
+}
@@ -0,0 +1,94 @@
+package hjson
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// KeyNamer converts the name of a struct field to the key used in Hjson, see
+// EncoderOptions.KeyNamer and DecoderOptions.KeyMatcher. SnakeCase,
+// KebabCase and CamelCase can be used as KeyNamer.
+type KeyNamer func(fieldName string) string
+
+// SnakeCase converts a field name like "MaxConns" or "HTTPServer" to
+// "max_conns" or "http_server".
+func SnakeCase(fieldName string) string {
+	return strings.ToLower(strings.Join(splitWords(fieldName), "_"))
+}
+
+// KebabCase converts a field name like "MaxConns" or "HTTPServer" to
+// "max-conns" or "http-server".
+func KebabCase(fieldName string) string {
+	return strings.ToLower(strings.Join(splitWords(fieldName), "-"))
+}
+
+// CamelCase converts a field name like "MaxConns", "HTTPServer" or "UserID"
+// to "maxConns", "httpServer" or "userID".
+func CamelCase(fieldName string) string {
+	words := splitWords(fieldName)
+	for i, w := range words {
+		if i == 0 {
+			words[i] = strings.ToLower(w)
+			continue
+		}
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		words[i] = string(r)
+	}
+	return strings.Join(words, "")
+}
+
+// splitWords splits name into words at underscores, hyphens and spaces, and
+// where a lowercase letter or digit is followed by an uppercase letter, or
+// where an acronym like "HTTP" is followed by a capitalized word.
+func splitWords(name string) []string {
+	var words []string
+	var word []rune
+	runes := []rune(name)
+	for i, r := range runes {
+		if r == '_' || r == '-' || r == ' ' {
+			if len(word) > 0 {
+				words = append(words, string(word))
+				word = nil
+			}
+			continue
+		}
+		if len(word) > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+				unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+
+				words = append(words, string(word))
+				word = nil
+			}
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
+
+// matchKey returns the field of the struct type t whose name, converted by
+// p.KeyMatcher, is key. Fields with a name in a tag are not converted.
+func (p *hjsonParser) matchKey(t reflect.Type, stm structFieldMap, key string) (structFieldInfo, bool) {
+	names, ok := p.matchedNames[t]
+	if !ok {
+		names = map[string]structFieldInfo{}
+		for _, arr := range stm {
+			for _, sfi := range arr {
+				if !sfi.tagged {
+					names[p.KeyMatcher(sfi.name)] = sfi
+				}
+			}
+		}
+		if p.matchedNames == nil {
+			p.matchedNames = map[reflect.Type]map[string]structFieldInfo{}
+		}
+		p.matchedNames[t] = names
+	}
+	sfi, ok := names[key]
+	return sfi, ok
+}
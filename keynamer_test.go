@@ -0,0 +1,83 @@
+package hjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeyNamers(t *testing.T) {
+	cases := []struct {
+		name, snake, kebab, camel string
+	}{
+		{"MaxConns", "max_conns", "max-conns", "maxConns"},
+		{"HTTPServer", "http_server", "http-server", "httpServer"},
+		{"UserID", "user_id", "user-id", "userID"},
+		{"Port", "port", "port", "port"},
+		{"max_conns", "max_conns", "max-conns", "maxConns"},
+	}
+	for _, c := range cases {
+		if s := SnakeCase(c.name); s != c.snake {
+			t.Errorf("SnakeCase(%q): expected %q, got %q", c.name, c.snake, s)
+		}
+		if s := KebabCase(c.name); s != c.kebab {
+			t.Errorf("KebabCase(%q): expected %q, got %q", c.name, c.kebab, s)
+		}
+		if s := CamelCase(c.name); s != c.camel {
+			t.Errorf("CamelCase(%q): expected %q, got %q", c.name, c.camel, s)
+		}
+	}
+}
+
+func TestKeyNamerRoundTrip(t *testing.T) {
+	type db struct {
+		MaxConns int
+		UserID   string
+	}
+	type config struct {
+		HTTPServer string
+		Database   db
+		Tagged     int `json:"TaggedName"`
+	}
+
+	c := config{HTTPServer: "x", Database: db{MaxConns: 3, UserID: "u"}, Tagged: 1}
+	eo := DefaultOptions()
+	eo.KeyNamer = SnakeCase
+	out, err := MarshalWithOptions(c, eo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  http_server: x
+  database: {
+    max_conns: 3
+    user_id: u
+  }
+  TaggedName: 1
+}`
+	if string(out) != want {
+		t.Errorf("Expected:\n%s\nGot:\n%s", want, out)
+	}
+
+	do := DefaultDecoderOptions()
+	do.KeyMatcher = SnakeCase
+	do.DisallowUnknownFields = true
+	do.FieldStates = FieldStates{}
+	var c2 config
+	if err = UnmarshalWithOptions(out, &c2, do); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(c, c2) {
+		t.Errorf("Expected:\n%#v\nGot:\n%#v", c, c2)
+	}
+	if do.FieldStates.State("Database.MaxConns") != FieldSet {
+		t.Errorf("Unexpected field states: %#v", do.FieldStates)
+	}
+
+	// The field names still match.
+	c2 = config{}
+	if err = UnmarshalWithOptions([]byte("{\n  HTTPServer: y\n}"), &c2, do); err != nil ||
+		c2.HTTPServer != "y" {
+
+		t.Errorf("Unexpected result: %#v, %v", c2, err)
+	}
+}
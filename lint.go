@@ -0,0 +1,576 @@
+package hjson
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Names of the rules checked by Lint(), used in Diagnostic.Rule.
+const (
+	lintMixedIndent  = "mixed-indent"
+	lintDuplicateKey = "duplicate-key"
+	lintSimilarKey   = "similar-key"
+)
+
+// mlString holds the offsets of a multiline string in a document: the offset
+// of its opening triple quotes and the offset just after its closing quotes.
+type mlString struct {
+	start, end int
+}
+
+// findMLStrings returns the multiline strings in data. If data contains a
+// syntax error, only the multiline strings before the error are returned.
+func findMLStrings(data []byte) []mlString {
+	var res []mlString
+	dec := NewDecoder(bytes.NewReader(data))
+	for {
+		prev := int(dec.InputOffset())
+		tok, err := dec.Token()
+		if err != nil {
+			return res
+		}
+		if _, ok := tok.(string); !ok {
+			continue
+		}
+		end := int(dec.InputOffset())
+		start := prev + len(data[prev:end]) - len(bytes.TrimLeft(data[prev:end], " \t\r\n,"))
+		if bytes.HasPrefix(data[start:end], []byte("'''")) {
+			res = append(res, mlString{start, end})
+		}
+	}
+}
+
+// lintLine is a line in a document, as seen by Lint() and FixIndentation().
+type lintLine struct {
+	start, end int // offsets of the line in the document, without the EOL
+	number     int // 1-based
+	// Number of bytes preceding ''' on the line that opens the multiline string
+	// containing this line, or -1 if this line is not inside a multiline string
+	// (the line that opens the string is not inside it).
+	mlIndent int
+	// The line opening the multiline string containing this line.
+	mlOpening int
+}
+
+func splitLintLines(data []byte) []lintLine {
+	var lines []lintLine
+	for start := 0; start <= len(data); {
+		end := bytes.IndexByte(data[start:], '\n')
+		if end < 0 {
+			end = len(data)
+		} else {
+			end += start
+		}
+		lines = append(lines, lintLine{
+			start:    start,
+			end:      end,
+			number:   len(lines) + 1,
+			mlIndent: -1,
+		})
+		start = end + 1
+	}
+
+	li := 0
+	for _, ml := range findMLStrings(data) {
+		for lines[li].end < ml.start {
+			li++
+		}
+		opening := li
+		for li++; li < len(lines) && lines[li].start < ml.end; li++ {
+			lines[li].mlIndent = ml.start - lines[opening].start
+			lines[li].mlOpening = opening
+		}
+		li--
+	}
+
+	return lines
+}
+
+func leadingWhite(line []byte) []byte {
+	i := 0
+	for i < len(line) && (line[i] == ' ' || line[i] == '\t') {
+		i++
+	}
+	return line[:i]
+}
+
+// lintIndent returns the column (0-based) of the first tab or space in the
+// indentation of a line that is inconsistent with the indentation before it,
+// or -1 if there is no such character.
+func lintIndent(data []byte, lines []lintLine, l lintLine) int {
+	line := data[l.start:l.end]
+	ws := leadingWhite(line)
+
+	if l.mlIndent < 0 {
+		if bytes.IndexByte(ws, ' ') >= 0 && bytes.IndexByte(ws, '\t') >= 0 {
+			return bytes.IndexByte(ws, ws[0]^' '^'\t')
+		}
+		return -1
+	}
+
+	// Inside a multiline string, up to mlIndent whitespace characters are
+	// removed from the start of each line. Any other character than the one at
+	// the same column on the line that opens the string changes the content of
+	// the string compared to what is seen in an editor.
+	opening := data[lines[l.mlOpening].start:lines[l.mlOpening].end]
+	for i := 0; i < len(ws) && i < l.mlIndent; i++ {
+		expected := byte(' ')
+		if opening[i] == '\t' {
+			expected = '\t'
+		}
+		if ws[i] != expected {
+			return i
+		}
+	}
+	return -1
+}
+
+// LintOptions are options for LintWithOptions().
+type LintOptions struct {
+	// Messages, if not nil, builds the messages of the diagnostics, so that
+	// they can be localized or rephrased. DefaultMessages is used if Messages
+	// is nil.
+	Messages Messages
+}
+
+// Lint checks data for problems that do not stop it from being decoded, but
+// that are likely to cause surprises:
+//
+//   - mixed-indent: mixed tabs and spaces in indentation. Inside multiline
+//     strings that changes the content of the string, because the indentation
+//     that is removed from each line is counted in bytes, not in columns. See
+//     also FixIndentation().
+//   - duplicate-key: a key that is defined more than once in the same object.
+//     Only the last value is kept when decoding.
+//   - similar-key: a key that differs from another key in the same object
+//     only by case, or by a single inserted, removed or replaced character
+//     that is not a digit (for keys of at least 5 characters), like "timeout"
+//     and "timeOut". That almost always indicates a typo, which decoding into
+//     a struct would hide because struct fields are matched without regard to
+//     case. Numbered keys like "user1" and "user2" and short keys like "port"
+//     and "sort" are not reported.
+//
+// Diagnostics can be suppressed with comments on lines of their own, like
+// "# hjson-lint: disable=duplicate-key", that apply to the next entry (a
+// key/value pair in an object or an element in an array) including everything
+// inside it, or "# hjson-lint: disable-block=duplicate-key", that applies to
+// the rest of the enclosing object or array (or of the document, at the root
+// level). A comma-separated list of rule names or codes (see Codes()) can be
+// given after "=". Without a list all rules are disabled.
+//
+// Lint does not check the syntax of data.
+func Lint(data []byte) []Diagnostic {
+	return LintWithOptions(data, LintOptions{})
+}
+
+// LintWithOptions is like Lint(), but uses the given options.
+func LintWithOptions(data []byte, options LintOptions) []Diagnostic {
+	h := &lintHandler{data: data, messages: options.Messages}
+	h.frames = []*lintFrame{{}}
+	// Lint does not check the syntax, so use what was found up to any error.
+	_ = Parse(data, h)
+	for len(h.frames) > 0 {
+		h.endFrame(len(data))
+	}
+
+	lines := splitLintLines(data)
+	for _, l := range lines {
+		col := lintIndent(data, lines, l)
+		if col < 0 {
+			continue
+		}
+		id := MsgMixedIndent
+		if l.mlIndent >= 0 {
+			id = MsgMixedIndentMultiline
+		}
+		h.diags = append(h.diags, lintDiag{l.start + col, Diagnostic{
+			Line:    l.number,
+			Column:  col + 1,
+			Message: message(options.Messages, id),
+			Rule:    lintMixedIndent,
+			Code:    CodeOf(id),
+		}})
+	}
+
+	sort.SliceStable(h.diags, func(i, j int) bool {
+		return h.diags[i].offset < h.diags[j].offset
+	})
+	var diags []Diagnostic
+	for _, ld := range h.diags {
+		if !h.suppressed(ld) {
+			diags = append(diags, ld.Diagnostic)
+		}
+	}
+	return diags
+}
+
+// lintDiag is a Diagnostic found at an offset in a document.
+type lintDiag struct {
+	offset int
+	Diagnostic
+}
+
+// suppression is a part of a document in which the diagnostics of some rules
+// are not reported, created by a "hjson-lint:" comment.
+type suppression struct {
+	start, end int
+	// rules holds the names or codes of the suppressed rules, nil means all
+	// rules.
+	rules []string
+}
+
+func (s *suppression) covers(ld lintDiag) bool {
+	if ld.offset < s.start || ld.offset >= s.end {
+		return false
+	}
+	if s.rules == nil {
+		return true
+	}
+	for _, rule := range s.rules {
+		if rule == ld.Rule || rule == ld.Code {
+			return true
+		}
+	}
+	return false
+}
+
+// lintFrame is an object or array being parsed by lintHandler. The root of
+// the document is a frame containing a single entry, the root value.
+type lintFrame struct {
+	// keys holds the line number of each key found, if the frame is an object.
+	keys map[string]int
+	// keyOrder holds the keys in the order they were found.
+	keyOrder []string
+	// pending holds the suppressions of "disable" comments that apply to the
+	// next entry.
+	pending []*suppression
+	// entry holds the suppressions applying to the current entry.
+	entry []*suppression
+	// block holds the suppressions of "disable-block" comments.
+	block []*suppression
+}
+
+// lintHandler finds the problems in a document that need its structure, and
+// the suppression comments.
+type lintHandler struct {
+	BaseHandler
+	data     []byte
+	messages Messages
+	frames   []*lintFrame
+	supps    []*suppression
+	diags    []lintDiag
+}
+
+func (h *lintHandler) top() *lintFrame {
+	return h.frames[len(h.frames)-1]
+}
+
+// startOf returns offset, or the start of its line if only whitespace
+// precedes offset on its line.
+func (h *lintHandler) startOf(offset int) int {
+	lineStart := bytes.LastIndexByte(h.data[:offset], '\n') + 1
+	if len(bytes.Trim(h.data[lineStart:offset], " \t\r")) == 0 {
+		return lineStart
+	}
+	return offset
+}
+
+// startEntry is called at the first character of an entry in the top frame.
+func (h *lintHandler) startEntry(offset int) {
+	f := h.top()
+	start := h.startOf(offset)
+	for _, s := range f.entry {
+		s.end = start
+	}
+	for _, s := range f.pending {
+		s.start = start
+	}
+	f.entry, f.pending = f.pending, nil
+}
+
+// startValue is called at the first character of a value.
+func (h *lintHandler) startValue(offset int) {
+	if h.top().keys == nil {
+		// Elements of arrays and the root value are entries of their own.
+		h.startEntry(offset)
+	}
+}
+
+func (h *lintHandler) startFrame(offset int, object bool) {
+	root := h.top()
+	braceless := object && len(h.frames) == 1 &&
+		(offset >= len(h.data) || h.data[offset] != '{')
+	f := &lintFrame{}
+	if braceless {
+		// Comments before the first key of a root object without braces belong
+		// to the first key.
+		f.pending, root.pending = root.pending, nil
+	}
+	h.startValue(offset)
+	if object {
+		f.keys = map[string]int{}
+	}
+	h.frames = append(h.frames, f)
+}
+
+// endFrame is called with the offset just after the end of the top frame.
+func (h *lintHandler) endFrame(end int) {
+	f := h.top()
+	for _, s := range append(f.entry, f.block...) {
+		s.end = end
+	}
+	h.frames = h.frames[:len(h.frames)-1]
+}
+
+func (h *lintHandler) ObjectStart(pos Position) error {
+	h.startFrame(pos.Offset, true)
+	return nil
+}
+
+func (h *lintHandler) ObjectEnd(pos Position) error {
+	if len(h.frames) > 1 {
+		h.endFrame(pos.Offset + 1)
+	}
+	return nil
+}
+
+func (h *lintHandler) ArrayStart(pos Position) error {
+	h.startFrame(pos.Offset, false)
+	return nil
+}
+
+func (h *lintHandler) ArrayEnd(pos Position) error {
+	if len(h.frames) > 1 {
+		h.endFrame(pos.Offset + 1)
+	}
+	return nil
+}
+
+func (h *lintHandler) Key(key string, pos Position) error {
+	h.startEntry(pos.Offset)
+	f := h.top()
+	if line, ok := f.keys[key]; ok {
+		h.diags = append(h.diags, lintDiag{pos.Offset, Diagnostic{
+			Line:    pos.Line,
+			Column:  pos.Column,
+			Message: message(h.messages, MsgKeyRedefined, key, line),
+			Rule:    lintDuplicateKey,
+			Code:    CodeOf(MsgKeyRedefined),
+		}})
+	} else {
+		for _, other := range f.keyOrder {
+			if similarKeys(key, other) {
+				h.diags = append(h.diags, lintDiag{pos.Offset, Diagnostic{
+					Line:    pos.Line,
+					Column:  pos.Column,
+					Message: message(h.messages, MsgSimilarKey, key, other, f.keys[other]),
+					Rule:    lintSimilarKey,
+					Code:    CodeOf(MsgSimilarKey),
+				}})
+				break
+			}
+		}
+		f.keyOrder = append(f.keyOrder, key)
+	}
+	f.keys[key] = pos.Line
+	return nil
+}
+
+// similarKeys returns true if the different keys a and b only differ by case,
+// or by one edit that does not involve a digit if both have at least 5
+// characters. Shorter keys differing by one character are often different
+// words, and keys differing in a digit are usually numbered on purpose.
+func similarKeys(a, b string) bool {
+	if strings.EqualFold(a, b) {
+		return true
+	}
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) < 5 || len(rb) < 5 {
+		return false
+	}
+	if len(ra) < len(rb) {
+		ra, rb = rb, ra
+	}
+	if len(ra)-len(rb) > 1 {
+		return false
+	}
+	// Skip the common prefix and suffix, then at most one character may be
+	// left in each key.
+	i := 0
+	for i < len(rb) && ra[i] == rb[i] {
+		i++
+	}
+	j := 0
+	for j < len(rb)-i && ra[len(ra)-1-j] == rb[len(rb)-1-j] {
+		j++
+	}
+	if len(ra)-i-j > 1 || len(rb)-i-j > 1 {
+		return false
+	}
+	for _, r := range append(ra[i:len(ra)-j], rb[i:len(rb)-j]...) {
+		if unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func (h *lintHandler) Value(value interface{}, pos Position) error {
+	h.startValue(pos.Offset)
+	return nil
+}
+
+func (h *lintHandler) Comment(comment string, pos Position) error {
+	if h.startOf(pos.Offset) == pos.Offset && pos.Column > 1 {
+		// Other text precedes the comment on its line.
+		return nil
+	}
+	directive, ok := lintDirective(comment)
+	if !ok {
+		return nil
+	}
+	s := &suppression{}
+	if i := strings.IndexByte(directive, '='); i >= 0 {
+		for _, rule := range strings.Split(directive[i+1:], ",") {
+			if rule = strings.TrimSpace(rule); rule != "" {
+				s.rules = append(s.rules, rule)
+			}
+		}
+		directive = strings.TrimSpace(directive[:i])
+	}
+	f := h.top()
+	switch directive {
+	case "disable":
+		f.pending = append(f.pending, s)
+	case "disable-block":
+		s.start = h.startOf(pos.Offset)
+		f.block = append(f.block, s)
+	default:
+		return nil
+	}
+	h.supps = append(h.supps, s)
+	return nil
+}
+
+// lintDirective returns the text following "hjson-lint:" in comment, if the
+// comment starts with it.
+func lintDirective(comment string) (string, bool) {
+	switch {
+	case strings.HasPrefix(comment, "#"):
+		comment = comment[1:]
+	case strings.HasPrefix(comment, "//"):
+		comment = comment[2:]
+	case strings.HasPrefix(comment, "/*"):
+		comment = strings.TrimSuffix(comment[2:], "*/")
+	}
+	comment = strings.TrimSpace(comment)
+	if !strings.HasPrefix(comment, "hjson-lint:") {
+		return "", false
+	}
+	return strings.TrimSpace(comment[len("hjson-lint:"):]), true
+}
+
+func (h *lintHandler) suppressed(ld lintDiag) bool {
+	for _, s := range h.supps {
+		if s.covers(ld) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandTabs returns s with all tabs replaced by spaces, using tab stops
+// every tabWidth columns.
+func expandTabs(s []byte, tabWidth int) []byte {
+	var res []byte
+	for _, c := range s {
+		if c == '\t' {
+			res = append(res, bytes.Repeat([]byte{' '}, tabWidth-len(res)%tabWidth)...)
+		} else {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// expandIndent returns line with the tabs and spaces at its start replaced by
+// spaces, using tab stops every tabWidth columns. At most maxCol columns are
+// replaced, unless maxCol < 0. If the last replaced tab ends after maxCol, the
+// extra columns are kept as spaces.
+func expandIndent(line []byte, tabWidth, maxCol int) []byte {
+	col := 0
+	i := 0
+	for ; i < len(line) && (maxCol < 0 || col < maxCol); i++ {
+		if line[i] == ' ' {
+			col++
+		} else if line[i] == '\t' {
+			col = (col/tabWidth + 1) * tabWidth
+		} else {
+			break
+		}
+	}
+	return append(bytes.Repeat([]byte{' '}, col), line[i:]...)
+}
+
+// FixIndentation fixes the problems reported by Lint() by replacing tabs with
+// spaces in indentation, using tab stops every tabWidth columns. Only lines
+// with problems are changed, except that a multiline string containing a
+// problem is rewritten as a whole, so that its content becomes what is seen
+// in an editor using the same tab width.
+func FixIndentation(data []byte, tabWidth int) []byte {
+	if tabWidth < 1 {
+		tabWidth = 1
+	}
+	lines := splitLintLines(data)
+
+	fixLine := make([]bool, len(lines))
+	for i, l := range lines {
+		if lintIndent(data, lines, l) < 0 {
+			continue
+		}
+		if l.mlIndent >= 0 {
+			// Fix the line opening the multiline string, and with it all lines of
+			// the string.
+			i = l.mlOpening
+		}
+		fixLine[i] = true
+	}
+
+	var out bytes.Buffer
+	// The number of columns preceding ''' on fixed lines opening a multiline
+	// string.
+	mlCols := make(map[int]int)
+	for i, l := range lines {
+		line := data[l.start:l.end]
+		switch {
+		case l.mlIndent >= 0:
+			if fixLine[l.mlOpening] {
+				line = expandIndent(line, tabWidth, mlCols[l.mlOpening])
+			}
+		case fixLine[i]:
+			if start := lineMLStart(lines, i); start >= 0 {
+				prefix := expandTabs(data[l.start:start], tabWidth)
+				mlCols[i] = len(prefix)
+				line = append(prefix, data[start:l.end]...)
+			} else {
+				line = expandIndent(line, tabWidth, -1)
+			}
+		}
+		out.Write(line)
+		if l.end < len(data) {
+			out.WriteByte('\n')
+		}
+	}
+	return out.Bytes()
+}
+
+// lineMLStart returns the offset of the triple quotes on line i opening a
+// multiline string, or -1 if line i does not open a multiline string.
+func lineMLStart(lines []lintLine, i int) int {
+	if i+1 < len(lines) && lines[i+1].mlIndent >= 0 && lines[i+1].mlOpening == i {
+		return lines[i].start + lines[i+1].mlIndent
+	}
+	return -1
+}
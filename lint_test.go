@@ -0,0 +1,154 @@
+package hjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLintMixedIndent(t *testing.T) {
+	txt := "{\n" +
+		"  a: 1\n" +
+		"\t b: 2\n" +
+		"  c:\n" +
+		"    '''\n" +
+		"    one\n" +
+		"  \t  two\n" +
+		"    \tthree\n" +
+		"    '''\n" +
+		"}\n"
+
+	expected := []Diagnostic{
+		{Line: 3, Column: 2, Message: "Mixed tabs and spaces in indentation", Rule: lintMixedIndent, Code: "HJ030"},
+		{Line: 7, Column: 3, Message: "Mixed tabs and spaces in the indentation of a multiline string (this changes the content of the string)", Rule: lintMixedIndent, Code: "HJ031"},
+	}
+	diags := Lint([]byte(txt))
+	if !reflect.DeepEqual(diags, expected) {
+		t.Errorf("Expected:\n%#v\nGot:\n%#v", expected, diags)
+	}
+
+	fixed := FixIndentation([]byte(txt), 4)
+	expectedFix := "{\n" +
+		"  a: 1\n" +
+		"     b: 2\n" +
+		"  c:\n" +
+		"    '''\n" +
+		"    one\n" +
+		"      two\n" +
+		"    \tthree\n" +
+		"    '''\n" +
+		"}\n"
+	if string(fixed) != expectedFix {
+		t.Errorf("Expected:\n%q\nGot:\n%q", expectedFix, fixed)
+	}
+	if diags := Lint(fixed); len(diags) != 0 {
+		t.Errorf("Expected no diagnostics after fix, got %v", diags)
+	}
+
+	var v map[string]interface{}
+	if err := Unmarshal(fixed, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v["c"] != "one\n  two\n\tthree" {
+		t.Errorf("Unexpected multiline string %q", v["c"])
+	}
+}
+
+func TestLintFixture(t *testing.T) {
+	// Tabs after the indentation of a multiline string are part of its content.
+	if diags := Lint(getContent("assets/mltabs_result.hjson")); len(diags) != 0 {
+		t.Errorf("Expected no diagnostics, got %v", diags)
+	}
+}
+
+func TestLintDuplicateKey(t *testing.T) {
+	txt := `a: 1
+b: {
+  c: 1
+  c: 2
+}
+a: 3
+`
+	expected := []Diagnostic{
+		{Line: 4, Column: 3, Message: "Key 'c' is defined again, the value on line 3 is ignored", Rule: lintDuplicateKey, Code: "HJ001"},
+		{Line: 6, Column: 1, Message: "Key 'a' is defined again, the value on line 1 is ignored", Rule: lintDuplicateKey, Code: "HJ001"},
+	}
+	diags := Lint([]byte(txt))
+	if !reflect.DeepEqual(diags, expected) {
+		t.Errorf("Expected:\n%#v\nGot:\n%#v", expected, diags)
+	}
+}
+
+func TestLintSimilarKey(t *testing.T) {
+	txt := `timeout: 5
+retry: 3
+server: {
+  host: a
+  Host: b
+}
+timeOut: 10
+retrys: 4
+id: 1
+ip: 2
+port: 80
+sort: true
+user1: a
+user2: b
+# hjson-lint: disable=similar-key
+Retry: 5
+`
+	expected := []Diagnostic{
+		{Line: 5, Column: 3, Message: "Key 'Host' is very similar to 'host' on line 4 (check for a typo)", Rule: lintSimilarKey, Code: CodeOf(MsgSimilarKey)},
+		{Line: 7, Column: 1, Message: "Key 'timeOut' is very similar to 'timeout' on line 1 (check for a typo)", Rule: lintSimilarKey, Code: CodeOf(MsgSimilarKey)},
+		{Line: 8, Column: 1, Message: "Key 'retrys' is very similar to 'retry' on line 2 (check for a typo)", Rule: lintSimilarKey, Code: CodeOf(MsgSimilarKey)},
+	}
+	diags := Lint([]byte(txt))
+	if !reflect.DeepEqual(diags, expected) {
+		t.Errorf("Expected:\n%#v\nGot:\n%#v", expected, diags)
+	}
+
+	for keys, exp := range map[[2]string]bool{
+		{"color", "colour"}:   true,
+		{"width", "widht"}:    false,
+		{"width", "wXdth"}:    true,
+		{"abc", "ABC"}:        true,
+		{"abc", "abd"}:        false,
+		{"name", "names"}:     false,
+		{"port", "sort"}:      false,
+		{"user1", "user2"}:    false,
+		{"user1", "user12"}:   false,
+		{"items", "items2"}:   false,
+		{"server", "server1"}: false,
+	} {
+		if got := similarKeys(keys[0], keys[1]); got != exp {
+			t.Errorf("similarKeys(%q, %q): expected %v, got %v", keys[0], keys[1], exp, got)
+		}
+	}
+}
+
+func TestLintSuppression(t *testing.T) {
+	testCases := []struct {
+		txt   string
+		lines []int
+	}{
+		{"a: 1\n# hjson-lint: disable=duplicate-key\na: 2\na: 3\n", []int{4}},
+		{"a: 1\n// hjson-lint: disable=HJ001\na: 2\n", nil},
+		{"a: 1\n/* hjson-lint: disable */\na: 2\n", nil},
+		{"a: 1\n# hjson-lint: disable=mixed-indent\na: 2\n", []int{3}},
+		{"a: 1\na: 2 # hjson-lint: disable\na: 3\n", []int{2, 3}},
+		{"# hjson-lint: disable=duplicate-key\na: 1\na: 2\n", []int{3}},
+		{"# hjson-lint: disable-block=duplicate-key\na: 1\na: 2\na: 3\n", nil},
+		{"{\n  # hjson-lint: disable=duplicate-key\n  b: {\n    c: 1\n    c: 2\n  }\n  d: {\n    c: 1\n    c: 2\n  }\n}\n", []int{9}},
+		{"{\n  b: {\n    # hjson-lint: disable-block\n    c: 1\n    c: 2\n  }\n  b: 2\n}\n", []int{7}},
+		{"[\n  1\n  # hjson-lint: disable=mixed-indent\n \t 2\n \t 3\n]\n", []int{5}},
+		{"{\"a\": 1,\n# hjson-lint: disable\n\"a\": 2, \"a\": 3}\n", []int{3}},
+	}
+	for _, tc := range testCases {
+		var lines []int
+		for _, d := range Lint([]byte(tc.txt)) {
+			lines = append(lines, d.Line)
+		}
+		if !reflect.DeepEqual(lines, tc.lines) {
+			t.Errorf("Input:\n%s\nExpected diagnostics on lines %v, got %v", tc.txt, tc.lines, lines)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package hjson
+
+import (
+	"reflect"
+)
+
+// Literal recognizes a custom kind of quoteless value, like hexadecimal
+// numbers, dates or network addresses, and writes such values again. Set
+// DecoderOptions.Literals and EncoderOptions.Literals to use it.
+type Literal interface {
+	// ParseLiteral is called with the text of a quoteless value that is not a
+	// keyword (true, false or null) or a number. It returns the value
+	// represented by text and true, or false if text is not recognized.
+	ParseLiteral(text string) (interface{}, bool)
+	// FormatLiteral returns the text of v and true if v is a value written by
+	// this Literal, otherwise false. The text should be recognized by
+	// ParseLiteral().
+	FormatLiteral(v interface{}) (string, bool)
+}
+
+// parseLiteral returns the value of text recognized by any of p.Literals, or
+// nil if the value is assigned after json.Unmarshal(). t is the type of the
+// destination, after unravelDestination(). If textDest is true the
+// destination implements encoding.TextUnmarshaler, and only values that can
+// be assigned to it are used.
+func (p *hjsonParser) parseLiteral(text string, t reflect.Type, textDest bool) (interface{}, bool) {
+	for _, lit := range p.Literals {
+		v, ok := lit.ParseLiteral(text)
+		if !ok {
+			continue
+		}
+		assignable := v != nil && t != nil && reflect.TypeOf(v).AssignableTo(t)
+		if textDest && !assignable {
+			continue
+		}
+		if p.willMarshalToJSON && (t == nil || t.Kind() == reflect.Interface || assignable) {
+			// Assign v as it is, instead of converting it to JSON and back.
+			p.fixups = append(p.fixups, destFixup{
+				path:  append([]interface{}(nil), p.path...),
+				value: v,
+				// t is also nil if there is no destination for the value, for
+				// example an unknown struct field.
+				ignorable: t == nil,
+			})
+			return nil, true
+		}
+		return v, true
+	}
+	return nil, false
+}
+
+// writeLiteral writes value using the first of e.Literals that handles it.
+// Returns false if no Literal handles value.
+func (e *hjsonEncoder) writeLiteral(value reflect.Value, separator string) bool {
+	if !value.CanInterface() {
+		return false
+	}
+	v := value.Interface()
+	for _, lit := range e.Literals {
+		text, ok := lit.FormatLiteral(v)
+		if !ok {
+			continue
+		}
+		l, r := "", ""
+		if e.EnableColor {
+			l, r = e.ColorStyle.String[0], e.ColorStyle.String[1]
+		}
+		if text == "" || needsQuotes.MatchString(text) {
+			text = `"` + e.quoteReplace(text) + `"`
+		}
+		e.WriteString(separator + l + text + r)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package hjson
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type hexNumber int64
+
+type hexLiteral struct{}
+
+func (hexLiteral) ParseLiteral(text string) (interface{}, bool) {
+	if !strings.HasPrefix(text, "0x") {
+		return nil, false
+	}
+	n, err := strconv.ParseInt(text[2:], 16, 64)
+	if err != nil {
+		return nil, false
+	}
+	return hexNumber(n), true
+}
+
+func (hexLiteral) FormatLiteral(v interface{}) (string, bool) {
+	if n, ok := v.(hexNumber); ok {
+		return fmt.Sprintf("0x%X", int64(n)), true
+	}
+	return "", false
+}
+
+type dateLiteral struct{}
+
+func (dateLiteral) ParseLiteral(text string) (interface{}, bool) {
+	d, err := time.Parse("2006-01-02", text)
+	return d, err == nil
+}
+
+func (dateLiteral) FormatLiteral(v interface{}) (string, bool) {
+	d, ok := v.(time.Time)
+	if !ok || !d.Equal(d.Truncate(24*time.Hour)) {
+		return "", false
+	}
+	return d.Format("2006-01-02"), true
+}
+
+func TestLiterals(t *testing.T) {
+	decOpt := DefaultDecoderOptions()
+	decOpt.Literals = []Literal{hexLiteral{}, dateLiteral{}}
+	day := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	var v interface{}
+	err := UnmarshalWithOptions([]byte(`{
+  mask: 0x1F
+  day: 2023-05-01
+  list: [0xFF, 0x10, 2023-05-02]
+  text: 0xZZ
+  num: 12
+}`), &v, decOpt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"mask": hexNumber(0x1F),
+		"day":  day,
+		"list": []interface{}{hexNumber(0xFF), hexNumber(0x10), day.AddDate(0, 0, 1)},
+		"text": "0xZZ",
+		"num":  12.0,
+	}
+	if !reflect.DeepEqual(v, expected) {
+		t.Errorf("Expected:\n%#v\nGot:\n%#v", expected, v)
+	}
+
+	type Config struct {
+		Mask  int
+		Day   time.Time
+		Name  string
+		Flags []hexNumber
+	}
+	var c Config
+	err = UnmarshalWithOptions([]byte(`
+Mask: 0xFF
+Day: 2023-05-01
+Name: 0x10
+Flags: [0x1, 0x2]
+`), &c, decOpt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedConfig := Config{Mask: 255, Day: day, Name: "0x10", Flags: []hexNumber{1, 2}}
+	if !reflect.DeepEqual(c, expectedConfig) {
+		t.Errorf("Expected:\n%#v\nGot:\n%#v", expectedConfig, c)
+	}
+
+	var node Node
+	if err = UnmarshalWithOptions([]byte(`a: 0x20`), &node, decOpt); err != nil {
+		t.Fatal(err)
+	}
+	if a := node.NK("a"); a == nil || a.Value != hexNumber(0x20) || a.Lit != "0x20" {
+		t.Errorf("Unexpected node %#v", a)
+	}
+
+	// Without Literals the values are strings.
+	v = nil
+	if err = Unmarshal([]byte(`a: 0x20`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.(map[string]interface{})["a"] != "0x20" {
+		t.Errorf("Unexpected value %#v", v)
+	}
+
+	encOpt := DefaultOptions()
+	encOpt.Literals = decOpt.Literals
+	out, err := MarshalWithOptions(struct {
+		Mask  hexNumber
+		Day   time.Time
+		Later time.Time
+		Flags []hexNumber
+	}{0xAB, day, day.Add(time.Hour), []hexNumber{1}}, encOpt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedOut := `{
+  Mask: 0xAB
+  Day: 2023-05-01
+  Later: 2023-05-01T01:00:00Z
+  Flags: [
+    0x1
+  ]
+}`
+	if string(out) != expectedOut {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expectedOut, out)
+	}
+
+	var back struct {
+		Mask hexNumber
+		Day  time.Time
+	}
+	if err = UnmarshalWithOptions(out, &back, decOpt); err != nil {
+		t.Fatal(err)
+	}
+	if back.Mask != 0xAB || !back.Day.Equal(day) {
+		t.Errorf("Unexpected round trip %#v", back)
+	}
+}
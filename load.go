@@ -0,0 +1,82 @@
+package hjson
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"path/filepath"
+)
+
+// LoadFile reads the Hjson file path and decodes it into the value pointed to
+// by v, using the first of opts, or DefaultDecoderOptions() if opts is empty.
+// A UTF-8 byte order mark at the start of the file is skipped.
+//
+// If DecoderOptions.Include is set, the names in the $include directives of
+// the file are relative to the directory of path, so the resolver is
+// typically IncludeDir(filepath.Dir(path)).
+func LoadFile(path string, v interface{}, opts ...DecoderOptions) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return load(data, filepath.Base(path), v, opts)
+}
+
+// LoadURL fetches the Hjson document at url with an HTTP GET request and
+// decodes it into the value pointed to by v, like LoadFile(). The request is
+// made with http.DefaultClient and is canceled when ctx is done. An error is
+// returned if the response status is not 200 OK.
+//
+// If DecoderOptions.MaxBytes is set, no more than MaxBytes+1 bytes of the
+// response are read, so that a larger document results in a *LimitError
+// without being downloaded completely.
+func LoadURL(ctx context.Context, url string, v interface{}, opts ...DecoderOptions) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("hjson: GET %s: %s", url, resp.Status)
+	}
+
+	var r io.Reader = resp.Body
+	max := 0
+	if len(opts) > 0 {
+		max = opts[0].MaxBytes
+	}
+	if max > 0 {
+		r = io.LimitReader(r, int64(max)+1)
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if max > 0 && len(data) > max {
+		// The document was cut, it must not be decoded.
+		return &LimitError{Limit: "MaxBytes", Max: max, Offset: max}
+	}
+	name := path.Base(req.URL.Path)
+	if name == "." || name == "/" {
+		name = ""
+	}
+	return load(data, name, v, opts)
+}
+
+// load decodes the content of the file name.
+func load(data []byte, name string, v interface{}, opts []DecoderOptions) error {
+	options := DefaultDecoderOptions()
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	options.includeName = name
+	return UnmarshalWithOptions(bytes.TrimPrefix(data, utf8BOM), v, options)
+}
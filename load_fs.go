@@ -0,0 +1,17 @@
+//go:build go1.16
+
+package hjson
+
+import "io/fs"
+
+// LoadFS reads the Hjson file name from fsys and decodes it into the value
+// pointed to by v, like LoadFile(). If DecoderOptions.Include is set, the
+// names in the $include directives of the file are relative to its directory
+// in fsys, so the resolver is typically IncludeFS(fsys).
+func LoadFS(fsys fs.FS, name string, v interface{}, opts ...DecoderOptions) error {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return err
+	}
+	return load(data, name, v, opts)
+}
@@ -0,0 +1,24 @@
+//go:build go1.16
+
+package hjson
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"conf/app.hjson": {Data: []byte("\xef\xbb\xbfname: app\ndb: {\n  $include: db.hjson\n}\n")},
+		"conf/db.hjson":  {Data: []byte("host: localhost\n")},
+	}
+	options := DefaultDecoderOptions()
+	options.Include = IncludeFS(fsys)
+	var c loadConfig
+	if err := LoadFS(fsys, "conf/app.hjson", &c, options); err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "app" || c.DB.Host != "localhost" {
+		t.Errorf("Unexpected result %+v", c)
+	}
+}
@@ -0,0 +1,96 @@
+package hjson
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type loadConfig struct {
+	Name string
+	Port int
+	DB   struct {
+		Host string
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hjson-load")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"app.hjson": "\xef\xbb\xbfname: app\nport: 8080\ndb: {\n  $include: db.hjson\n}\n",
+		"db.hjson":  "host: localhost\n",
+	}
+	for name, content := range files {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path := filepath.Join(dir, "app.hjson")
+	options := DefaultDecoderOptions()
+	options.Include = IncludeDir(dir)
+	var c loadConfig
+	if err = LoadFile(path, &c, options); err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "app" || c.Port != 8080 || c.DB.Host != "localhost" {
+		t.Errorf("Unexpected result %+v", c)
+	}
+
+	// Without options, $include is an ordinary key.
+	options.DisallowUnknownFields = true
+	options.Include = nil
+	if err = LoadFile(path, &c, options); err == nil || !strings.Contains(err.Error(), "$include") {
+		t.Errorf("Expected an error about the unknown field $include, got %v", err)
+	}
+
+	if err = LoadFile(filepath.Join(dir, "missing.hjson"), &c); !os.IsNotExist(err) {
+		t.Errorf("Expected a not exist error, got %v", err)
+	}
+}
+
+func TestLoadURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/conf/app.hjson" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("\xef\xbb\xbfname: app\nport: 8080\n"))
+	}))
+	defer server.Close()
+
+	var c loadConfig
+	if err := LoadURL(context.Background(), server.URL+"/conf/app.hjson", &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "app" || c.Port != 8080 {
+		t.Errorf("Unexpected result %+v", c)
+	}
+
+	err := LoadURL(context.Background(), server.URL+"/missing.hjson", &c)
+	if err == nil || !strings.Contains(err.Error(), "404 Not Found") {
+		t.Errorf("Expected a status error, got %v", err)
+	}
+
+	options := DefaultDecoderOptions()
+	options.MaxBytes = 10
+	err = LoadURL(context.Background(), server.URL+"/conf/app.hjson", &c, options)
+	if _, ok := err.(*LimitError); !ok {
+		t.Errorf("Expected a *LimitError, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err = LoadURL(ctx, server.URL+"/conf/app.hjson", &c); err == nil {
+		t.Error("Expected an error for the canceled context")
+	}
+}
@@ -0,0 +1,57 @@
+package hjson
+
+import (
+	"encoding"
+	"errors"
+	"reflect"
+	"strconv"
+)
+
+// parseMapKey calls MapKeyParser for key, the text of an object key decoded
+// into a map with keys of type t found at offset. Because the map is filled
+// by json.Unmarshal() it returns the text that json.Unmarshal() decodes into
+// the key returned by MapKeyParser.
+func (p *hjsonParser) parseMapKey(t reflect.Type, key string, offset int) (string, error) {
+	if p.MapKeyParser == nil || t == reflect.TypeOf("") {
+		return key, nil
+	}
+	parsed, err := p.MapKeyParser(t, key)
+	if err != nil {
+		return "", p.errAtOffset(offset, MsgBadMapKey, key, t, err.Error())
+	}
+	if parsed == nil {
+		return key, nil
+	}
+	text, err := mapKeyText(t, reflect.ValueOf(parsed))
+	if err != nil {
+		return "", p.errAtOffset(offset, MsgBadMapKey, key, t, err.Error())
+	}
+	return text, nil
+}
+
+// mapKeyText returns the text of the map key v that json.Unmarshal() decodes
+// into a key of type t equal to v.
+func mapKeyText(t reflect.Type, v reflect.Value) (string, error) {
+	if !v.Type().ConvertibleTo(t) {
+		return "", errors.New("MapKeyParser returned a " + v.Type().String())
+	}
+	v = v.Convert(t)
+	// json.Unmarshal() prefers encoding.TextUnmarshaler, like Marshal() does.
+	if reflect.PtrTo(t).Implements(unmarshalerText) {
+		if !t.Implements(marshalerText) {
+			return "", errors.New("the key type implements encoding.TextUnmarshaler but not encoding.TextMarshaler")
+		}
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	}
+	return "", errors.New("the key type is not supported")
+}
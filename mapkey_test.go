@@ -0,0 +1,96 @@
+package hjson
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+)
+
+type testLevel int
+
+type testColor string
+
+func TestMapKeyParser(t *testing.T) {
+	options := DefaultDecoderOptions()
+	options.MapKeyParser = func(keyType reflect.Type, key string) (interface{}, error) {
+		switch keyType {
+		case reflect.TypeOf(testLevel(0)):
+			for i, name := range []string{"debug", "info", "warn"} {
+				if key == name {
+					return testLevel(i), nil
+				}
+			}
+			return nil, errors.New("unknown level")
+		case reflect.TypeOf(testColor("")):
+			return testColor("#" + key), nil
+		case reflect.TypeOf(ipKey("")):
+			if key == "localhost" {
+				return ipKey("127.0.0.1"), nil
+			}
+		}
+		return nil, nil
+	}
+
+	var v struct {
+		Levels map[testLevel]string
+		Colors map[testColor]int
+		Nums   map[int]bool
+	}
+	txt := []byte(`{
+  Levels: {
+    info: a
+    warn: b
+  }
+  Colors: {
+    fff: 1
+  }
+  Nums: {
+    7: true
+  }
+}`)
+	if err := UnmarshalWithOptions(txt, &v, options); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(v.Levels, map[testLevel]string{1: "a", 2: "b"}) ||
+		!reflect.DeepEqual(v.Colors, map[testColor]int{"#fff": 1}) ||
+		!reflect.DeepEqual(v.Nums, map[int]bool{7: true}) {
+		t.Errorf("Unexpected result: %#v", v)
+	}
+
+	var peers map[string]int
+	if err := UnmarshalWithOptions([]byte("{localhost: 1}"), &peers, options); err != nil ||
+		peers["localhost"] != 1 {
+		t.Errorf("Unexpected result: %v %v", peers, err)
+	}
+
+	var byIP map[ipKey]int
+	if err := UnmarshalWithOptions([]byte("{localhost: 1, \"10.0.0.1\": 2}"), &byIP, options); err != nil {
+		t.Fatal(err)
+	}
+	if byIP["127.0.0.1"] != 1 || byIP["10.0.0.1"] != 2 {
+		t.Errorf("Unexpected result: %v", byIP)
+	}
+
+	var levels map[testLevel]int
+	err := UnmarshalWithOptions([]byte("{\n  info: 1\n  fatal: 2\n}"), &levels, options)
+	if pe, ok := err.(*ParseError); !ok || pe.Kind != MsgBadMapKey || pe.Line != 3 {
+		t.Errorf("Expected %s on line 3, got %#v", MsgBadMapKey, err)
+	}
+}
+
+// ipKey is a map key type decoded with encoding.TextUnmarshaler.
+type ipKey string
+
+func (k ipKey) MarshalText() ([]byte, error) {
+	return []byte(k), nil
+}
+
+func (k *ipKey) UnmarshalText(text []byte) error {
+	ip := net.ParseIP(string(text))
+	if ip == nil {
+		return errors.New("bad IP address " + string(text))
+	}
+	*k = ipKey(ip.String())
+	return nil
+}
@@ -0,0 +1,284 @@
+package hjson
+
+import "strconv"
+
+// MergeStrategy selects how Merge() combines a value of the base document
+// with the value at the same path in an overlay.
+type MergeStrategy int
+
+const (
+	// MergeDeep merges objects member by member, recursively. Arrays and all
+	// other values of the overlay replace the values of the base.
+	MergeDeep MergeStrategy = iota
+	// MergeReplace replaces the value of the base with the value of the
+	// overlay, also if both are objects.
+	MergeReplace
+	// MergeAppend appends the elements of an array of the overlay to the
+	// array of the base.
+	MergeAppend
+	// MergeByKey merges the objects of an array of the overlay with the
+	// objects of the array of the base that have the same value of the key
+	// member (see MergeOptions.Keys), and appends the others.
+	MergeByKey
+)
+
+// MergeOptions configures MergeWithOptions().
+type MergeOptions struct {
+	// Strategies maps paths to the strategy used for the values at those
+	// paths, MergeDeep if a path is not found. Paths are dot-separated lists
+	// of object keys, without array indexes, so "servers.tags" is the path of
+	// the member tags of all objects in the array servers. The path of the
+	// root value is "".
+	Strategies map[string]MergeStrategy
+	// Keys maps the paths of arrays merged with MergeByKey to the name of the
+	// member identifying their objects, "name" if a path is not found.
+	Keys map[string]string
+}
+
+// Merge merges the overlays into the Hjson or JSON document base, in order,
+// and returns the result. Objects are merged recursively and all other values
+// of the overlays replace those of base, see MergeWithOptions() to choose
+// other strategies. This is meant for layered config files, like defaults
+// overridden by the config of an environment.
+func Merge(base []byte, overlays ...[]byte) ([]byte, error) {
+	return MergeWithOptions(MergeOptions{}, base, overlays...)
+}
+
+// MergeWithOptions is like Merge(), using the strategies in options for the
+// values at the paths in options.Strategies.
+//
+// The result is built by editing base like Document.Set(), so the comments and
+// the formatting of all values of base that are not changed are kept. New
+// members are added after the other members of their object. null in an
+// overlay replaces the value of base like any other value.
+func MergeWithOptions(options MergeOptions, base []byte, overlays ...[]byte) ([]byte, error) {
+	doc, err := ParseDocument(base)
+	if err != nil {
+		return nil, err
+	}
+	for _, overlay := range overlays {
+		o, err := ParseNode(overlay)
+		if err != nil {
+			return nil, err
+		}
+		if err = doc.merge(options, nil, "", o); err != nil {
+			return nil, err
+		}
+	}
+	return doc.Bytes(), nil
+}
+
+// merge merges overlay into the value referenced by tokens, with the merge
+// path path.
+func (d *Document) merge(options MergeOptions, tokens []string, path string, overlay *Node) error {
+	target, err := resolvePointer(d.root, tokens, formatPointer)
+	if err != nil {
+		return err
+	}
+	child := func(token string) []string {
+		return append(tokens[:len(tokens):len(tokens)], token)
+	}
+
+	strategy := options.Strategies[path]
+	switch ov := overlay.Value.(type) {
+	case *OrderedMap:
+		if _, ok := target.Value.(*OrderedMap); !ok || strategy == MergeReplace {
+			break
+		}
+		for _, key := range ov.Keys {
+			value, _ := ov.Map[key].(*Node)
+			if pointerChild(target, key) == nil {
+				err = d.set(child(key), plainValue(value), formatPointer)
+			} else {
+				err = d.merge(options, child(key), mergePath(path, key), value)
+			}
+			if err != nil {
+				return err
+			}
+			if target, err = resolvePointer(d.root, tokens, formatPointer); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case []interface{}:
+		if _, ok := target.Value.([]interface{}); !ok {
+			break
+		}
+		switch strategy {
+		case MergeAppend:
+			for _, elem := range ov {
+				value, _ := elem.(*Node)
+				if err = d.set(child("-"), plainValue(value), formatPointer); err != nil {
+					return err
+				}
+			}
+			return nil
+		case MergeByKey:
+			key, ok := options.Keys[path]
+			if !ok {
+				key = "name"
+			}
+			for _, elem := range ov {
+				value, _ := elem.(*Node)
+				if target, err = resolvePointer(d.root, tokens, formatPointer); err != nil {
+					return err
+				}
+				if i := indexByKey(target, key, value); i >= 0 {
+					err = d.merge(options, child(strconv.Itoa(i)), path, value)
+				} else {
+					err = d.set(child("-"), plainValue(value), formatPointer)
+				}
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	value := plainValue(overlay)
+	if equal, _ := jsonEqual(plainValue(target), value); equal {
+		// Keep the text of the base.
+		return nil
+	}
+	return d.set(tokens, value, formatPointer)
+}
+
+// mergePath returns the merge path of the member key of the value at path.
+func mergePath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// indexByKey returns the index of the first object in the array arr with the
+// same value of the member key as the object value, or -1.
+func indexByKey(arr *Node, key string, value *Node) int {
+	want := pointerChild(value, key)
+	if _, ok := value.Value.(*OrderedMap); !ok || want == nil {
+		return -1
+	}
+	for i, elem := range arr.Value.([]interface{}) {
+		n, _ := elem.(*Node)
+		if _, ok := n.Value.(*OrderedMap); !ok {
+			continue
+		}
+		if got := pointerChild(n, key); got != nil {
+			if equal, _ := jsonEqual(plainValue(got), plainValue(want)); equal {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// MergePatch applies the JSON Merge Patch (RFC 7386) patch to the document
+// target and returns the result. Both documents can be Hjson or JSON.
+//
+// Members of patch set to null are removed from target, objects are merged
+// recursively and all other values replace the values in target. If patch is
+// not an object it replaces the whole document. The result is built by
+// editing target like Document.Set(), so the comments and the formatting of
+// all members that are not changed by patch are kept. New members are added
+// after the other members of their object.
+func MergePatch(target, patch []byte) ([]byte, error) {
+	p, err := ParseNode(patch)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := ParseDocument(target)
+	if err != nil {
+		return nil, err
+	}
+	if err = doc.mergePatch(nil, p); err != nil {
+		return nil, err
+	}
+	return doc.Bytes(), nil
+}
+
+// mergePatch merges patch into the value referenced by tokens.
+func (d *Document) mergePatch(tokens []string, patch *Node) error {
+	pom, ok := patch.Value.(*OrderedMap)
+	if !ok {
+		return d.set(tokens, mergedValue(patch), formatPointer)
+	}
+	target, err := resolvePointer(d.root, tokens, formatPointer)
+	if err != nil {
+		return err
+	}
+	tom, ok := target.Value.(*OrderedMap)
+	if !ok {
+		return d.set(tokens, mergedValue(patch), formatPointer)
+	}
+
+	for _, key := range pom.Keys {
+		child := append(tokens[:len(tokens):len(tokens)], key)
+		value, _ := pom.Map[key].(*Node)
+		_, found := tom.Map[key]
+		switch {
+		case value.Value == nil:
+			if found {
+				err = d.remove(child, formatPointer)
+			}
+		case found:
+			err = d.mergePatch(child, value)
+		default:
+			err = d.set(child, mergedValue(value), formatPointer)
+		}
+		if err != nil {
+			return err
+		}
+		// The edits can replace the Nodes of the target.
+		if target, err = resolvePointer(d.root, tokens, formatPointer); err != nil {
+			return err
+		}
+		tom, _ = target.Value.(*OrderedMap)
+	}
+	return nil
+}
+
+// mergedValue returns the value of the patch node without Nodes, with the
+// null members of its objects removed, like when merging it into an empty
+// document.
+func mergedValue(patch *Node) interface{} {
+	switch v := patch.Value.(type) {
+	case *OrderedMap:
+		om := NewOrderedMap()
+		for _, key := range v.Keys {
+			if n, _ := v.Map[key].(*Node); n.Value != nil {
+				om.Set(key, mergedValue(n))
+			}
+		}
+		return om
+	case []interface{}:
+		arr := make([]interface{}, len(v))
+		for i, elem := range v {
+			n, _ := elem.(*Node)
+			arr[i] = plainValue(n)
+		}
+		return arr
+	}
+	return patch.Value
+}
+
+// plainValue returns the value of n without Nodes.
+func plainValue(n *Node) interface{} {
+	switch v := n.Value.(type) {
+	case *OrderedMap:
+		om := NewOrderedMap()
+		for _, key := range v.Keys {
+			elem, _ := v.Map[key].(*Node)
+			om.Set(key, plainValue(elem))
+		}
+		return om
+	case []interface{}:
+		arr := make([]interface{}, len(v))
+		for i, elem := range v {
+			n, _ := elem.(*Node)
+			arr[i] = plainValue(n)
+		}
+		return arr
+	}
+	return n.Value
+}
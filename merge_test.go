@@ -0,0 +1,208 @@
+package hjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+	target := `# Blog post.
+{
+  title: Goodbye!
+  author: {
+    givenName: John # First name.
+    familyName: Doe
+  }
+  // Labels.
+  tags: ["example", "sample"]
+  content: This will be unchanged
+}
+`
+	patch := `{
+  "title": "Hello!",
+  "phoneNumber": "+01-123-456-7890",
+  "author": {
+    "familyName": null
+  },
+  "tags": ["example"]
+}`
+	expected := `# Blog post.
+{
+  title: Hello!
+  author: {
+    givenName: John # First name.
+  }
+  // Labels.
+  tags: [
+    example
+  ]
+  content: This will be unchanged
+  phoneNumber: +01-123-456-7890
+}
+`
+	out, err := MergePatch([]byte(target), []byte(patch))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, out)
+	}
+
+	// The test cases of RFC 7386, appendix A.
+	testCases := []struct {
+		target, patch string
+		expected      interface{}
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, map[string]interface{}{"a": "c"}},
+		{`{"a":"b"}`, `{"b":"c"}`, map[string]interface{}{"a": "b", "b": "c"}},
+		{`{"a":"b"}`, `{"a":null}`, map[string]interface{}{}},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, map[string]interface{}{"b": "c"}},
+		{`{"a":["b"]}`, `{"a":"c"}`, map[string]interface{}{"a": "c"}},
+		{`{"a":"c"}`, `{"a":["b"]}`, map[string]interface{}{"a": []interface{}{"b"}}},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`,
+			map[string]interface{}{"a": map[string]interface{}{"b": "d"}}},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, map[string]interface{}{"a": []interface{}{1.0}}},
+		{`["a","b"]`, `["c","d"]`, []interface{}{"c", "d"}},
+		{`{"a":"b"}`, `["c"]`, []interface{}{"c"}},
+		{`{"a":"foo"}`, `null`, nil},
+		{`{"a":"foo"}`, `"bar"`, "bar"},
+		{`{"e":null}`, `{"a":1}`, map[string]interface{}{"e": nil, "a": 1.0}},
+		{`[1,2]`, `{"a":"b","c":null}`, map[string]interface{}{"a": "b"}},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`,
+			map[string]interface{}{"a": map[string]interface{}{"bb": map[string]interface{}{}}}},
+	}
+	for _, tc := range testCases {
+		out, err := MergePatch([]byte(tc.target), []byte(tc.patch))
+		if err != nil {
+			t.Errorf("%s %s: %v", tc.target, tc.patch, err)
+			continue
+		}
+		var got interface{}
+		if err = Unmarshal(out, &got); err != nil {
+			t.Errorf("%s %s: %v\n%s", tc.target, tc.patch, err, out)
+		} else if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("%s %s: expected %#v, got %#v", tc.target, tc.patch, tc.expected, got)
+		}
+	}
+
+	if _, err = MergePatch([]byte("{"), []byte("{}")); err == nil {
+		t.Error("Expected an error for an invalid target")
+	}
+	if _, err = MergePatch([]byte("{}"), []byte("[")); err == nil {
+		t.Error("Expected an error for an invalid patch")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	base := `# Defaults.
+name: api
+timeout: 1.50 # Seconds.
+db: {
+  host: localhost
+  port: 5432
+}
+tags: ["a"]
+servers: [
+  {
+    name: one
+    port: 80
+  }
+]
+plugins: [
+  {
+    name: auth
+    order: 1
+  }
+]
+`
+	overlay := `{
+  timeout: 1.5
+  db: {
+    host: db.example.com
+    user: admin
+  }
+  tags: ["b"]
+  servers: [
+    {
+      name: two
+    }
+  ]
+  plugins: [
+    {
+      name: auth
+      order: 2
+    }
+    {
+      name: cache
+    }
+  ]
+}`
+	expected := `# Defaults.
+name: api
+timeout: 1.50 # Seconds.
+db: {
+  host: db.example.com
+  port: 5432
+  user: admin
+}
+tags: ["a", "b"]
+servers: [
+  {
+    name: two
+  }
+]
+plugins: [
+  {
+    name: auth
+    order: 2
+  }
+  {
+    name: cache
+  }
+]
+`
+	options := MergeOptions{Strategies: map[string]MergeStrategy{
+		"tags":    MergeAppend,
+		"plugins": MergeByKey,
+	}}
+	out, err := MergeWithOptions(options, []byte(base), []byte(overlay))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, out)
+	}
+
+	out, err = Merge([]byte("a: {\n  b: 1\n  c: 2\n}\nd: [1]\n"), []byte("a: {\n  b: 3\n}"),
+		[]byte("{\"d\": [2], \"e\": null}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "a: {\n  b: 3\n  c: 2\n}\nd: [\n  2\n]\ne: null\n" {
+		t.Errorf("Unexpected result:\n%s", out)
+	}
+
+	options = MergeOptions{Strategies: map[string]MergeStrategy{"a": MergeReplace},
+		Keys: map[string]string{"list": "id"}}
+	options.Strategies["list"] = MergeByKey
+	out, err = MergeWithOptions(options, []byte("a: {\n  b: 1\n}\nlist: [\n  {\n    id: 1\n    v: x\n  }\n]\n"),
+		[]byte("a: {\n  c: 2\n}\nlist: [\n  {\n    id: 1\n    v: y\n  }\n]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got interface{}
+	if err = Unmarshal(out, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"a":    map[string]interface{}{"c": 2.0},
+		"list": []interface{}{map[string]interface{}{"id": 1.0, "v": "y"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %#v, got %#v", want, got)
+	}
+
+	if _, err = Merge([]byte("a: 1"), []byte("{")); err == nil {
+		t.Error("Expected an error for an invalid overlay")
+	}
+}
@@ -0,0 +1,253 @@
+package hjson
+
+import (
+	"fmt"
+)
+
+// Identifiers of the messages of errors and diagnostics, passed to
+// Messages.Message(). The arguments passed with each identifier are listed
+// after it. The identifiers are stable and can be used for programmatic
+// handling of errors, see ParseError.Kind and Diagnostic.Rule. Each identifier
+// also has a stable code, see Codes().
+const (
+	MsgBadComplex           = "bad-complex"            // reason (string)
+	MsgBadUnicodeEscape     = "bad-unicode-escape"     // found character (string)
+	MsgBadEscape            = "bad-escape"             // escaped character (string)
+	MsgNewlineInString      = "newline-in-string"      //
+	MsgUnterminatedString   = "unterminated-string"    //
+	MsgUnterminatedMLString = "unterminated-ml-string" //
+	MsgEmptyKey             = "empty-key"              //
+	MsgWhitespaceInKey      = "whitespace-in-key"      //
+	MsgEOFInKey             = "eof-in-key"             //
+	MsgPunctuatorInKey      = "punctuator-in-key"      // found character (string)
+	MsgPunctuatorInValue    = "punctuator-in-value"    // found character (string)
+	MsgNumberOutOfRange     = "number-out-of-range"    //
+	MsgMaxDepth             = "max-depth"              // max depth (int)
+	MsgUnterminatedArray    = "unterminated-array"     //
+	MsgUnterminatedObject   = "unterminated-object"    //
+	MsgMissingColon         = "missing-colon"          // found character (string)
+	MsgDuplicateKey         = "duplicate-key"          // old value, new value, key (string)
+	MsgTrailingCharacters   = "trailing-characters"    //
+	MsgInternalError        = "internal-error"         //
+	MsgExpectedScalar       = "expected-scalar"        // found character (string)
+	MsgEOFInValue           = "eof-in-value"           //
+	MsgExpectedBoolean      = "expected-boolean"       //
+	MsgExpectedInteger      = "expected-integer"       //
+	MsgInt64OutOfRange      = "int64-out-of-range"     //
+	MsgValueInsteadOfKey    = "value-instead-of-key"   //
+	MsgUnexpectedInValue    = "unexpected-in-value"    // found character (string)
+	MsgUnexpectedInKey      = "unexpected-in-key"      // found character (string)
+	MsgUnexpectedInElement  = "unexpected-in-element"  // found character (string)
+	MsgInvalidUTF8          = "invalid-utf8"           //
+	MsgMixedIndent          = "mixed-indent"           //
+	MsgMixedIndentMultiline = "mixed-indent-multiline" //
+	MsgKeyRedefined         = "key-redefined"          // key (string), line of the previous definition (int)
+	MsgNotInDialect         = "not-in-dialect"         // syntax (string), dialect (Format)
+	MsgMissingComma         = "missing-comma"          // found character (string)
+	MsgNotFiniteNumber      = "not-finite-number"      // number (string)
+	MsgBadMapKey            = "bad-map-key"            // key (string), key type (reflect.Type), reason (string)
+	MsgBadNumber            = "bad-number"             // number (string), reason (string)
+	MsgSimilarKey           = "similar-key"            // key (string), similar key (string), line of the similar key (int)
+	MsgNotInEnum            = "not-in-enum"            // value (string), key (string), allowed values (string)
+	MsgBadDuration          = "bad-duration"           // duration (string)
+	MsgBadBytes             = "bad-bytes"              // format (string), reason (string)
+	MsgBadFieldValue        = "bad-field-value"        // value (string), key (string), decoder name (string), reason (string)
+	MsgNullIntoNonPointer   = "null-into-non-pointer"  // destination type (reflect.Type)
+	MsgMissingRequired      = "missing-required"       // paths of the missing fields (string)
+	MsgBadDefault           = "bad-default"            // default (string), key (string), reason (string)
+	MsgDecodeHook           = "decode-hook"            // value (string), destination type (reflect.Type), reason (string)
+	MsgUnknownField         = "unknown-field"          // key (string)
+	MsgExpectedArray        = "expected-array"         // found character (string)
+	MsgLimitExceeded        = "limit-exceeded"         // name of the option (string), max (int), offset (int)
+	MsgPathNotFound         = "path-not-found"         // path (string)
+)
+
+// Messages builds the human readable messages of errors and diagnostics, so
+// that applications can localize or rephrase them, see
+// DecoderOptions.Messages and LintOptions.Messages.
+type Messages interface {
+	// Message returns the message identified by id (one of the Msg constants),
+	// with args inserted.
+	Message(id string, args ...interface{}) string
+}
+
+// DefaultMessages are the English messages used if no other Messages are set.
+// Other implementations of Messages can fall back to DefaultMessages for
+// identifiers that they do not handle.
+var DefaultMessages Messages = defaultMessages{}
+
+type defaultMessages struct{}
+
+func (defaultMessages) Message(id string, args ...interface{}) string {
+	format, ok := defaultMessageFormats[id]
+	if !ok {
+		return id
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+var defaultMessageFormats = map[string]string{
+	MsgBadComplex:           "Cannot unmarshal into complex number: %s",
+	MsgBadUnicodeEscape:     "Bad \\u char %s",
+	MsgBadEscape:            "Bad escape \\%s",
+	MsgNewlineInString:      "Bad string containing newline",
+	MsgUnterminatedString:   "Bad string",
+	MsgUnterminatedMLString: "Bad multiline string",
+	MsgEmptyKey:             "Found ':' but no key name (for an empty key name use quotes)",
+	MsgWhitespaceInKey:      "Found whitespace in your key name (use quotes to include)",
+	MsgEOFInKey:             "Found EOF while looking for a key name (check your syntax)",
+	MsgPunctuatorInKey:      "Found '%s' where a key name was expected (check your syntax or use quotes if the key name includes {}[],: or whitespace)",
+	MsgPunctuatorInValue:    "Found a punctuator character '%s' when expecting a quoteless string (check your syntax)",
+	MsgNumberOutOfRange:     "Number out of range",
+	MsgMaxDepth:             "Exceeded max depth (%d)",
+	MsgUnterminatedArray:    "End of input while parsing an array (did you forget a closing ']'?)",
+	MsgUnterminatedObject:   "End of input while parsing an object (did you forget a closing '}'?)",
+	MsgMissingColon:         "Expected ':' instead of '%s'",
+	MsgDuplicateKey:         "Found duplicate values ('%#v' and '%#v') for key '%v'",
+	MsgTrailingCharacters:   "Syntax error, found trailing characters",
+	MsgInternalError:        "Internal error",
+	MsgExpectedScalar:       "Expected a scalar value instead of '%s'",
+	MsgEOFInValue:           "Found EOF while looking for a value",
+	MsgExpectedBoolean:      "Expected a boolean value",
+	MsgExpectedInteger:      "Expected an integer value",
+	MsgInt64OutOfRange:      "Number out of range for int64",
+	MsgValueInsteadOfKey:    "Found a value when expecting a key name",
+	MsgUnexpectedInValue:    "Found '%s' when expecting a value",
+	MsgUnexpectedInKey:      "Found '%s' when expecting a key name",
+	MsgUnexpectedInElement:  "Found '%s' when expecting the next element",
+	MsgInvalidUTF8:          "Invalid UTF-8 encoding",
+	MsgMixedIndent:          "Mixed tabs and spaces in indentation",
+	MsgMixedIndentMultiline: "Mixed tabs and spaces in the indentation of a multiline string (this changes the content of the string)",
+	MsgKeyRedefined:         "Key '%s' is defined again, the value on line %d is ignored",
+	MsgNotInDialect:         "Found %s, which are not allowed in %v",
+	MsgMissingComma:         "Expected ',' instead of '%s'",
+	MsgNotFiniteNumber:      "Cannot decode %s, only finite numbers are supported",
+	MsgBadMapKey:            "Cannot unmarshal key '%s' into %v: %s",
+	MsgBadNumber:            "Cannot decode number %s: %s",
+	MsgSimilarKey:           "Key '%s' is very similar to '%s' on line %d (check for a typo)",
+	MsgNotInEnum:            "Invalid value '%s' for '%s', expected one of: %s",
+	MsgBadDuration:          "Cannot unmarshal '%s' into time.Duration, expected a duration like 1h30m or 500ms",
+	MsgBadBytes:             "Cannot unmarshal %s into []byte: %s",
+	MsgBadFieldValue:        "Cannot decode '%s' for '%s' with decoder %s: %s",
+	MsgNullIntoNonPointer:   "Cannot unmarshal null into %v, only pointers, interfaces, maps and slices can be null",
+	MsgMissingRequired:      "Missing required fields: %s",
+	MsgBadDefault:           "Cannot decode the default value '%s' of '%s': %s",
+	MsgDecodeHook:           "Cannot convert %s to %v: %s",
+	MsgUnknownField:         "Unknown field '%s'",
+	MsgExpectedArray:        "Expected an array instead of '%s'",
+	MsgLimitExceeded:        "Exceeded %s (%d) at offset %d",
+	MsgPathNotFound:         "Path '%s' not found",
+}
+
+// message returns the message identified by id from messages, or from
+// DefaultMessages if messages is nil.
+func message(messages Messages, id string, args ...interface{}) string {
+	if messages == nil {
+		messages = DefaultMessages
+	}
+	return messages.Message(id, args...)
+}
+
+// CodeInfo describes a kind of error or diagnostic, see Codes().
+type CodeInfo struct {
+	// Code is the stable code of the error, for example "HJ001". Codes are
+	// never reused for other problems.
+	Code string
+	// Kind is the message identifier, one of the Msg constants.
+	Kind string
+	// Message is the English message format of DefaultMessages, with
+	// placeholders for the arguments.
+	Message string
+}
+
+// codeTable assigns the stable code of each kind of error, ordered by code.
+// Codes are assigned explicitly so that they never change, and a code that is
+// no longer used is never assigned again. Kinds that are different messages
+// for the same problem share a code, so that a single code suppresses or
+// documents the problem wherever it is reported.
+var codeTable = []struct {
+	code, kind string
+}{
+	{"HJ001", MsgDuplicateKey},
+	{"HJ001", MsgKeyRedefined}, // the same problem, as reported by Lint()
+	{"HJ002", MsgBadComplex},
+	{"HJ003", MsgBadUnicodeEscape},
+	{"HJ004", MsgBadEscape},
+	{"HJ005", MsgNewlineInString},
+	{"HJ006", MsgUnterminatedString},
+	{"HJ007", MsgUnterminatedMLString},
+	{"HJ008", MsgEmptyKey},
+	{"HJ009", MsgWhitespaceInKey},
+	{"HJ010", MsgEOFInKey},
+	{"HJ011", MsgPunctuatorInKey},
+	{"HJ012", MsgPunctuatorInValue},
+	{"HJ013", MsgNumberOutOfRange},
+	{"HJ014", MsgMaxDepth},
+	{"HJ015", MsgUnterminatedArray},
+	{"HJ016", MsgUnterminatedObject},
+	{"HJ017", MsgMissingColon},
+	{"HJ018", MsgTrailingCharacters},
+	{"HJ019", MsgInternalError},
+	{"HJ020", MsgExpectedScalar},
+	{"HJ021", MsgEOFInValue},
+	{"HJ022", MsgExpectedBoolean},
+	{"HJ023", MsgExpectedInteger},
+	{"HJ024", MsgInt64OutOfRange},
+	{"HJ025", MsgValueInsteadOfKey},
+	{"HJ026", MsgUnexpectedInValue},
+	{"HJ027", MsgUnexpectedInKey},
+	{"HJ028", MsgUnexpectedInElement},
+	{"HJ029", MsgInvalidUTF8},
+	{"HJ030", MsgMixedIndent},
+	{"HJ031", MsgMixedIndentMultiline},
+	// HJ032 was the code of MsgKeyRedefined before it got the code of
+	// MsgDuplicateKey, it is not reused.
+	{"HJ033", MsgNotInDialect},
+	{"HJ034", MsgMissingComma},
+	{"HJ035", MsgNotFiniteNumber},
+	{"HJ036", MsgBadMapKey},
+	{"HJ037", MsgBadNumber},
+	{"HJ038", MsgSimilarKey},
+	{"HJ039", MsgNotInEnum},
+	{"HJ040", MsgBadDuration},
+	{"HJ041", MsgBadBytes},
+	{"HJ042", MsgBadFieldValue},
+	{"HJ043", MsgNullIntoNonPointer},
+	{"HJ044", MsgMissingRequired},
+	{"HJ045", MsgBadDefault},
+	{"HJ046", MsgDecodeHook},
+	{"HJ047", MsgUnknownField},
+	{"HJ048", MsgExpectedArray},
+	{"HJ049", MsgLimitExceeded},
+	{"HJ050", MsgPathNotFound},
+}
+
+var kindCodes = func() map[string]string {
+	m := make(map[string]string, len(codeTable))
+	for _, c := range codeTable {
+		m[c.kind] = c.code
+	}
+	return m
+}()
+
+// Codes returns the catalog of all kinds of errors and diagnostics, ordered by
+// code. Kinds sharing a code (because they are different messages for the same
+// problem) have an entry each. It can be used to build documentation or to
+// validate suppression lists.
+func Codes() []CodeInfo {
+	res := make([]CodeInfo, len(codeTable))
+	for i, c := range codeTable {
+		res[i] = CodeInfo{
+			Code:    c.code,
+			Kind:    c.kind,
+			Message: defaultMessageFormats[c.kind],
+		}
+	}
+	return res
+}
+
+// CodeOf returns the stable code of the message identifier kind (one of the
+// Msg constants), or "" if kind is unknown.
+func CodeOf(kind string) string {
+	return kindCodes[kind]
+}
@@ -0,0 +1,90 @@
+package hjson
+
+import (
+	"testing"
+)
+
+type testMessages map[string]string
+
+func (m testMessages) Message(id string, args ...interface{}) string {
+	if msg, ok := m[id]; ok {
+		return msg
+	}
+	return DefaultMessages.Message(id, args...)
+}
+
+func TestMessages(t *testing.T) {
+	opt := DefaultDecoderOptions()
+	opt.DisallowDuplicateKeys = true
+	opt.Messages = testMessages{MsgDuplicateKey: "Doppelter Schlüssel"}
+
+	var v interface{}
+	err := UnmarshalWithOptions([]byte("{\n  a: 1\n  a: 2\n}"), &v, opt)
+	pe, ok := err.(*ParseError)
+	if !ok || pe.Kind != MsgDuplicateKey || pe.Message != "Doppelter Schlüssel" || pe.Line != 3 {
+		t.Errorf("Unexpected error: %#v", err)
+	}
+
+	err = UnmarshalWithOptions([]byte("{\n  a: [1\n}"), &v, opt)
+	pe, ok = err.(*ParseError)
+	if !ok || pe.Kind != MsgPunctuatorInValue ||
+		pe.Message != "Found a punctuator character '}' when expecting a quoteless string (check your syntax)" {
+		t.Errorf("Unexpected error: %#v", err)
+	}
+
+	diags := LintWithOptions([]byte("a: 1\n \tb: 2\n"), LintOptions{
+		Messages: testMessages{MsgMixedIndent: "Tabs und Leerzeichen gemischt"},
+	})
+	if len(diags) != 1 || diags[0].Message != "Tabs und Leerzeichen gemischt" {
+		t.Errorf("Unexpected diagnostics: %v", diags)
+	}
+
+	for id := range defaultMessageFormats {
+		if DefaultMessages.Message(id) == "" {
+			t.Errorf("Empty message for %s", id)
+		}
+	}
+	if DefaultMessages.Message("unknown-id") != "unknown-id" {
+		t.Error("Expected the identifier for unknown messages")
+	}
+}
+
+func TestCodes(t *testing.T) {
+	codes := Codes()
+	if len(codes) != len(defaultMessageFormats) {
+		t.Errorf("Expected a code for each of the %d messages, got %d", len(defaultMessageFormats), len(codes))
+	}
+	seen := map[string]bool{}
+	for i, c := range codes {
+		if seen[c.Kind] || c.Message == "" || CodeOf(c.Kind) != c.Code || i > 0 && codes[i-1].Code > c.Code {
+			t.Errorf("Bad catalog entry %#v", c)
+		}
+		seen[c.Kind] = true
+	}
+	// Codes must never change.
+	for kind, code := range map[string]string{
+		MsgDuplicateKey:         "HJ001",
+		MsgKeyRedefined:         "HJ001",
+		MsgMixedIndentMultiline: "HJ031",
+		MsgNotInDialect:         "HJ033",
+		MsgExpectedArray:        "HJ048",
+	} {
+		if CodeOf(kind) != code {
+			t.Errorf("The code of %s has changed to %s", kind, CodeOf(kind))
+		}
+	}
+	if code := (&LimitError{}).Code(); code != "HJ049" {
+		t.Errorf("Unexpected code for LimitError: %s", code)
+	}
+	if code := (&PathNotFoundError{}).Code(); code != "HJ050" {
+		t.Errorf("Unexpected code for PathNotFoundError: %s", code)
+	}
+	if CodeOf("unknown-id") != "" {
+		t.Error("Expected no code for an unknown identifier")
+	}
+
+	d := Diagnostic{Line: 2, Message: "msg", Rule: lintMixedIndent, Code: "HJ030"}
+	if d.String() != "2: msg [HJ030 mixed-indent]" {
+		t.Errorf("Unexpected diagnostic string: %s", d.String())
+	}
+}
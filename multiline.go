@@ -0,0 +1,88 @@
+package hjson
+
+import (
+	"strings"
+)
+
+// MultilineIndentMode defines how the indentation of the lines in multiline
+// strings (strings in triple quotes) is handled, see
+// DecoderOptions.MultilineIndent and EncoderOptions.MultilineIndent.
+type MultilineIndentMode int
+
+const (
+	// MultilineIndentQuotes is the mode defined by the Hjson specification.
+	// When decoding, up to as many whitespace characters as precede the
+	// opening triple quotes on their line are removed from the start of each
+	// line. When encoding, the opening triple quotes are placed on a line of
+	// their own and each line is indented to the same column.
+	MultilineIndentQuotes MultilineIndentMode = iota
+	// MultilineIndentCommon removes the longest run of whitespace characters
+	// that all lines of the string start with, regardless of the column of the
+	// triple quotes. Lines that contain only whitespace are not considered when
+	// finding the common indentation, and a last line containing only
+	// whitespace is removed, because it is the indentation of the closing triple
+	// quotes. When encoding, the opening triple quotes
+	// are placed directly after the key and the lines are indented one level
+	// more than the key, unless all lines of the string start with whitespace
+	// (then the string is written in double quotes instead). Strings written in
+	// this mode must be decoded in the same mode.
+	MultilineIndentCommon
+	// MultilineIndentNone keeps all indentation in the lines of the string,
+	// except that a last line containing only whitespace is removed like in
+	// MultilineIndentCommon. When encoding, the lines of the string are written without any
+	// indentation. Strings written in this mode must be decoded in the same
+	// mode.
+	MultilineIndentNone
+)
+
+// trimClosingIndent removes the last line of s if it is not empty but
+// contains only whitespace, together with the line feed preceding it. Unless
+// the decoder removes the indentation of each line, that line is the
+// indentation of the closing triple quotes.
+func trimClosingIndent(s string) string {
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 && i < len(s)-1 && strings.Trim(s[i+1:], " \t") == "" {
+		return s[:i]
+	}
+	return s
+}
+
+// dedentCommon removes the longest common run of leading spaces and tabs from
+// the lines of s, ignoring lines containing only whitespace when finding it.
+// If skipFirst is true the first line is neither considered nor changed,
+// because it started on the same line as the opening triple quotes.
+func dedentCommon(s string, skipFirst bool) string {
+	lines := strings.Split(s, "\n")
+
+	first := 0
+	if skipFirst {
+		first = 1
+	}
+	prefix := ""
+	found := false
+	for _, line := range lines[first:] {
+		if strings.Trim(line, " \t") == "" {
+			continue
+		}
+		ws := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if !found {
+			prefix = ws
+			found = true
+			continue
+		}
+		i := 0
+		for i < len(prefix) && i < len(ws) && prefix[i] == ws[i] {
+			i++
+		}
+		prefix = prefix[:i]
+	}
+
+	for i := first; i < len(lines); i++ {
+		if strings.HasPrefix(lines[i], prefix) {
+			lines[i] = lines[i][len(prefix):]
+		} else {
+			// A line containing only whitespace, shorter than the prefix.
+			lines[i] = ""
+		}
+	}
+	return strings.Join(lines, "\n")
+}
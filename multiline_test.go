@@ -0,0 +1,118 @@
+package hjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMultilineIndentDecode(t *testing.T) {
+	txt := `{
+  snippet:
+    '''
+    a:
+      b: 1
+      c: 2
+    '''
+  code: '''
+      if x {
+        y()
+      }
+      '''
+}`
+	expected := map[string]map[string]interface{}{
+		"quotes": {
+			"snippet": "a:\n  b: 1\n  c: 2",
+			// The triple quotes are in column 9, so up to 8 characters are
+			// removed from each line.
+			"code": "if x {\ny()\n}",
+		},
+		"common": {
+			"snippet": "a:\n  b: 1\n  c: 2",
+			"code":    "if x {\n  y()\n}",
+		},
+		"none": {
+			"snippet": "    a:\n      b: 1\n      c: 2",
+			"code":    "      if x {\n        y()\n      }",
+		},
+	}
+	modes := map[string]MultilineIndentMode{
+		"quotes": MultilineIndentQuotes,
+		"common": MultilineIndentCommon,
+		"none":   MultilineIndentNone,
+	}
+	for name, mode := range modes {
+		opt := DefaultDecoderOptions()
+		opt.MultilineIndent = mode
+		var v map[string]interface{}
+		if err := UnmarshalWithOptions([]byte(txt), &v, opt); err != nil {
+			t.Fatal(name, err)
+		}
+		if !reflect.DeepEqual(v, expected[name]) {
+			t.Errorf("%s: expected %#v, got %#v", name, expected[name], v)
+		}
+	}
+}
+
+func TestMultilineIndentRoundTrip(t *testing.T) {
+	values := []string{
+		"a:\n  b: 1\n  c: 2",
+		"x\n\n  y\n",
+		"x\n  ",
+		"  x\n  y",
+		"\tx\n\ty\n",
+	}
+	for _, mode := range []MultilineIndentMode{
+		MultilineIndentQuotes,
+		MultilineIndentCommon,
+		MultilineIndentNone,
+	} {
+		eOpt := DefaultOptions()
+		eOpt.MultilineIndent = mode
+		dOpt := DefaultDecoderOptions()
+		dOpt.MultilineIndent = mode
+		for _, value := range values {
+			in := map[string]interface{}{
+				"a": value,
+				"b": []interface{}{value},
+			}
+			b, err := MarshalWithOptions(in, eOpt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var out map[string]interface{}
+			if err = UnmarshalWithOptions(b, &out, dOpt); err != nil {
+				t.Fatal(mode, err)
+			}
+			if !reflect.DeepEqual(in, out) {
+				t.Errorf("mode %d: expected %#v, got %#v from:\n%s", mode, in, out, b)
+			}
+		}
+	}
+}
+
+func TestMultilineIndentEncode(t *testing.T) {
+	opt := DefaultOptions()
+	opt.MultilineIndent = MultilineIndentCommon
+	b, err := MarshalWithOptions(map[string]interface{}{"a": "x\n  y"}, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareStrings(t, b, `{
+  a: '''
+    x
+      y
+    '''
+}`)
+
+	opt.MultilineIndent = MultilineIndentNone
+	if b, err = MarshalWithOptions(map[string]interface{}{"a": "x\n  y"}, opt); err != nil {
+		t.Fatal(err)
+	}
+	compareStrings(t, b, `{
+  a:
+    '''
+x
+  y
+    '''
+}`)
+}
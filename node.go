@@ -2,8 +2,10 @@ package hjson
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
+	"strconv"
 )
 
 type Comments struct {
@@ -31,6 +33,21 @@ type Comments struct {
 	After string
 }
 
+// NodeStyle holds encoder options that override EncoderOptions for a Node
+// and its descendants, see Node.Style.
+type NodeStyle struct {
+	// Inline writes the value on a single line, like [1, 2, 3] or {a: 1, b: 2}.
+	// All strings are written in double quotes and comments are left out,
+	// because nothing else can be safely followed by other values on the same
+	// line.
+	Inline bool
+	// QuoteAlways writes all strings in quotes, like EncoderOptions.QuoteAlways.
+	QuoteAlways bool
+	// IndentBy, if not empty, replaces EncoderOptions.IndentBy. The indentation
+	// of the Node itself is not changed.
+	IndentBy string
+}
+
 // Node must be used as destination for Unmarshal() or UnmarshalWithOptions()
 // whenever comments should be read from the input. The struct is simply a
 // wrapper for the actual values and a helper struct containing any comments.
@@ -41,6 +58,7 @@ type Comments struct {
 //	nil (no type)
 //	float64 (if UseJSONNumber == false)
 //	json.Number (if UseJSONNumber == true)
+//	int64 (if UseInt64 == true, for integers)
 //	string
 //	bool
 //	[]interface{}
@@ -67,9 +85,138 @@ type Comments struct {
 //	if err != nil {
 //	  return err
 //	}
+//
+// Nodes created by Unmarshal() also contain the original text of the value
+// (Lit) and its position in the input (Pos and End). If
+// EncoderOptions.PreserveFormatting is true, the original text is written
+// instead of formatting the value again for as long as a Node and its
+// descendants are unchanged, so that an unchanged node tree is written
+// byte-for-byte identical to the input.
+//
+// Set Style to encode a Node and its descendants differently from the rest of
+// the document, for example to write a matrix of numbers compactly:
+//
+//	node.NK("matrix").Style = &hjson.NodeStyle{Inline: true}
 type Node struct {
 	Value interface{}
 	Cm    Comments
+	// Style, if not nil, overrides some encoder options for this Node and its
+	// descendants.
+	Style *NodeStyle
+	// Lit is the original text of the value in the decoded input, not
+	// including the comments in Cm.Before, Cm.Key and Cm.After.
+	Lit string
+	// Pos is the position of the first character of Lit in the decoded input.
+	Pos Position
+	// End is the position just after the last character of Lit in the decoded
+	// input.
+	End Position
+
+	// The state of this Node when it was decoded, used for checking if Lit can
+	// still be used when encoding.
+	src *nodeSource
+	// The original text of the key, if this Node is the value of an object
+	// member, used for keeping the quotes of keys when encoding.
+	keyLit string
+}
+
+type nodeSource struct {
+	cm Comments
+	// value is the scalar value of the Node, if it is not an array or object.
+	value    interface{}
+	isArray  bool
+	isObject bool
+	// keys and elems hold the keys and child nodes of an object, or only the
+	// child nodes of an array.
+	keys  []string
+	elems []*Node
+}
+
+// ParseNode decodes data into a tree of Nodes, including comments, original
+// text and positions. It is a shorthand for calling Unmarshal() with a
+// **Node as destination.
+func ParseNode(data []byte) (*Node, error) {
+	var node *Node
+	if err := Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// snapshot saves the current state of c and all its descendants.
+func (c *Node) snapshot() {
+	src := &nodeSource{cm: c.Cm}
+	switch cont := c.Value.(type) {
+	case []interface{}:
+		src.isArray = true
+		for _, elem := range cont {
+			node, ok := elem.(*Node)
+			if !ok {
+				return
+			}
+			node.snapshot()
+			src.elems = append(src.elems, node)
+		}
+	case *OrderedMap:
+		src.isObject = true
+		for _, key := range cont.Keys {
+			node, ok := cont.Map[key].(*Node)
+			if !ok {
+				return
+			}
+			node.snapshot()
+			src.keys = append(src.keys, key)
+			src.elems = append(src.elems, node)
+		}
+	default:
+		src.value = c.Value
+	}
+	c.src = src
+}
+
+// unchanged returns true if c.Lit can be used for writing c. Only the comments
+// inside the value itself are checked, not Cm.Before, Cm.Key or Cm.After.
+func (c *Node) unchanged() bool {
+	src := c.src
+	if src == nil || c.Lit == "" ||
+		c.Cm.InsideFirst != src.cm.InsideFirst || c.Cm.InsideLast != src.cm.InsideLast {
+
+		return false
+	}
+
+	switch cont := c.Value.(type) {
+	case []interface{}:
+		if !src.isArray || len(cont) != len(src.elems) {
+			return false
+		}
+		for i, elem := range cont {
+			if node, ok := elem.(*Node); !ok || node != src.elems[i] || !node.unchangedElem() {
+				return false
+			}
+		}
+		return true
+	case *OrderedMap:
+		if !src.isObject || len(cont.Keys) != len(src.keys) {
+			return false
+		}
+		for i, key := range cont.Keys {
+			if key != src.keys[i] {
+				return false
+			}
+			if node, ok := cont.Map[key].(*Node); !ok || node != src.elems[i] || !node.unchangedElem() {
+				return false
+			}
+		}
+		return true
+	}
+
+	// src.value is always comparable, so this comparison cannot panic.
+	return !src.isArray && !src.isObject && c.Value == src.value
+}
+
+// unchangedElem is like unchanged(), but also checks all comments of c.
+func (c *Node) unchangedElem() bool {
+	return c.src != nil && c.Cm == c.src.cm && c.unchanged()
 }
 
 // Len returns the length of the value wrapped by this Node, if the value is of
@@ -402,6 +549,53 @@ func (c *Node) NKC(key string) *Node {
 	return nil
 }
 
+// SkipChildren can be returned by the function passed to Walk() to skip the
+// children of the current Node.
+var SkipChildren = errors.New("skip children")
+
+// Walk calls fn for c and all *Node elements found in the arrays and objects
+// contained in c, depth-first and in document order. The path passed to fn
+// holds the keys (for objects) and indexes (for arrays, as decimal strings)
+// leading from c to the Node, so it is empty for c itself. If fn returns
+// SkipChildren, the children of that Node are skipped. Any other error stops
+// the walk and is returned by Walk.
+func (c *Node) Walk(fn func(path []string, node *Node) error) error {
+	err := c.walk(nil, fn)
+	if err == SkipChildren {
+		return nil
+	}
+	return err
+}
+
+func (c *Node) walk(path []string, fn func(path []string, node *Node) error) error {
+	if c == nil {
+		return nil
+	}
+	if err := fn(path, c); err != nil {
+		return err
+	}
+	path = path[:len(path):len(path)]
+	switch cont := c.Value.(type) {
+	case *OrderedMap:
+		for _, key := range cont.Keys {
+			if node, ok := cont.Map[key].(*Node); ok {
+				if err := node.walk(append(path, key), fn); err != nil && err != SkipChildren {
+					return err
+				}
+			}
+		}
+	case []interface{}:
+		for i, elem := range cont {
+			if node, ok := elem.(*Node); ok {
+				if err := node.walk(append(path, strconv.Itoa(i)), fn); err != nil && err != SkipChildren {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
 // MarshalJSON is an implementation of the json.Marshaler interface, enabling
 // hjson.Node trees to be used as input for json.Marshal().
 func (c Node) MarshalJSON() ([]byte, error) {
@@ -2,6 +2,9 @@ package hjson
 
 import (
 	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -586,3 +589,309 @@ func TestNodeSliceInsertDelete(t *testing.T) {
   1
 ]`)
 }
+
+func TestNodeSource(t *testing.T) {
+	txt := `{
+  # comment
+  a: 1.50
+  b: [1,'x']
+  c: 2  # after
+}
+`
+	node, err := ParseNode([]byte(txt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node.Lit != txt[:len(txt)-1] {
+		t.Errorf("Unexpected root Lit %q", node.Lit)
+	}
+	if node.Pos != (Position{Offset: 0, Line: 1, Column: 1}) ||
+		node.End != (Position{Offset: len(txt) - 1, Line: 6, Column: 2}) {
+
+		t.Errorf("Unexpected root position %v - %v", node.Pos, node.End)
+	}
+
+	a := node.NK("a")
+	if a.Lit != "1.50" || a.Pos != (Position{Offset: 19, Line: 3, Column: 6}) {
+		t.Errorf("Unexpected a: %q %v", a.Lit, a.Pos)
+	}
+	b := node.NK("b")
+	if b.Lit != "[1,'x']" || b.NI(1).Lit != "'x'" || b.NI(1).End.Column != 12 {
+		t.Errorf("Unexpected b: %q %q %v", b.Lit, b.NI(1).Lit, b.NI(1).End)
+	}
+	if c := node.NK("c"); c.Lit != "2" || c.Cm.After != "  # after" {
+		t.Errorf("Unexpected c: %q", c.Lit)
+	}
+}
+
+func TestPreserveFormatting(t *testing.T) {
+	opt := DefaultOptions()
+	opt.PreserveFormatting = true
+
+	txt := `// header
+a:1.50
+"b": {x: 'y', z: [1,2,]}
+c: '''x'''
+
+`
+	node, err := ParseNode([]byte(txt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bOut, err := MarshalWithOptions(node, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareStrings(t, bOut, txt)
+
+	// Only changed values are formatted again.
+	txt = `{
+  a: 1.50
+  b: {x: 'y', z: [1,2,]}
+}
+`
+	if node, err = ParseNode([]byte(txt)); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = node.NK("b").SetKey("x", "new"); err != nil {
+		t.Fatal(err)
+	}
+	bOut, err = MarshalWithOptions(node, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareStrings(t, bOut, `{
+  a: 1.50
+  b: {
+    x: new
+    z: [1,2,]
+  }
+}
+`)
+
+	// The original text is not used unless requested.
+	bOut, err = Marshal(node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(bOut), "1.50") {
+		t.Errorf("Unexpected original text in:\n%s", bOut)
+	}
+}
+
+func TestPreserveKeyQuotes(t *testing.T) {
+	opt := DefaultOptions()
+	opt.PreserveFormatting = true
+
+	node, err := ParseNode([]byte(`{
+  "x-header": 1
+  plain: 2
+  'single': 3
+  "esc\u0041": 4
+  "renamed": 5
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	node.NK("plain").Value = 20
+	// A renamed member gets the usual quoting.
+	om := node.Value.(*OrderedMap)
+	renamed, _ := om.DeleteKey("renamed")
+	om.Set("new", renamed)
+
+	bOut, err := MarshalWithOptions(node, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareStrings(t, bOut, `{
+  "x-header": 1
+  plain: 20
+  'single': 3
+  "esc\u0041": 4
+  new: 5
+}`)
+
+	// Keys are normalized unless PreserveFormatting is set.
+	node.NK("plain").Value = 2
+	bOut, err = Marshal(node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(bOut), `"x-header"`) {
+		t.Errorf("Unexpected quotes in:\n%s", bOut)
+	}
+}
+
+func TestPreserveFormattingAssets(t *testing.T) {
+	opt := DefaultOptions()
+	opt.PreserveFormatting = true
+
+	files, err := filepath.Glob("assets/*_test.hjson")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		if strings.HasPrefix(filepath.Base(file), "fail") {
+			continue
+		}
+		data := getContent(file)
+		node, err := ParseNode(data)
+		if err != nil {
+			t.Errorf("%s: %v", file, err)
+			continue
+		}
+		bOut, err := MarshalWithOptions(node, opt)
+		if err != nil {
+			t.Errorf("%s: %v", file, err)
+			continue
+		}
+		if string(bOut) != string(data) {
+			t.Errorf("%s: not reproduced byte-for-byte:\n%s", file, bOut)
+		}
+	}
+}
+
+func TestKeepComments(t *testing.T) {
+	txt := `# header
+{
+  # about b
+  b: 2 # two
+  a: [
+    1 # one
+  ]
+}
+`
+	opt := DefaultDecoderOptions()
+	opt.KeepComments = true
+
+	var v interface{}
+	if err := UnmarshalWithOptions([]byte(txt), &v, opt); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := v.(*Node); !ok {
+		t.Fatalf("Expected *Node, got %T", v)
+	}
+	bOut, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareStrings(t, bOut, txt)
+
+	m := map[string]interface{}{"c": 3}
+	if err = UnmarshalWithOptions([]byte(txt), &m, opt); err != nil {
+		t.Fatal(err)
+	}
+	if len(m) != 3 {
+		t.Errorf("Expected 3 keys, got %v", m)
+	}
+	if b, ok := m["b"].(*Node); !ok || b.Value != 2.0 {
+		t.Fatalf("Expected *Node with value 2, got %#v", m["b"])
+	}
+	if bOut, err = Marshal(m); err != nil {
+		t.Fatal(err)
+	}
+	compareStrings(t, bOut, `{
+  a: [
+    1 # one
+  ]
+  # about b
+  b: 2 # two
+  c: 3
+}`)
+
+	// Other destinations are not affected.
+	var s struct{ B int }
+	if err = UnmarshalWithOptions([]byte(txt), &s, opt); err != nil || s.B != 2 {
+		t.Errorf("Unexpected result %v, %v", s, err)
+	}
+	var m2 map[string]int
+	if err = UnmarshalWithOptions([]byte("{b: 2}"), &m2, opt); err != nil || m2["b"] != 2 {
+		t.Errorf("Unexpected result %v, %v", m2, err)
+	}
+}
+
+func TestNodeWalk(t *testing.T) {
+	node, err := ParseNode([]byte("{\n  a: [1, {b: 2}]\n  c: {d: 3}\n}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	err = node.Walk(func(path []string, n *Node) error {
+		paths = append(paths, strings.Join(path, "."))
+		if len(path) == 1 && path[0] == "c" {
+			return SkipChildren
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(paths, ",") != ",a,a.0,a.1,a.1.b,c" {
+		t.Errorf("Unexpected paths %q", paths)
+	}
+
+	errStop := errors.New("stop")
+	count := 0
+	err = node.Walk(func(path []string, n *Node) error {
+		count++
+		if len(path) == 2 {
+			return errStop
+		}
+		return nil
+	})
+	if err != errStop || count != 3 {
+		t.Errorf("Unexpected result %v, %d", err, count)
+	}
+}
+
+func TestNodeStyle(t *testing.T) {
+	node, err := ParseNode([]byte(`{
+  name: demo
+  matrix: [
+    [1, 0]
+    [0, 1]
+  ]
+  nested: {
+    # A comment.
+    tags: [
+      a b
+      "c\nd"
+    ]
+  }
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	node.NK("matrix").Style = &NodeStyle{Inline: true}
+	node.NK("nested").NK("tags").Style = &NodeStyle{Inline: true}
+	// Indentation is kept in the comments of decoded nodes, so IndentBy only
+	// affects new nodes.
+	if _, _, err := node.NK("nested").SetKey("deep", map[string]string{"x": "y"}); err != nil {
+		t.Fatal(err)
+	}
+	node.NK("nested").NK("deep").Style = &NodeStyle{IndentBy: "    ", QuoteAlways: true}
+
+	out, err := Marshal(node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := `{
+  name: demo
+  matrix: [[1, 0], [0, 1]]
+  nested: {
+    # A comment.
+    tags: ["a b", "c\nd"]
+    deep: {
+        x: "y"
+    }
+  }
+}`
+	if string(out) != exp {
+		t.Errorf("Expected:\n%s\nGot:\n%s", exp, out)
+	}
+
+	var v interface{}
+	if err := Unmarshal(out, &v); err != nil {
+		t.Fatal(err)
+	}
+}
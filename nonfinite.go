@@ -0,0 +1,62 @@
+package hjson
+
+import (
+	"math"
+	"reflect"
+)
+
+// parseNonFinite returns the float64 value of the literals NaN, Infinity,
+// +Infinity and -Infinity (and +NaN and -NaN, which are valid in JSON5).
+func parseNonFinite(lit string) (float64, bool) {
+	switch lit {
+	case "NaN", "+NaN", "-NaN":
+		return math.NaN(), true
+	case "Infinity", "+Infinity":
+		return math.Inf(1), true
+	case "-Infinity":
+		return math.Inf(-1), true
+	}
+	return 0, false
+}
+
+// formatNonFinite returns the literal of f, which must be NaN or infinite.
+func formatNonFinite(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+	return "NaN"
+}
+
+// isFloatDest returns true if t, the type of a destination after
+// unravelDestination(), can hold a non-finite number. t is nil if the type is
+// not known.
+func isFloatDest(t reflect.Type) bool {
+	if t == nil {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64, reflect.Interface:
+		return true
+	}
+	return false
+}
+
+// nonFiniteValue returns f, or nil if f is assigned after json.Unmarshal(),
+// which cannot decode non-finite numbers. t is the type of the destination,
+// after unravelDestination().
+func (p *hjsonParser) nonFiniteValue(f float64, t reflect.Type) interface{} {
+	if !p.willMarshalToJSON {
+		return f
+	}
+	p.fixups = append(p.fixups, destFixup{
+		path:  append([]interface{}(nil), p.path...),
+		value: f,
+		// t is also nil if there is no destination for the value, for example
+		// an unknown struct field.
+		ignorable: t == nil,
+	})
+	return nil
+}
@@ -0,0 +1,74 @@
+package hjson
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNonFiniteNumbers(t *testing.T) {
+	options := DefaultDecoderOptions()
+	options.NonFiniteNumbers = true
+
+	var s struct {
+		A float64
+		B float32
+		C *float64
+		D interface{}
+		E string
+		F []float64
+	}
+	txt := "{\n  a: NaN\n  b: -Infinity\n  c: +Infinity\n  d: Infinity\n  e: NaN\n  f: [\n    1\n    NaN\n  ]\n  unknown: NaN\n}"
+	if err := UnmarshalWithOptions([]byte(txt), &s, options); err != nil {
+		t.Fatal(err)
+	}
+	if !math.IsNaN(s.A) || !math.IsInf(float64(s.B), -1) || s.C == nil || !math.IsInf(*s.C, 1) ||
+		s.D != math.Inf(1) || s.E != "NaN" || len(s.F) != 2 || s.F[0] != 1 || !math.IsNaN(s.F[1]) {
+
+		t.Errorf("Unexpected result: %#v", s)
+	}
+
+	var node *Node
+	if err := UnmarshalWithOptions([]byte("[-Infinity, 1]"), &node, options); err != nil {
+		t.Fatal(err)
+	}
+	if v := node.NI(0).Value; v != math.Inf(-1) {
+		t.Errorf("Unexpected node value: %#v", v)
+	}
+
+	// Without the option the literals are strings.
+	var v map[string]interface{}
+	if err := Unmarshal([]byte("a: NaN"), &v); err != nil || v["a"] != "NaN" {
+		t.Errorf("Unexpected result: %v %#v", err, v)
+	}
+
+	options.Dialect = FormatJSON5
+	var arr []float64
+	if err := UnmarshalWithOptions([]byte("[NaN, -Infinity, +Infinity, 1]"), &arr, options); err != nil {
+		t.Fatal(err)
+	}
+	if len(arr) != 4 || !math.IsNaN(arr[0]) || !math.IsInf(arr[1], -1) || !math.IsInf(arr[2], 1) {
+		t.Errorf("Unexpected result: %v", arr)
+	}
+
+	encOptions := DefaultOptions()
+	b, err := MarshalWithOptions([]float64{math.NaN(), math.Inf(1), math.Inf(-1), 2}, encOptions)
+	if err != nil || string(b) != "[\n  null\n  null\n  null\n  2\n]" {
+		t.Errorf("Unexpected output: %s %v", b, err)
+	}
+	encOptions.NonFiniteNumbers = true
+	b, err = MarshalWithOptions([]float64{math.NaN(), math.Inf(1), math.Inf(-1), 2}, encOptions)
+	if err != nil || string(b) != "[\n  NaN\n  Infinity\n  -Infinity\n  2\n]" {
+		t.Errorf("Unexpected output: %s %v", b, err)
+	}
+	if b, err := MarshalWithOptions([]string{"NaN", "Infinity x"}, encOptions); err != nil ||
+		string(b) != "[\n  \"NaN\"\n  Infinity x\n]" {
+
+		t.Errorf("Unexpected output: %s %v", b, err)
+	}
+	options.Dialect = FormatUnknown
+	if err := UnmarshalWithOptions(b, &arr, options); err != nil || len(arr) != 4 ||
+		!math.IsNaN(arr[0]) || !math.IsInf(arr[1], 1) || !math.IsInf(arr[2], -1) || arr[3] != 2 {
+
+		t.Errorf("Round trip failed: %v %v", arr, err)
+	}
+}
@@ -0,0 +1,87 @@
+package hjson
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+)
+
+// Number is a number that keeps the exact text it was written with in the
+// Hjson input, like "0.10", "1e3" or "-0", so that decoding a file into a
+// struct and encoding it again does not change how its numbers are written.
+// Marshal() writes the text as it is, without quotes.
+//
+// Unlike json.Number, a Number only accepts text that is a valid number when
+// it is decoded, so that a quoteless string in the input is reported as an
+// error.
+type Number string
+
+var numberType = reflect.TypeOf(Number(""))
+
+// String returns the literal text of the number.
+func (n Number) String() string {
+	return string(n)
+}
+
+// Float64 returns the number as a float64.
+func (n Number) Float64() (float64, error) {
+	return strconv.ParseFloat(string(n), 64)
+}
+
+// Int64 returns the number as an int64.
+func (n Number) Int64() (int64, error) {
+	return strconv.ParseInt(string(n), 10, 64)
+}
+
+// MarshalJSON is an implementation of the json.Marshaler interface, writing
+// the text of the number.
+func (n Number) MarshalJSON() ([]byte, error) {
+	if n == "" {
+		return []byte("0"), nil
+	}
+	if !isNumberText(string(n)) {
+		return nil, errors.New("hjson: invalid number literal " + strconv.Quote(string(n)))
+	}
+	return []byte(n), nil
+}
+
+// UnmarshalJSON is an implementation of the json.Unmarshaler interface,
+// accepting a number or a string containing a number.
+func (n *Number) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	text := string(b)
+	if len(b) > 0 && b[0] == '"' {
+		if err := json.Unmarshal(b, &text); err != nil {
+			return err
+		}
+	}
+	if !isNumberText(text) {
+		return errors.New("hjson: invalid number literal " + strconv.Quote(text))
+	}
+	*n = Number(text)
+	return nil
+}
+
+// isNumberText returns true if text is a number in Hjson (and JSON) syntax.
+func isNumberText(text string) bool {
+	_, err := tryParseNumber([]byte(text), false, true)
+	return err == nil && text != "" && text[len(text)-1] > ' '
+}
+
+// writeNumberText writes n, the text of a json.Number or a Number, without
+// quotes.
+func (e *hjsonEncoder) writeNumberText(n, separator string) {
+	if n == "" {
+		n = "0"
+	}
+	e.WriteString(separator)
+
+	l, r := "", ""
+	if e.EnableColor {
+		l, r = e.ColorStyle.Number[0], e.ColorStyle.Number[1]
+	}
+	e.WriteString(l + n + r)
+}
@@ -0,0 +1,69 @@
+package hjson
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNumber(t *testing.T) {
+	txt := `{
+  price: 0.10
+  big: 1e3
+  zero: -0
+  list: [
+    1.50
+    2
+  ]
+  quoted: "12.0"
+}`
+	var v struct {
+		Price  Number   `json:"price"`
+		Big    Number   `json:"big"`
+		Zero   Number   `json:"zero"`
+		List   []Number `json:"list"`
+		Quoted Number   `json:"quoted"`
+	}
+	if err := Unmarshal([]byte(txt), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Price != "0.10" || v.Big != "1e3" || v.Zero != "-0" || v.List[0] != "1.50" ||
+		v.Quoted != "12.0" {
+
+		t.Errorf("Unexpected result: %#v", v)
+	}
+	if f, err := v.Big.Float64(); err != nil || f != 1000 {
+		t.Errorf("Unexpected Float64(): %v %v", f, err)
+	}
+	if _, err := v.Price.Int64(); err == nil {
+		t.Error("Expected an error from Int64()")
+	}
+
+	out, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareStrings(t, out, `{
+  price: 0.10
+  big: 1e3
+  zero: -0
+  list: [
+    1.50
+    2
+  ]
+  quoted: 12.0
+}`)
+
+	b, err := json.Marshal(v)
+	if err != nil || string(b) != `{"price":0.10,"big":1e3,"zero":-0,"list":[1.50,2],"quoted":12.0}` {
+		t.Errorf("Unexpected JSON: %s %v", b, err)
+	}
+
+	for _, bad := range []string{"price: abc", "price: 1.5.5", "price: \"\""} {
+		if err := Unmarshal([]byte(bad), &v); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+	if _, err := json.Marshal(Number("x")); err == nil {
+		t.Error("Expected an error for an invalid Number")
+	}
+}
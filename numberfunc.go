@@ -0,0 +1,37 @@
+package hjson
+
+import (
+	"reflect"
+)
+
+// callNumberFunc calls NumberFunc for lit, the text of a valid number, and
+// returns its result and true if it is to be used. t is the type of the
+// destination, after unravelDestination(). The result is returned as nil if
+// it is assigned after json.Unmarshal(), like for Literals.
+func (p *hjsonParser) callNumberFunc(lit string, t reflect.Type) (interface{}, bool, error) {
+	if p.NumberFunc == nil {
+		return nil, false, nil
+	}
+	v, err := p.NumberFunc(lit)
+	if err != nil {
+		return nil, false, p.errAt(MsgBadNumber, lit, err.Error())
+	}
+	if v == nil {
+		return nil, false, nil
+	}
+	if !p.willMarshalToJSON {
+		// Decoding into a Node or an OrderedMap, which can hold any value.
+		return v, true, nil
+	}
+	if t != nil && t.Kind() != reflect.Interface && !reflect.TypeOf(v).AssignableTo(t) {
+		return nil, false, nil
+	}
+	p.fixups = append(p.fixups, destFixup{
+		path:  append([]interface{}(nil), p.path...),
+		value: v,
+		// t is also nil if there is no destination for the value, for example
+		// an unknown struct field.
+		ignorable: t == nil,
+	})
+	return nil, true, nil
+}
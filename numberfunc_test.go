@@ -0,0 +1,85 @@
+package hjson
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNumberFunc(t *testing.T) {
+	options := DefaultDecoderOptions()
+	options.NumberFunc = func(lit string) (interface{}, error) {
+		if strings.ContainsAny(lit, ".eE") {
+			return nil, nil
+		}
+		n, ok := new(big.Int).SetString(lit, 10)
+		if !ok {
+			return nil, errors.New("not an integer")
+		}
+		return n, nil
+	}
+
+	big1, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	var v interface{}
+	if err := UnmarshalWithOptions([]byte("{\n  a: 123456789012345678901234567890\n  b: 1.5\n  c: [2]\n}"), &v, options); err != nil {
+		t.Fatal(err)
+	}
+	exp := map[string]interface{}{"a": big1, "b": 1.5, "c": []interface{}{big.NewInt(2)}}
+	if !reflect.DeepEqual(v, exp) {
+		t.Errorf("Expected\n%#v\ngot\n%#v", exp, v)
+	}
+
+	var s struct {
+		B float64
+		C int
+		D interface{}
+	}
+	txt := []byte("{\n  B: 2\n  C: 3\n  D: 4\n  E: 5\n}")
+	if err := UnmarshalWithOptions(txt, &s, options); err != nil {
+		t.Fatal(err)
+	}
+	if s.B != 2 || s.C != 3 || !reflect.DeepEqual(s.D, big.NewInt(4)) {
+		t.Errorf("Unexpected result: %#v", s)
+	}
+
+	node := &Node{}
+	if err := UnmarshalWithOptions([]byte("[7, 7.5]"), node, options); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(node.NI(0).Value, big.NewInt(7)) || node.NI(1).Value != 7.5 {
+		t.Errorf("Unexpected node: %#v", node)
+	}
+
+	options.Dialect = FormatJSON5
+	if err := UnmarshalWithOptions([]byte("[10, 0x10]"), &v, options); err == nil {
+		t.Error("Expected an error for 0x10")
+	} else if pe, ok := err.(*ParseError); !ok || pe.Kind != MsgBadNumber {
+		t.Errorf("Expected %s, got %#v", MsgBadNumber, err)
+	}
+
+	// A type with units, which is not a number kind.
+	options = DefaultDecoderOptions()
+	options.NumberFunc = func(lit string) (interface{}, error) {
+		f, _, err := big.ParseFloat(lit, 10, 64, big.ToNearestEven)
+		if err != nil {
+			return nil, err
+		}
+		g, _ := f.Float64()
+		return testWeight{Milligrams: int64(g * 1000)}, nil
+	}
+	var w struct {
+		Weight testWeight
+		Count  int
+	}
+	if err := UnmarshalWithOptions([]byte("{\n  Weight: 1.5\n  Count: 2\n}"), &w, options); err != nil ||
+		w.Weight.Milligrams != 1500 || w.Count != 2 {
+
+		t.Errorf("Unexpected result: %#v %v", w, err)
+	}
+}
+
+type testWeight struct {
+	Milligrams int64
+}
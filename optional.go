@@ -0,0 +1,60 @@
+//go:build go1.18
+
+package hjson
+
+import (
+	"encoding/json"
+)
+
+// Optional holds a value that may be left out of a document, and records how
+// it was given when decoding: Present is true if the key was found, and Null
+// is true if its value was null. This tells apart a setting that is absent
+// from one that is explicitly disabled with null, without using pointers:
+//
+//	type Config struct {
+//	  Proxy hjson.Optional[string]
+//	}
+//
+// Marshal() leaves out struct fields of type Optional that are not Present,
+// writes null for fields that are Null, and writes Value otherwise.
+type Optional[T any] struct {
+	Value   T
+	Present bool
+	Null    bool
+}
+
+// Some returns an Optional that is Present with the value v.
+func Some[T any](v T) Optional[T] {
+	return Optional[T]{Value: v, Present: true}
+}
+
+// Get returns Value, and true if Present and not Null.
+func (o Optional[T]) Get() (T, bool) {
+	return o.Value, o.Present && !o.Null
+}
+
+// IsZero returns true if o is not Present, so that the omitzero option of
+// encoding/json leaves it out too.
+func (o Optional[T]) IsZero() bool {
+	return !o.Present
+}
+
+// MarshalJSON implements json.Marshaler.
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.Present || o.Null {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.Value)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	var zero T
+	o.Value, o.Present, o.Null = zero, true, string(data) == "null"
+	if o.Null {
+		return nil
+	}
+	return json.Unmarshal(data, &o.Value)
+}
+
+func (Optional[T]) isOptional() {}
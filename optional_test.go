@@ -0,0 +1,76 @@
+//go:build go1.18
+
+package hjson
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestOptional(t *testing.T) {
+	type Config struct {
+		Name    Optional[string]
+		Proxy   Optional[string]
+		Port    Optional[int]
+		Tags    Optional[[]string]
+		Phase   Optional[complex128]
+		Missing Optional[bool]
+	}
+	var c Config
+	err := Unmarshal([]byte(`
+Name: 123
+Proxy: null
+Port: 8080
+Tags: ["a", "b"]
+Phase: 1+2i
+`), &c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Config{
+		Name:  Some("123"),
+		Proxy: Optional[string]{Present: true, Null: true},
+		Port:  Some(8080),
+		Tags:  Some([]string{"a", "b"}),
+		Phase: Some(1 + 2i),
+	}
+	if !reflect.DeepEqual(c, expected) {
+		t.Errorf("Expected:\n%#v\nGot:\n%#v", expected, c)
+	}
+	if v, ok := c.Port.Get(); !ok || v != 8080 {
+		t.Errorf("Unexpected Get() result %v, %v", v, ok)
+	}
+	if _, ok := c.Proxy.Get(); ok {
+		t.Error("Expected no value for a null Optional")
+	}
+
+	out, err := Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedOut := `{
+  Name: "123"
+  Proxy: null
+  Port: 8080
+  Tags: [
+    a
+    b
+  ]
+  Phase: 1+2i
+}`
+	if string(out) != expectedOut {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expectedOut, out)
+	}
+
+	j, err := json.Marshal(struct {
+		A Optional[int]
+		B Optional[int]
+	}{A: Some(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(j) != `{"A":1,"B":null}` {
+		t.Errorf("Unexpected JSON %s", j)
+	}
+}
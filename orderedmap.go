@@ -3,6 +3,7 @@ package hjson
 import (
 	"bytes"
 	"encoding/json"
+	"reflect"
 )
 
 // OrderedMap wraps a map and a slice containing all of the keys from the map,
@@ -22,6 +23,11 @@ import (
 // OrderedMap.Keys. Otherwise those keys will be ignored when iterating through
 // the elements of the OrderedMap in order, as for example happens in the
 // function hjson.Marshal().
+//
+// The zero value of OrderedMap is an empty map ready to use. A *OrderedMap or
+// **OrderedMap can be used as destination for Unmarshal(), which then keeps
+// the order of the keys in the Hjson input. Nested objects are then also
+// decoded as *OrderedMap.
 type OrderedMap struct {
 	Keys []string
 	Map  map[string]interface{}
@@ -33,6 +39,43 @@ type KeyValue struct {
 	Value interface{}
 }
 
+// entry is implemented by the generic type Entry, so that slices of it can be
+// recognized without using generics.
+type entry interface {
+	isEntry()
+}
+
+var entryInterface = reflect.TypeOf((*entry)(nil)).Elem()
+
+// optional is implemented by the generic type Optional. Its fields are
+// accessed by index: Value (0), Present (1) and Null (2).
+type optional interface {
+	isOptional()
+}
+
+var optionalInterface = reflect.TypeOf((*optional)(nil)).Elem()
+
+// isOptionalType returns true if t is an Optional.
+func isOptionalType(t reflect.Type) bool {
+	return t != nil && t.Kind() == reflect.Struct && t.Implements(optionalInterface)
+}
+
+// isUnsetOptional returns true if v is an Optional that is not Present.
+func isUnsetOptional(v reflect.Value) bool {
+	return isOptionalType(v.Type()) && !v.Field(1).Bool()
+}
+
+// getEntryValueType returns the type of Entry.Value if the destination is a
+// slice of Entry, otherwise nil.
+func getEntryValueType(dest reflect.Value, t reflect.Type) reflect.Type {
+	_, t = unravelDestination(dest, t)
+	if t == nil || t.Kind() != reflect.Slice || !t.Elem().Implements(entryInterface) ||
+		t.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	return t.Elem().Field(1).Type
+}
+
 // NewOrderedMap returns a pointer to a new OrderedMap. An OrderedMap should
 // always be passed by reference, never by value. If an OrderedMap is passed
 // by value then appending new keys won't affect all of the copies of the
@@ -77,12 +120,22 @@ func (c *OrderedMap) AtKey(key string) (interface{}, bool) {
 	return ret, ok
 }
 
+// Get returns the value found for the specified key, and true if the value
+// was found. Returns nil and false if the value was not found. Same as
+// AtKey().
+func (c *OrderedMap) Get(key string) (interface{}, bool) {
+	return c.AtKey(key)
+}
+
 // Insert inserts a new key/value pair at the specified index. Panics if
 // index < 0 or index > c.Len(). If the key already exists in the OrderedMap,
 // the new value is set but the position of the key is not changed. Returns
 // the old value and true if the key already exists in the OrderedMap, nil and
 // false otherwise.
 func (c *OrderedMap) Insert(index int, key string, value interface{}) (interface{}, bool) {
+	if c.Map == nil {
+		c.Map = map[string]interface{}{}
+	}
 	oldValue, exists := c.Map[key]
 	c.Map[key] = value
 	if exists {
@@ -129,6 +182,13 @@ func (c *OrderedMap) DeleteKey(key string) (interface{}, bool) {
 	return nil, false
 }
 
+// Delete deletes the key/value pair with the specified key, if found. Returns
+// the deleted value and true if the key was found, nil and false otherwise.
+// Same as DeleteKey().
+func (c *OrderedMap) Delete(key string) (interface{}, bool) {
+	return c.DeleteKey(key)
+}
+
 // MarshalJSON is an implementation of the json.Marshaler interface, enabling
 // hjson.OrderedMap to be used as input for json.Marshal().
 func (c *OrderedMap) MarshalJSON() ([]byte, error) {
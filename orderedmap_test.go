@@ -138,3 +138,30 @@ func TestUnmarshalHJSON_2(t *testing.T) {
 
 	verifyContent(t, &om, `{"B":"first","C":3,"sub":{"z":7,"y":8},"A":2}`)
 }
+
+func TestGetDelete(t *testing.T) {
+	var om OrderedMap
+	om.Set("B", "first")
+	om.Set("A", 2)
+	if v, ok := om.Get("A"); !ok || v != 2 {
+		t.Errorf("Unexpected result %v, %v", v, ok)
+	}
+	if v, ok := om.Delete("B"); !ok || v != "first" {
+		t.Errorf("Unexpected result %v, %v", v, ok)
+	}
+	if _, ok := om.Get("B"); ok || om.Len() != 1 {
+		t.Errorf("Key B should have been deleted: %v", om.Keys)
+	}
+	if _, ok := om.Delete("B"); ok {
+		t.Error("Key B should not be found")
+	}
+
+	om.Set("C", []int{3})
+	b, err := Marshal(&om)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "{\n  A: 2\n  C: [\n    3\n  ]\n}" {
+		t.Errorf("Unexpected output:\n%s", b)
+	}
+}
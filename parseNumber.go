@@ -43,6 +43,20 @@ func startsWithNumber(text []byte) bool {
 	return false
 }
 
+// isNumberOutOfRange returns true if v is a json.Number that cannot be
+// represented as a float64, or if v is the error returned from
+// tryParseNumber() for such a number.
+func isNumberOutOfRange(v interface{}) bool {
+	switch n := v.(type) {
+	case json.Number:
+		_, err := strconv.ParseFloat(string(n), 64)
+		return isNumberOutOfRange(err)
+	case *strconv.NumError:
+		return n.Err == strconv.ErrRange
+	}
+	return false
+}
+
 func tryParseNumber(text []byte, stopAtNext, useJSONNumber bool) (interface{}, error) {
 	// Parse a number value.
 
@@ -0,0 +1,145 @@
+package hjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// The operations of a JSON Patch, see Operation.
+const (
+	OpAdd     = "add"
+	OpRemove  = "remove"
+	OpReplace = "replace"
+	OpMove    = "move"
+	OpCopy    = "copy"
+	OpTest    = "test"
+)
+
+// Operation is an operation of a JSON Patch (RFC 6902). A patch can be read
+// from JSON or Hjson into a []Operation.
+type Operation struct {
+	// Op is one of OpAdd, OpRemove, OpReplace, OpMove, OpCopy and OpTest.
+	Op string `json:"op"`
+	// Path is the JSON Pointer of the value to change or to test.
+	Path string `json:"path"`
+	// From is the JSON Pointer of the value to move or copy.
+	From string `json:"from,omitempty"`
+	// Value is the value to add, to replace with or to test against.
+	Value interface{} `json:"value,omitempty"`
+}
+
+// ApplyPatch applies the operations of a JSON Patch (RFC 6902) in order to
+// the Hjson or JSON document doc and returns the result. If an operation
+// fails, or a test operation finds another value, an error is returned for
+// the whole patch.
+//
+// The result is built by editing doc like Document.Set(), so the comments and
+// the formatting of all values that are not changed by the patch are kept.
+// Values are compared by test operations like JSON values, so that for
+// example the number 1 equals 1.0.
+func ApplyPatch(doc []byte, patch []Operation) ([]byte, error) {
+	d, err := ParseDocument(doc)
+	if err != nil {
+		return nil, err
+	}
+	for i, op := range patch {
+		if err = d.applyOperation(op); err != nil {
+			return nil, fmt.Errorf("hjson: patch operation %d (%s %s): %v", i, op.Op, op.Path, err)
+		}
+	}
+	return d.Bytes(), nil
+}
+
+func (d *Document) applyOperation(op Operation) error {
+	tokens, err := parsePointer(op.Path)
+	if err != nil {
+		return err
+	}
+	var from []string
+	if op.Op == OpMove || op.Op == OpCopy {
+		if from, err = parsePointer(op.From); err != nil {
+			return err
+		}
+	}
+
+	switch op.Op {
+	case OpAdd:
+		return d.add(tokens, op.Value)
+	case OpRemove:
+		return d.remove(tokens, formatPointer)
+	case OpReplace:
+		if _, err = resolvePointer(d.root, tokens, formatPointer); err != nil {
+			return err
+		}
+		return d.set(tokens, op.Value, formatPointer)
+	case OpMove:
+		if op.From == op.Path {
+			_, err = resolvePointer(d.root, from, formatPointer)
+			return err
+		}
+		if strings.HasPrefix(op.Path, op.From+"/") {
+			return fmt.Errorf("cannot move %s into itself", op.From)
+		}
+		node, err := resolvePointer(d.root, from, formatPointer)
+		if err != nil {
+			return err
+		}
+		value := plainValue(node)
+		if err = d.remove(from, formatPointer); err != nil {
+			return err
+		}
+		return d.add(tokens, value)
+	case OpCopy:
+		node, err := resolvePointer(d.root, from, formatPointer)
+		if err != nil {
+			return err
+		}
+		return d.add(tokens, plainValue(node))
+	case OpTest:
+		node, err := resolvePointer(d.root, tokens, formatPointer)
+		if err != nil {
+			return err
+		}
+		equal, err := jsonEqual(plainValue(node), op.Value)
+		if err != nil {
+			return err
+		}
+		if !equal {
+			return fmt.Errorf("test failed, found %s", diffValue(plainValue(node)))
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown operation %q", op.Op)
+}
+
+// add adds value at tokens like the JSON Patch operation "add": elements are
+// inserted into arrays, and members of objects are added or replaced.
+func (d *Document) add(tokens []string, value interface{}) error {
+	if len(tokens) > 0 {
+		parent, err := resolvePointer(d.root, tokens[:len(tokens)-1], formatPointer)
+		if err != nil {
+			return err
+		}
+		if _, ok := parent.Value.([]interface{}); ok && tokens[len(tokens)-1] != "-" {
+			return d.insert(tokens, value, formatPointer)
+		}
+	}
+	return d.set(tokens, value, formatPointer)
+}
+
+// jsonEqual returns true if a and b are encoded as equal JSON values.
+func jsonEqual(a, b interface{}) (bool, error) {
+	var values [2]interface{}
+	for i, v := range []interface{}{a, b} {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return false, err
+		}
+		if err = json.Unmarshal(data, &values[i]); err != nil {
+			return false, err
+		}
+	}
+	return reflect.DeepEqual(values[0], values[1]), nil
+}
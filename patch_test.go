@@ -0,0 +1,147 @@
+package hjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplyPatch(t *testing.T) {
+	var patch []Operation
+	err := Unmarshal([]byte(`[
+  { op: "replace", path: "/server/port", value: 8443 }
+  { op: "add", path: "/server/hosts/1", value: "b.example.com" }
+  { op: "remove", path: "/debug" }
+  { op: "copy", from: "/server/port", path: "/admin/port" }
+  { op: "move", from: "/server/tls", path: "/tls" }
+  { op: "test", path: "/admin/port", value: 8443 }
+]`), &patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := `# Service.
+server: {
+  port: 80 # HTTP.
+  hosts: [
+    a.example.com
+    # The last one.
+    c.example.com
+  ]
+  tls: {cert: "a.pem"}
+}
+debug: true
+admin: {
+  user: root
+}
+`
+	expected := `# Service.
+server: {
+  port: 8443 # HTTP.
+  hosts: [
+    a.example.com
+    b.example.com
+    # The last one.
+    c.example.com
+  ]
+}
+admin: {
+  user: root
+  port: 8443
+}
+tls: {
+  cert: a.pem
+}
+`
+	out, err := ApplyPatch([]byte(doc), patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, out)
+	}
+
+	// Examples of RFC 6902, appendix A.
+	testCases := []struct {
+		doc      string
+		patch    []Operation
+		expected interface{}
+	}{
+		{`{"foo": "bar"}`, []Operation{{Op: OpAdd, Path: "/baz", Value: "qux"}},
+			map[string]interface{}{"foo": "bar", "baz": "qux"}},
+		{`{"foo": ["bar", "baz"]}`, []Operation{{Op: OpAdd, Path: "/foo/1", Value: "qux"}},
+			map[string]interface{}{"foo": []interface{}{"bar", "qux", "baz"}}},
+		{`{"baz": "qux", "foo": "bar"}`, []Operation{{Op: OpRemove, Path: "/baz"}},
+			map[string]interface{}{"foo": "bar"}},
+		{`{"foo": ["bar", "qux", "baz"]}`, []Operation{{Op: OpRemove, Path: "/foo/1"}},
+			map[string]interface{}{"foo": []interface{}{"bar", "baz"}}},
+		{`{"baz": "qux", "foo": "bar"}`, []Operation{{Op: OpReplace, Path: "/baz", Value: "boo"}},
+			map[string]interface{}{"baz": "boo", "foo": "bar"}},
+		{`{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`,
+			[]Operation{{Op: OpMove, From: "/foo/waldo", Path: "/qux/thud"}},
+			map[string]interface{}{
+				"foo": map[string]interface{}{"bar": "baz"},
+				"qux": map[string]interface{}{"corge": "grault", "thud": "fred"},
+			}},
+		{`{"foo": ["all", "grass", "cows", "eat"]}`, []Operation{{Op: OpMove, From: "/foo/1", Path: "/foo/3"}},
+			map[string]interface{}{"foo": []interface{}{"all", "cows", "eat", "grass"}}},
+		{`{"baz": "qux", "foo": ["a", 2, "c"]}`, []Operation{
+			{Op: OpTest, Path: "/baz", Value: "qux"},
+			{Op: OpTest, Path: "/foo/1", Value: 2},
+		}, map[string]interface{}{"baz": "qux", "foo": []interface{}{"a", 2.0, "c"}}},
+		{`{"foo": "bar"}`, []Operation{{Op: OpAdd, Path: "/child", Value: map[string]interface{}{
+			"grandchild": map[string]interface{}{}}}},
+			map[string]interface{}{"foo": "bar", "child": map[string]interface{}{
+				"grandchild": map[string]interface{}{}}}},
+		{`{"foo": ["bar"]}`, []Operation{{Op: OpAdd, Path: "/foo/-", Value: []string{"abc", "def"}}},
+			map[string]interface{}{"foo": []interface{}{"bar", []interface{}{"abc", "def"}}}},
+		{`{"/": 9, "~1": 10}`, []Operation{{Op: OpTest, Path: "/~01", Value: 10}},
+			map[string]interface{}{"/": 9.0, "~1": 10.0}},
+	}
+	for _, tc := range testCases {
+		out, err := ApplyPatch([]byte(tc.doc), tc.patch)
+		if err != nil {
+			t.Errorf("%s %v: %v", tc.doc, tc.patch, err)
+			continue
+		}
+		var got interface{}
+		if err = Unmarshal(out, &got); err != nil {
+			t.Errorf("%s %v: %v\n%s", tc.doc, tc.patch, err, out)
+		} else if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("%s %v: expected %#v, got %#v", tc.doc, tc.patch, tc.expected, got)
+		}
+	}
+
+	for _, tc := range []struct {
+		doc   string
+		patch []Operation
+	}{
+		{`{"baz": "qux"}`, []Operation{{Op: OpTest, Path: "/baz", Value: "bar"}}},
+		{`{"foo": "bar"}`, []Operation{{Op: OpAdd, Path: "/baz/bat", Value: "qux"}}},
+		{`{"foo": "bar"}`, []Operation{{Op: OpRemove, Path: "/baz"}}},
+		{`{"foo": "bar"}`, []Operation{{Op: OpReplace, Path: "/baz", Value: 1}}},
+		{`{"foo": [1]}`, []Operation{{Op: OpAdd, Path: "/foo/2", Value: 1}}},
+		{`{"foo": {"a": 1}}`, []Operation{{Op: OpMove, From: "/foo", Path: "/foo/b"}}},
+		{`{"foo": "bar"}`, []Operation{{Op: "invalid", Path: "/foo"}}},
+	} {
+		if out, err := ApplyPatch([]byte(tc.doc), tc.patch); err == nil {
+			t.Errorf("%s %v: expected an error, got:\n%s", tc.doc, tc.patch, out)
+		}
+	}
+}
+
+func TestApplyPatchInline(t *testing.T) {
+	doc := "tags: [\"a\", \"b\"] # Tags.\nempty: []\npoint: {x: 1}\n"
+	out, err := ApplyPatch([]byte(doc), []Operation{
+		{Op: OpAdd, Path: "/tags/-", Value: "c d"},
+		{Op: OpAdd, Path: "/tags/0", Value: 1},
+		{Op: OpAdd, Path: "/empty/-", Value: []int{2}},
+		{Op: OpAdd, Path: "/point/y", Value: 2},
+		{Op: OpRemove, Path: "/tags/1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "tags: [1, \"b\", \"c d\"] # Tags.\nempty: [[2]]\npoint: {x: 1, y: 2}\n"
+	if string(out) != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, out)
+	}
+}
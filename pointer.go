@@ -0,0 +1,431 @@
+package hjson
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parsePointer splits the JSON Pointer (RFC 6901) pointer into its unescaped
+// reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("hjson: invalid JSON pointer %q, it must start with '/'", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		for j := 0; j < len(token); j++ {
+			if token[j] == '~' && (j+1 == len(token) || token[j+1] != '0' && token[j+1] != '1') {
+				return nil, fmt.Errorf("hjson: invalid escape in JSON pointer %q", pointer)
+			}
+		}
+		tokens[i] = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+// pointerIndex returns the array index of the reference token, or -1 if
+// token is not an index without leading zeros.
+func pointerIndex(token string) int {
+	if token == "" || len(token) > 1 && token[0] == '0' {
+		return -1
+	}
+	for _, c := range token {
+		if c < '0' || c > '9' {
+			return -1
+		}
+	}
+	n, err := strconv.Atoi(token)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// pointerChild returns the member or element of node referenced by token, or
+// nil if there is none.
+func pointerChild(node *Node, token string) *Node {
+	switch v := node.Value.(type) {
+	case *OrderedMap:
+		child, _ := v.Map[token].(*Node)
+		return child
+	case []interface{}:
+		if i := pointerIndex(token); i >= 0 && i < len(v) {
+			child, _ := v[i].(*Node)
+			return child
+		}
+	}
+	return nil
+}
+
+// resolvePointer returns the Node referenced by tokens, starting from root.
+// format writes the path of a PathNotFoundError.
+func resolvePointer(root *Node, tokens []string, format func([]string) string) (*Node, error) {
+	node := root
+	for i, token := range tokens {
+		if node = pointerChild(node, token); node == nil {
+			return nil, &PathNotFoundError{Path: format(tokens[:i+1])}
+		}
+	}
+	return node, nil
+}
+
+// formatPointer joins tokens into a JSON Pointer, escaping '~' and '/'.
+func formatPointer(tokens []string) string {
+	var sb strings.Builder
+	for _, token := range tokens {
+		sb.WriteByte('/')
+		sb.WriteString(strings.Replace(strings.Replace(token, "~", "~0", -1), "/", "~1", -1))
+	}
+	return sb.String()
+}
+
+// Get returns the Node referenced by the JSON Pointer (RFC 6901) pointer,
+// like "/server/ports/0". The empty pointer "" refers to the root value. A
+// *PathNotFoundError is returned if there is no such value.
+//
+// The returned Node must not be changed, use Set() instead.
+func (d *Document) Get(pointer string) (*Node, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	return resolvePointer(d.root, tokens, formatPointer)
+}
+
+// Set sets the value referenced by the JSON Pointer (RFC 6901) pointer to
+// value, which can be any value supported by Marshal(). The last reference
+// token can name a new member of an object, which is added after the other
+// members, or be "-" to append an element to an array. The empty pointer ""
+// replaces the whole document. A *PathNotFoundError is returned if the object
+// or array that would hold the value does not exist.
+//
+// Only the text of the changed value is replaced, using ApplyEdit(), so that
+// the comments and the formatting of the rest of the document are kept. New
+// members and elements are written on their own line after the last one,
+// with the same indentation, and with the line endings of the document ("\r\n"
+// if its first line ends with it). Elements are appended to arrays written on
+// a single line on the same line. Objects written on a single line are written
+// again on a single line when a member is added, without the comments inside
+// them.
+func (d *Document) Set(pointer string, value interface{}) error {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return err
+	}
+	return d.set(tokens, value, formatPointer)
+}
+
+// set sets the value referenced by tokens, see Set(). format writes the path
+// of a PathNotFoundError.
+func (d *Document) set(tokens []string, value interface{}, format func([]string) string) error {
+	if len(tokens) == 0 {
+		options := DefaultOptions()
+		options.Eol = d.eol()
+		data, err := MarshalWithOptions(value, options)
+		if err != nil {
+			return err
+		}
+		return d.reparse(data)
+	}
+	parent, err := resolvePointer(d.root, tokens[:len(tokens)-1], format)
+	if err != nil {
+		return err
+	}
+	token := tokens[len(tokens)-1]
+
+	if child := pointerChild(parent, token); child != nil {
+		// A quoteless string would include anything following it on its line.
+		rest := d.data[child.End.Offset:]
+		if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+			rest = rest[:i]
+		}
+		quote := len(bytes.TrimSpace(rest)) > 0
+		text, err := d.valueText(value, lineIndent(d.data, child.Pos.Offset), quote)
+		if err != nil {
+			return err
+		}
+		return d.ApplyEdit(child.Pos.Offset, child.End.Offset-child.Pos.Offset, text)
+	}
+
+	var last *Node
+	switch v := parent.Value.(type) {
+	case *OrderedMap:
+		if len(v.Keys) > 0 {
+			last, _ = v.Map[v.Keys[len(v.Keys)-1]].(*Node)
+		}
+	case []interface{}:
+		if token != "-" {
+			return &PathNotFoundError{Path: format(tokens)}
+		}
+		if len(v) > 0 {
+			last, _ = v[len(v)-1].(*Node)
+		}
+	default:
+		return &PathNotFoundError{Path: format(tokens)}
+	}
+	braceless := parent == d.root && !strings.HasPrefix(parent.Lit, "{")
+
+	if last != nil && (braceless || last.Pos.Line != parent.Pos.Line) {
+		// Add the value on a new line after the last member or element.
+		indent := lineIndent(d.data, last.Pos.Offset)
+		var text []byte
+		if _, ok := parent.Value.(*OrderedMap); ok {
+			text, err = d.memberText(token, value, indent)
+		} else if text, err = d.valueText(value, indent, false); err == nil {
+			text = append([]byte(indent), text...)
+		}
+		if err != nil {
+			return err
+		}
+		at := len(d.data)
+		if i := bytes.IndexByte(d.data[last.End.Offset:], '\n'); i >= 0 {
+			at = last.End.Offset + i
+		}
+		if !braceless && at >= parent.End.Offset {
+			at = parent.End.Offset - 1
+		}
+		if at > 0 && d.data[at-1] == '\r' {
+			at--
+		}
+		return d.ApplyEdit(at, 0, append([]byte(d.eol()), text...))
+	}
+
+	if _, ok := parent.Value.([]interface{}); ok && !braceless && !strings.Contains(parent.Lit, "\n") {
+		// Append the element on the same line.
+		text, err := inlineText(value)
+		if err != nil {
+			return err
+		}
+		if last == nil {
+			return d.ApplyEdit(parent.End.Offset-1, 0, text)
+		}
+		return d.ApplyEdit(last.End.Offset, 0, append([]byte(", "), text...))
+	}
+
+	// Write the whole object or array again.
+	var newValue interface{}
+	if _, ok := parent.Value.(*OrderedMap); ok {
+		var om *OrderedMap
+		if err = Unmarshal([]byte(parent.Lit), &om); err != nil {
+			return err
+		}
+		om.Set(token, value)
+		newValue = om
+	} else {
+		var arr []interface{}
+		if err = Unmarshal([]byte(parent.Lit), &arr); err != nil {
+			return err
+		}
+		newValue = append(arr, value)
+	}
+	if braceless {
+		// An empty document.
+		text, err := d.memberText(token, value, "")
+		if err != nil {
+			return err
+		}
+		return d.ApplyEdit(len(d.data), 0, text)
+	}
+	return d.rewrite(parent, newValue)
+}
+
+// remove removes the member or element referenced by tokens, together with
+// the comments before it and the rest of its last line. Objects and arrays
+// written on a single line are written again. format writes the path of a
+// PathNotFoundError.
+func (d *Document) remove(tokens []string, format func([]string) string) error {
+	if len(tokens) == 0 {
+		return d.reparse(nil)
+	}
+	parent, err := resolvePointer(d.root, tokens[:len(tokens)-1], format)
+	if err != nil {
+		return err
+	}
+	child := pointerChild(parent, tokens[len(tokens)-1])
+	if child == nil {
+		return &PathNotFoundError{Path: format(tokens)}
+	}
+	braceless := parent == d.root && !strings.HasPrefix(parent.Lit, "{")
+
+	start := d.lineStart(parent, child)
+	// It ends with the line ending after its trailing comment.
+	end := child.End.Offset + len(child.Cm.After)
+	rest := d.data[end:]
+	if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+		rest = rest[:i+1]
+	}
+	if start >= 0 && len(bytes.Trim(rest, " \t\r\n,")) == 0 && bytes.Count(rest, []byte(",")) <= 1 {
+
+		return d.ApplyEdit(start, end+len(rest)-start, nil)
+	}
+
+	// Write the whole object or array again.
+	var newValue interface{}
+	if _, ok := parent.Value.(*OrderedMap); ok {
+		var om *OrderedMap
+		if err = Unmarshal([]byte(parent.Lit), &om); err != nil {
+			return err
+		}
+		om.DeleteKey(tokens[len(tokens)-1])
+		newValue = om
+	} else {
+		var arr []interface{}
+		if err = Unmarshal([]byte(parent.Lit), &arr); err != nil {
+			return err
+		}
+		i := pointerIndex(tokens[len(tokens)-1])
+		newValue = append(arr[:i:i], arr[i+1:]...)
+	}
+	if braceless {
+		return d.set(nil, newValue, format)
+	}
+	return d.rewrite(parent, newValue)
+}
+
+// lineStart returns the offset of the first line of the member or element
+// child of parent, including the comments before it, or -1 if child does not
+// start on its own line.
+func (d *Document) lineStart(parent, child *Node) int {
+	braceless := parent == d.root && !strings.HasPrefix(parent.Lit, "{")
+	if !braceless && child.Pos.Line == parent.Pos.Line {
+		return -1
+	}
+	start := child.Pos.Offset
+	if _, ok := parent.Value.(*OrderedMap); ok {
+		start -= len(child.Cm.Key) + 1 + len(child.keyLit)
+		if start < 0 || !bytes.HasPrefix(d.data[start:], []byte(child.keyLit+":")) {
+			return -1
+		}
+	}
+	if start < len(child.Cm.Before) {
+		return -1
+	}
+	start -= len(child.Cm.Before)
+	if start > 0 && d.data[start-1] != '\n' {
+		return -1
+	}
+	return start
+}
+
+// insert inserts value into the array referenced by all but the last of
+// tokens, before the element with the index in the last token. format writes
+// the path of a PathNotFoundError.
+func (d *Document) insert(tokens []string, value interface{}, format func([]string) string) error {
+	parent, err := resolvePointer(d.root, tokens[:len(tokens)-1], format)
+	if err != nil {
+		return err
+	}
+	arr, ok := parent.Value.([]interface{})
+	i := pointerIndex(tokens[len(tokens)-1])
+	if !ok || i < 0 || i > len(arr) {
+		return &PathNotFoundError{Path: format(tokens)}
+	}
+	if i == len(arr) {
+		return d.set(append(tokens[:len(tokens)-1:len(tokens)-1], "-"), value, format)
+	}
+
+	child, _ := arr[i].(*Node)
+	if start := d.lineStart(parent, child); start >= 0 {
+		indent := lineIndent(d.data, child.Pos.Offset)
+		text, err := d.valueText(value, indent, false)
+		if err != nil {
+			return err
+		}
+		text = append(append([]byte(indent), text...), d.eol()...)
+		return d.ApplyEdit(start, 0, text)
+	}
+	if !strings.Contains(parent.Lit, "\n") {
+		// Insert the element on the same line.
+		text, err := inlineText(value)
+		if err != nil {
+			return err
+		}
+		return d.ApplyEdit(child.Pos.Offset, 0, append(text, ", "...))
+	}
+
+	// Write the whole array again.
+	var newValue []interface{}
+	if err = Unmarshal([]byte(parent.Lit), &newValue); err != nil {
+		return err
+	}
+	newValue = append(newValue[:i], append([]interface{}{value}, newValue[i:]...)...)
+	return d.rewrite(parent, newValue)
+}
+
+// lineIndent returns the spaces and tabs at the start of the line containing
+// offset in data.
+func lineIndent(data []byte, offset int) string {
+	start := bytes.LastIndexByte(data[:offset], '\n') + 1
+	end := start
+	for end < offset && (data[end] == ' ' || data[end] == '\t') {
+		end++
+	}
+	return string(data[start:end])
+}
+
+// eol returns the line ending of the first line of the document, "\n" if it
+// has a single line.
+func (d *Document) eol() string {
+	if i := bytes.IndexByte(d.data, '\n'); i > 0 && d.data[i-1] == '\r' {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// rewrite replaces the object or array parent with newValue. If parent is
+// written on a single line, so is newValue.
+func (d *Document) rewrite(parent *Node, newValue interface{}) error {
+	var text []byte
+	var err error
+	if strings.Contains(parent.Lit, "\n") {
+		text, err = d.valueText(newValue, lineIndent(d.data, parent.Pos.Offset), false)
+	} else {
+		text, err = inlineText(newValue)
+	}
+	if err != nil {
+		return err
+	}
+	return d.ApplyEdit(parent.Pos.Offset, parent.End.Offset-parent.Pos.Offset, text)
+}
+
+// valueText encodes value for a line starting with indent. If quote is true
+// strings are always quoted.
+func (d *Document) valueText(value interface{}, indent string, quote bool) ([]byte, error) {
+	options := DefaultOptions()
+	options.Eol = d.eol()
+	options.BaseIndentation = indent
+	options.QuoteAlways = quote
+	text, err := MarshalWithOptions(value, options)
+	if err != nil {
+		return nil, err
+	}
+	return text[len(indent):], nil
+}
+
+// memberText encodes an object member with key and value, indented by
+// indent.
+func (d *Document) memberText(key string, value interface{}, indent string) ([]byte, error) {
+	om := NewOrderedMap()
+	om.Set(key, value)
+	options := DefaultOptions()
+	options.Eol = d.eol()
+	options.BaseIndentation = indent
+	options.EmitRootBraces = false
+	return MarshalWithOptions(om, options)
+}
+
+// inlineText encodes value on a single line, with all strings quoted so that
+// they end before anything following them on the line.
+func inlineText(value interface{}) ([]byte, error) {
+	options := DefaultOptions()
+	options.Eol, options.IndentBy = "", ""
+	options.BracesSameLine = true
+	options.QuoteAlways = true
+	options.Comments = false
+	return MarshalWithOptions(value, options)
+}
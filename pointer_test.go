@@ -0,0 +1,136 @@
+package hjson
+
+import (
+	"testing"
+)
+
+func TestDocumentPointer(t *testing.T) {
+	doc, err := ParseDocument([]byte(`# config
+server: {
+  # The host name.
+  host: localhost
+  ports: [80, 443]
+  "a/b~c": 1.50
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for pointer, expected := range map[string]interface{}{
+		"/server/host":    "localhost",
+		"/server/ports/1": 443.0,
+		"/server/a~1b~0c": 1.5,
+	} {
+		node, err := doc.Get(pointer)
+		if err != nil {
+			t.Errorf("%s: %v", pointer, err)
+		} else if node.Value != expected {
+			t.Errorf("%s: expected %v, got %v", pointer, expected, node.Value)
+		}
+	}
+	if node, err := doc.Get(""); err != nil || node != doc.Root() {
+		t.Errorf("Expected the root, got %v, %v", node, err)
+	}
+
+	for pointer, missing := range map[string]string{
+		"/server/port":       "/server/port",
+		"/server/ports/2":    "/server/ports/2",
+		"/server/ports/01":   "/server/ports/01",
+		"/server/ports/-":    "/server/ports/-",
+		"/server/host/x/y":   "/server/host/x",
+		"/missing/a~1b/more": "/missing",
+	} {
+		_, err := doc.Get(pointer)
+		if pe, ok := err.(*PathNotFoundError); !ok || pe.Path != missing {
+			t.Errorf("%s: unexpected error %v", pointer, err)
+		}
+	}
+	for _, pointer := range []string{"server", "/server/~2", "/server/a~"} {
+		if _, err := doc.Get(pointer); err == nil {
+			t.Errorf("%s: expected an error", pointer)
+		} else if _, ok := err.(*PathNotFoundError); ok {
+			t.Errorf("%s: unexpected error %v", pointer, err)
+		}
+	}
+
+	if err = doc.Set("/server/host", "example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err = doc.Set("/server/ports/-", 8080); err != nil {
+		t.Fatal(err)
+	}
+	if err = doc.Set("/server/tls", map[string]interface{}{"cert": "a.pem"}); err != nil {
+		t.Fatal(err)
+	}
+	expected := `# config
+server: {
+  # The host name.
+  host: example.com
+  ports: [80, 443, 8080]
+  "a/b~c": 1.50
+  tls: {
+    cert: a.pem
+  }
+}
+`
+	if string(doc.Bytes()) != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, doc.Bytes())
+	}
+	if node, err := doc.Get("/server/tls/cert"); err != nil || node.Value != "a.pem" {
+		t.Errorf("Unexpected result %v, %v", node, err)
+	}
+
+	before := string(doc.Bytes())
+	if err = doc.Set("/server/ports/3", 1); err == nil {
+		t.Error("Expected an error for an index out of range")
+	}
+	if err = doc.Set("/missing/key", 1); err == nil {
+		t.Error("Expected an error for a missing parent")
+	}
+	if string(doc.Bytes()) != before {
+		t.Errorf("The document was changed by a failed Set:\n%s", doc.Bytes())
+	}
+
+	doc, err = ParseDocument([]byte("a: 1 # One.\nlist: [\n  x\n]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = doc.Set("/b", "two words"); err != nil {
+		t.Fatal(err)
+	}
+	if err = doc.Set("/list/-", map[string]int{"n": 1}); err != nil {
+		t.Fatal(err)
+	}
+	expected = "a: 1 # One.\nlist: [\n  x\n  {\n    n: 1\n  }\n]\nb: two words\n"
+	if string(doc.Bytes()) != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, doc.Bytes())
+	}
+
+	if err = doc.Set("", []int{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if node, err := doc.Get("/1"); err != nil || node.Value != 2.0 {
+		t.Errorf("Unexpected result %v, %v", node, err)
+	}
+}
+
+func TestDocumentSetCRLF(t *testing.T) {
+	doc, err := ParseDocument([]byte("a: 1\r\nlist: [\r\n  x\r\n]\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = doc.Set("/list/-", "y"); err != nil {
+		t.Fatal(err)
+	}
+	if err = doc.Set("/list/0", "z"); err != nil {
+		t.Fatal(err)
+	}
+	if err = doc.Set("/b", map[string]int{"n": 1}); err != nil {
+		t.Fatal(err)
+	}
+	expected := "a: 1\r\nlist: [\r\n  z\r\n  y\r\n]\r\nb: {\r\n  n: 1\r\n}\r\n"
+	if string(doc.Bytes()) != expected {
+		t.Errorf("Expected %q, got %q", expected, doc.Bytes())
+	}
+}
@@ -0,0 +1,235 @@
+package hjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+type registeredType struct {
+	key, value string
+}
+
+var registeredTypes = struct {
+	sync.RWMutex
+	// byKey maps discriminator keys and their values to types.
+	byKey map[string]map[string]reflect.Type
+	// byType maps types to their discriminator key and value.
+	byType map[reflect.Type]registeredType
+}{
+	byKey:  map[string]map[string]reflect.Type{},
+	byType: map[reflect.Type]registeredType{},
+}
+
+// RegisterType registers t as the type of objects with the member key set to
+// the string value, the discriminator, for decoding into interface types that
+// t (or a pointer to t) implements. This is meant for plugin-style configs,
+// where an object is decoded into the type named by a member like
+// kind: webhook:
+//
+//	hjson.RegisterType("kind", "webhook", reflect.TypeOf(Webhook{}))
+//
+// An object decoded into a nil value of a non-empty interface type is first
+// read to find the discriminator, and then read again as the registered type.
+// The new value is assigned if t implements the interface, otherwise a
+// pointer to it. The discriminator member is left out if t has no field for
+// it. Objects without a registered discriminator are decoded as usual, which
+// fails for nil values of non-empty interface types.
+//
+// When a value of type t is encoded as an object, the discriminator member is
+// written first, unless t has a field for it.
+//
+// Registering a type again replaces its previous discriminator. RegisterType
+// is safe for concurrent use, but is normally called from init functions.
+func RegisterType(key, value string, t reflect.Type) {
+	registeredTypes.Lock()
+	defer registeredTypes.Unlock()
+	if old, ok := registeredTypes.byType[t]; ok {
+		delete(registeredTypes.byKey[old.key], old.value)
+	}
+	if registeredTypes.byKey[key] == nil {
+		registeredTypes.byKey[key] = map[string]reflect.Type{}
+	}
+	registeredTypes.byKey[key][value] = t
+	registeredTypes.byType[t] = registeredType{key: key, value: value}
+}
+
+// hasRegisteredTypes returns true if RegisterType() has been called.
+func hasRegisteredTypes() bool {
+	registeredTypes.RLock()
+	defer registeredTypes.RUnlock()
+	return len(registeredTypes.byType) > 0
+}
+
+// lookupRegisteredType returns the registered type of the object om that
+// implements the interface it, and the discriminator key.
+func lookupRegisteredType(om *OrderedMap, it reflect.Type) (reflect.Type, string) {
+	registeredTypes.RLock()
+	defer registeredTypes.RUnlock()
+	for _, key := range om.Keys {
+		values := registeredTypes.byKey[key]
+		value, ok := om.Map[key].(string)
+		if values == nil || !ok {
+			continue
+		}
+		if t, ok := values[value]; ok &&
+			(t.Implements(it) || reflect.PtrTo(t).Implements(it)) {
+
+			return t, key
+		}
+	}
+	return nil, ""
+}
+
+// discriminatorField returns the discriminator member written for the
+// struct value, if its type is registered and has no field named like the
+// discriminator key.
+func discriminatorField(value reflect.Value, sfis []structFieldInfo) (fieldInfo, bool) {
+	registeredTypes.RLock()
+	rt, ok := registeredTypes.byType[value.Type()]
+	registeredTypes.RUnlock()
+	if !ok {
+		return fieldInfo{}, false
+	}
+	for _, sfi := range sfis {
+		if sfi.name == rt.key {
+			return fieldInfo{}, false
+		}
+	}
+	return fieldInfo{field: reflect.ValueOf(rt.value), name: rt.key}, true
+}
+
+var interfaceFactories = struct {
+	sync.RWMutex
+	m map[reflect.Type]func() interface{}
+}{m: map[reflect.Type]func() interface{}{}}
+
+// registerInterfaceFactory is called by RegisterInterface() with the
+// interface type it.
+func registerInterfaceFactory(it reflect.Type, factory func() interface{}) {
+	if it.Kind() != reflect.Interface {
+		panic("hjson: RegisterInterface of non-interface type " + it.String())
+	}
+	interfaceFactories.Lock()
+	defer interfaceFactories.Unlock()
+	interfaceFactories.m[it] = factory
+}
+
+// lookupInterfaceFactory returns the factory registered for the interface
+// type it, or nil.
+func lookupInterfaceFactory(it reflect.Type) func() interface{} {
+	interfaceFactories.RLock()
+	defer interfaceFactories.RUnlock()
+	return interfaceFactories.m[it]
+}
+
+// interfaceDest returns the type of the destination, if it is a nil value of
+// a non-empty interface type that objects can be decoded into with
+// RegisterType() or RegisterInterface().
+func (p *hjsonParser) interfaceDest(dest reflect.Value, t reflect.Type) reflect.Type {
+	if p.nodeDestination || !p.willMarshalToJSON || t == nil {
+		return nil
+	}
+	_, ut := unravelDestination(dest, t)
+	if ut.Kind() != reflect.Interface || ut.NumMethod() == 0 ||
+		!hasRegisteredTypes() && lookupInterfaceFactory(ut) == nil {
+
+		return nil
+	}
+	return ut
+}
+
+// readRegisteredType reads an object for a destination of the interface type
+// it. If the object has the discriminator of a type registered with
+// RegisterType(), it is read again as that type. Otherwise it is read into the
+// value returned by the factory registered with RegisterInterface(), if any.
+// The new value is assigned after json.Unmarshal(), so nil is returned in its
+// place.
+func (p *hjsonParser) readRegisteredType(it reflect.Type, ciBefore commentInfo) (interface{}, error) {
+	at, ch := p.at, p.ch
+	fixupAt, errsAt := len(p.fixups), len(p.errs)
+
+	// proto is the value to read the object into: a pointer or a value of a
+	// type implementing it.
+	var proto reflect.Value
+	var key string
+	if hasRegisteredTypes() {
+		v, err := p.readObject(false, reflect.Value{}, nil, ciBefore)
+		if err != nil {
+			return nil, err
+		}
+		om, ok := v.(*OrderedMap)
+		if !ok {
+			return v, nil
+		}
+		var t reflect.Type
+		if t, key = lookupRegisteredType(om, it); t != nil {
+			proto = reflect.New(t)
+			if t.Implements(it) {
+				proto = proto.Elem()
+			}
+		} else if lookupInterfaceFactory(it) == nil {
+			return v, nil
+		}
+		p.at, p.ch = at, ch
+		p.fixups, p.errs = p.fixups[:fixupAt], p.errs[:errsAt]
+	}
+	if !proto.IsValid() {
+		if proto = reflect.ValueOf(lookupInterfaceFactory(it)()); !proto.IsValid() {
+			return p.readObject(false, reflect.Value{}, nil, ciBefore)
+		}
+	}
+
+	// Read into a pointer to the value, unless proto already is one.
+	ptr, isPtr := proto, proto.Kind() == reflect.Ptr && !proto.IsNil()
+	if !isPtr {
+		ptr = reflect.New(proto.Type())
+		ptr.Elem().Set(proto)
+	}
+	t := ptr.Type().Elem()
+	v, err := p.readObject(false, ptr.Elem(), t, ciBefore)
+	if err != nil {
+		return nil, err
+	}
+	if om, ok := v.(*OrderedMap); ok && key != "" {
+		if _, ok := getStructFieldInfoMap(t).getField(key); !ok {
+			om.DeleteKey(key)
+		}
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if p.UseJSONNumber || p.UseInt64 {
+		dec.UseNumber()
+	}
+	if p.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if err = dec.Decode(ptr.Interface()); err != nil {
+		return nil, err
+	}
+	if p.UseInt64 && !p.UseJSONNumber {
+		replaceJSONNumbers(ptr, 0)
+	}
+	// The fixups of the object were made for paths below p.path, apply them
+	// to the new value before it is assigned.
+	for _, f := range p.fixups[fixupAt:] {
+		if !applyFixup(ptr, f.path[len(p.path):], f.value) && !f.ignorable {
+			return nil, fmt.Errorf("cannot assign %v to %v", f.describe(), t)
+		}
+	}
+
+	value := ptr.Interface()
+	if !isPtr {
+		value = ptr.Elem().Interface()
+	}
+	p.fixups = append(p.fixups[:fixupAt], destFixup{
+		path:  append([]interface{}(nil), p.path...),
+		value: value,
+	})
+	return nil, nil
+}
@@ -0,0 +1,112 @@
+package hjson
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type polyPlugin interface {
+	PluginName() string
+}
+
+type polyWebhook struct {
+	URL     string        `json:"url"`
+	Timeout time.Duration `json:"timeout"`
+}
+
+func (w *polyWebhook) PluginName() string { return "webhook" }
+
+type polyLog struct {
+	Kind  string `json:"kind"`
+	Level string `json:"level"`
+}
+
+func (l polyLog) PluginName() string { return "log" }
+
+type polyConfig struct {
+	Main    polyPlugin   `json:"main"`
+	Plugins []polyPlugin `json:"plugins"`
+}
+
+func init() {
+	RegisterType("kind", "webhook", reflect.TypeOf(polyWebhook{}))
+	RegisterType("kind", "log", reflect.TypeOf(polyLog{}))
+}
+
+func TestRegisterType(t *testing.T) {
+	var c polyConfig
+	err := Unmarshal([]byte(`{
+  main: {
+    url: https://example.com/hook
+    kind: webhook
+    timeout: 5s
+  }
+  plugins: [
+    {
+      kind: log
+      level: debug
+    }
+    {
+      kind: webhook
+      url: https://example.com/other
+    }
+  ]
+}`), &c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := polyConfig{
+		Main: &polyWebhook{URL: "https://example.com/hook", Timeout: 5 * time.Second},
+		Plugins: []polyPlugin{
+			polyLog{Kind: "log", Level: "debug"},
+			&polyWebhook{URL: "https://example.com/other"},
+		},
+	}
+	if !reflect.DeepEqual(c, expected) {
+		t.Errorf("Expected:\n%#v\nGot:\n%#v", expected, c)
+	}
+
+	out, err := Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  main: {
+    kind: webhook
+    url: https://example.com/hook
+    timeout: 5s
+  }
+  plugins: [
+    {
+      kind: log
+      level: debug
+    }
+    {
+      kind: webhook
+      url: https://example.com/other
+      timeout: 0s
+    }
+  ]
+}`
+	if string(out) != want {
+		t.Errorf("Expected:\n%s\nGot:\n%s", want, out)
+	}
+
+	var p polyPlugin
+	if err = Unmarshal([]byte("kind: log\nlevel: info"), &p); err == nil {
+		// Root objects without braces are not inspected.
+		t.Errorf("Expected an error, got %#v", p)
+	}
+	if err = Unmarshal([]byte("{\n  kind: log\n  level: info\n}"), &p); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(p, polyLog{Kind: "log", Level: "info"}) {
+		t.Errorf("Unexpected value %#v", p)
+	}
+
+	c = polyConfig{}
+	if err = Unmarshal([]byte("{\n  main: {\n    kind: unknown\n  }\n}"), &c); err == nil {
+		t.Error("Expected an error for an unregistered discriminator")
+	}
+}
@@ -0,0 +1,91 @@
+package hjson
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// PreformattedValue is Hjson text that Marshal() inserts verbatim into its
+// output, for example a templated section or a hand-tuned fragment inside an
+// otherwise generated document. The text must be a single valid Hjson value,
+// optionally with comments before and after it, which are kept. All lines but
+// the first are re-indented to the level where the value is written. The
+// text of a root object without braces is written as the members of an object
+// with braces.
+//
+// The text is decoded and encoded again instead if it cannot be written
+// verbatim: if it starts with a multiline string (whose content depends on its
+// column) or if the output has no line feeds (EncoderOptions.Eol is empty).
+//
+// Unlike RawMessage, which also captures values when decoding, a
+// PreformattedValue is only used for encoding. json.Marshal() converts it to
+// JSON.
+type PreformattedValue []byte
+
+var preformattedValueType = reflect.TypeOf(PreformattedValue(nil))
+
+// MarshalJSON is an implementation of the json.Marshaler interface, converting
+// the Hjson text in v to JSON. The order of object keys is kept.
+func (v PreformattedValue) MarshalJSON() ([]byte, error) {
+	return RawMessage(v).MarshalJSON()
+}
+
+// writePreformatted writes the text of v, see PreformattedValue.
+func (e *hjsonEncoder) writePreformatted(
+	v PreformattedValue,
+	noIndent bool,
+	separator string,
+	isRootObject,
+	isObjElement bool,
+) error {
+	text := strings.TrimRight(strings.Replace(string(v), "\r\n", "\n", -1), " \t\n")
+	// Keep the indentation of the first line, which matters for objects
+	// without braces.
+	text = strings.TrimLeft(text, "\n")
+	if strings.TrimSpace(text) == "" {
+		e.WriteString(separator)
+		e.writeNull()
+		return nil
+	}
+
+	var node Node
+	if err := Unmarshal(v, &node); err != nil {
+		return errors.New("Invalid hjson.PreformattedValue: " + err.Error())
+	}
+	_, isObject := node.Value.(*OrderedMap)
+	braceless := isObject && !strings.HasPrefix(node.Lit, "{")
+	lines := strings.Split(dedentCommon(text, !braceless), "\n")
+	if e.Eol == "" || strings.Contains(lines[0], "'''") {
+		return e.str(reflect.ValueOf(&node), noIndent, separator, isRootObject,
+			isObjElement, Comments{})
+	}
+
+	indent := e.indent
+	if braceless {
+		if !isRootObject || e.EmitRootBraces {
+			e.WriteString(separator + "{")
+			indent++
+		} else {
+			e.writeIndentNoEOL(indent)
+			e.WriteString(lines[0])
+			lines = lines[1:]
+		}
+	} else {
+		e.WriteString(separator + lines[0])
+		lines = lines[1:]
+	}
+	for _, line := range lines {
+		if line == "" {
+			e.WriteString(e.Eol)
+			continue
+		}
+		e.writeIndent(indent)
+		e.WriteString(line)
+	}
+	if indent > e.indent {
+		e.writeIndent(e.indent)
+		e.WriteString("}")
+	}
+	return nil
+}
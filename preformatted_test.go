@@ -0,0 +1,67 @@
+package hjson
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPreformattedValue(t *testing.T) {
+	type Config struct {
+		Name   string
+		Routes PreformattedValue
+		Extra  PreformattedValue
+		Empty  PreformattedValue
+	}
+	v := Config{
+		Name: "svc",
+		Routes: PreformattedValue(`[
+    # most specific first
+    { path: "/api/v1",  backend: "api-v1" }
+    { path: "/",        backend: "web"    }
+  ]`),
+		Extra: PreformattedValue("\n  timeout:  30  # seconds\n  retries:  3\n"),
+	}
+	b, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := `{
+  Name: svc
+  Routes: [
+    # most specific first
+    { path: "/api/v1",  backend: "api-v1" }
+    { path: "/",        backend: "web"    }
+  ]
+  Extra: {
+    timeout:  30  # seconds
+    retries:  3
+  }
+  Empty: null
+}`
+	if string(b) != exp {
+		t.Errorf("Expected:\n%s\n\nGot:\n%s", exp, b)
+	}
+
+	j, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expJSON := `{"Name":"svc","Routes":[{"path":"/api/v1","backend":"api-v1"},{"path":"/","backend":"web"}],` +
+		`"Extra":{"timeout":30,"retries":3},"Empty":null}`
+	if string(j) != expJSON {
+		t.Errorf("Expected:\n%s\n\nGot:\n%s", expJSON, j)
+	}
+
+	// A multiline string depends on its column and is encoded again.
+	b, err = Marshal(map[string]interface{}{"text": PreformattedValue("'''\na\nb\n'''")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "{\n  text:\n    '''\n    a\n    b\n    '''\n}" {
+		t.Errorf("Unexpected output:\n%s", b)
+	}
+
+	if _, err = Marshal(PreformattedValue("{a: 1")); err == nil {
+		t.Error("Expected an error for invalid Hjson")
+	}
+}
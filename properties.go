@@ -0,0 +1,221 @@
+package hjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// flatWriter writes the values of a node tree as flat key/value lines.
+type flatWriter struct {
+	bytes.Buffer
+	formatKey func(path []string) string
+	formatVal func(s string) string
+}
+
+// ToProperties converts the Hjson document data to the Java .properties
+// format. Each value is written on a line of its own like "db.ports.0=80",
+// using the keys of Flatten() with the separator ".". Comments are kept as
+// lines starting with "#", placed before the values they belong to. Empty
+// objects and arrays are written as "{}" and "[]", and null as an empty value.
+// The output is encoded as UTF-8.
+func ToProperties(data []byte) ([]byte, error) {
+	w := flatWriter{
+		formatKey: func(path []string) string {
+			return escapeProperty(strings.Join(path, "."), true)
+		},
+		formatVal: func(s string) string {
+			return escapeProperty(s, false)
+		},
+	}
+	return w.convert(data)
+}
+
+// ToDotenv converts the Hjson document data to the .env format, read by many
+// deployment tools and shells. It works like ToProperties(), but the keys are
+// joined by "_", converted to upper case and any characters other than
+// letters, digits and "_" are replaced by "_", for example "DB_PORTS_0=80".
+// Values containing other characters than letters, digits and "_-.,/:@+" are
+// written in double quotes.
+func ToDotenv(data []byte) ([]byte, error) {
+	w := flatWriter{
+		formatKey: func(path []string) string {
+			return strings.Map(func(r rune) rune {
+				switch {
+				case r >= 'a' && r <= 'z':
+					return r - 'a' + 'A'
+				case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+					return r
+				}
+				return '_'
+			}, strings.Join(path, "_"))
+		},
+		formatVal: quoteDotenv,
+	}
+	return w.convert(data)
+}
+
+func (w *flatWriter) convert(data []byte) ([]byte, error) {
+	root, err := ParseNode(data)
+	if err != nil {
+		return nil, err
+	}
+	switch root.Value.(type) {
+	case *OrderedMap, []interface{}:
+	default:
+		return nil, errors.New("hjson: the root of the document must be an object or an array")
+	}
+	w.writeNode(root, nil)
+	return w.Bytes(), nil
+}
+
+func (w *flatWriter) writeNode(node *Node, path []string) {
+	w.writeComments(node.Cm.Before, node.Cm.Key)
+
+	var children []*Node
+	var keys []string
+	switch v := node.Value.(type) {
+	case *OrderedMap:
+		for _, key := range v.Keys {
+			if child, ok := v.Map[key].(*Node); ok {
+				children = append(children, child)
+				keys = append(keys, key)
+			}
+		}
+	case []interface{}:
+		for i, elem := range v {
+			if child, ok := elem.(*Node); ok {
+				children = append(children, child)
+				keys = append(keys, strconv.Itoa(i))
+			}
+		}
+	default:
+		w.writeComments(node.Cm.After)
+		w.writeLine(path, w.formatVal(flatString(node)))
+		return
+	}
+
+	w.writeComments(node.Cm.InsideFirst)
+	if len(children) == 0 && len(path) > 0 {
+		if _, ok := node.Value.(*OrderedMap); ok {
+			w.writeLine(path, w.formatVal("{}"))
+		} else {
+			w.writeLine(path, w.formatVal("[]"))
+		}
+	}
+	for i, child := range children {
+		w.writeNode(child, append(path[:len(path):len(path)], keys[i]))
+	}
+	w.writeComments(node.Cm.InsideLast, node.Cm.After)
+}
+
+func (w *flatWriter) writeLine(path []string, value string) {
+	w.WriteString(w.formatKey(path) + "=" + value + "\n")
+}
+
+// writeComments writes the text of all comments in cms as lines starting with
+// "#", removing the comment markers.
+func (w *flatWriter) writeComments(cms ...string) {
+	for _, cm := range cms {
+		inBlock := false
+		for _, line := range strings.Split(cm, "\n") {
+			line = strings.TrimSpace(line)
+			if !inBlock && line == "" {
+				continue
+			}
+			switch {
+			case inBlock:
+			case strings.HasPrefix(line, "#"):
+				line = line[1:]
+			case strings.HasPrefix(line, "//"):
+				line = line[2:]
+			case strings.HasPrefix(line, "/*"):
+				line = line[2:]
+				inBlock = true
+			}
+			if inBlock {
+				if i := strings.Index(line, "*/"); i >= 0 {
+					line = line[:i]
+					inBlock = false
+				} else if strings.HasPrefix(line, "*") {
+					line = line[1:]
+				}
+			}
+			if line = strings.TrimSpace(line); line == "" {
+				w.WriteString("#\n")
+			} else {
+				w.WriteString("# " + line + "\n")
+			}
+		}
+	}
+}
+
+// flatString returns the text of a scalar value.
+func flatString(node *Node) string {
+	switch v := node.Value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	if node.Lit != "" {
+		return node.Lit
+	}
+	b, _ := json.Marshal(node.Value)
+	return string(b)
+}
+
+// escapeProperty escapes s for use as a key (if isKey is true) or a value in a
+// .properties file.
+func escapeProperty(s string, isKey bool) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\f':
+			b.WriteString(`\f`)
+		case ' ':
+			if isKey || i == 0 {
+				b.WriteString(`\ `)
+			} else {
+				b.WriteRune(r)
+			}
+		case '=', ':', '#', '!':
+			if isKey || i == 0 {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// quoteDotenv returns s, in double quotes if needed in a .env file.
+func quoteDotenv(s string) string {
+	plain := true
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+			strings.ContainsRune("_-.,/:@+", r)) {
+			plain = false
+			break
+		}
+	}
+	if plain {
+		return s
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "$", `\$`, "`", "\\`")
+	return `"` + r.Replace(s) + `"`
+}
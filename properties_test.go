@@ -0,0 +1,77 @@
+package hjson
+
+import (
+	"testing"
+)
+
+const flatInput = `{
+  # Database settings.
+  db: {
+    host: local host
+    /* The ports
+       to listen on. */
+    ports: [
+      80
+      443
+    ]
+    user: "$admin" // Not root.
+  }
+  tags: []
+  debug: true
+  "key with=": null
+  text:
+    '''
+    line 1
+    line 2
+    '''
+}`
+
+func TestToProperties(t *testing.T) {
+	out, err := ToProperties([]byte(flatInput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := `# Database settings.
+db.host=local host
+# The ports
+# to listen on.
+db.ports.0=80
+db.ports.1=443
+# Not root.
+db.user=$admin
+tags=[]
+debug=true
+key\ with\==
+text=line 1\nline 2
+`
+	if string(out) != exp {
+		t.Errorf("Expected:\n%s\nGot:\n%s", exp, out)
+	}
+
+	if _, err := ToProperties([]byte("5")); err == nil {
+		t.Error("Expected an error for a root value that is not an object or array")
+	}
+}
+
+func TestToDotenv(t *testing.T) {
+	out, err := ToDotenv([]byte(flatInput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := `# Database settings.
+DB_HOST="local host"
+# The ports
+# to listen on.
+DB_PORTS_0=80
+DB_PORTS_1=443
+# Not root.
+DB_USER="\$admin"
+TAGS="[]"
+DEBUG=true
+KEY_WITH_=
+TEXT="line 1\nline 2"
+`
+	if string(out) != exp {
+		t.Errorf("Expected:\n%s\nGot:\n%s", exp, out)
+	}
+}
@@ -0,0 +1,121 @@
+package hjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// RawMessage is a raw Hjson value. It can be used as a destination in
+// Unmarshal() to capture the Hjson text of a value, including its comments and
+// formatting, for example to decode it later or to pass it through unchanged.
+// When encoding, the text is written verbatim by Marshal() and converted to
+// JSON by json.Marshal().
+//
+// A RawMessage decoded from a root object without braces holds the whole
+// document with braces added, so that it is always a single Hjson value.
+type RawMessage []byte
+
+var rawMessageType = reflect.TypeOf(RawMessage(nil))
+
+// MarshalJSON is an implementation of the json.Marshaler interface, converting
+// the Hjson text in m to JSON. The order of object keys is kept.
+func (m RawMessage) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return []byte("null"), nil
+	}
+	var node Node
+	if err := Unmarshal(m, &node); err != nil {
+		return nil, err
+	}
+	return json.Marshal(node)
+}
+
+// UnmarshalJSON is an implementation of the json.Unmarshaler interface,
+// setting *m to a copy of data. JSON is valid Hjson.
+func (m *RawMessage) UnmarshalJSON(data []byte) error {
+	if m == nil {
+		return errors.New("hjson.RawMessage: UnmarshalJSON on nil pointer")
+	}
+	*m = append((*m)[0:0], data...)
+	return nil
+}
+
+// readRaw reads a value for a destination of type RawMessage. It returns nil
+// and stores the text of the value in p.fixups.
+func (p *hjsonParser) readRaw() (interface{}, error) {
+	p.white()
+	start := p.at - 1
+	if _, err := p.readValue(reflect.Value{}, nil); err != nil {
+		return nil, err
+	}
+	end := p.valueEnd
+	if end > len(p.data) {
+		end = len(p.data)
+	}
+	raw := bytes.TrimRight(p.data[start:end], " \t\r\n")
+	p.fixups = append(p.fixups, destFixup{
+		path:  append([]interface{}(nil), p.path...),
+		value: RawMessage(append([]byte(nil), raw...)),
+	})
+	return nil, nil
+}
+
+// unmarshalRaw stores the whole document data in raw, after checking that it
+// is valid.
+func unmarshalRaw(data []byte, raw *RawMessage, options DecoderOptions) error {
+	var node Node
+	if err := UnmarshalWithOptions(data, &node, options); err != nil {
+		return err
+	}
+	text := bytes.TrimSpace(data)
+	if _, ok := node.Value.(*OrderedMap); ok && !strings.HasPrefix(node.Lit, "{") {
+		// A root object without braces.
+		text = append(append([]byte("{\n"), text...), "\n}"...)
+	}
+	*raw = append((*raw)[0:0], text...)
+	return nil
+}
+
+// writeRaw writes the text in raw, indenting all lines except the first one
+// by the current indentation. Values that cannot be written verbatim, like
+// multiline strings (which depend on their column), are decoded and encoded
+// again.
+func (e *hjsonEncoder) writeRaw(
+	raw RawMessage,
+	noIndent bool,
+	separator string,
+	isRootObject,
+	isObjElement bool,
+) error {
+	if len(bytes.TrimSpace(raw)) == 0 {
+		e.WriteString(separator)
+		e.writeNull()
+		return nil
+	}
+
+	var node Node
+	if err := Unmarshal(raw, &node); err != nil {
+		return errors.New("Invalid hjson.RawMessage: " + err.Error())
+	}
+	text := strings.TrimSpace(string(raw))
+	_, isObject := node.Value.(*OrderedMap)
+	if (isObject && !strings.HasPrefix(node.Lit, "{")) || strings.Contains(text, "'''") {
+		return e.str(reflect.ValueOf(&node), noIndent, separator, isRootObject,
+			isObjElement, Comments{})
+	}
+
+	lines := strings.Split(dedentCommon(strings.Replace(text, "\r\n", "\n", -1), true), "\n")
+	e.WriteString(separator + lines[0])
+	for _, line := range lines[1:] {
+		if line == "" {
+			e.WriteString(e.Eol)
+			continue
+		}
+		e.writeIndent(e.indent)
+		e.WriteString(line)
+	}
+	return nil
+}
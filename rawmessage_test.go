@@ -0,0 +1,131 @@
+package hjson
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRawMessage(t *testing.T) {
+	type config struct {
+		Name    string
+		Plugins map[string]RawMessage
+		Extra   *RawMessage
+		List    []RawMessage
+	}
+
+	data := []byte(`{
+  name: demo
+  plugins: {
+    auth: {
+      # The provider to use.
+      provider: ldap
+      hosts: ["a", "b"]
+    }
+    cache: 5 # minutes
+  }
+  extra: quoteless text
+  list: [
+    1
+    "two"
+    [3]
+  ]
+}`)
+
+	var c config
+	if err := Unmarshal(data, &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "demo" {
+		t.Errorf("Unexpected name: %q", c.Name)
+	}
+	expAuth := `{
+      # The provider to use.
+      provider: ldap
+      hosts: ["a", "b"]
+    }`
+	if string(c.Plugins["auth"]) != expAuth {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expAuth, c.Plugins["auth"])
+	}
+	if string(c.Plugins["cache"]) != "5" {
+		t.Errorf("Unexpected cache: %q", c.Plugins["cache"])
+	}
+	if c.Extra == nil || string(*c.Extra) != "quoteless text" {
+		t.Errorf("Unexpected extra: %v", c.Extra)
+	}
+	if len(c.List) != 3 || string(c.List[0]) != "1" || string(c.List[1]) != `"two"` ||
+		string(c.List[2]) != "[3]" {
+		t.Errorf("Unexpected list: %q", c.List)
+	}
+
+	// Deferred decoding.
+	var auth struct {
+		Provider string
+		Hosts    []string
+	}
+	if err := Unmarshal(c.Plugins["auth"], &auth); err != nil {
+		t.Fatal(err)
+	}
+	if auth.Provider != "ldap" || len(auth.Hosts) != 2 {
+		t.Errorf("Unexpected auth: %+v", auth)
+	}
+
+	out, err := Marshal(struct {
+		Auth  RawMessage
+		Cache RawMessage
+		Empty RawMessage
+	}{c.Plugins["auth"], c.Plugins["cache"], nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := `{
+  Auth: {
+    # The provider to use.
+    provider: ldap
+    hosts: ["a", "b"]
+  }
+  Cache: 5
+  Empty: null
+}`
+	if string(out) != exp {
+		t.Errorf("Expected:\n%s\nGot:\n%s", exp, out)
+	}
+
+	js, err := json.Marshal(c.Plugins["auth"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(js) != `{"provider":"ldap","hosts":["a","b"]}` {
+		t.Errorf("Unexpected JSON: %s", js)
+	}
+}
+
+func TestRawMessageRoot(t *testing.T) {
+	var raw RawMessage
+	if err := Unmarshal([]byte("\n# comment\na: 1\nb: 2\n"), &raw); err != nil {
+		t.Fatal(err)
+	}
+	exp := "{\n# comment\na: 1\nb: 2\n}"
+	if string(raw) != exp {
+		t.Errorf("Expected:\n%s\nGot:\n%s", exp, raw)
+	}
+
+	if err := Unmarshal([]byte("{a: [1"), &raw); err == nil {
+		t.Error("Expected an error for invalid input")
+	}
+
+	// Multiline strings depend on their column, so they are encoded again.
+	out, err := Marshal(map[string]RawMessage{
+		"text": RawMessage("'''\nline 1\nline 2\n'''"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp = "{\n  text:\n    '''\n    line 1\n    line 2\n    '''\n}"
+	if string(out) != exp {
+		t.Errorf("Expected:\n%s\nGot:\n%s", exp, out)
+	}
+
+	if _, err := Marshal(RawMessage("[1")); err == nil {
+		t.Error("Expected an error for an invalid RawMessage")
+	}
+}
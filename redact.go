@@ -0,0 +1,87 @@
+package hjson
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// matchPath returns true if path matches pattern, a dot-separated list of
+// keys and array indexes in which "*" matches any single key or index.
+func matchPath(pattern []string, path []string) bool {
+	if len(pattern) != len(path) {
+		return false
+	}
+	for i, seg := range pattern {
+		if seg != "*" && seg != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Redact replaces the values found at the specified paths in the Hjson
+// document data with the string replacement, and returns the new document.
+// Everything else in data, including comments, whitespace and the formatting
+// of other values, is kept byte-for-byte. This makes Redact suitable for
+// scrubbing secrets from configuration files that are then shown to people.
+//
+// Each path is a dot-separated list of object keys and array indexes, for
+// example "db.password" or "servers.0.token". A path segment "*" matches any
+// key or index, for example "servers.*.token". If a path matches an object or
+// an array, the whole object or array is replaced. Paths that do not match
+// any value are ignored.
+//
+// The replacement is always written as a quoted string, so that it cannot be
+// confused with the syntax around it.
+func Redact(data []byte, paths []string, replacement string) ([]byte, error) {
+	var patterns [][]string
+	for _, path := range paths {
+		if path == "" {
+			return nil, errors.New("hjson: empty path given to Redact")
+		}
+		patterns = append(patterns, strings.Split(path, "."))
+	}
+
+	if !utf8.Valid(data) {
+		// Keep the offsets of the parsed nodes valid in data.
+		data = toValidUTF8(data, nil)
+	}
+	root, err := ParseNode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []*Node
+	err = root.Walk(func(path []string, node *Node) error {
+		for _, pattern := range patterns {
+			if matchPath(pattern, path) {
+				matches = append(matches, node)
+				// Any values inside node are replaced too.
+				return SkipChildren
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Pos.Offset < matches[j].Pos.Offset
+	})
+
+	var e hjsonEncoder
+	quoted := []byte(`"` + e.quoteReplace(replacement) + `"`)
+
+	var out bytes.Buffer
+	at := 0
+	for _, node := range matches {
+		out.Write(data[at:node.Pos.Offset])
+		out.Write(quoted)
+		at = node.End.Offset
+	}
+	out.Write(data[at:])
+	return out.Bytes(), nil
+}
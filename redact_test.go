@@ -0,0 +1,71 @@
+package hjson
+
+import (
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	txt := `# config
+db: {
+  user: admin
+  password: "s3cr3t"   # keep this comment
+  "pass word": 'x'
+}
+servers: [
+  {host: "a", token: '''t1'''}
+  {
+    host: b
+    token: t2
+  }
+]
+nested: {
+  password: {
+    x: 1
+  }
+}
+`
+	out, err := Redact([]byte(txt), []string{
+		"db.password",
+		"db.pass word",
+		"servers.*.token",
+		"nested.*",
+		"missing.key",
+	}, "***")
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareStrings(t, out, `# config
+db: {
+  user: admin
+  password: "***"   # keep this comment
+  "pass word": "***"
+}
+servers: [
+  {host: "a", token: "***"}
+  {
+    host: b
+    token: "***"
+  }
+]
+nested: {
+  password: "***"
+}
+`)
+
+	var v map[string]interface{}
+	if err = Unmarshal(out, &v); err != nil {
+		t.Fatal(err)
+	}
+
+	if out, err = Redact([]byte("[1, 2, 3]"), []string{"1"}, "a\"b\n"); err != nil {
+		t.Fatal(err)
+	}
+	compareStrings(t, out, `[1, "a\"b\n", 3]`)
+
+	if _, err = Redact([]byte("{a: 1"), []string{"a"}, "x"); err == nil {
+		t.Error("Expected error for invalid input")
+	}
+	if _, err = Redact([]byte("{a: 1}"), []string{""}, "x"); err == nil {
+		t.Error("Expected error for empty path")
+	}
+}
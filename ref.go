@@ -0,0 +1,162 @@
+package hjson
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// refKey is the key of $ref directives, see DecoderOptions.ResolveRefs.
+const refKey = "$ref"
+
+// The states of the nodes in refResolver.state.
+const (
+	refActive = iota + 1
+	refDone
+)
+
+// refResolver resolves the $ref directives of a document.
+type refResolver struct {
+	root *Node
+	// state is refActive for the nodes whose tree is being resolved and
+	// refDone for the nodes whose tree has been resolved.
+	state map[*Node]int
+	// The resolved trees are measured if options has a MaxBytes or
+	// AllocBudget, because a value referenced several times is written each
+	// time.
+	options DecoderOptions
+	meter   sizeMeter
+	// The number of $ref directives whose targets are being resolved.
+	depth int
+}
+
+// expandOptions returns the options for parsing a document into a Node to
+// expand its directives, before it is decoded again with options.
+func expandOptions(options DecoderOptions) DecoderOptions {
+	options.Include = nil
+	options.ResolveRefs = false
+	options.FieldStates = nil
+	options.unusedKeys = nil
+	options.valuePath = nil
+	// Keep the text of numbers, which are decoded again later.
+	options.UseJSONNumber = true
+	return options
+}
+
+// resolveRefs resolves all $ref directives in the tree of root.
+func resolveRefs(root *Node, options DecoderOptions) error {
+	r := refResolver{root: root, state: map[*Node]int{}, options: options}
+	if options.MaxBytes > 0 || options.AllocBudget > 0 {
+		r.meter = sizeMeter{}
+	}
+	return r.resolve(root, nil)
+}
+
+// resolve resolves the directives in the tree of node, found at path.
+func (r *refResolver) resolve(node *Node, path []string) error {
+	if r.state[node] == refDone {
+		return nil
+	}
+	r.state[node] = refActive
+	child := func(key string) []string {
+		return append(path[:len(path):len(path)], key)
+	}
+
+	switch v := node.Value.(type) {
+	case []interface{}:
+		for i, elem := range v {
+			if n, ok := elem.(*Node); ok {
+				if err := r.resolve(n, child(strconv.Itoa(i))); err != nil {
+					return err
+				}
+			}
+		}
+	case *OrderedMap:
+		var target *Node
+		if directive, ok := v.Map[refKey].(*Node); ok {
+			var err error
+			if target, err = r.target(directive, path); err != nil {
+				return err
+			}
+		}
+		for _, key := range v.Keys {
+			if n, ok := v.Map[key].(*Node); ok && key != refKey {
+				if err := r.resolve(n, child(key)); err != nil {
+					return err
+				}
+			}
+		}
+		if target != nil {
+			if err := r.replace(node, target, path); err != nil {
+				return err
+			}
+		}
+	}
+
+	if r.meter != nil {
+		if err := r.meter.measure(node).check(r.options, node.Pos.Offset); err != nil {
+			return err
+		}
+	}
+	r.state[node] = refDone
+	return nil
+}
+
+// target returns the resolved value referenced by the $ref directive of the
+// object at path.
+func (r *refResolver) target(directive *Node, path []string) (*Node, error) {
+	at := "#" + formatPointer(path)
+	ref, ok := directive.Value.(string)
+	if !ok || !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("%s: %s must be a string like \"#/defaults/db\"", at, refKey)
+	}
+	tokens, err := parsePointer(ref[1:])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", at, err)
+	}
+	target, err := resolvePointer(r.root, tokens, formatPointer)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s %q: %v", at, refKey, ref, err)
+	}
+	if r.state[target] == refActive {
+		return nil, fmt.Errorf("%s: %s %q refers to a value containing it", at, refKey, ref)
+	}
+	if r.depth >= r.options.maxDepth() {
+		return nil, &LimitError{Limit: "MaxDepth", Max: r.options.maxDepth(), Offset: directive.Pos.Offset}
+	}
+	r.depth++
+	err = r.resolve(target, tokens)
+	r.depth--
+	if err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// replace sets the value of the object node to the value of target, with the
+// members of node overriding those of target.
+func (r *refResolver) replace(node, target *Node, path []string) error {
+	om := node.Value.(*OrderedMap)
+	tom, ok := target.Value.(*OrderedMap)
+	if !ok {
+		if len(om.Keys) != 1 {
+			return fmt.Errorf("#%s: the referenced value must be an object", formatPointer(path))
+		}
+		node.Value = target.Value
+		return nil
+	}
+	result := NewOrderedMap()
+	for _, key := range om.Keys {
+		if key != refKey {
+			result.Set(key, om.Map[key])
+			continue
+		}
+		for _, tkey := range tom.Keys {
+			if _, own := om.Map[tkey]; !own {
+				result.Set(tkey, tom.Map[tkey])
+			}
+		}
+	}
+	node.Value = result
+	return nil
+}
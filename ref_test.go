@@ -0,0 +1,143 @@
+package hjson
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveRefs(t *testing.T) {
+	type DB struct {
+		Host string
+		Port int
+		User string
+	}
+	var config struct {
+		Defaults struct {
+			DB DB
+		}
+		Primary DB
+		Replica DB
+		Hosts   []string
+		Backup  []string
+	}
+	options := DefaultDecoderOptions()
+	options.ResolveRefs = true
+	err := UnmarshalWithOptions([]byte(`
+defaults: {
+  db: {
+    host: localhost
+    port: 5432
+    user: admin
+  }
+}
+primary: {
+  $ref: "#/defaults/db"
+}
+replica: {
+  $ref: "#/primary"
+  # Overrides the referenced host.
+  host: replica.local
+}
+hosts: [
+  {
+    $ref: "#/primary/host"
+  }
+  {
+    $ref: "#/replica/host"
+  }
+]
+backup: {
+  $ref: "#/hosts"
+}
+`), &config, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Primary != config.Defaults.DB || config.Primary.Port != 5432 {
+		t.Errorf("Unexpected primary %+v", config.Primary)
+	}
+	if expected := (DB{Host: "replica.local", Port: 5432, User: "admin"}); config.Replica != expected {
+		t.Errorf("Unexpected replica %+v", config.Replica)
+	}
+	if expected := []string{"localhost", "replica.local"}; !reflect.DeepEqual(config.Hosts, expected) ||
+		!reflect.DeepEqual(config.Backup, expected) {
+
+		t.Errorf("Unexpected hosts %v and backup %v", config.Hosts, config.Backup)
+	}
+
+	// Without ResolveRefs, $ref is an ordinary key.
+	var v interface{}
+	if err = Unmarshal([]byte("$ref: \"#/a\""), &v); err != nil ||
+		!reflect.DeepEqual(v, map[string]interface{}{"$ref": "#/a"}) {
+
+		t.Errorf("Unexpected result %#v, %v", v, err)
+	}
+
+	for input, msg := range map[string]string{
+		"a: {\n  $ref: \"#/b\"\n}\nb: {\n  $ref: \"#/a\"\n}": `#/b: $ref "#/a" refers to a value containing it`,
+		"a: {\n  b: {\n    $ref: \"#/a\"\n  }\n}":            `#/a/b: $ref "#/a" refers to a value containing it`,
+		"a: {\n  $ref: \"#/missing\"\n}":                     `#/a: $ref "#/missing": Path '/missing' not found`,
+		"a: {\n  $ref: 1\n}":                                 `#/a: $ref must be a string like "#/defaults/db"`,
+		"a: {\n  $ref: \"other.hjson\"\n}":                   `#/a: $ref must be a string`,
+		"a: 1\nb: {\n  $ref: \"#/a\"\n  c: 2\n}":             "#/b: the referenced value must be an object",
+		"a: {\n  $ref: \"#b\"\n}":                            "#/a: hjson: invalid JSON pointer",
+	} {
+		err = UnmarshalWithOptions([]byte(input), &v, options)
+		if err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("%q: expected an error containing %q, got %v", input, msg, err)
+		}
+	}
+}
+
+func TestResolveRefsLimits(t *testing.T) {
+	// Each level refers to the previous one twice, so the expanded document
+	// doubles with each level.
+	input := "l0: {text: " + strings.Repeat("x", 1000) + "\n}\n"
+	for i := 1; i <= 16; i++ {
+		input += fmt.Sprintf("l%d: {\n  a: {$ref: \"#/l%d\"}\n  b: {$ref: \"#/l%[2]d\"}\n}\n", i, i-1)
+	}
+
+	for _, limit := range []string{"MaxBytes", "AllocBudget"} {
+		options := DefaultDecoderOptions()
+		options.ResolveRefs = true
+		if limit == "MaxBytes" {
+			options.MaxBytes = 1 << 20
+		} else {
+			options.AllocBudget = 1 << 20
+		}
+		var v interface{}
+		err := UnmarshalWithOptions([]byte(input), &v, options)
+		// The limit is found while resolving, at a $ref in the input, not in
+		// the expanded document.
+		if le, ok := err.(*LimitError); !ok || le.Limit != limit || le.Offset >= len(input) {
+			t.Errorf("Expected a %s *LimitError in the input, got %v", limit, err)
+		}
+	}
+}
+
+func TestResolveRefsPositions(t *testing.T) {
+	options := DefaultDecoderOptions()
+	options.ResolveRefs = true
+	options.Dialect = FormatJSON5
+	var v map[string]map[string]int
+	if err := UnmarshalWithOptions([]byte(`{a: {x: 1}, b: {"$ref": "#/a"}}`), &v, options); err != nil {
+		t.Fatal(err)
+	}
+	if v["b"]["x"] != 1 {
+		t.Errorf("Unexpected result %v", v)
+	}
+
+	// Errors found after resolving point into the decoded document.
+	var config struct {
+		A    map[string]int
+		B    map[string]int
+		Mode string `enum:"fast|safe"`
+	}
+	options.Dialect = FormatHjson
+	err := UnmarshalWithOptions([]byte("a: {x: 1}\nb: {\n  $ref: \"#/a\"\n}\nmode: slow\n"), &config, options)
+	if pe, ok := err.(*ParseError); !ok || pe.Line != 5 || pe.Column != 7 {
+		t.Errorf("Expected an error at line 5, column 7, got %v", err)
+	}
+}
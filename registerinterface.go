@@ -0,0 +1,22 @@
+//go:build go1.18
+
+package hjson
+
+import "reflect"
+
+// RegisterInterface registers factory as the default implementation of the
+// interface type I. When an object is decoded into a nil value of type I,
+// and the object has no discriminator registered with RegisterType(), it is
+// decoded into the value returned by factory, which is then assigned:
+//
+//	hjson.RegisterInterface[InterfaceA](func() InterfaceA { return &itsB{} })
+//
+// factory is called once for each object. A factory returning nil leaves the
+// decoding to json.Unmarshal(), which fails for non-empty interfaces.
+// RegisterInterface panics if I is not an interface type. Registering another
+// factory for I replaces the previous one.
+func RegisterInterface[I any](factory func() I) {
+	registerInterfaceFactory(reflect.TypeOf((*I)(nil)).Elem(), func() interface{} {
+		return factory()
+	})
+}
@@ -0,0 +1,77 @@
+//go:build go1.18
+
+package hjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+type registeredA interface {
+	FuncA() string
+}
+
+type registeredB struct {
+	Sub1 string
+	Sub2 string
+}
+
+func (b *registeredB) FuncA() string { return b.Sub1 }
+
+// unregisterInterface removes the factory of the interface type I.
+func unregisterInterface[I any]() {
+	interfaceFactories.Lock()
+	defer interfaceFactories.Unlock()
+	delete(interfaceFactories.m, reflect.TypeOf((*I)(nil)).Elem())
+}
+
+func TestRegisterInterface(t *testing.T) {
+	defer unregisterInterface[registeredA]()
+	defer unregisterInterface[polyPlugin]()
+
+	var s struct {
+		Five registeredA
+		Many []registeredA
+	}
+	if err := Unmarshal([]byte("{\n  five: {\n    sub1: 1\n  }\n}"), &s); err == nil {
+		t.Errorf("Expected an error before RegisterInterface, got %#v", s.Five)
+	}
+
+	RegisterInterface[registeredA](func() registeredA { return &registeredB{Sub2: "default"} })
+	err := Unmarshal([]byte(`{
+  five: {
+    sub1: 1
+  }
+  many: [
+    {
+      sub2: 2
+    }
+  ]
+}`), &s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(s.Five, &registeredB{Sub1: "1", Sub2: "default"}) {
+		t.Errorf("Unexpected value %#v", s.Five)
+	}
+	if len(s.Many) != 1 || !reflect.DeepEqual(s.Many[0], &registeredB{Sub2: "2"}) {
+		t.Errorf("Unexpected value %#v", s.Many)
+	}
+
+	// Objects with a registered discriminator get the registered type.
+	var p polyPlugin
+	RegisterInterface[polyPlugin](func() polyPlugin { return &polyWebhook{} })
+	if err = Unmarshal([]byte("{\n  kind: log\n}"), &p); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(p, polyLog{Kind: "log"}) {
+		t.Errorf("Unexpected value %#v", p)
+	}
+	p = nil
+	if err = Unmarshal([]byte("{\n  url: x\n}"), &p); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(p, &polyWebhook{URL: "x"}) {
+		t.Errorf("Unexpected value %#v", p)
+	}
+}
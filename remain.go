@@ -0,0 +1,54 @@
+package hjson
+
+import (
+	"reflect"
+	"sort"
+)
+
+// tagRemain is the option of the hjson struct tag marking the field that
+// receives all object members not matching any other field of the struct.
+const tagRemain = "remain"
+
+// isRemainType returns true if a field of type t can be tagged with the
+// "remain" option, i.e. if t is a map with string keys.
+func isRemainType(t reflect.Type) bool {
+	return t.Kind() == reflect.Map && t.Key().Kind() == reflect.String
+}
+
+// remainField returns the field tagged with the "remain" option, if any.
+func (s structFieldMap) remainField() (structFieldInfo, bool) {
+	for _, arr := range s {
+		for _, sfi := range arr {
+			if sfi.remain {
+				return sfi, true
+			}
+		}
+	}
+	return structFieldInfo{}, false
+}
+
+// remainFields appends the members of the map in the field tagged with the
+// "remain" option to fis, sorted by key, except for keys that are already the
+// names of other fields.
+func remainFields(fis []fieldInfo, value reflect.Value) []fieldInfo {
+	if value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Map || value.Len() == 0 {
+		return fis
+	}
+	names := map[string]bool{}
+	for _, fi := range fis {
+		names[fi.name] = true
+	}
+	keys := value.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+	for _, key := range keys {
+		if !names[key.String()] {
+			fis = append(fis, fieldInfo{field: value.MapIndex(key), name: key.String()})
+		}
+	}
+	return fis
+}
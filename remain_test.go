@@ -0,0 +1,69 @@
+package hjson
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type remainServer struct {
+	Host  string                 `json:"host"`
+	Port  int                    `json:"port"`
+	Extra map[string]interface{} `json:"extra" hjson:",remain"`
+}
+
+func TestRemain(t *testing.T) {
+	var s remainServer
+	err := UnmarshalWithOptions([]byte(`{
+  host: localhost
+  port: 80
+  tls: true
+  limits: {
+    conns: 10
+  }
+  extra: 1
+}`), &s, StrictDecoderOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := remainServer{
+		Host: "localhost",
+		Port: 80,
+		Extra: map[string]interface{}{
+			"tls":    true,
+			"limits": map[string]interface{}{"conns": 10.0},
+			"extra":  1.0,
+		},
+	}
+	if !reflect.DeepEqual(s, expected) {
+		t.Errorf("Expected:\n%#v\nGot:\n%#v", expected, s)
+	}
+
+	out, err := Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  host: localhost
+  port: 80
+  extra: 1
+  limits: {
+    conns: 10
+  }
+  tls: true
+}`
+	if string(out) != want {
+		t.Errorf("Expected:\n%s\nGot:\n%s", want, out)
+	}
+
+	s = remainServer{}
+	if err = Unmarshal([]byte("{\n  host: x\n}"), &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Extra != nil {
+		t.Errorf("Unexpected remainder: %#v", s.Extra)
+	}
+	if out, err = Marshal(s); err != nil || strings.Contains(string(out), "extra") {
+		t.Errorf("Unexpected result: %s, %v", out, err)
+	}
+}
@@ -0,0 +1,154 @@
+package hjson
+
+import (
+	"strconv"
+	"strings"
+)
+
+// The kinds of lossy transformations reported by CheckConversion().
+const (
+	// LossComment means that a comment of the value was dropped or changed.
+	LossComment = "comment"
+	// LossNumber means that the number was written with other text, for
+	// example "1.50" as "1.5" or "1e3" as "1000", possibly losing precision.
+	LossNumber = "number"
+	// LossKeyOrder means that the keys of the object were reordered.
+	LossKeyOrder = "keyOrder"
+	// LossValue means that a value was changed, dropped or added.
+	LossValue = "value"
+)
+
+// Loss is a lossy transformation found by CheckConversion().
+type Loss struct {
+	// Kind is one of LossComment, LossNumber, LossKeyOrder and LossValue.
+	Kind string `json:"kind"`
+	// Path is the dot-separated list of object keys and array indexes leading
+	// to the value, like in RoundTripDiff. Empty for the root value.
+	Path string `json:"path"`
+	// Before and After describe the value in the source and in the converted
+	// document: the comment text for LossComment, the number text for
+	// LossNumber, the comma-separated keys for LossKeyOrder and the value as
+	// JSON for LossValue. Empty if there is nothing to describe.
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// ConvertReport is the result of CheckConversion(). Encoded by json.Marshal()
+// it is meant to be read by scripts and pipelines.
+type ConvertReport struct {
+	// Lossless is true if no losses were found.
+	Lossless bool `json:"lossless"`
+	// Losses holds all lossy transformations, in document order.
+	Losses []Loss `json:"losses"`
+}
+
+// Count returns the number of losses of the given kind.
+func (r *ConvertReport) Count(kind string) int {
+	n := 0
+	for _, l := range r.Losses {
+		if l.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// CheckConversion compares the Hjson or JSON document src with dst, the result
+// of converting or normalizing it to Hjson or JSON, and reports the lossy
+// transformations that were performed: comments that were dropped (for
+// example because dst is JSON), numbers that were reformatted, objects whose
+// keys were reordered and values that were changed. Whitespace and the
+// quoting of strings are not compared.
+//
+// An error is returned if src or dst cannot be decoded.
+func CheckConversion(src, dst []byte) (*ConvertReport, error) {
+	before, err := ParseNode(src)
+	if err != nil {
+		return nil, err
+	}
+	after, err := ParseNode(dst)
+	if err != nil {
+		return nil, err
+	}
+	r := &ConvertReport{Losses: []Loss{}}
+	r.compare(nil, before, after)
+	r.Lossless = len(r.Losses) == 0
+	return r, nil
+}
+
+func (r *ConvertReport) compare(path []string, before, after *Node) {
+	add := func(kind, b, a string) {
+		r.Losses = append(r.Losses, Loss{Kind: kind, Path: strings.Join(path, "."),
+			Before: b, After: a})
+	}
+
+	if cb, ca := commentText(before), commentText(after); cb != "" && cb != ca {
+		add(LossComment, cb, ca)
+	}
+
+	child := func(key string) []string {
+		return append(path[:len(path):len(path)], key)
+	}
+	switch vb := before.Value.(type) {
+	case *OrderedMap:
+		va, ok := after.Value.(*OrderedMap)
+		if !ok {
+			break
+		}
+		if kb, ka := commonKeys(vb, va), commonKeys(va, vb); kb != ka {
+			add(LossKeyOrder, kb, ka)
+		}
+		for _, key := range vb.Keys {
+			nb, _ := vb.Map[key].(*Node)
+			if na, ok := va.Map[key].(*Node); ok {
+				r.compare(child(key), nb, na)
+			} else {
+				r.Losses = append(r.Losses, Loss{Kind: LossValue,
+					Path: strings.Join(child(key), "."), Before: diffValue(nb.Value)})
+			}
+		}
+		for _, key := range va.Keys {
+			if _, ok := vb.Map[key]; !ok {
+				na, _ := va.Map[key].(*Node)
+				r.Losses = append(r.Losses, Loss{Kind: LossValue,
+					Path: strings.Join(child(key), "."), After: diffValue(na.Value)})
+			}
+		}
+		return
+	case []interface{}:
+		va, ok := after.Value.([]interface{})
+		if !ok || len(va) != len(vb) {
+			break
+		}
+		for i := range vb {
+			nb, _ := vb[i].(*Node)
+			na, _ := va[i].(*Node)
+			r.compare(child(strconv.Itoa(i)), nb, na)
+		}
+		return
+	case float64:
+		if _, ok := after.Value.(float64); ok {
+			if before.Lit != after.Lit {
+				add(LossNumber, before.Lit, after.Lit)
+			}
+			return
+		}
+	default:
+		if before.Value == after.Value {
+			return
+		}
+	}
+	add(LossValue, diffValue(before.Value), diffValue(after.Value))
+}
+
+// commonKeys returns the keys of a that are also found in b, in the order of
+// a, separated by commas.
+func commonKeys(a, b *OrderedMap) string {
+	keys := make([]string, 0, len(a.Keys))
+	for _, key := range a.Keys {
+		if _, ok := b.Map[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	return strings.Join(keys, ",")
+}
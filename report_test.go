@@ -0,0 +1,71 @@
+package hjson
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestCheckConversion(t *testing.T) {
+	src := []byte(`# Server settings.
+{
+  port: 8080 # http
+  ratio: 1.50
+  big: 12345678901234567890
+  name: web
+  list: [1e3, 2]
+}`)
+
+	var v interface{}
+	if err := Unmarshal(src, &v); err != nil {
+		t.Fatal(err)
+	}
+	dst, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := CheckConversion(src, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []Loss{
+		{Kind: LossComment, Before: "Server settings."},
+		{Kind: LossKeyOrder, Before: "port,ratio,big,name,list", After: "big,list,name,port,ratio"},
+		{Kind: LossComment, Path: "port", Before: "http"},
+		{Kind: LossNumber, Path: "ratio", Before: "1.50", After: "1.5"},
+		{Kind: LossNumber, Path: "big", Before: "12345678901234567890", After: "12345678901234567000"},
+		{Kind: LossNumber, Path: "list.0", Before: "1e3", After: "1000"},
+	}
+	if report.Lossless || !reflect.DeepEqual(report.Losses, exp) {
+		t.Errorf("Expected\n%#v\ngot\n%#v", exp, report.Losses)
+	}
+	if n := report.Count(LossNumber); n != 3 {
+		t.Errorf("Expected 3 number losses, got %d", n)
+	}
+
+	report, err = CheckConversion(src, src)
+	if err != nil || !report.Lossless {
+		t.Errorf("Unexpected result: %#v %v", report, err)
+	}
+	b, _ := json.Marshal(report)
+	if string(b) != `{"lossless":true,"losses":[]}` {
+		t.Errorf("Unexpected JSON: %s", b)
+	}
+
+	report, err = CheckConversion([]byte("{a: 1, b: \"x\"}"), []byte(`{"a": "1", "c": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp = []Loss{
+		{Kind: LossValue, Path: "a", Before: "1", After: `"1"`},
+		{Kind: LossValue, Path: "b", Before: `"x"`},
+		{Kind: LossValue, Path: "c", After: "true"},
+	}
+	if !reflect.DeepEqual(report.Losses, exp) {
+		t.Errorf("Expected\n%#v\ngot\n%#v", exp, report.Losses)
+	}
+
+	if _, err := CheckConversion([]byte("{a: [1"), src); err == nil {
+		t.Error("Expected an error for invalid input")
+	}
+}
@@ -0,0 +1,41 @@
+package hjson
+
+import (
+	"sort"
+	"strings"
+)
+
+// tagRequired is the option of the hjson struct tag marking a field that must
+// be found in the Hjson input.
+const tagRequired = "required"
+
+// requiredFields returns the fields tagged with the "required" option, in the
+// order of the struct.
+func (s structFieldMap) requiredFields() []structFieldInfo {
+	var sfis []structFieldInfo
+	for _, arr := range s {
+		for _, sfi := range arr {
+			if sfi.required {
+				sfis = append(sfis, sfi)
+			}
+		}
+	}
+	sort.Sort(byIndex(sfis))
+	return sfis
+}
+
+// checkRequired returns an error if any of the required fields was not found
+// among the members of the object starting at offset, naming all missing
+// fields by their paths. found holds the names of the fields that were found.
+func (p *hjsonParser) checkRequired(required []structFieldInfo, found map[string]bool, offset int) error {
+	var missing []string
+	for _, sfi := range required {
+		if !found[sfi.name] {
+			missing = append(missing, joinPath(p.path, sfi.name))
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return p.errAtOffset(offset, MsgMissingRequired, strings.Join(missing, ", "))
+}
@@ -0,0 +1,58 @@
+package hjson
+
+import (
+	"testing"
+)
+
+func TestRequiredTag(t *testing.T) {
+	type db struct {
+		Host string `json:"host" hjson:",required"`
+		User string `json:"user" hjson:",required"`
+	}
+	type config struct {
+		Name string `json:"name" hjson:",required"`
+		Port *int   `json:"port" hjson:",required"`
+		DB   db     `json:"db"`
+	}
+
+	var c config
+	err := Unmarshal([]byte("{\n  name: x\n  port: null\n  db: {\n    host: h\n    user: u\n  }\n}"), &c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "x" || c.Port != nil || c.DB.Host != "h" {
+		t.Errorf("Unexpected result: %#v", c)
+	}
+
+	err = Unmarshal([]byte("{\n  db: {\n    host: h\n    user: u\n  }\n}"), &c)
+	pe, ok := err.(*ParseError)
+	if !ok || pe.Kind != MsgMissingRequired || pe.Line != 1 || pe.Column != 1 {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+	if exp := "Missing required fields: name, port"; pe.Message != exp {
+		t.Errorf("Expected %q, got %q", exp, pe.Message)
+	}
+
+	err = Unmarshal([]byte("name: x\nport: 1\ndb: {}"), &c)
+	if pe, ok = err.(*ParseError); !ok || pe.Message != "Missing required fields: db.host, db.user" {
+		t.Errorf("Unexpected error: %#v", err)
+	}
+
+	// A root object without braces on a single line.
+	err = Unmarshal([]byte("other: c"), &c)
+	if pe, ok = err.(*ParseError); !ok || pe.Message != "Missing required fields: name, port" {
+		t.Errorf("Unexpected error: %#v", err)
+	}
+
+	options := DefaultDecoderOptions()
+	options.CollectErrors = true
+	err = UnmarshalWithOptions([]byte("other: c"), &c, options)
+	if list, ok := err.(ErrorList); !ok || len(list) != 1 || list[0].Kind != MsgMissingRequired {
+		t.Errorf("Unexpected error: %#v", err)
+	}
+	err = UnmarshalWithOptions([]byte("db: {\n  user: u\n}\n"), &c, options)
+	if list, ok := err.(ErrorList); !ok || len(list) != 2 ||
+		list[0].Kind != MsgMissingRequired || list[1].Kind != MsgMissingRequired {
+		t.Errorf("Unexpected error: %#v", err)
+	}
+}
@@ -0,0 +1,192 @@
+package hjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// RoundTripDiff is a difference found by RoundTripCheck() between a value in
+// the original document and the same value after re-encoding it.
+type RoundTripDiff struct {
+	// Path is the dot-separated list of object keys and array indexes leading
+	// to the value, like in Redact(). Empty for the root value.
+	Path string
+	// Comment is true if the comments of the value differ, false if the values
+	// themselves differ.
+	Comment bool
+	// Before and After are the values (or the comments, without comment
+	// markers) in the original and in the re-encoded document. A value that is
+	// missing in one of the documents is reported as nil.
+	Before, After interface{}
+	// Missing is true if the value was only found in the original document.
+	Missing bool
+	// Added is true if the value was only found in the re-encoded document.
+	Added bool
+}
+
+func (d RoundTripDiff) String() string {
+	path := d.Path
+	if path == "" {
+		path = "(root)"
+	}
+	switch {
+	case d.Comment:
+		return fmt.Sprintf("%s: comment changed from %q to %q", path, d.Before, d.After)
+	case d.Missing:
+		return fmt.Sprintf("%s: value %s is missing", path, diffValue(d.Before))
+	case d.Added:
+		return fmt.Sprintf("%s: value %s was added", path, diffValue(d.After))
+	}
+	return fmt.Sprintf("%s: value changed from %s to %s", path, diffValue(d.Before),
+		diffValue(d.After))
+}
+
+// diffValue formats v as JSON, for RoundTripDiff.String().
+func diffValue(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
+
+// RoundTripReport is the result of RoundTripCheck().
+type RoundTripReport struct {
+	// Output is the re-encoded document.
+	Output []byte
+	// EncodeErr is the error returned when encoding the original document, if
+	// any. Then Output is empty and nothing else was checked.
+	EncodeErr error
+	// DecodeErr is the error returned when decoding Output, if any. Then no
+	// values were compared.
+	DecodeErr error
+	// Diffs holds all differences between the original and the re-encoded
+	// document, in document order.
+	Diffs []RoundTripDiff
+}
+
+// OK returns true if the round trip was free of errors and differences.
+func (r *RoundTripReport) OK() bool {
+	return r.EncodeErr == nil && r.DecodeErr == nil && len(r.Diffs) == 0
+}
+
+func (r *RoundTripReport) String() string {
+	switch {
+	case r.EncodeErr != nil:
+		return "encoding failed: " + r.EncodeErr.Error()
+	case r.DecodeErr != nil:
+		return "decoding the output failed: " + r.DecodeErr.Error()
+	case len(r.Diffs) == 0:
+		return "ok"
+	}
+	lines := make([]string, len(r.Diffs))
+	for i, d := range r.Diffs {
+		lines[i] = d.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// RoundTripCheck decodes the Hjson document data, encodes it again using opts
+// (or DefaultOptions() if no options are given), decodes the result and
+// compares it with the original. The returned report lists every step that
+// lost information: encoding errors, output that cannot be decoded, and
+// values or comments that differ. Comments are only compared if
+// EncoderOptions.Comments is true. Numbers are compared by value and the order
+// of object keys is not compared.
+//
+// RoundTripCheck can be used as a safety gate before automatically rewriting
+// files written by people. An error is only returned if data cannot be
+// decoded.
+func RoundTripCheck(data []byte, opts ...EncoderOptions) (*RoundTripReport, error) {
+	opt := DefaultOptions()
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	before, err := ParseNode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RoundTripReport{}
+	if report.Output, report.EncodeErr = MarshalWithOptions(before, opt); report.EncodeErr != nil {
+		return report, nil
+	}
+	after, err := ParseNode(report.Output)
+	if err != nil {
+		report.DecodeErr = err
+		return report, nil
+	}
+
+	report.compare(nil, before, after, opt.Comments)
+	return report, nil
+}
+
+func (r *RoundTripReport) compare(path []string, before, after *Node, comments bool) {
+	add := func(d RoundTripDiff) {
+		d.Path = strings.Join(path, ".")
+		r.Diffs = append(r.Diffs, d)
+	}
+
+	if comments {
+		if cb, ca := commentText(before), commentText(after); cb != ca {
+			add(RoundTripDiff{Comment: true, Before: cb, After: ca})
+		}
+	}
+
+	child := func(key string) []string {
+		return append(path[:len(path):len(path)], key)
+	}
+	switch vb := before.Value.(type) {
+	case *OrderedMap:
+		if va, ok := after.Value.(*OrderedMap); ok {
+			for _, key := range vb.Keys {
+				nb, _ := vb.Map[key].(*Node)
+				if na, ok := va.Map[key].(*Node); ok {
+					r.compare(child(key), nb, na, comments)
+				} else {
+					r.Diffs = append(r.Diffs, RoundTripDiff{
+						Path: strings.Join(child(key), "."), Before: nb.Value, Missing: true})
+				}
+			}
+			for _, key := range va.Keys {
+				if _, ok := vb.Map[key]; !ok {
+					na, _ := va.Map[key].(*Node)
+					r.Diffs = append(r.Diffs, RoundTripDiff{
+						Path: strings.Join(child(key), "."), After: na.Value, Added: true})
+				}
+			}
+			return
+		}
+	case []interface{}:
+		if va, ok := after.Value.([]interface{}); ok && len(va) == len(vb) {
+			for i := range vb {
+				nb, _ := vb[i].(*Node)
+				na, _ := va[i].(*Node)
+				r.compare(child(strconv.Itoa(i)), nb, na, comments)
+			}
+			return
+		}
+	default:
+		if reflect.DeepEqual(before.Value, after.Value) {
+			return
+		}
+	}
+	add(RoundTripDiff{Before: before.Value, After: after.Value})
+}
+
+// commentText returns the text of all comments in node.Cm, without comment
+// markers and whitespace, one line per line of comment.
+func commentText(node *Node) string {
+	var w flatWriter
+	w.writeComments(node.Cm.Before, node.Cm.Key, node.Cm.InsideFirst, node.Cm.InsideLast,
+		node.Cm.After)
+	lines := strings.Split(strings.TrimSpace(w.String()), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(strings.TrimPrefix(line, "#"))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,74 @@
+package hjson
+
+import (
+	"testing"
+)
+
+func TestRoundTripCheck(t *testing.T) {
+	data := []byte(`# Server settings.
+{
+  // The host.
+  host: localhost
+  ports: [
+    80 # http
+    443
+  ]
+  /* Ratio */
+  ratio: 1.50
+  text:
+    '''
+    a
+    b
+    '''
+}`)
+
+	report, err := RoundTripCheck(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() {
+		t.Errorf("Unexpected report:\n%s\nOutput:\n%s", report, report.Output)
+	}
+
+	opt := DefaultOptions()
+	opt.Comments = false
+	report, err = RoundTripCheck(data, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() {
+		t.Errorf("Comments must not be compared if not written, got:\n%s", report)
+	}
+
+	// Writing multiline strings without indentation is lossy when the output
+	// is decoded in the default mode.
+	opt = DefaultOptions()
+	opt.MultilineIndent = MultilineIndentNone
+	report, err = RoundTripCheck([]byte("{\n  a: {\n    text:\n      '''\n      x\n        y\n      '''\n  }\n}"), opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.OK() || len(report.Diffs) != 1 || report.Diffs[0].Path != "a.text" ||
+		report.Diffs[0].Comment {
+		t.Errorf("Unexpected report:\n%s\nOutput:\n%s", report, report.Output)
+	}
+
+	if _, err := RoundTripCheck([]byte("{a: [1")); err == nil {
+		t.Error("Expected an error for invalid input")
+	}
+}
+
+func TestRoundTripDiff(t *testing.T) {
+	before, _ := ParseNode([]byte("{\n  a: 1\n  b: [1, 2]\n  c: \"x\" # note\n  e: 3\n}"))
+	after, _ := ParseNode([]byte("{\n  a: 2\n  b: [1]\n  d: true\n  c: \"x\"\n}"))
+	var r RoundTripReport
+	r.compare(nil, before, after, true)
+	exp := `a: value changed from 1 to 2
+b: value changed from [1,2] to [1]
+c: comment changed from "note" to ""
+e: value 3 is missing
+d: value true was added`
+	if r.String() != exp {
+		t.Errorf("Expected:\n%s\nGot:\n%s", exp, &r)
+	}
+}
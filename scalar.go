@@ -0,0 +1,254 @@
+package hjson
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// newScalarParser returns a parser for the Decode functions, which is only
+// used for the values that scanScalar() cannot handle, and to report errors.
+func newScalarParser(data []byte) *hjsonParser {
+	p := &hjsonParser{
+		DecoderOptions: DefaultDecoderOptions(),
+		data:           data,
+	}
+	p.resetAt()
+	return p
+}
+
+// readScalarText reads a quoted or quoteless scalar value, without converting
+// it to any other type than string. If untilEOL is false a quoteless value
+// ends at the first punctuator or comment (like a number, boolean or null in
+// Hjson), otherwise at the end of the line (like a quoteless string). The
+// second returned value is true if the value was quoted.
+func (p *hjsonParser) readScalarText(untilEOL bool) (string, bool, error) {
+	p.white()
+
+	switch p.ch {
+	case '"', '\'':
+		s, err := p.readString(true)
+		if err != nil {
+			return "", false, err
+		}
+		_, err = p.checkTrailing()
+		return s, true, err
+	case '{', '[':
+		return "", false, p.errAt(MsgExpectedScalar, string(p.ch))
+	case 0:
+		return "", false, p.errAt(MsgEOFInValue)
+	}
+
+	if isPunctuatorChar(p.ch) {
+		return "", false, p.errAt(MsgPunctuatorInValue, string(p.ch))
+	}
+
+	value := new(bytes.Buffer)
+	for ; p.ch != 0 && p.ch != '\r' && p.ch != '\n'; p.next() {
+		if !untilEOL && (p.ch == ',' || p.ch == '}' || p.ch == ']' ||
+			p.ch == '#' ||
+			p.ch == '/' && (p.peek(0) == '/' || p.peek(0) == '*')) {
+
+			break
+		}
+		value.WriteByte(p.ch)
+	}
+
+	_, err := p.checkTrailing()
+	return strings.TrimSpace(value.String()), false, err
+}
+
+// skipWhite returns the offset of the first byte at or after data[i] that is
+// neither whitespace nor part of a comment.
+func skipWhite(data []byte, i int) int {
+	for i < len(data) {
+		switch c := data[i]; {
+		case c <= ' ':
+			i++
+		case c == '#' || c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			for i += 2; i < len(data) && !(data[i] == '*' && i+1 < len(data) && data[i+1] == '/'); i++ {
+			}
+			i += 2
+		default:
+			return i
+		}
+	}
+	return len(data)
+}
+
+// scalarAt returns the bounds of the quoteless or double quoted value
+// starting at data[i], without its quotes, and the offset next just after it.
+// If untilEOL is false a quoteless value ends at the first punctuator or
+// comment, otherwise at the end of the line. ok is false if the value needs
+// the full parser (because it is in single quotes or has escape sequences) or
+// is not a scalar value. No memory is allocated, so that the Decode functions
+// are cheap for the values that are most common in hot paths.
+func scalarAt(data []byte, i int, untilEOL bool) (start, end, next int, quoted, ok bool) {
+	if i >= len(data) {
+		return 0, 0, 0, false, false
+	}
+	switch c := data[i]; {
+	case c == '"':
+		end = i + 1
+		for end < len(data) && data[end] != '"' {
+			if c := data[end]; c == '\\' || c == '\n' || c == '\r' {
+				return 0, 0, 0, false, false
+			}
+			end++
+		}
+		if end == len(data) {
+			return 0, 0, 0, false, false
+		}
+		return i + 1, end, end + 1, true, true
+	case c == '\'' || isPunctuatorChar(c):
+		return 0, 0, 0, false, false
+	}
+
+	next = i
+	for ; next < len(data) && data[next] != '\r' && data[next] != '\n'; next++ {
+		if c := data[next]; !untilEOL && (c == ',' || c == '}' || c == ']' || c == '#' ||
+			c == '/' && next+1 < len(data) && (data[next+1] == '/' || data[next+1] == '*')) {
+
+			break
+		}
+	}
+	end = next
+	for end > i && data[end-1] <= ' ' {
+		end--
+	}
+	return i, end, next, false, true
+}
+
+// scanScalar returns the bounds of the single quoteless or double quoted
+// value in data, see scalarAt(). ok is also false if anything but whitespace
+// and comments follows the value.
+func scanScalar(data []byte, untilEOL bool) (start, end int, quoted, ok bool) {
+	start, end, next, quoted, ok := scalarAt(data, skipWhite(data, 0), untilEOL)
+	if !ok || skipWhite(data, next) != len(data) {
+		return 0, 0, false, false
+	}
+	return start, end, quoted, true
+}
+
+// hasLeadingZero returns true if the integer text s has a leading zero, like
+// "0123" or "-01". Hjson reads such values as quoteless strings.
+func hasLeadingZero(s []byte) bool {
+	if len(s) > 0 && s[0] == '-' {
+		s = s[1:]
+	}
+	return len(s) > 1 && s[0] == '0'
+}
+
+// parseInt64 parses an optional '-' followed by decimal digits, like
+// strconv.ParseInt() but without converting data to a string. ok is false
+// if data is not an integer, has a leading zero or is out of range.
+func parseInt64(data []byte) (n int64, ok bool) {
+	if hasLeadingZero(data) {
+		return 0, false
+	}
+	neg := len(data) > 0 && data[0] == '-'
+	if neg {
+		data = data[1:]
+	}
+	if len(data) == 0 {
+		return 0, false
+	}
+	const cutoff = 1<<63/10 + 1
+	var u uint64
+	for _, c := range data {
+		if c < '0' || c > '9' || u >= cutoff {
+			return 0, false
+		}
+		u = u*10 + uint64(c-'0')
+	}
+	if !neg && u > 1<<63-1 || neg && u > 1<<63 {
+		return 0, false
+	}
+	if neg {
+		return -int64(u), true
+	}
+	return int64(u), true
+}
+
+// DecodeBool decodes data containing a single Hjson boolean value (true or
+// false), optionally surrounded by whitespace and comments. Unlike
+// Unmarshal(), DecodeBool does not use reflection, does not box the value in
+// an interface{} and does not allocate memory unless it returns an error.
+func DecodeBool(data []byte) (bool, error) {
+	if start, end, quoted, ok := scanScalar(data, false); ok && !quoted {
+		switch string(data[start:end]) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	}
+
+	p := newScalarParser(data)
+	s, quoted, err := p.readScalarText(false)
+	if err != nil {
+		return false, err
+	}
+	if !quoted {
+		switch s {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	}
+	p.resetAt()
+	p.white()
+	return false, p.errAt(MsgExpectedBoolean)
+}
+
+// DecodeString decodes data containing a single Hjson string value (quoted,
+// quoteless or multiline), optionally surrounded by whitespace and comments.
+// Quoteless values are always returned as strings, even if they could be
+// treated as numbers, booleans or null. Unlike Unmarshal(), DecodeString
+// does not use reflection and does not box the value in an interface{}. Only
+// the returned string is allocated, unless the value contains escape
+// sequences or is a multiline string.
+func DecodeString(data []byte) (string, error) {
+	if start, end, _, ok := scanScalar(data, true); ok {
+		return string(data[start:end]), nil
+	}
+	s, _, err := newScalarParser(data).readScalarText(true)
+	return s, err
+}
+
+// DecodeInt64 decodes data containing a single Hjson integer value, optionally
+// surrounded by whitespace and comments. Unlike Unmarshal(), DecodeInt64 does
+// not use reflection, does not box the value in an interface{} and does not
+// allocate memory unless it returns an error.
+func DecodeInt64(data []byte) (int64, error) {
+	if start, end, quoted, ok := scanScalar(data, false); ok && !quoted {
+		if n, ok := parseInt64(data[start:end]); ok {
+			return n, nil
+		}
+	}
+
+	p := newScalarParser(data)
+	s, quoted, err := p.readScalarText(false)
+	if err != nil {
+		return 0, err
+	}
+	if !quoted && s != "" && s[0] != '+' && !hasLeadingZero([]byte(s)) {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err == nil {
+			return n, nil
+		}
+		if ne, ok := err.(*strconv.NumError); ok && ne.Err == strconv.ErrRange {
+			p.resetAt()
+			p.white()
+			return 0, p.errAt(MsgInt64OutOfRange)
+		}
+	}
+	p.resetAt()
+	p.white()
+	return 0, p.errAt(MsgExpectedInteger)
+}
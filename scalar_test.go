@@ -0,0 +1,85 @@
+package hjson
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecodeScalars(t *testing.T) {
+	if b, err := DecodeBool([]byte("  true # comment\n")); err != nil || !b {
+		t.Errorf("Unexpected result: %v %v", b, err)
+	}
+	if b, err := DecodeBool([]byte("false")); err != nil || b {
+		t.Errorf("Unexpected result: %v %v", b, err)
+	}
+	for _, txt := range []string{`"true"`, "yes", "true, false", "", "{}"} {
+		if _, err := DecodeBool([]byte(txt)); err == nil {
+			t.Errorf("Should have failed for %q", txt)
+		}
+	}
+
+	for txt, exp := range map[string]string{
+		"quoteless # not a comment":     "quoteless # not a comment",
+		`"quoted" // comment`:           "quoted",
+		"3":                             "3",
+		`"a\"b" /* c */`:                `a"b`,
+		"'single'":                      "single",
+		"  '''\n  multi\n  line\n  '''": "multi\nline",
+	} {
+		if s, err := DecodeString([]byte(txt)); err != nil || s != exp {
+			t.Errorf("Unexpected result for %q: %q %v", txt, s, err)
+		}
+	}
+	if _, err := DecodeString([]byte(`"a" b`)); err == nil {
+		t.Error("Should have failed because of trailing characters")
+	}
+
+	if n, err := DecodeInt64([]byte("/* c */ -9007199254740993")); err != nil || n != -9007199254740993 {
+		t.Errorf("Unexpected result: %v %v", n, err)
+	}
+	for txt, exp := range map[string]int64{
+		"9223372036854775807":       1<<63 - 1,
+		" -9223372036854775808 # c": -1 << 63,
+		"0":                         0,
+	} {
+		if n, err := DecodeInt64([]byte(txt)); err != nil || n != exp {
+			t.Errorf("Unexpected result for %q: %v %v", txt, n, err)
+		}
+	}
+	for _, txt := range []string{"1.5", "+1", "-", "0123", "-01", "00", "-9223372036854775809", "18446744073709551616", `"1"`, "9223372036854775808", "1 2"} {
+		if _, err := DecodeInt64([]byte(txt)); err == nil {
+			t.Errorf("Should have failed for %q", txt)
+		}
+	}
+}
+
+func BenchmarkDecodeBool(b *testing.B) {
+	data := []byte("  true # comment\n")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if v, err := DecodeBool(data); err != nil || !v {
+			b.Fatal(v, err)
+		}
+	}
+}
+
+func BenchmarkDecodeInt64(b *testing.B) {
+	data := []byte("/* c */ -9007199254740993")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if n, err := DecodeInt64(data); err != nil || n != -9007199254740993 {
+			b.Fatal(n, err)
+		}
+	}
+}
+
+func BenchmarkDecoderDecodeInt64(b *testing.B) {
+	dec := NewDecoder(bytes.NewReader(bytes.Repeat([]byte("123456\n"), b.N)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if n, err := dec.DecodeInt64(); err != nil || n != 123456 {
+			b.Fatal(n, err)
+		}
+	}
+}
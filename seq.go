@@ -0,0 +1,48 @@
+//go:build go1.23
+
+package hjson
+
+import (
+	"io"
+	"iter"
+)
+
+// DecodeSeq returns an iterator over the elements of the Hjson array read
+// from r, each decoded into a new value of type T using default options. Only
+// one element at a time is kept in memory, so that very large arrays of
+// records can be processed with constant memory:
+//
+//	for rec, err := range hjson.DecodeSeq[Record](f) {
+//		if err != nil {
+//			return err
+//		}
+//		process(rec)
+//	}
+//
+// Comments before the array are skipped. If the input is not an array, or an
+// element cannot be decoded, the error is yielded with the zero value of T
+// and the iteration ends. Any input following the closing bracket is not
+// read.
+func DecodeSeq[T any](r io.Reader) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		dec := NewDecoder(r)
+		if err := dec.startArray(); err != nil {
+			yield(zero, err)
+			return
+		}
+		for dec.More() {
+			var v T
+			if err := dec.Decode(&v); err != nil {
+				yield(zero, err)
+				return
+			}
+			if !yield(v, nil) {
+				return
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			yield(zero, err)
+		}
+	}
+}
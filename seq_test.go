@@ -0,0 +1,74 @@
+//go:build go1.23
+
+package hjson
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeSeq(t *testing.T) {
+	type record struct {
+		ID   int
+		Name string
+	}
+	var got []record
+	for rec, err := range DecodeSeq[record](strings.NewReader(`# Records.
+[
+  {
+    id: 1
+    name: a
+  }
+  // The second one.
+  {
+    id: 2
+    name: b
+  }
+]`)) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, rec)
+	}
+	expected := []record{{1, "a"}, {2, "b"}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected:\n%#v\nGot:\n%#v", expected, got)
+	}
+
+	// Stopping early.
+	n := 0
+	for range DecodeSeq[int](strings.NewReader("[1, 2, 3]")) {
+		n++
+		break
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 iteration, got %d", n)
+	}
+
+	for _, tc := range []struct {
+		input string
+		kind  string
+	}{
+		{"{\n  a: 1\n}", MsgExpectedArray},
+		{"[1, x]", ""},
+		{"[1, 2", MsgUnterminatedArray},
+	} {
+		var err error
+		for _, err = range DecodeSeq[int](strings.NewReader(tc.input)) {
+			if err != nil {
+				break
+			}
+		}
+		if err == nil {
+			t.Errorf("Expected an error for %q", tc.input)
+		} else if pe, ok := err.(*ParseError); tc.kind != "" && (!ok || pe.Kind != tc.kind) {
+			t.Errorf("Unexpected error for %q: %v", tc.input, err)
+		}
+	}
+	for _, err := range DecodeSeq[int](strings.NewReader("")) {
+		if err == nil {
+			t.Error("Expected an error for empty input")
+		}
+	}
+}
@@ -0,0 +1,906 @@
+package hjson
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+)
+
+// errNeedMore is returned by scanValue() when the data ends before the end of
+// the value could be determined.
+var errNeedMore = errors.New("need more data")
+
+// maxKeepLine is the maximum number of bytes of the current line that a
+// Decoder keeps in its buffer before the current position.
+const maxKeepLine = 256
+
+// firstLineIsKey returns true if the line starting at data[start] begins with
+// a key name followed by a colon, i.e. if it is the first line of a root
+// object without braces.
+func firstLineIsKey(data []byte, start int, atEOF bool) (bool, error) {
+	lineEnd := bytes.IndexByte(data[start:], '\n')
+	if lineEnd < 0 {
+		if !atEOF {
+			return false, errNeedMore
+		}
+		lineEnd = len(data)
+	} else {
+		lineEnd += start
+	}
+	if c := data[start]; c == '"' || c == '\'' {
+		p := &hjsonParser{
+			DecoderOptions: DefaultDecoderOptions(),
+			data:           data[:lineEnd],
+		}
+		p.resetAt()
+		p.at = start
+		p.next()
+		if _, err := p.readKeyname(); err != nil {
+			return false, nil
+		}
+		p.white()
+		return p.ch == ':', nil
+	}
+
+	// A quoteless key name is checked without the parser, so that scanning
+	// scalar values (which are no key names) does not allocate any memory.
+	n, space := 0, -1
+	for _, c := range data[start:lineEnd] {
+		switch {
+		case c == ':':
+			return n > 0 && (space < 0 || space == n), nil
+		case c == 0 || isPunctuatorChar(c):
+			return false, nil
+		case c <= ' ':
+			if space < 0 {
+				space = n
+			}
+		default:
+			n++
+		}
+	}
+	return false, nil
+}
+
+// separatorAt returns the length of the document separator starting at
+// data[i], including the line feed ending it, or 0 if there is none. A
+// document separator is a line holding only "---", optionally followed by
+// spaces. If the line is not complete and atEOF is false, errNeedMore is
+// returned.
+func separatorAt(data []byte, i int, atEOF bool) (int, error) {
+	if i > 0 && data[i-1] != '\n' {
+		return 0, nil
+	}
+	n := i
+	for n < len(data) && n-i < 3 && data[n] == '-' {
+		n++
+	}
+	if n < len(data) && n-i < 3 {
+		return 0, nil
+	}
+	for ; n < len(data); n++ {
+		switch data[n] {
+		case ' ', '\t', '\r':
+			continue
+		case '\n':
+			return n + 1 - i, nil
+		}
+		return 0, nil
+	}
+	if !atEOF {
+		return 0, errNeedMore
+	}
+	if n-i < 3 {
+		return 0, nil
+	}
+	return n - i, nil
+}
+
+// scanValue finds the first Hjson value in data, skipping any preceding
+// whitespace and comments. It returns the start and end offsets of the value
+// in data. If atEOF is false and more data is needed to find the end of the
+// value, errNeedMore is returned. If data only contains whitespace and
+// comments, and atEOF is true, io.EOF is returned.
+//
+// If inContainer is false, document separators before the value are skipped,
+// see separatorAt().
+//
+// Objects, arrays and quoted strings end at their closing character. If
+// inContainer is false, a root object without braces ends at the next
+// document separator or at the end of the data, and any other value (a
+// quoteless string, number, boolean or null) ends at the end of its line. If
+// inContainer is true, numbers, booleans and null can also be followed by a
+// comma or a closing bracket, like in any Hjson array or object.
+func scanValue(data []byte, atEOF, inContainer bool) (int, int, error) {
+	p := &hjsonParser{
+		DecoderOptions: DefaultDecoderOptions(),
+		data:           data,
+	}
+	p.resetAt()
+	p.white()
+
+	ranPastEnd := func() bool {
+		return p.at > len(data)
+	}
+
+	for !inContainer && !ranPastEnd() {
+		n, err := separatorAt(data, p.at-1, atEOF)
+		if err != nil {
+			return 0, 0, err
+		}
+		if n == 0 {
+			break
+		}
+		p.at += n - 1
+		p.next()
+		p.white()
+	}
+
+	if ranPastEnd() {
+		if !atEOF {
+			return 0, 0, errNeedMore
+		}
+		return 0, 0, io.EOF
+	}
+	start := p.at - 1
+
+	if !inContainer && p.ch != '{' && p.ch != '[' {
+		isKey, err := firstLineIsKey(data, start, atEOF)
+		if err != nil {
+			return 0, 0, err
+		}
+		if isKey {
+			// A root object without braces, it ends at a document separator or
+			// where the data ends.
+			p.documentSeparators = true
+			p.nestingDepth++
+			_, err = p.readObject(true, reflect.Value{}, nil, commentInfo{})
+			if !ranPastEnd() && err == nil {
+				return start, p.at - 1, nil
+			}
+			if !atEOF {
+				return 0, 0, errNeedMore
+			}
+			return start, len(data), nil
+		}
+	}
+
+	var err error
+	switch p.ch {
+	case '{', '[':
+		p.nestingDepth++
+		if p.ch == '{' {
+			_, err = p.readObject(false, reflect.Value{}, nil, commentInfo{})
+		} else {
+			_, err = p.readArray(reflect.Value{}, nil)
+		}
+		if err != nil && ranPastEnd() && !atEOF {
+			return 0, 0, errNeedMore
+		}
+	case '"', '\'':
+		first := p.ch
+		_, err = p.readString(true)
+		if ranPastEnd() && !atEOF && (err != nil || first == '\'') {
+			// An empty single quoted string might turn out to be the start of a
+			// multiline string.
+			return 0, 0, errNeedMore
+		}
+	default:
+		if inContainer {
+			_, err = p.readTfnns(reflect.Value{}, nil)
+			if ranPastEnd() && !atEOF {
+				return 0, 0, errNeedMore
+			}
+			break
+		}
+		lineEnd := bytes.IndexByte(data[start:], '\n')
+		if lineEnd < 0 {
+			return start, len(data), nil
+		}
+		return start, start + lineEnd, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	end := p.at - 1
+	if end > len(data) {
+		end = len(data)
+	}
+
+	return start, end, nil
+}
+
+// States of a valueScanner.
+const (
+	vsWhite        = iota // whitespace between tokens
+	vsLineComment         // a # or // comment
+	vsBlockComment        // a /* */ comment
+	vsString              // a quoted string or key name
+	vsEscape              // the character after a backslash in a quoted string
+	vsMLString            // a multiline string
+	vsKey                 // a quoteless key name
+	vsQuoteless           // a quoteless string, number, boolean or null
+	vsRootLine            // the first line of a root value that is no array or object
+	vsBraceless           // a root object without braces
+)
+
+// A valueScanner follows the structure of the value that a Decoder is reading,
+// so that scanValue(), which parses the buffered value from its start, only
+// needs to be called when the value might be complete instead of after every
+// read. Otherwise reading a large value in small chunks would take quadratic
+// time. Each byte is only looked at once, the state is kept across refills.
+//
+// The scanner does not check the syntax, it must only never miss the end of
+// a value. When it finds anything unexpected it gives up, and scanValue() is
+// called after every read again.
+type valueScanner struct {
+	inContainer bool
+	state       int
+	off         int // offset of the next byte to look at
+	// For each open array '[', and for each open object the token expected
+	// next: 'k' for a key name, ':' for a colon and 'v' for a value.
+	stack []byte
+	// The start of the quoteless value, of the root value or of the current
+	// line of a root object without braces.
+	start  int
+	quote  byte // the quote of the string being read
+	quotes int  // the number of consecutive quotes in a multiline string
+	done   bool // the value seems to be complete
+	failed bool
+}
+
+// ready looks at the data not seen before, and returns true if scanValue()
+// should be called.
+func (s *valueScanner) ready(data []byte) bool {
+	trigger := false
+	for !s.done && !s.failed && s.off < len(data) {
+		n, t, ok := s.step(data, s.off)
+		if !ok {
+			break
+		}
+		s.off += n
+		trigger = trigger || t
+	}
+	return trigger || s.done || s.failed
+}
+
+// step looks at data[i] in the current state, and returns the number of bytes
+// consumed (which can be 0 after a change of state) and whether scanValue()
+// should be called. If more data is needed to decide, ok is false.
+func (s *valueScanner) step(data []byte, i int) (n int, trigger, ok bool) {
+	c := data[i]
+	if c == 0 {
+		// Treated like the end of the data by the parser.
+		s.failed = true
+		return 0, true, true
+	}
+	switch s.state {
+	case vsLineComment:
+		if c == '\n' {
+			s.state = vsWhite
+		}
+	case vsBlockComment:
+		if c == '*' {
+			if i+1 == len(data) {
+				return 0, false, false
+			}
+			if data[i+1] == '/' {
+				s.state = vsWhite
+				return 2, false, true
+			}
+		}
+	case vsString:
+		switch c {
+		case '\\':
+			s.state = vsEscape
+		case s.quote:
+			s.state = vsWhite
+			s.endValue()
+		case '\n', '\r':
+			s.failed = true
+		}
+	case vsEscape:
+		s.state = vsString
+	case vsMLString:
+		if c != '\'' {
+			s.quotes = 0
+			break
+		}
+		if s.quotes++; s.quotes == 3 {
+			s.state = vsWhite
+			if len(s.stack) == 0 && !s.inContainer &&
+				bytes.IndexByte(data[s.start:i], '\n') < 0 {
+
+				// The first line of a root value must be complete, see
+				// firstLineIsKey().
+				s.state = vsRootLine
+				break
+			}
+			s.endValue()
+		}
+	case vsKey:
+		if c == ':' {
+			s.state = vsWhite
+			s.stack[len(s.stack)-1] = 'v'
+		} else if isPunctuatorChar(c) {
+			s.failed = true
+		}
+	case vsQuoteless:
+		// Like readTfnns().
+		end := c == '\n' || c == '\r'
+		if !end && (c == ',' || c == '}' || c == ']' || c == '#' || c == '/') {
+			if c == '/' {
+				if i+1 == len(data) {
+					return 0, false, false
+				}
+				if c := data[i+1]; c != '/' && c != '*' {
+					break
+				}
+			}
+			end = quotelessEndsAt(data[s.start:i])
+		}
+		if end {
+			s.state = vsWhite
+			s.endValue()
+			return 0, false, true
+		}
+	case vsRootLine:
+		if c == '\n' {
+			// Unless the value is complete now, it is a root object without
+			// braces.
+			s.state = vsBraceless
+			s.start = i + 1
+			return 1, true, true
+		}
+	case vsBraceless:
+		// The object ends at a document separator, which the parser already
+		// finds before the end of its line.
+		if c == '\n' {
+			s.start = i + 1
+		} else if i == s.start+2 && string(data[s.start:i+1]) == "---" {
+			return 1, true, true
+		}
+	default:
+		return s.white(data, i)
+	}
+	return 1, false, true
+}
+
+// white is step() for the vsWhite state.
+func (s *valueScanner) white(data []byte, i int) (n int, trigger, ok bool) {
+	c := data[i]
+	switch {
+	case c <= ' ':
+		return 1, false, true
+	case c == '#':
+		s.state = vsLineComment
+		return 1, false, true
+	case c == '/':
+		if i+1 == len(data) {
+			return 0, false, false
+		}
+		switch data[i+1] {
+		case '/':
+			s.state = vsLineComment
+			return 2, false, true
+		case '*':
+			s.state = vsBlockComment
+			return 2, false, true
+		}
+	}
+
+	if len(s.stack) == 0 {
+		return s.rootValue(data, i)
+	}
+	top := &s.stack[len(s.stack)-1]
+	switch c {
+	case ',':
+		if *top == ':' || *top == 'v' {
+			break
+		}
+		return 1, false, true
+	case ':':
+		if *top != ':' {
+			break
+		}
+		*top = 'v'
+		return 1, false, true
+	case '}', ']':
+		if c == '}' && *top != 'k' || c == ']' && *top != '[' {
+			break
+		}
+		s.stack = s.stack[:len(s.stack)-1]
+		s.endValue()
+		return 1, false, true
+	default:
+		switch {
+		case *top == 'k' && (c == '"' || c == '\''):
+			s.state, s.quote, *top = vsString, c, ':'
+			return 1, false, true
+		case *top == 'k' && !isPunctuatorChar(c):
+			s.state, *top = vsKey, ':'
+			return 1, false, true
+		case *top == 'v' || *top == '[':
+			j := len(s.stack) - 1
+			n, trigger, ok = s.value(data, i)
+			if ok && s.stack[j] == 'v' {
+				s.stack[j] = 'k'
+			}
+			return n, trigger, ok
+		}
+	}
+	s.failed = true
+	return 0, true, true
+}
+
+// rootValue is step() for the vsWhite state before the value.
+func (s *valueScanner) rootValue(data []byte, i int) (n int, trigger, ok bool) {
+	c := data[i]
+	if !s.inContainer && c == '-' {
+		sep, err := separatorAt(data, i, false)
+		if err != nil {
+			return 0, false, false
+		}
+		if sep > 0 {
+			return sep, false, true
+		}
+	}
+	s.start = i
+	if !s.inContainer && c != '{' && c != '[' && c != '\'' {
+		s.state = vsRootLine
+		return 1, false, true
+	}
+	return s.value(data, i)
+}
+
+// value is step() for the first byte of a value.
+func (s *valueScanner) value(data []byte, i int) (n int, trigger, ok bool) {
+	switch c := data[i]; c {
+	case '{':
+		s.stack = append(s.stack, 'k')
+	case '[':
+		s.stack = append(s.stack, '[')
+	case '\'':
+		if i+3 > len(data) {
+			return 0, false, false
+		}
+		if data[i+1] == '\'' && data[i+2] == '\'' {
+			s.state, s.quotes = vsMLString, 0
+			return 3, false, true
+		}
+		if len(s.stack) == 0 && !s.inContainer {
+			s.state = vsRootLine
+			break
+		}
+		s.state, s.quote = vsString, c
+	case '"':
+		s.state, s.quote = vsString, c
+	case ',', ':', '}', ']':
+		s.failed = true
+		return 0, true, true
+	default:
+		s.state, s.start = vsQuoteless, i
+	}
+	return 1, false, true
+}
+
+// endValue is called at the end of a value or key name.
+func (s *valueScanner) endValue() {
+	if len(s.stack) == 0 {
+		s.done = true
+	}
+}
+
+// quotelessEndsAt returns true if the quoteless value text, followed by a
+// comma, a closing bracket or a comment, ends there, i.e. if it is a number,
+// a boolean or null, like in readTfnns() with the default options.
+func quotelessEndsAt(text []byte) bool {
+	switch text[0] {
+	case 'f':
+		return string(bytes.TrimSpace(text)) == "false"
+	case 'n':
+		return string(bytes.TrimSpace(text)) == "null"
+	case 't':
+		return string(bytes.TrimSpace(text)) == "true"
+	}
+	if c := text[0]; c == '-' || c >= '0' && c <= '9' {
+		_, err := tryParseNumber(text, false, false)
+		return err == nil
+	}
+	return false
+}
+
+// A Decoder reads and decodes Hjson values from an input stream.
+type Decoder struct {
+	r       io.Reader
+	buf     []byte
+	scanp   int   // start of unread data in buf
+	scanned int64 // amount of data already scanned and removed from buf
+	err     error // the first error returned by r, for example io.EOF
+	options DecoderOptions
+
+	// The number of line feeds in the data removed from buf, and the number of
+	// bytes of the line at the start of buf that were removed, so that errors
+	// can report lines and columns counted from the start of the stream.
+	scannedLines   int
+	scannedColumns int
+
+	tokenState int
+	tokenStack []int
+	// True if the outermost object of the current value was written without
+	// braces.
+	braceless bool
+}
+
+// NewDecoder returns a new Decoder that reads from r, using default decoding
+// options.
+//
+// The Decoder introduces its own buffering and may read data from r beyond
+// the Hjson values requested. Only one value at a time is kept in memory, so
+// a stream of many values can be decoded without reading the whole stream
+// first.
+func NewDecoder(r io.Reader) *Decoder {
+	return NewDecoderWithOptions(r, DefaultDecoderOptions())
+}
+
+// NewDecoderWithOptions returns a new Decoder that reads from r, using the
+// specified decoding options.
+func NewDecoderWithOptions(r io.Reader, options DecoderOptions) *Decoder {
+	return &Decoder{
+		r:       r,
+		options: options,
+	}
+}
+
+// UseNumber causes the Decoder to unmarshal a number into an interface{} as a
+// json.Number instead of as a float64.
+func (dec *Decoder) UseNumber() {
+	dec.options.UseJSONNumber = true
+}
+
+// DisallowUnknownFields causes the Decoder to return an error when the
+// destination is a struct and the input contains object keys which do not
+// match any non-ignored, exported fields in the destination.
+func (dec *Decoder) DisallowUnknownFields() {
+	dec.options.DisallowUnknownFields = true
+}
+
+// Decode reads the next Hjson value from its input and stores it in the value
+// pointed to by v. If there are no more values in the input, Decode returns
+// io.EOF.
+//
+// Values can follow each other directly in the input, separated only by
+// whitespace or comments, or by document separators: lines holding only
+// "---". A value that is not an object, array or quoted string ends at the
+// end of its line. A root object without braces ends at the next document
+// separator, or else at the end of the input.
+//
+// Decode can be mixed with calls to Token(), for example to decode the
+// elements of a large array one at a time.
+//
+// See the documentation for UnmarshalWithOptions for details about the
+// conversion of Hjson into a Go value.
+func (dec *Decoder) Decode(v interface{}) error {
+	data, err := dec.readValue()
+	if err != nil {
+		return err
+	}
+	err = UnmarshalWithOptions(data, v, dec.options)
+	start := dec.scanp - len(data)
+	switch err := err.(type) {
+	case *ParseError:
+		dec.fixPosition(err, start)
+	case ErrorList:
+		for _, pe := range err {
+			dec.fixPosition(pe, start)
+		}
+	}
+	return err
+}
+
+// UnmarshalPrefix decodes the first Hjson value in data using default options,
+// stores it in the value pointed to by v and returns the bytes following the
+// value. If data contains no value, io.EOF is returned.
+//
+// See UnmarshalPrefixWithOptions.
+func UnmarshalPrefix(data []byte, v interface{}) (rest []byte, err error) {
+	return UnmarshalPrefixWithOptions(data, v, DefaultDecoderOptions())
+}
+
+// UnmarshalPrefixWithOptions decodes the first Hjson value in data, stores it
+// in the value pointed to by v and returns the bytes following the value, so
+// that Hjson documents can be embedded in larger streams or templates. If data
+// contains no value, io.EOF is returned.
+//
+// The value ends in the same place as for Decoder.Decode(): objects, arrays and
+// quoted strings end at their closing character, other values end at the end
+// of their line, and a root object without braces extends to the next
+// document separator or to the end of data.
+func UnmarshalPrefixWithOptions(data []byte, v interface{}, options DecoderOptions) (rest []byte, err error) {
+	dec := NewDecoderWithOptions(bytes.NewReader(data), options)
+	if err = dec.Decode(v); err != nil {
+		return nil, err
+	}
+	return data[dec.InputOffset():], nil
+}
+
+// DecodeBool reads the next Hjson value from its input, which must be a
+// boolean. See the function DecodeBool.
+func (dec *Decoder) DecodeBool() (bool, error) {
+	if err := dec.prepareValue(); err != nil {
+		return false, err
+	}
+	if v, quoted, next, ok := dec.scalarValue(false); ok && !quoted {
+		switch string(v) {
+		case "true":
+			dec.endValue(next)
+			return true, nil
+		case "false":
+			dec.endValue(next)
+			return false, nil
+		}
+	}
+	data, err := dec.readValue()
+	if err != nil {
+		return false, err
+	}
+	return DecodeBool(data)
+}
+
+// DecodeString reads the next Hjson value from its input, which must be a
+// string. See the function DecodeString.
+func (dec *Decoder) DecodeString() (string, error) {
+	if err := dec.prepareValue(); err != nil {
+		return "", err
+	}
+	if v, _, next, ok := dec.scalarValue(true); ok {
+		dec.endValue(next)
+		return string(v), nil
+	}
+	data, err := dec.readValue()
+	if err != nil {
+		return "", err
+	}
+	return DecodeString(data)
+}
+
+// DecodeInt64 reads the next Hjson value from its input, which must be an
+// integer. See the function DecodeInt64.
+func (dec *Decoder) DecodeInt64() (int64, error) {
+	if err := dec.prepareValue(); err != nil {
+		return 0, err
+	}
+	if v, quoted, next, ok := dec.scalarValue(false); ok && !quoted {
+		if n, ok := parseInt64(v); ok {
+			dec.endValue(next)
+			return n, nil
+		}
+	}
+	data, err := dec.readValue()
+	if err != nil {
+		return 0, err
+	}
+	return DecodeInt64(data)
+}
+
+// More reports whether there is another element in the current array or
+// object being parsed, or another value in the input if no array or object
+// is being parsed.
+func (dec *Decoder) More() bool {
+	if len(dec.tokenStack) == 0 {
+		_, _, err := dec.scan(false)
+		return err == nil
+	}
+	c, err := dec.peekValue()
+	return err == nil && c != ']' && c != '}'
+}
+
+// Buffered returns a reader of the data remaining in the Decoder's buffer.
+// The reader is valid until the next call to Decode.
+func (dec *Decoder) Buffered() io.Reader {
+	return bytes.NewReader(dec.buf[dec.scanp:])
+}
+
+// InputOffset returns the input stream byte offset of the current decoder
+// position. The offset gives the location of the end of the most recently
+// returned value and the beginning of the next value.
+func (dec *Decoder) InputOffset() int64 {
+	return dec.scanned + int64(dec.scanp)
+}
+
+// scan reads from the input until the next value has been found in the
+// buffer, and returns its offsets in the buffer. The buffered data is only
+// parsed by scanValue() when a valueScanner finds that the value might be
+// complete, or at the end of the input.
+func (dec *Decoder) scan(inContainer bool) (int, int, error) {
+	s := valueScanner{inContainer: inContainer}
+	for {
+		data := dec.buf[dec.scanp:]
+		err := errNeedMore
+		if s.ready(data) || dec.err != nil {
+			var start, end int
+			start, end, err = scanValue(data, dec.err != nil, inContainer)
+			if err == nil {
+				return dec.scanp + start, dec.scanp + end, nil
+			}
+		}
+		if err != errNeedMore {
+			if err == io.EOF && dec.err != io.EOF {
+				err = dec.err
+			}
+			if pe, ok := err.(*ParseError); ok {
+				dec.fixPosition(pe, dec.scanp)
+			}
+			return 0, 0, err
+		}
+		if dec.err != nil {
+			return 0, 0, dec.err
+		}
+		if max := dec.options.MaxBytes; max > 0 && len(dec.buf)-dec.scanp > max {
+			return 0, 0, &LimitError{
+				Limit:  "MaxBytes",
+				Max:    max,
+				Offset: int(dec.InputOffset()) + max,
+			}
+		}
+		if err = dec.refill(); err != nil {
+			dec.err = err
+		}
+	}
+}
+
+// prepareValue consumes the comma before the next value in an array or
+// object, and checks that a value can be read. Calling it again before the
+// value is read has no effect.
+func (dec *Decoder) prepareValue() error {
+	if err := dec.tokenPrepareForDecode(); err != nil {
+		return err
+	}
+	if !dec.tokenValueAllowed() {
+		return dec.errAt(MsgValueInsteadOfKey)
+	}
+	return nil
+}
+
+// scalarValue returns the next value without consuming it, if it is a scalar
+// that is complete in the buffer and can be read by scalarAt(), so that the
+// Decode methods do not need the parser. value holds the text of the value
+// without quotes, and next is the offset in the buffer where decoding
+// continues, see endValue(). ok is false for any other value, which must be
+// read with readValue().
+func (dec *Decoder) scalarValue(untilEOL bool) (value []byte, quoted bool, next int, ok bool) {
+	data := dec.buf[dec.scanp:]
+	i := skipWhite(data, 0)
+	lineEnd := i + bytes.IndexByte(data[i:], '\n')
+	if lineEnd < i {
+		if dec.err == nil {
+			return nil, false, 0, false
+		}
+		lineEnd = len(data)
+	}
+	start, end, n, quoted, ok := scalarAt(data[:lineEnd], i, untilEOL)
+	if !ok {
+		return nil, false, 0, false
+	}
+	if len(dec.tokenStack) == 0 {
+		// A root value ends at the end of its line, like in scanValue(). The
+		// line must not be a document separator or the first line of a root
+		// object without braces (a quoted key is followed by a colon).
+		if skipWhite(data[:lineEnd], n) != lineEnd {
+			return nil, false, 0, false
+		}
+		if sep, _ := separatorAt(data, i, true); sep > 0 {
+			return nil, false, 0, false
+		}
+		if !quoted {
+			if isKey, _ := firstLineIsKey(data, i, true); isKey {
+				return nil, false, 0, false
+			}
+		}
+		n = lineEnd
+	}
+	return data[start:end], quoted, dec.scanp + n, true
+}
+
+// endValue consumes the value returned by scalarValue().
+func (dec *Decoder) endValue(next int) {
+	dec.scanp = next
+	dec.tokenValueEnd()
+}
+
+// readValue returns the next complete value in the input.
+func (dec *Decoder) readValue() ([]byte, error) {
+	if err := dec.prepareValue(); err != nil {
+		return nil, err
+	}
+	start, end, err := dec.scan(len(dec.tokenStack) > 0)
+	if err != nil {
+		return nil, err
+	}
+	dec.scanp = end
+	dec.tokenValueEnd()
+	return dec.buf[start:end], nil
+}
+
+// peekValue skips whitespace, comments and (if an array or object is being
+// parsed) a single comma, and returns the next byte in the input without
+// consuming it.
+func (dec *Decoder) peekValue() (byte, error) {
+	for {
+		data := dec.buf[dec.scanp:]
+		p := &hjsonParser{
+			DecoderOptions: DefaultDecoderOptions(),
+			data:           data,
+		}
+		p.resetAt()
+		p.white()
+		if p.ch == ',' && len(dec.tokenStack) > 0 {
+			p.next()
+			p.white()
+		}
+		if p.at <= len(data) {
+			return p.ch, nil
+		}
+		if dec.err != nil {
+			return 0, dec.err
+		}
+		if err := dec.refill(); err != nil {
+			dec.err = err
+		}
+	}
+}
+
+// fixPosition changes the position of pe, found in the data starting at
+// buf[start], to the position in the stream.
+func (dec *Decoder) fixPosition(pe *ParseError, start int) {
+	before := dec.buf[:start]
+	line := dec.scannedLines + bytes.Count(before, []byte{'\n'}) + 1
+	column := dec.scannedColumns + start + 1
+	if i := bytes.LastIndexByte(before, '\n'); i >= 0 {
+		column = start - i
+	}
+	if pe.Line == 1 {
+		pe.Column += column - 1
+	}
+	pe.Line += line - 1
+	pe.Offset += int(dec.scanned) + start
+}
+
+func (dec *Decoder) refill() error {
+	// Make room to read more into the buffer.
+	// First slide down data already consumed. The start of the current line is
+	// kept (unless it is very long), because the indentation of a multiline
+	// string depends on it.
+	keep := dec.scanp - (bytes.LastIndexByte(dec.buf[:dec.scanp], '\n') + 1)
+	if keep > maxKeepLine {
+		keep = 0
+	}
+	if drop := dec.scanp - keep; drop > 0 {
+		dec.scanned += int64(drop)
+		if n := bytes.Count(dec.buf[:drop], []byte{'\n'}); n > 0 {
+			dec.scannedLines += n
+			dec.scannedColumns = drop - (bytes.LastIndexByte(dec.buf[:drop], '\n') + 1)
+		} else {
+			dec.scannedColumns += drop
+		}
+		n := copy(dec.buf, dec.buf[drop:])
+		dec.buf = dec.buf[:n]
+		dec.scanp = keep
+	}
+
+	// Grow buffer if not large enough.
+	const minRead = 512
+	if cap(dec.buf)-len(dec.buf) < minRead {
+		newBuf := make([]byte, len(dec.buf), 2*cap(dec.buf)+minRead)
+		copy(newBuf, dec.buf)
+		dec.buf = newBuf
+	}
+
+	// Read. Delay error for next iteration (after scan).
+	n, err := dec.r.Read(dec.buf[len(dec.buf):cap(dec.buf)])
+	dec.buf = dec.buf[0 : len(dec.buf)+n]
+
+	return err
+}
@@ -0,0 +1,308 @@
+package hjson
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestDecoder(t *testing.T) {
+	txt := `# first value
+{
+  a: 1
+  b: [2, 3] # comment
+}
+[
+  x
+  'y'
+]
+"quoted"
+''
+'''
+multi
+'''
+quoteless string
+4 // comment
+c: 5
+d: 6
+`
+	expected := []interface{}{
+		map[string]interface{}{"a": 1.0, "b": []interface{}{2.0, 3.0}},
+		[]interface{}{"x", "y"},
+		"quoted",
+		"",
+		"multi",
+		"quoteless string",
+		4.0,
+		map[string]interface{}{"c": 5.0, "d": 6.0},
+	}
+
+	dec := NewDecoder(iotest.OneByteReader(strings.NewReader(txt)))
+	for i, exp := range expected {
+		if !dec.More() {
+			t.Fatalf("Expected more values at index %d", i)
+		}
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(v, exp) {
+			t.Errorf("Value %d: expected %#v, got %#v", i, exp, v)
+		}
+	}
+	if dec.More() {
+		t.Error("Expected no more values")
+	}
+	var v interface{}
+	if err := dec.Decode(&v); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+	if dec.InputOffset() != int64(len(txt)) {
+		t.Errorf("Unexpected input offset %d", dec.InputOffset())
+	}
+}
+
+func TestDecoderDocumentSeparators(t *testing.T) {
+	txt := `---
+# first document
+name: web
+ports: [
+  80
+  ---
+]
+note:
+  '''
+  ---
+  '''
+---
+name: db
+---
+{
+  name: cache
+}
+` + "---  \r\n  ---\n"
+	expected := []interface{}{
+		map[string]interface{}{"name": "web", "ports": []interface{}{80.0, "---"}, "note": "---"},
+		map[string]interface{}{"name": "db"},
+		map[string]interface{}{"name": "cache"},
+		"---",
+	}
+
+	for _, r := range []io.Reader{
+		strings.NewReader(txt),
+		iotest.OneByteReader(strings.NewReader(txt)),
+	} {
+		dec := NewDecoder(r)
+		var values []interface{}
+		for dec.More() {
+			var v interface{}
+			if err := dec.Decode(&v); err != nil {
+				t.Fatal(err)
+			}
+			values = append(values, v)
+		}
+		if !reflect.DeepEqual(values, expected) {
+			t.Errorf("Expected:\n%#v\nGot:\n%#v", expected, values)
+		}
+	}
+}
+
+func TestDecoderLargeValue(t *testing.T) {
+	// Reading a large value one byte at a time must not parse the buffered
+	// value again after every byte.
+	var b strings.Builder
+	b.WriteString("# many members\n{\n")
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&b, "  m%d: {\n    n: %d, s: \"a}\", q: b ] c, t: [true, 'x', null]\n", i, i)
+		b.WriteString("    ml:\n      '''\n      text }\n      '''\n  } /* } */\n")
+	}
+	b.WriteString("}\nnext\n")
+	txt := b.String()
+
+	var expected interface{}
+	if err := Unmarshal([]byte(txt[:len(txt)-len("next\n")]), &expected); err != nil {
+		t.Fatal(err)
+	}
+	dec := NewDecoder(iotest.OneByteReader(strings.NewReader(txt)))
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(v, expected) {
+		t.Error("Unexpected value")
+	}
+	if err := dec.Decode(&v); err != nil || v != "next" {
+		t.Errorf("Expected next, got %v %v", v, err)
+	}
+
+	// The value is only parsed when its closing brace has been read.
+	data := []byte(txt)
+	s := valueScanner{}
+	for i := 1; i <= len(data); i++ {
+		if s.ready(data[:i]) {
+			if i != len(txt)-len("\nnext\n") {
+				t.Errorf("Value parsed after %d bytes", i)
+			}
+			break
+		}
+	}
+}
+
+func TestDecoderScalars(t *testing.T) {
+	dec := NewDecoder(strings.NewReader("true\n\"text\"\n-17\n"))
+	if b, err := dec.DecodeBool(); err != nil || !b {
+		t.Errorf("Unexpected result: %v %v", b, err)
+	}
+	if s, err := dec.DecodeString(); err != nil || s != "text" {
+		t.Errorf("Unexpected result: %v %v", s, err)
+	}
+	if n, err := dec.DecodeInt64(); err != nil || n != -17 {
+		t.Errorf("Unexpected result: %v %v", n, err)
+	}
+	if _, err := dec.DecodeInt64(); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
+
+func TestDecoderScalarsInContainers(t *testing.T) {
+	txt := "[1, -2 # c\n  3]\n[\"a\", b, c\n  /* c */ \"d\"]\n[true,false]\n"
+	for _, r := range []io.Reader{strings.NewReader(txt), iotest.OneByteReader(strings.NewReader(txt))} {
+		dec := NewDecoder(r)
+		var ints []int64
+		var strs []string
+		var bools []bool
+		for i := 0; i < 3; i++ {
+			if tok, err := dec.Token(); err != nil || tok != Delim('[') {
+				t.Fatalf("Expected '[', got %v %v", tok, err)
+			}
+			for dec.More() {
+				var err error
+				switch i {
+				case 0:
+					var n int64
+					n, err = dec.DecodeInt64()
+					ints = append(ints, n)
+				case 1:
+					var s string
+					s, err = dec.DecodeString()
+					strs = append(strs, s)
+				case 2:
+					var b bool
+					b, err = dec.DecodeBool()
+					bools = append(bools, b)
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tok, err := dec.Token(); err != nil || tok != Delim(']') {
+				t.Fatalf("Expected ']', got %v %v", tok, err)
+			}
+		}
+		if !reflect.DeepEqual(ints, []int64{1, -2, 3}) ||
+			!reflect.DeepEqual(strs, []string{"a", "b, c", "d"}) ||
+			!reflect.DeepEqual(bools, []bool{true, false}) {
+
+			t.Errorf("Unexpected values %v %q %v", ints, strs, bools)
+		}
+	}
+
+	for _, txt := range []string{"0123", "-01", "1 2", "[0123]"} {
+		dec := NewDecoder(strings.NewReader(txt))
+		if txt[0] == '[' {
+			dec.Token()
+		}
+		if n, err := dec.DecodeInt64(); err == nil {
+			t.Errorf("Should have failed for %q, got %d", txt, n)
+		}
+	}
+
+	data := []byte(strings.Repeat("[1, 2]\n", 100))
+	dec := NewDecoder(bytes.NewReader(data))
+	dec.Token()
+	allocs := testing.AllocsPerRun(50, func() {
+		if !dec.More() {
+			dec.Token()
+			dec.Token()
+		}
+		if _, err := dec.DecodeInt64(); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("Expected no allocations, got %v", allocs)
+	}
+}
+
+func TestDecoderOptions(t *testing.T) {
+	var dest struct {
+		A int
+	}
+	dec := NewDecoder(strings.NewReader("{A: 1}\n{B: 2}"))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&dest); err != nil || dest.A != 1 {
+		t.Errorf("Unexpected result: %v %v", dest, err)
+	}
+	if err := dec.Decode(&dest); err == nil {
+		t.Error("Should have failed because of unknown field")
+	}
+
+	dec = NewDecoder(strings.NewReader("{a: 1"))
+	var v interface{}
+	if err := dec.Decode(&v); err == nil || err == io.EOF {
+		t.Errorf("Expected syntax error, got %v", err)
+	}
+}
+
+func TestUnmarshalPrefix(t *testing.T) {
+	txt := "{\n  a: 1\n} trailing {{ template }}"
+	var v map[string]int
+	rest, err := UnmarshalPrefix([]byte(txt), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v["a"] != 1 || string(rest) != " trailing {{ template }}" {
+		t.Errorf("Unexpected result %v, %q", v, rest)
+	}
+
+	var s string
+	if rest, err = UnmarshalPrefix([]byte("  'x' 'y'"), &s); err != nil {
+		t.Fatal(err)
+	}
+	if s != "x" || string(rest) != " 'y'" {
+		t.Errorf("Unexpected result %q, %q", s, rest)
+	}
+
+	var n int
+	if rest, err = UnmarshalPrefix([]byte("# comment\n5\nrest"), &n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 5 || string(rest) != "\nrest" {
+		t.Errorf("Unexpected result %v, %q", n, rest)
+	}
+
+	if _, err = UnmarshalPrefix([]byte(" # only a comment\n"), &n); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+	if _, err = UnmarshalPrefix([]byte("[1, 2"), &n); err == nil {
+		t.Error("Expected error for incomplete value")
+	}
+}
+
+func TestDecoderErrorPosition(t *testing.T) {
+	txt := strings.Repeat("{\n  a: 1\n}\n", 100) + "{\n  a: [}\n}\n"
+	dec := NewDecoder(iotest.OneByteReader(strings.NewReader(txt)))
+	var err error
+	for err == nil {
+		var v interface{}
+		err = dec.Decode(&v)
+	}
+	pe, ok := err.(*ParseError)
+	if !ok || pe.Line != 302 || pe.Column != 7 || pe.Offset != len(txt)-4 {
+		t.Errorf("Unexpected error %#v", err)
+	}
+}
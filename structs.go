@@ -1,24 +1,45 @@
 package hjson
 
 import (
-	"fmt"
 	"reflect"
 	"sort"
 	"strings"
+	"sync"
 )
 
 type fieldInfo struct {
-	field   reflect.Value
-	name    string
-	comment string
+	field     reflect.Value
+	name      string
+	comment   string
+	multiline bool
+	rune      bool
+	bytes     string
+	intFormat string
+	encoder   string
 }
 
 type structFieldInfo struct {
-	name      string
+	name string
+	// jsonName is the name used for the field by json.Unmarshal(), which
+	// differs from name if the field is named by the hjson tag. Empty if the
+	// json tag is "-".
+	jsonName  string
 	tagged    bool
 	comment   string
 	omitEmpty bool
-	indexPath []int
+	multiline bool
+	rune      bool
+	bytes     string
+	intFormat string
+	encoder   string
+	decoder   string
+	enum      []string
+	remain    bool
+	required  bool
+	// defaultValue is the Hjson text of the default tag, used if the field
+	// is not found in the input.
+	defaultValue string
+	indexPath    []int
 }
 
 // Use lower key name as key. Values are arrays in case some fields only differ
@@ -83,6 +104,10 @@ func getStructFieldInfo(rootType reflect.Type) []structFieldInfo {
 	type structInfo struct {
 		typ       reflect.Type
 		indexPath []int
+		// viaInline is true if the struct is found through a field tagged
+		// with the "inline" option, whose fields json.Unmarshal() does not
+		// know about.
+		viaInline bool
 	}
 	var sfis []structFieldInfo
 	structsToInvestigate := []structInfo{structInfo{typ: rootType}}
@@ -127,7 +152,10 @@ func getStructFieldInfo(rootType reflect.Type) []structFieldInfo {
 				}
 
 				jsonTag := sf.Tag.Get("json")
-				if jsonTag == "-" {
+				hjsonTag := strings.Split(sf.Tag.Get("hjson"), ",")
+				if hjsonTag[0] == "-" && len(hjsonTag) == 1 ||
+					jsonTag == "-" && hjsonTag[0] == "" {
+
 					continue
 				}
 
@@ -135,9 +163,12 @@ func getStructFieldInfo(rootType reflect.Type) []structFieldInfo {
 					name:    sf.Name,
 					comment: sf.Tag.Get("comment"),
 				}
+				if sfi.comment == "" {
+					sfi.comment = sf.Tag.Get("hjsonComment")
+				}
 
 				splits := strings.Split(jsonTag, ",")
-				if splits[0] != "" {
+				if splits[0] != "" && jsonTag != "-" {
 					sfi.name = splits[0]
 					sfi.tagged = true
 				}
@@ -148,6 +179,52 @@ func getStructFieldInfo(rootType reflect.Type) []structFieldInfo {
 						}
 					}
 				}
+				sfi.jsonName = sfi.name
+				if jsonTag == "-" || curStruct.viaInline {
+					sfi.jsonName = ""
+				}
+				if hjsonTag[0] != "" {
+					// A name in the hjson tag replaces the json tag.
+					sfi.name = hjsonTag[0]
+					sfi.tagged = true
+					sfi.omitEmpty = false
+				}
+
+				inline := false
+				for _, opt := range hjsonTag[1:] {
+					switch opt {
+					case "omitempty":
+						sfi.omitEmpty = true
+					case "multiline":
+						sfi.multiline = true
+					case "rune":
+						sfi.rune = true
+					case tagRemain:
+						sfi.remain = isRemainType(sf.Type)
+					case tagRequired:
+						sfi.required = true
+					case tagInline, tagSquash:
+						inline = true
+					case bytesBase64, bytesHex, bytesText, intOctal, intBinary:
+						if isByteSlice(sf.Type) && isBytesFormat(opt) {
+							sfi.bytes = opt
+						} else if isIntType(sf.Type) && isIntFormat(opt) {
+							sfi.intFormat = opt
+						}
+					default:
+						if strings.HasPrefix(opt, "encoder=") {
+							sfi.encoder = strings.TrimPrefix(opt, "encoder=")
+						} else if strings.HasPrefix(opt, "decoder=") {
+							sfi.decoder = strings.TrimPrefix(opt, "decoder=")
+						}
+					}
+				}
+
+				sfi.defaultValue = sf.Tag.Get("default")
+
+				if enum := sf.Tag.Get("enum"); enum != "" {
+					sfi.enum = strings.Split(enum, "|")
+				}
 
 				sfi.indexPath = make([]int, len(curStruct.indexPath)+1)
 				copy(sfi.indexPath, curStruct.indexPath)
@@ -159,8 +236,15 @@ func getStructFieldInfo(rootType reflect.Type) []structFieldInfo {
 					ft = ft.Elem()
 				}
 
+				// Fields tagged with the "inline" option are treated like embedded
+				// structs, except that json.Unmarshal() does not promote the
+				// fields of named fields and of embedded structs with a name in
+				// the json tag.
+				inline = inline && ft.Kind() == reflect.Struct
+				viaInline := curStruct.viaInline || inline && (sfi.tagged || !sf.Anonymous)
+
 				// If the current field should be included.
-				if sfi.tagged || !sf.Anonymous || ft.Kind() != reflect.Struct {
+				if !inline && (sfi.tagged || !sf.Anonymous || ft.Kind() != reflect.Struct) {
 					sfis = append(sfis, sfi)
 					if curTDC[curStruct.typ] > 1 {
 						// If there were multiple instances, add a second,
@@ -178,6 +262,7 @@ func getStructFieldInfo(rootType reflect.Type) []structFieldInfo {
 					structsToInvestigate = append(structsToInvestigate, structInfo{
 						typ:       ft,
 						indexPath: sfi.indexPath,
+						viaInline: viaInline,
 					})
 				}
 			}
@@ -231,15 +316,29 @@ func getStructFieldInfo(rootType reflect.Type) []structFieldInfo {
 	return out
 }
 
+// Struct field info is identical for all instances of the same type, and is
+// never modified after it has been created, so it is shared by all encoders
+// and decoders, also when running concurrently.
+var structFieldInfoSliceCache, structFieldInfoMapCache sync.Map
+
 func getStructFieldInfoSlice(rootType reflect.Type) []structFieldInfo {
+	if cached, ok := structFieldInfoSliceCache.Load(rootType); ok {
+		return cached.([]structFieldInfo)
+	}
+
 	sfis := getStructFieldInfo(rootType)
 
 	sort.Sort(byIndex(sfis))
 
+	structFieldInfoSliceCache.Store(rootType, sfis)
 	return sfis
 }
 
 func getStructFieldInfoMap(rootType reflect.Type) structFieldMap {
+	if cached, ok := structFieldInfoMapCache.Load(rootType); ok {
+		return cached.(structFieldMap)
+	}
+
 	sfis := getStructFieldInfo(rootType)
 
 	out := structFieldMap{}
@@ -247,6 +346,7 @@ func getStructFieldInfoMap(rootType reflect.Type) structFieldMap {
 		out.insert(elem)
 	}
 
+	structFieldInfoMapCache.Store(rootType, out)
 	return out
 }
 
@@ -288,11 +388,29 @@ func (e *hjsonEncoder) writeFields(
 		e.WriteString(cm.InsideFirst)
 	}
 
+	var keyWidth int
+	if e.AlignValues {
+		for _, fi := range fis {
+			if w := displayWidth(e.memberName(fi.name, fi.field)); w > keyWidth {
+				keyWidth = w
+			}
+		}
+	}
+
+	var align *alignment
+	var names []string
+	var nodes []*Node
+	if e.Comments && e.Eol != "" {
+		for _, fi := range fis {
+			names = append(names, e.memberName(fi.name, fi.field))
+			nodes = append(nodes, asNode(fi.field))
+		}
+		align = newAlignment(names, nodes)
+	}
+
 	// Join all of the member texts together, separated with newlines
-	var elemCm Comments
 	for i, fi := range fis {
-		var elem reflect.Value
-		elem, elemCm = e.unpackNode(fi.field, elemCm)
+		_, elemCm := e.unpackNode(fi.field, Comments{})
 		if i > 0 || !isRootObject || e.EmitRootBraces {
 			e.WriteString(e.Eol)
 		}
@@ -306,7 +424,7 @@ func (e *hjsonEncoder) writeFields(
 				if e.EnableColor {
 					l, r = e.ColorStyle.Remark[0], e.ColorStyle.Remark[1]
 				}
-				e.WriteString(fmt.Sprintf("%s# %s%s\n", l, line, r))
+				e.WriteString(l + e.CommentStyle.tagComment(line) + r + "\n")
 			}
 		}
 		if elemCm.Before == "" {
@@ -318,11 +436,43 @@ func (e *hjsonEncoder) writeFields(
 		if e.EnableColor {
 			l, r = e.ColorStyle.Key[0], e.ColorStyle.Key[1]
 		}
-		e.WriteString(l + e.quoteName(fi.name) + r)
+		name := e.memberName(fi.name, fi.field)
+		keyStart := e.column()
+		e.WriteString(l + name + r)
 		e.WriteString(":")
 		e.WriteString(elemCm.Key)
 
-		if err := e.str(elem, false, " ", false, true, elemCm); err != nil {
+		separator := " "
+		if e.AlignValues {
+			separator += strings.Repeat(" ", keyWidth-displayWidth(name))
+		} else if align != nil && align.valueCol >= 0 && elemCm.Key == "" &&
+			(nodes[i] == nil || nodes[i].src == nil && !isContainer(nodes[i].Value)) {
+
+			// Align an added member with the decoded ones.
+			separator = align.separator(name)
+		}
+
+		field := fi.field
+		if fi.encoder != "" {
+			var err error
+			if field, err = encodeField(fi.encoder, field); err != nil {
+				return err
+			}
+		}
+		if fi.rune {
+			field = runeField(field)
+		}
+		if fi.bytes != "" {
+			var err error
+			if field, err = bytesField(field, fi.bytes); err != nil {
+				return err
+			}
+		}
+		if (fi.multiline || fi.bytes == bytesText) && e.writeMLField(field, separator, elemCm) {
+			// Written as a multiline string.
+		} else if fi.intFormat != "" && e.writeIntField(field, separator, fi.intFormat) {
+			// Written in another base.
+		} else if err := e.str(field, false, separator, false, true, elemCm); err != nil {
 			return err
 		}
 
@@ -330,6 +480,9 @@ func (e *hjsonEncoder) writeFields(
 			e.WriteString(e.Eol)
 		}
 
+		if align != nil {
+			elemCm.After = align.after(names, i, nodes[i], elemCm.After, e.column()-keyStart)
+		}
 		e.WriteString(elemCm.After)
 	}
 
@@ -355,3 +508,44 @@ func (e *hjsonEncoder) writeFields(
 
 	return nil
 }
+
+// writeMLField writes a struct field tagged with the "multiline" option as a
+// multiline string block, if the field contains a string that can be written
+// as a multiline string. Returns false if nothing was written, for example if
+// the string is empty.
+func (e *hjsonEncoder) writeMLField(value reflect.Value, separator string, cm Comments) bool {
+	value, _ = e.unpackNode(value, cm)
+	for value.IsValid() && (value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface) {
+		if value.IsNil() {
+			return false
+		}
+		value = value.Elem()
+	}
+	if !value.IsValid() || value.Kind() != reflect.String || value.Len() == 0 ||
+		!e.canWriteML(value.String()) {
+		return false
+	}
+	l, r := "", ""
+	if e.EnableColor {
+		l, r = e.ColorStyle.String[0], e.ColorStyle.String[1]
+	}
+	e.mlString(value.String(), separator, cm.Key, l, r, true)
+	return true
+}
+
+// runeField returns the character in a struct field tagged with the "rune"
+// option as a string, if the field contains an int32. Otherwise value is
+// returned unchanged.
+func runeField(value reflect.Value) reflect.Value {
+	v := value
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return value
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Int32 {
+		return value
+	}
+	return reflect.ValueOf(string(rune(v.Int())))
+}
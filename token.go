@@ -0,0 +1,452 @@
+package hjson
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+)
+
+// A Token holds a value of one of these types:
+//
+//	Delim, for the four Hjson delimiters [ ] { }
+//	Key, for Hjson object keys
+//	Comment, for Hjson comments
+//	bool, for Hjson booleans
+//	float64, for Hjson numbers
+//	json.Number, for Hjson numbers if UseNumber() has been called
+//	string, for Hjson strings (quoted, quoteless or multiline)
+//	nil, for Hjson null
+type Token interface{}
+
+// A Delim is an Hjson array or object delimiter, one of [ ] { or }.
+type Delim rune
+
+func (d Delim) String() string {
+	return string(d)
+}
+
+// A Key is the name of an Hjson object member. The colon following the key is
+// consumed together with the key.
+type Key string
+
+// A Comment is an Hjson comment, including its markers (#, // or /* */) but
+// not including the line feed ending a line comment.
+type Comment string
+
+const (
+	tokenTopValue = iota
+	tokenArrayValue
+	tokenArrayComma
+	tokenObjectKey
+	tokenObjectValue
+	tokenObjectComma
+)
+
+// Token returns the next Hjson token in the input stream. At the end of the
+// input stream, Token returns nil, io.EOF.
+//
+// Token guarantees that the delimiters [ ] { } it returns are properly nested
+// and matched: if Token encounters an unexpected delimiter in the input, it
+// will return an error. Commas are optional in Hjson and are never returned
+// as tokens. A root object without braces is reported as if it had braces,
+// i.e. starting with Delim('{') and ending with Delim('}') at the end of the
+// input.
+//
+// Unlike Decode(), Token does not use reflection. It can be used by tools
+// like linters and syntax highlighters that need to look at the structure of
+// a document rather than at its values.
+func (dec *Decoder) Token() (Token, error) {
+	for {
+		c, err := dec.peekNonSpace()
+		if err != nil {
+			return dec.tokenEOF(err)
+		}
+		if c == '#' || c == '/' {
+			if cm, ok := dec.readComment(); ok {
+				return cm, nil
+			}
+		}
+		if c == '-' && (dec.tokenState == tokenTopValue || dec.braceless && len(dec.tokenStack) == 1 &&
+			(dec.tokenState == tokenObjectKey || dec.tokenState == tokenObjectComma)) {
+
+			n, err := dec.separator()
+			if err != nil {
+				return nil, err
+			}
+			if n > 0 {
+				if len(dec.tokenStack) == 0 {
+					dec.scanp += n
+					continue
+				}
+				// A document separator ends a root object without braces.
+				dec.tokenPop()
+				return Delim('}'), nil
+			}
+		}
+
+		switch dec.tokenState {
+		case tokenArrayComma:
+			if c == ',' {
+				dec.scanp++
+			}
+			dec.tokenState = tokenArrayValue
+			continue
+		case tokenObjectComma:
+			if c == ',' {
+				dec.scanp++
+			}
+			dec.tokenState = tokenObjectKey
+			continue
+		}
+
+		switch c {
+		case '{', '[':
+			if !dec.tokenValueAllowed() {
+				return dec.tokenError(c)
+			}
+			dec.scanp++
+			dec.tokenStack = append(dec.tokenStack, dec.tokenState)
+			if c == '{' {
+				dec.tokenState = tokenObjectKey
+			} else {
+				dec.tokenState = tokenArrayValue
+			}
+			return Delim(c), nil
+		case '}':
+			if dec.tokenState != tokenObjectKey || dec.braceless && len(dec.tokenStack) == 1 {
+				return dec.tokenError(c)
+			}
+			dec.scanp++
+			dec.tokenPop()
+			return Delim(c), nil
+		case ']':
+			if dec.tokenState != tokenArrayValue {
+				return dec.tokenError(c)
+			}
+			dec.scanp++
+			dec.tokenPop()
+			return Delim(c), nil
+		case ',', ':':
+			return dec.tokenError(c)
+		}
+
+		if dec.tokenState == tokenObjectKey {
+			return dec.readKey()
+		}
+		if !dec.tokenValueAllowed() {
+			return dec.tokenError(c)
+		}
+
+		if dec.tokenState == tokenTopValue {
+			isKey, err := dec.lineIsKey()
+			if err != nil {
+				return nil, err
+			}
+			if isKey {
+				dec.braceless = true
+				dec.tokenStack = append(dec.tokenStack, dec.tokenState)
+				dec.tokenState = tokenObjectKey
+				return Delim('{'), nil
+			}
+		}
+
+		return dec.readScalar()
+	}
+}
+
+func (dec *Decoder) tokenValueAllowed() bool {
+	switch dec.tokenState {
+	case tokenTopValue, tokenArrayValue, tokenObjectValue:
+		return true
+	}
+	return false
+}
+
+// tokenValueEnd updates the token state after a complete value has been read.
+func (dec *Decoder) tokenValueEnd() {
+	switch dec.tokenState {
+	case tokenArrayValue:
+		dec.tokenState = tokenArrayComma
+	case tokenObjectValue:
+		dec.tokenState = tokenObjectComma
+	}
+}
+
+func (dec *Decoder) tokenPop() {
+	dec.tokenState = dec.tokenStack[len(dec.tokenStack)-1]
+	dec.tokenStack = dec.tokenStack[:len(dec.tokenStack)-1]
+	if len(dec.tokenStack) == 0 {
+		dec.braceless = false
+	}
+	dec.tokenValueEnd()
+}
+
+// tokenPrepareForDecode consumes the optional comma between the elements of
+// an array or an object, so that the next element can be read by Decode().
+func (dec *Decoder) tokenPrepareForDecode() error {
+	switch dec.tokenState {
+	case tokenArrayComma, tokenObjectComma:
+		for {
+			c, err := dec.peekNonSpace()
+			if err != nil {
+				if err == io.EOF {
+					err = dec.unexpectedEOF()
+				}
+				return err
+			}
+			if c == '#' || c == '/' {
+				if _, ok := dec.readComment(); ok {
+					continue
+				}
+			}
+			if c == ',' {
+				dec.scanp++
+			}
+			break
+		}
+		if dec.tokenState == tokenArrayComma {
+			dec.tokenState = tokenArrayValue
+		} else {
+			dec.tokenState = tokenObjectKey
+		}
+	}
+	return nil
+}
+
+func (dec *Decoder) tokenError(c byte) (Token, error) {
+	var id string
+	switch dec.tokenState {
+	case tokenTopValue, tokenArrayValue, tokenObjectValue:
+		id = MsgUnexpectedInValue
+	case tokenObjectKey:
+		id = MsgUnexpectedInKey
+	default:
+		id = MsgUnexpectedInElement
+	}
+	return nil, dec.errAt(id, string(c))
+}
+
+// tokenEOF handles the end of the input stream (or a read error).
+func (dec *Decoder) tokenEOF(err error) (Token, error) {
+	if err != io.EOF || len(dec.tokenStack) == 0 {
+		return nil, err
+	}
+	if dec.braceless && len(dec.tokenStack) == 1 &&
+		(dec.tokenState == tokenObjectKey || dec.tokenState == tokenObjectComma) {
+
+		dec.tokenPop()
+		return Delim('}'), nil
+	}
+	return nil, dec.unexpectedEOF()
+}
+
+// unexpectedEOF returns the error for the end of the input inside the
+// current array or object.
+func (dec *Decoder) unexpectedEOF() error {
+	switch dec.tokenState {
+	case tokenArrayValue, tokenArrayComma:
+		return dec.errAt(MsgUnterminatedArray)
+	}
+	return dec.errAt(MsgUnterminatedObject)
+}
+
+// errAt returns an error for the current position in the buffer.
+func (dec *Decoder) errAt(id string, args ...interface{}) error {
+	p := &hjsonParser{DecoderOptions: dec.options, data: dec.buf}
+	p.resetAt()
+	p.at = dec.scanp + 1
+	err := p.errAt(id, args...).(*ParseError)
+	dec.fixPosition(err, 0)
+	return err
+}
+
+// peekNonSpace skips whitespace and returns the next byte in the input
+// without consuming it.
+func (dec *Decoder) peekNonSpace() (byte, error) {
+	for {
+		for ; dec.scanp < len(dec.buf); dec.scanp++ {
+			if c := dec.buf[dec.scanp]; c > ' ' {
+				return c, nil
+			}
+		}
+		if dec.err != nil {
+			return 0, dec.err
+		}
+		if err := dec.refill(); err != nil {
+			dec.err = err
+		}
+	}
+}
+
+// fill reads from the input until at least n unread bytes are buffered.
+// Returns false if the input ended first.
+func (dec *Decoder) fill(n int) bool {
+	for len(dec.buf)-dec.scanp < n {
+		if dec.err != nil {
+			return false
+		}
+		if err := dec.refill(); err != nil {
+			dec.err = err
+		}
+	}
+	return true
+}
+
+// readComment reads a comment starting at the current position. Returns
+// false if there is no comment at the current position.
+func (dec *Decoder) readComment() (Comment, bool) {
+	dec.fill(2)
+	data := dec.buf[dec.scanp:]
+
+	var end []byte
+	from := 2
+	switch {
+	case data[0] == '#':
+		end = []byte("\n")
+		from = 1
+	case len(data) > 1 && data[0] == '/' && data[1] == '/':
+		end = []byte("\n")
+	case len(data) > 1 && data[0] == '/' && data[1] == '*':
+		end = []byte("*/")
+	default:
+		return "", false
+	}
+
+	n := len(dec.buf) - dec.scanp
+	for {
+		i := bytes.Index(dec.buf[dec.scanp+from:], end)
+		if i >= 0 {
+			n = from + i
+			if end[0] == '*' {
+				n += len(end)
+			}
+			break
+		}
+		// The end could be split between two reads.
+		if f := len(dec.buf) - dec.scanp - len(end) + 1; f > from {
+			from = f
+		}
+		if !dec.fill(len(dec.buf) - dec.scanp + 1) {
+			n = len(dec.buf) - dec.scanp
+			break
+		}
+	}
+
+	cm := dec.buf[dec.scanp : dec.scanp+n]
+	dec.scanp += n
+	return Comment(bytes.TrimRight(cm, "\r")), true
+}
+
+// lineIsKey returns true if the current line starts with a key name followed
+// by a colon.
+func (dec *Decoder) lineIsKey() (bool, error) {
+	for {
+		isKey, err := firstLineIsKey(dec.buf, dec.scanp, dec.err != nil)
+		if err != errNeedMore {
+			return isKey, err
+		}
+		if err := dec.refill(); err != nil {
+			dec.err = err
+		}
+	}
+}
+
+// separator returns the length of the document separator at the current
+// position, or 0 if there is none. See separatorAt().
+func (dec *Decoder) separator() (int, error) {
+	for {
+		n, err := separatorAt(dec.buf, dec.scanp, dec.err != nil)
+		if err != errNeedMore {
+			return n, err
+		}
+		if err := dec.refill(); err != nil {
+			dec.err = err
+		}
+	}
+}
+
+// parse calls fn with a parser positioned at the current position in the
+// buffer, reading more input for as long as fn needs more data. On success
+// the data read by fn is consumed.
+func (dec *Decoder) parse(fn func(p *hjsonParser) (interface{}, error)) (interface{}, error) {
+	for {
+		p := &hjsonParser{
+			DecoderOptions: dec.options,
+			data:           dec.buf,
+		}
+		p.resetAt()
+		p.at = dec.scanp
+		p.next()
+		v, err := fn(p)
+		if p.at <= len(dec.buf) || dec.err != nil {
+			if err != nil {
+				if pe, ok := err.(*ParseError); ok {
+					dec.fixPosition(pe, 0)
+				}
+				return nil, err
+			}
+			if p.at > len(dec.buf) {
+				p.at = len(dec.buf) + 1
+			}
+			dec.scanp = p.at - 1
+			return v, nil
+		}
+		if err := dec.refill(); err != nil {
+			dec.err = err
+		}
+	}
+}
+
+func (dec *Decoder) readKey() (Token, error) {
+	key, err := dec.parse(func(p *hjsonParser) (interface{}, error) {
+		key, err := p.readKeyname()
+		if err != nil {
+			return nil, err
+		}
+		p.white()
+		if p.ch != ':' {
+			return nil, p.errAt(MsgMissingColon, string(p.ch))
+		}
+		p.next()
+		return Key(key), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	dec.tokenState = tokenObjectValue
+	return key, nil
+}
+
+func (dec *Decoder) readScalar() (Token, error) {
+	v, err := dec.parse(func(p *hjsonParser) (interface{}, error) {
+		if p.ch == '"' || p.ch == '\'' {
+			return p.readString(true)
+		}
+		return p.readTfnns(reflect.Value{}, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	dec.tokenValueEnd()
+	return v, nil
+}
+
+// startArray consumes the '[' of an array that is the next value in the
+// input, skipping any comments before it.
+func (dec *Decoder) startArray() error {
+	for {
+		c, err := dec.peekNonSpace()
+		if err == io.EOF {
+			return dec.errAt(MsgEOFInValue)
+		} else if err != nil {
+			return err
+		}
+		if c == '[' {
+			_, err = dec.Token()
+			return err
+		}
+		if _, ok := dec.readComment(); !ok {
+			return dec.errAt(MsgExpectedArray, string(c))
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package hjson
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func readTokens(t *testing.T, dec *Decoder) []Token {
+	var tokens []Token
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return tokens
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokens = append(tokens, tok)
+	}
+}
+
+func TestToken(t *testing.T) {
+	txt := `# header
+{
+  a: 1
+  "b c": [true, null, 'x',]
+  // line
+  d: quoteless string
+  e: /* block */ {}
+  f:
+    '''
+    multi
+    '''
+}
+[2]
+`
+	expected := []Token{
+		Comment("# header"),
+		Delim('{'),
+		Key("a"), 1.0,
+		Key("b c"), Delim('['), true, nil, "x", Delim(']'),
+		Comment("// line"),
+		Key("d"), "quoteless string",
+		Key("e"), Comment("/* block */"), Delim('{'), Delim('}'),
+		Key("f"), "multi",
+		Delim('}'),
+		Delim('['), 2.0, Delim(']'),
+	}
+
+	for _, r := range []io.Reader{
+		strings.NewReader(txt),
+		iotest.OneByteReader(strings.NewReader(txt)),
+	} {
+		tokens := readTokens(t, NewDecoder(r))
+		if !reflect.DeepEqual(tokens, expected) {
+			t.Errorf("Expected:\n%#v\nGot:\n%#v", expected, tokens)
+		}
+	}
+}
+
+func TestTokenWithoutBraces(t *testing.T) {
+	dec := NewDecoder(strings.NewReader("a: 1\nb: [\n  x\n]\n"))
+	dec.UseNumber()
+	expected := []Token{
+		Delim('{'),
+		Key("a"), json.Number("1"),
+		Key("b"), Delim('['), "x", Delim(']'),
+		Delim('}'),
+	}
+	tokens := readTokens(t, dec)
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("Expected:\n%#v\nGot:\n%#v", expected, tokens)
+	}
+}
+
+func TestTokenDocumentSeparators(t *testing.T) {
+	dec := NewDecoder(strings.NewReader("---\na: 1\n---\nb: 2\n---\n[3]\n"))
+	expected := []Token{
+		Delim('{'), Key("a"), 1.0, Delim('}'),
+		Delim('{'), Key("b"), 2.0, Delim('}'),
+		Delim('['), 3.0, Delim(']'),
+	}
+	tokens := readTokens(t, dec)
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("Expected:\n%#v\nGot:\n%#v", expected, tokens)
+	}
+}
+
+func TestTokenDecode(t *testing.T) {
+	dec := NewDecoder(iotest.OneByteReader(strings.NewReader(`[
+  {a: 1}
+  {a: 2}, {a: 3}
+]`)))
+	tok, err := dec.Token()
+	if err != nil || tok != Delim('[') {
+		t.Fatalf("Expected '[', got %v (%v)", tok, err)
+	}
+	var sum int
+	for dec.More() {
+		var v struct{ A int }
+		if err := dec.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+		sum += v.A
+	}
+	if sum != 6 {
+		t.Errorf("Expected sum 6, got %d", sum)
+	}
+	tok, err = dec.Token()
+	if err != nil || tok != Delim(']') {
+		t.Fatalf("Expected ']', got %v (%v)", tok, err)
+	}
+	if _, err = dec.Token(); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
+
+func TestTokenErrors(t *testing.T) {
+	for _, txt := range []string{
+		"[1}",
+		"{a: 1]",
+		"{a 1}",
+		"[1,,2]",
+		"{a: 1",
+		"[1, 2",
+		"[",
+	} {
+		dec := NewDecoder(strings.NewReader(txt))
+		var err error
+		for err == nil {
+			_, err = dec.Token()
+		}
+		if pe, ok := err.(*ParseError); !ok || pe.Code == "" {
+			t.Errorf("Expected a ParseError with a code for %q, got %#v", txt, err)
+		}
+	}
+}
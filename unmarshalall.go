@@ -0,0 +1,64 @@
+//go:build go1.18
+
+package hjson
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+)
+
+// UnmarshalAll decodes each of inputs into a new value of type T, using
+// default options and up to workers goroutines at the same time (or
+// runtime.NumCPU() if workers < 1). The returned slices have the same length
+// as inputs: the value and the error (or nil) of inputs[i] are found at index
+// i. Information about struct types is cached and shared between the workers.
+//
+// See UnmarshalAllWithOptions.
+func UnmarshalAll[T any](inputs [][]byte, workers int) ([]T, []error) {
+	return UnmarshalAllWithOptions[T](inputs, workers, DefaultDecoderOptions())
+}
+
+// UnmarshalAllWithOptions is like UnmarshalAll, but decodes each input using
+// the given options. Functions set in options are called from many
+// goroutines at the same time. DecoderOptions.FieldStates cannot be used,
+// because the states of the inputs would be mixed in the same map: if it is
+// set, an error is returned for every input.
+func UnmarshalAllWithOptions[T any](inputs [][]byte, workers int, options DecoderOptions) ([]T, []error) {
+	values := make([]T, len(inputs))
+	errs := make([]error, len(inputs))
+
+	if options.FieldStates != nil {
+		err := errors.New("hjson: DecoderOptions.FieldStates cannot be used with UnmarshalAll")
+		for i := range errs {
+			errs[i] = err
+		}
+		return values, errs
+	}
+
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(inputs) {
+		workers = len(inputs)
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				errs[i] = UnmarshalWithOptions(inputs[i], &values[i], options)
+			}
+		}()
+	}
+	for i := range inputs {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	return values, errs
+}
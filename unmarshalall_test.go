@@ -0,0 +1,64 @@
+//go:build go1.18
+
+package hjson
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshalAll(t *testing.T) {
+	type config struct {
+		Name  string
+		Ports []int
+	}
+	var inputs [][]byte
+	for i := 0; i < 100; i++ {
+		inputs = append(inputs, []byte(fmt.Sprintf("name: n%d\nports: [%d, %d]", i, i, i+1)))
+	}
+	inputs[42] = []byte("name: [")
+
+	values, errs := UnmarshalAll[config](inputs, 8)
+	if len(values) != len(inputs) || len(errs) != len(inputs) {
+		t.Fatalf("Unexpected lengths %d and %d", len(values), len(errs))
+	}
+	for i, v := range values {
+		if i == 42 {
+			if errs[i] == nil {
+				t.Error("Expected an error for input 42")
+			}
+			continue
+		}
+		if errs[i] != nil {
+			t.Errorf("Input %d: %v", i, errs[i])
+		}
+		if v.Name != fmt.Sprintf("n%d", i) || len(v.Ports) != 2 || v.Ports[1] != i+1 {
+			t.Errorf("Input %d: unexpected value %+v", i, v)
+		}
+	}
+
+	values, errs = UnmarshalAll[config](nil, 0)
+	if len(values) != 0 || len(errs) != 0 {
+		t.Error("Expected empty results for no inputs")
+	}
+}
+
+func TestUnmarshalAllFieldStates(t *testing.T) {
+	// Run with -race: the workers must not share the map.
+	var inputs [][]byte
+	for i := 0; i < 20; i++ {
+		inputs = append(inputs, []byte(fmt.Sprintf("a: %d\nb: null", i)))
+	}
+	options := DefaultDecoderOptions()
+	options.FieldStates = FieldStates{}
+	_, errs := UnmarshalAllWithOptions[map[string]interface{}](inputs, 4, options)
+	for i, err := range errs {
+		if err == nil || !strings.Contains(err.Error(), "FieldStates") {
+			t.Errorf("Input %d: expected an error about FieldStates, got %v", i, err)
+		}
+	}
+	if len(options.FieldStates) != 0 {
+		t.Errorf("Expected no field states, got %v", options.FieldStates)
+	}
+}
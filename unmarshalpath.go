@@ -0,0 +1,301 @@
+package hjson
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// UnmarshalPath decodes the value found at path in the Hjson document data
+// using default options, and stores it in the value pointed to by v. It is
+// meant for programs that read a single setting from a large config file.
+//
+// See UnmarshalPathWithOptions.
+func UnmarshalPath(data []byte, path string, v interface{}) error {
+	return UnmarshalPathWithOptions(data, path, v, DefaultDecoderOptions())
+}
+
+// UnmarshalPathWithOptions is like UnmarshalWithOptions(), but only decodes
+// the value found at path. path is a dot-separated list of object keys and
+// array indexes, like "servers.0.port", or "" for the whole document. Keys
+// containing dots cannot be addressed.
+//
+// The values before and after the addressed value are only parsed as far as
+// needed to skip them, they are not decoded into Go values and syntax errors
+// following the addressed value are not reported. Like for duplicate keys in
+// the whole document, the last member with the requested key is used. The
+// skipped values do not count against AllocBudget or MaxElements and are not
+// recorded in FieldStates. Error positions are counted from the start of data.
+//
+// A *PathNotFoundError is returned if data has no value at path.
+func UnmarshalPathWithOptions(data []byte, path string, v interface{}, options DecoderOptions) error {
+	if path == "" {
+		return UnmarshalWithOptions(data, v, options)
+	}
+	options.valuePath = strings.Split(path, ".")
+	// KeepComments destinations are handled by UnmarshalWithOptions() before
+	// the document is parsed, so comments are only kept for *Node.
+	options.KeepComments = false
+	return UnmarshalWithOptions(data, v, options)
+}
+
+// pathValue reads the value at path, skipping the values before it.
+func (p *hjsonParser) pathValue(dest reflect.Value, path []string) (interface{}, error) {
+	if err := p.seekPath(path); err != nil {
+		return nil, err
+	}
+	dest = dest.Elem()
+	return p.readValue(dest, dest.Type())
+}
+
+// seekPath moves the parser to the start of the value at path.
+func (p *hjsonParser) seekPath(path []string) error {
+	p.white()
+	braceless := false
+	if p.ch != '{' && p.ch != '[' {
+		isKey, err := firstLineIsKey(p.data, p.at-1, true)
+		if err != nil {
+			return err
+		}
+		braceless = isKey
+	}
+
+	for i, seg := range path {
+		found := -1
+		switch {
+		case braceless || p.ch == '{':
+			if !braceless {
+				p.next()
+			}
+			braceless = false
+			for {
+				p.white()
+				if p.ch == '}' || p.ch == 0 {
+					break
+				}
+				key, err := p.readKeyname()
+				if err != nil {
+					return err
+				}
+				p.white()
+				if p.ch != ':' {
+					return p.errAt(MsgMissingColon, string(p.ch))
+				}
+				p.next()
+				p.white()
+				if key == seg {
+					found = p.at - 1
+				}
+				if err = p.skipValue(); err != nil {
+					return err
+				}
+			}
+		case p.ch == '[':
+			n, err := strconv.Atoi(seg)
+			if err != nil || n < 0 {
+				break
+			}
+			p.next()
+			for j := 0; ; j++ {
+				p.white()
+				if p.ch == ']' || p.ch == 0 {
+					break
+				}
+				if j == n {
+					found = p.at - 1
+					break
+				}
+				if err = p.skipValue(); err != nil {
+					return err
+				}
+			}
+		}
+		if found < 0 {
+			return &PathNotFoundError{Path: strings.Join(path[:i+1], ".")}
+		}
+		p.at = found
+		p.next()
+	}
+	return nil
+}
+
+// skipValue moves past the value at the current position, followed by an
+// optional comma. The value is only scanned, it is not built, so skipped
+// values do not count against AllocBudget or MaxElements and are not recorded
+// in FieldStates.
+func (p *hjsonParser) skipValue() error {
+	// The closing brackets of the objects and arrays being skipped.
+	var closers []byte
+	for {
+		p.white()
+		if n := len(closers); n > 0 && p.ch == closers[n-1] {
+			closers = closers[:n-1]
+			p.next()
+		} else {
+			if n > 0 && p.ch == 0 {
+				if closers[n-1] == '}' {
+					return p.errAt(MsgUnterminatedObject)
+				}
+				return p.errAt(MsgUnterminatedArray)
+			}
+			if n > 0 && closers[n-1] == '}' {
+				if err := p.skipKeyname(); err != nil {
+					return err
+				}
+				p.white()
+				if p.ch != ':' {
+					return p.errAt(MsgMissingColon, string(p.ch))
+				}
+				p.next()
+				p.white()
+			}
+			var err error
+			switch p.ch {
+			case '{', '[':
+				if maxDepth := p.maxDepth(); len(closers) >= maxDepth {
+					return p.errAt(MsgMaxDepth, maxDepth)
+				}
+				closer := byte('}')
+				if p.ch == '[' {
+					closer = ']'
+				}
+				closers = append(closers, closer)
+				p.next()
+				continue
+			case '"', '\'':
+				err = p.skipString(!p.strictDialect())
+			default:
+				err = p.skipQuoteless()
+			}
+			if err != nil {
+				return err
+			}
+		}
+		p.white()
+		if p.ch == ',' {
+			p.next()
+		}
+		if len(closers) == 0 {
+			return nil
+		}
+	}
+}
+
+// skipKeyname moves past the key name at the current position, like
+// readKeyname().
+func (p *hjsonParser) skipKeyname() error {
+	if p.ch == '"' || p.ch == '\'' {
+		return p.skipString(false)
+	}
+	for p.ch != ':' {
+		if p.ch == 0 {
+			return p.errAt(MsgEOFInKey)
+		}
+		if isPunctuatorChar(p.ch) {
+			return p.errAt(MsgPunctuatorInKey, string(p.ch))
+		}
+		p.next()
+	}
+	return nil
+}
+
+// skipString moves past the quoted or multiline string at the current
+// position, like readString().
+func (p *hjsonParser) skipString(allowML bool) error {
+	quote := p.ch
+	if allowML && quote == '\'' && p.peek(0) == '\'' && p.peek(1) == '\'' {
+		p.next()
+		p.next()
+		for p.next() {
+			if p.ch == '\'' && p.peek(0) == '\'' && p.peek(1) == '\'' {
+				p.next()
+				p.next()
+				p.next()
+				return nil
+			}
+		}
+		return p.errAt(MsgUnterminatedMLString)
+	}
+	for p.next() {
+		switch p.ch {
+		case quote:
+			p.next()
+			return nil
+		case '\\':
+			// The escaped character cannot end the string.
+			p.next()
+		case '\n', '\r':
+			return p.errAt(MsgNewlineInString)
+		}
+	}
+	return p.errAt(MsgUnterminatedString)
+}
+
+// skipQuoteless moves past the quoteless value at the current position,
+// which ends where readTfnns() would end it for a destination of unknown
+// type.
+func (p *hjsonParser) skipQuoteless() error {
+	if isPunctuatorChar(p.ch) {
+		return p.errAt(MsgPunctuatorInValue, string(p.ch))
+	}
+	start := p.at - 1
+	if p.strictDialect() {
+		for p.ch > ' ' && !isPunctuatorChar(p.ch) && p.ch != '#' &&
+			!(p.ch == '/' && (p.peek(0) == '/' || p.peek(0) == '*')) {
+
+			p.next()
+		}
+		return nil
+	}
+	for p.ch > 0 {
+		p.next()
+		isEol := p.ch == '\r' || p.ch == '\n' || p.ch == 0
+		if isEol ||
+			p.ch == ',' || p.ch == '}' || p.ch == ']' ||
+			p.ch == '#' ||
+			p.ch == '/' && (p.peek(0) == '/' || p.peek(0) == '*') {
+
+			ends, err := p.quotelessEnds(p.data[start : p.at-1])
+			if ends || isEol || err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// quotelessEnds returns true if the quoteless value text, followed by a
+// comma, a closing bracket or a comment, ends there, i.e. if readTfnns()
+// reads it as a number, a boolean, null or a literal.
+func (p *hjsonParser) quotelessEnds(text []byte) (bool, error) {
+	lit := strings.TrimSpace(string(text))
+	switch lit {
+	case "true", "false", "null":
+		return true, nil
+	}
+	if c := text[0]; c == '-' || c >= '0' && c <= '9' {
+		if p.ExtendedNumbers {
+			if n, ok := extendedNumber(lit); ok {
+				if _, err := tryParseNumber([]byte(n), false, false); err == nil {
+					return true, nil
+				}
+			}
+		}
+		if _, err := tryParseNumber(text, false, false); err == nil {
+			return true, nil
+		} else if p.StrictNumbers && isNumberOutOfRange(err) {
+			return false, p.errAt(MsgNumberOutOfRange)
+		}
+	}
+	if p.NonFiniteNumbers {
+		if _, ok := parseNonFinite(lit); ok {
+			return true, nil
+		}
+	}
+	for _, l := range p.Literals {
+		if _, ok := l.ParseLiteral(lit); ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,118 @@
+package hjson
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const pathDoc = `# Shared config.
+server: {
+  host: localhost
+  ports: [
+    80
+    "443"
+    {
+      port: 8080
+      tls: true
+    }
+  ]
+  timeout: 5s
+}
+clients: [1, 2, 3]
+server: {
+  host: example.com
+  ports: [8443]
+}
+name: last value
+`
+
+func TestUnmarshalPath(t *testing.T) {
+	var host string
+	if err := UnmarshalPath([]byte(pathDoc), "server.host", &host); err != nil {
+		t.Fatal(err)
+	}
+	if host != "example.com" {
+		t.Errorf("Expected the last duplicate key, got %q", host)
+	}
+
+	var name string
+	if err := UnmarshalPath([]byte(pathDoc), "name", &name); err != nil || name != "last value" {
+		t.Errorf("Unexpected result %q, %v", name, err)
+	}
+
+	var n int
+	if err := UnmarshalPath([]byte(pathDoc), "clients.2", &n); err != nil || n != 3 {
+		t.Errorf("Unexpected result %d, %v", n, err)
+	}
+
+	var raw RawMessage
+	if err := UnmarshalPath([]byte("a: {\n  b: [1, 2]\n}"), "a", &raw); err != nil {
+		t.Fatal(err)
+	}
+	if string(raw) != "{\n  b: [1, 2]\n}" {
+		t.Errorf("Unexpected raw value %q", raw)
+	}
+
+	var node Node
+	if err := UnmarshalPath([]byte(pathDoc), "name", &node); err != nil {
+		t.Fatal(err)
+	}
+	if node.Value != "last value" || node.Pos.Line != 19 {
+		t.Errorf("Unexpected node %#v", node)
+	}
+
+	var all map[string]interface{}
+	if err := UnmarshalPath([]byte("{\n  a: 1\n}"), "", &all); err != nil ||
+		!reflect.DeepEqual(all, map[string]interface{}{"a": 1.0}) {
+
+		t.Errorf("Unexpected result %#v, %v", all, err)
+	}
+
+	type endpoint struct {
+		Port int
+		TLS  bool
+	}
+	var ep endpoint
+	err := UnmarshalPath([]byte("server: {\n  ports: [80, {\n    port: 8080\n    tls: true\n  }]\n}"),
+		"server.ports.1", &ep)
+	if err != nil || ep != (endpoint{8080, true}) {
+		t.Errorf("Unexpected result %#v, %v", ep, err)
+	}
+
+	for _, path := range []string{"server.missing", "clients.3", "clients.x", "name.x"} {
+		err := UnmarshalPath([]byte(pathDoc), path, &n)
+		if _, ok := err.(*PathNotFoundError); !ok {
+			t.Errorf("Expected a PathNotFoundError for %s, got %v", path, err)
+		}
+	}
+	err = UnmarshalPath([]byte(pathDoc), "server.missing.x", &n)
+	if err == nil || err.Error() != "Path 'server.missing' not found" {
+		t.Errorf("Unexpected error %v", err)
+	}
+
+	// Skipped values are not built, so they do not count against the limits
+	// and are not recorded in FieldStates.
+	big := "big: {\n  a: [" + strings.Repeat("{x: 'y'}, [1, \"2\"], ", 50000) +
+		"]\n  b: '''\n    ]}\n    '''\n  c: x, ] # }\n}\n"
+	opt := DefaultDecoderOptions()
+	opt.AllocBudget = 1000
+	opt.MaxElements = 10
+	opt.FieldStates = FieldStates{}
+	if err := UnmarshalPathWithOptions([]byte(big+"port: 8080"), "port", &n, opt); err != nil || n != 8080 {
+		t.Errorf("Unexpected result %d, %v", n, err)
+	}
+	if len(opt.FieldStates) != 0 {
+		t.Errorf("Unexpected field states %v", opt.FieldStates)
+	}
+	var c string
+	if err := UnmarshalPathWithOptions([]byte(big), "big.c", &c, opt); err != nil || c != "x, ] # }" {
+		t.Errorf("Unexpected result %q, %v", c, err)
+	}
+
+	// Errors are reported at their position in the whole document.
+	err = UnmarshalPath([]byte("a: 1\nb: {\n  c: [1, 2\n}"), "b.c", &n)
+	if pe, ok := err.(*ParseError); !ok || pe.Line != 4 {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
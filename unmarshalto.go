@@ -0,0 +1,50 @@
+//go:build go1.18
+
+package hjson
+
+import (
+	"reflect"
+	"sync"
+)
+
+// UnmarshalTo decodes the Hjson document data into a new value of type T and
+// returns it, using the first of opts or DefaultDecoderOptions() if opts is
+// empty:
+//
+//	cfg, err := hjson.UnmarshalTo[Config](data)
+//
+// The information about T and the struct types it contains is computed once,
+// the first time T is decoded, instead of while decoding the first document.
+func UnmarshalTo[T any](data []byte, opts ...DecoderOptions) (T, error) {
+	var v T
+	prepareType(reflect.TypeOf(&v).Elem())
+	options := DefaultDecoderOptions()
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	err := UnmarshalWithOptions(data, &v, options)
+	return v, err
+}
+
+// preparedTypes holds the types that prepareType() has been called with.
+var preparedTypes sync.Map
+
+// prepareType caches the field information of t and of all struct types
+// reachable from it.
+func prepareType(t reflect.Type) {
+	if _, loaded := preparedTypes.LoadOrStore(t, true); loaded {
+		return
+	}
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		prepareType(t.Elem())
+	case reflect.Map:
+		prepareType(t.Key())
+		prepareType(t.Elem())
+	case reflect.Struct:
+		getStructFieldInfoMap(t)
+		for i := 0; i < t.NumField(); i++ {
+			prepareType(t.Field(i).Type)
+		}
+	}
+}
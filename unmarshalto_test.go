@@ -0,0 +1,35 @@
+//go:build go1.18
+
+package hjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnmarshalTo(t *testing.T) {
+	type node struct {
+		Name     string
+		Children []*node
+	}
+	n, err := UnmarshalTo[node]([]byte("name: root\nchildren: [\n  {\n    name: a\n  }\n]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := node{Name: "root", Children: []*node{{Name: "a"}}}
+	if !reflect.DeepEqual(n, expected) {
+		t.Errorf("Expected:\n%#v\nGot:\n%#v", expected, n)
+	}
+	if _, ok := preparedTypes.Load(reflect.TypeOf(&node{})); !ok {
+		t.Error("Expected *node to be prepared")
+	}
+
+	m, err := UnmarshalTo[map[string]int]([]byte("a: 1"), StrictDecoderOptions())
+	if err != nil || !reflect.DeepEqual(m, map[string]int{"a": 1}) {
+		t.Errorf("Unexpected result %#v, %v", m, err)
+	}
+
+	if _, err = UnmarshalTo[[]int]([]byte("[1, x]")); err == nil {
+		t.Error("Expected an error")
+	}
+}
@@ -0,0 +1,19 @@
+package hjson
+
+// UnmarshalWithReport is like UnmarshalWithOptions(), but also returns the
+// paths of the object members in data that were not used because they do not
+// match any field of a struct destination, in document order. The paths are
+// the object keys and array indexes leading to the members, joined by ".",
+// like "db.pasword" or "servers.0.hots". The values of unused members are not
+// searched for more unused members.
+//
+// Unlike DisallowUnknownFields, which fails on the first unknown member,
+// UnmarshalWithReport lets tools decode a config and warn about likely typos.
+// Members collected by a field tagged with the "remain" option are not
+// reported. The paths are returned also if an error occurs.
+func UnmarshalWithReport(data []byte, v interface{}, options DecoderOptions) ([]string, error) {
+	unused := []string{}
+	options.unusedKeys = &unused
+	err := UnmarshalWithOptions(data, v, options)
+	return unused, err
+}
@@ -0,0 +1,61 @@
+package hjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnmarshalWithReport(t *testing.T) {
+	type server struct {
+		Host string
+		Port int
+	}
+	type config struct {
+		Name    string
+		Servers []server
+		DB      map[string]interface{}
+	}
+
+	var c config
+	unused, err := UnmarshalWithReport([]byte(`{
+  Name: x
+  Nmae: y
+  Servers: [
+    {
+      Host: a
+      Hots: b
+      Port: 1
+    }
+  ]
+  DB: {
+    user: admin
+  }
+  Extra: {
+    nested: 1
+  }
+}`), &c, DefaultDecoderOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"Nmae", "Servers.0.Hots", "Extra"}
+	if !reflect.DeepEqual(unused, expected) {
+		t.Errorf("Expected %#v, got %#v", expected, unused)
+	}
+	if c.Name != "x" || len(c.Servers) != 1 || c.Servers[0].Host != "a" {
+		t.Errorf("Unexpected value: %#v", c)
+	}
+
+	var s server
+	if unused, err = UnmarshalWithReport([]byte("Host: a"), &s, DefaultDecoderOptions()); err != nil {
+		t.Fatal(err)
+	} else if len(unused) != 0 {
+		t.Errorf("Unexpected unused keys: %#v", unused)
+	}
+
+	var r remainServer
+	unused, err = UnmarshalWithReport([]byte("{\n  host: a\n  tls: true\n}"), &r,
+		DefaultDecoderOptions())
+	if err != nil || len(unused) != 0 {
+		t.Errorf("Unexpected result: %#v, %v", unused, err)
+	}
+}
@@ -0,0 +1,81 @@
+package hjson
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// InvalidUTF8Policy defines how invalid UTF-8 in the Hjson input is handled,
+// see DecoderOptions.InvalidUTF8.
+type InvalidUTF8Policy int
+
+const (
+	// InvalidUTF8Replace replaces each invalid UTF-8 byte sequence in the input
+	// with the replacement character U+FFFD before decoding. Offsets in errors
+	// and in Node.Pos and Node.End refer to the input after the replacement.
+	InvalidUTF8Replace InvalidUTF8Policy = iota
+	// InvalidUTF8Error causes a *ParseError to be returned for the first
+	// invalid UTF-8 byte sequence in the input.
+	InvalidUTF8Error
+)
+
+func (p InvalidUTF8Policy) String() string {
+	switch p {
+	case InvalidUTF8Replace:
+		return "replace"
+	case InvalidUTF8Error:
+		return "error"
+	}
+	return "unknown"
+}
+
+// checkUTF8 applies options.InvalidUTF8 to data.
+func checkUTF8(data []byte, options DecoderOptions) ([]byte, error) {
+	if utf8.Valid(data) {
+		return data, nil
+	}
+	if options.InvalidUTF8 == InvalidUTF8Error {
+		p := &hjsonParser{DecoderOptions: options, data: data}
+		return nil, p.errAtOffset(invalidUTF8Offset(data), MsgInvalidUTF8)
+	}
+	return toValidUTF8(data, options.OnInvalidUTF8), nil
+}
+
+// invalidUTF8Offset returns the offset of the first invalid UTF-8 byte
+// sequence in data, or -1 if data is valid.
+func invalidUTF8Offset(data []byte) int {
+	for i := 0; i < len(data); {
+		r, size := utf8.DecodeRune(data[i:])
+		if r == utf8.RuneError && size == 1 {
+			return i
+		}
+		i += size
+	}
+	return -1
+}
+
+// toValidUTF8 returns a copy of data in which each run of invalid UTF-8 bytes
+// is replaced by U+FFFD. If report is not nil, it is called with the offset of
+// each run in data.
+func toValidUTF8(data []byte, report func(offset int)) []byte {
+	var buf bytes.Buffer
+	buf.Grow(len(data))
+	invalid := false
+	for i := 0; i < len(data); {
+		r, size := utf8.DecodeRune(data[i:])
+		if r == utf8.RuneError && size == 1 {
+			if !invalid {
+				buf.WriteRune(utf8.RuneError)
+				if report != nil {
+					report(i)
+				}
+			}
+			invalid = true
+		} else {
+			buf.Write(data[i : i+size])
+			invalid = false
+		}
+		i += size
+	}
+	return buf.Bytes()
+}
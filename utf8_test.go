@@ -0,0 +1,78 @@
+package hjson
+
+import (
+	"testing"
+)
+
+func TestInvalidUTF8(t *testing.T) {
+	data := []byte("{\n  a: x\xff\xfey\n  b: \"\xc3\"\n}")
+
+	var offsets []int
+	opt := DefaultDecoderOptions()
+	opt.OnInvalidUTF8 = func(offset int) {
+		offsets = append(offsets, offset)
+	}
+	var v map[string]string
+	if err := UnmarshalWithOptions(data, &v, opt); err != nil {
+		t.Fatal(err)
+	}
+	if v["a"] != "x�y" || v["b"] != "�" {
+		t.Errorf("Unexpected values: %q", v)
+	}
+	if len(offsets) != 2 || offsets[0] != 8 || offsets[1] != 18 {
+		t.Errorf("Unexpected offsets: %v", offsets)
+	}
+
+	var node Node
+	if err := Unmarshal(data, &node); err != nil {
+		t.Fatal(err)
+	}
+	if lit := node.NK("a").Lit; lit != "x�y" {
+		t.Errorf("Unexpected Lit: %q", lit)
+	}
+
+	opt = DefaultDecoderOptions()
+	opt.InvalidUTF8 = InvalidUTF8Error
+	err := UnmarshalWithOptions(data, &v, opt)
+	pe, ok := err.(*ParseError)
+	if !ok || pe.Offset != 8 || pe.Line != 2 || pe.Column != 7 {
+		t.Errorf("Unexpected error: %#v", err)
+	}
+	if opt.InvalidUTF8.String() != "error" || InvalidUTF8Replace.String() != "replace" {
+		t.Error("Unexpected policy names")
+	}
+}
+
+func TestSurrogatePairs(t *testing.T) {
+	var v []string
+	if err := Unmarshal([]byte(`["😀", "\ud83d", "\ud83d\n", "\ud83dA"]`), &v); err != nil {
+		t.Fatal(err)
+	}
+	exp := []string{"\U0001F600", "�", "�\n", "�A"}
+	for i := range exp {
+		if v[i] != exp[i] {
+			t.Errorf("Expected %q, got %q", exp[i], v[i])
+		}
+	}
+
+	out, err := Marshal(map[string]string{"a": "x\xffy", "b": "\U0001F600\u2028"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "{\n  a: \"x\\ufffdy\"\n  b: \"\U0001F600\\u2028\"\n}" {
+		t.Errorf("Unexpected output: %q", out)
+	}
+
+	node, err := ParseNode([]byte("{\n  a: 1\n}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	node.NK("a").Cm.After = " # \xff"
+	out, err = Marshal(node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "{\n  a: 1 # �\n}" {
+		t.Errorf("Unexpected output: %q", out)
+	}
+}
@@ -0,0 +1,966 @@
+// Package yaml converts documents between Hjson and YAML, keeping the order of
+// keys and carrying comments over to the other format where it has a place for
+// them.
+//
+// Only the parts of YAML that have an equivalent in Hjson are supported: block
+// and flow mappings and sequences, plain, quoted and block scalars, and
+// comments. Anchors, aliases, tags and streams of several documents are
+// rejected with an error.
+package yaml
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bingoohuang/hjson"
+)
+
+// node is a value read from YAML, with the comments around it.
+type node struct {
+	// value is nil, a bool, a json.Number, a string, a []*node or a *mapping.
+	value interface{}
+	// before holds the lines before the value, comments starting with "#" or
+	// empty strings for empty lines.
+	before []string
+	// after is a comment on the same line as a scalar or flow value, or on the
+	// line of the key of a block mapping or sequence.
+	after string
+	// last holds the comment lines at the end of the document.
+	last []string
+}
+
+type mapping struct {
+	keys   []string
+	values []*node
+}
+
+type parser struct {
+	src    []byte
+	starts []int
+	// n is the index of the current line.
+	n int
+	// at is the offset in src used when reading quoted and flow values.
+	at int
+	// pending holds the comment lines read but not yet given to a value, like
+	// node.before.
+	pending []string
+}
+
+// FromYAML converts the YAML document data to Hjson, formatted like
+// hjson.Marshal() does. Mappings keep the order of their keys, and comments
+// are kept before the values they precede or after the values on the same
+// line. Comments inside flow collections ([...] and {...}) are left out.
+//
+// Plain scalars are resolved like in the core schema of YAML 1.2: null, ~ and
+// empty values become null, true and false (in lower case, upper case or with
+// a capital letter) become booleans, and decimal, octal (0o) and hexadecimal
+// (0x) numbers become Hjson numbers. Infinity and NaN cannot be written in
+// Hjson and give an error.
+func FromYAML(data []byte) ([]byte, error) {
+	if !utf8.Valid(data) {
+		return nil, errors.New("yaml: invalid UTF-8")
+	}
+	text := strings.TrimPrefix(string(data), "\uFEFF")
+	text = strings.Replace(text, "\r\n", "\n", -1)
+
+	p := &parser{src: []byte(text), starts: []int{0}}
+	for i, c := range p.src {
+		if c == '\n' {
+			p.starts = append(p.starts, i+1)
+		}
+	}
+	root, err := p.document()
+	if err != nil {
+		return nil, err
+	}
+	return hjson.Marshal(root.toHjson(0))
+}
+
+func (p *parser) errorf(format string, a ...interface{}) error {
+	return fmt.Errorf("yaml: line %d: %s", p.n+1, fmt.Sprintf(format, a...))
+}
+
+// errorAt returns an error at the line of offset in p.src.
+func (p *parser) errorAt(offset int, format string, a ...interface{}) error {
+	p.n = sort.SearchInts(p.starts, offset+1) - 1
+	return p.errorf(format, a...)
+}
+
+// line returns the line with index n, without the line feed.
+func (p *parser) line(n int) string {
+	end := len(p.src)
+	if n+1 < len(p.starts) {
+		end = p.starts[n+1] - 1
+	}
+	return string(p.src[p.starts[n]:end])
+}
+
+func (p *parser) eof() bool {
+	return p.n >= len(p.starts)
+}
+
+// indent returns the number of spaces at the start of line.
+func (p *parser) indent(line string) (int, error) {
+	i := 0
+	for i < len(line) && line[i] == ' ' {
+		i++
+	}
+	if i < len(line) && line[i] == '\t' {
+		return 0, p.errorf("tabs cannot be used for indentation")
+	}
+	return i, nil
+}
+
+func isDocMarker(line string) bool {
+	return (strings.HasPrefix(line, "---") || strings.HasPrefix(line, "...")) &&
+		(len(line) == 3 || line[3] == ' ' || line[3] == '\t')
+}
+
+func isSeqEntry(content string) bool {
+	return content == "-" || strings.HasPrefix(content, "- ") || strings.HasPrefix(content, "-\t")
+}
+
+// commentStart returns the index of the comment in text, or -1.
+func commentStart(text string) int {
+	for i := 0; i < len(text); i++ {
+		if text[i] == '#' && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t') {
+			return i
+		}
+	}
+	return -1
+}
+
+// skipEmpty skips empty lines and lines holding only a comment, and adds them
+// to p.pending.
+func (p *parser) skipEmpty() {
+	for ; !p.eof(); p.n++ {
+		t := strings.TrimSpace(p.line(p.n))
+		if t != "" && t[0] != '#' {
+			return
+		}
+		p.pending = append(p.pending, t)
+	}
+}
+
+// takePending returns the pending comment lines and clears them. Repeated
+// empty lines are collapsed, and leading empty lines are left out for the
+// first value in a collection.
+func (p *parser) takePending(first bool) []string {
+	var lines []string
+	for _, l := range p.pending {
+		if l == "" && (len(lines) == 0 && first || len(lines) > 0 && lines[len(lines)-1] == "") {
+			continue
+		}
+		lines = append(lines, l)
+	}
+	p.pending = nil
+	return lines
+}
+
+// document reads the whole YAML document.
+func (p *parser) document() (*node, error) {
+	p.skipEmpty()
+	for !p.eof() && strings.HasPrefix(p.line(p.n), "%") {
+		// Directives like %YAML 1.2 are not needed.
+		p.n++
+		p.skipEmpty()
+	}
+	if !p.eof() && strings.HasPrefix(p.line(p.n), "---") && isDocMarker(p.line(p.n)) {
+		rest := strings.TrimSpace(p.line(p.n)[3:])
+		if rest == "" || rest[0] == '#' {
+			if rest != "" {
+				p.pending = append(p.pending, rest)
+			}
+			p.n++
+		} else {
+			// The value starts on the same line.
+			copy(p.src[p.starts[p.n]:], "   ")
+		}
+	}
+
+	p.skipEmpty()
+	var before []string
+	if !p.eof() {
+		content := strings.TrimLeft(p.line(p.n), " ")
+		if _, _, ok, _ := splitKey(content); !ok && !isSeqEntry(content) {
+			before = p.takePending(true)
+		}
+	}
+	root, err := p.block(0)
+	if err != nil {
+		return nil, err
+	}
+	if before != nil {
+		root.before = before
+	}
+
+	p.skipEmpty()
+	if !p.eof() && strings.HasPrefix(p.line(p.n), "...") && isDocMarker(p.line(p.n)) {
+		p.n++
+		p.skipEmpty()
+	}
+	if !p.eof() {
+		if isDocMarker(p.line(p.n)) {
+			return nil, p.errorf("multiple documents are not supported")
+		}
+		return nil, p.errorf("unexpected %q", strings.TrimSpace(p.line(p.n)))
+	}
+	root.last = p.takePending(true)
+	for len(root.last) > 0 && root.last[len(root.last)-1] == "" {
+		root.last = root.last[:len(root.last)-1]
+	}
+	return root, nil
+}
+
+// block reads the value starting on the next line that is not empty, if that
+// line is indented by at least minIndent spaces. Otherwise the value is null.
+func (p *parser) block(minIndent int) (*node, error) {
+	p.skipEmpty()
+	if p.eof() {
+		return &node{}, nil
+	}
+	line := p.line(p.n)
+	ind, err := p.indent(line)
+	if err != nil {
+		return nil, err
+	}
+	if ind < minIndent || ind == 0 && isDocMarker(line) {
+		return &node{}, nil
+	}
+
+	content := line[ind:]
+	if isSeqEntry(content) {
+		return p.sequence(ind)
+	}
+	if _, _, ok, err := splitKey(content); err != nil {
+		return nil, p.errorf("%s", err.Error())
+	} else if ok {
+		return p.mapping(ind)
+	}
+	return p.value(ind, minIndent-1, false)
+}
+
+// splitKey returns the key and the rest of the line after the colon if
+// content is a mapping entry.
+func splitKey(content string) (key, rest string, ok bool, err error) {
+	if content == "" {
+		return "", "", false, nil
+	}
+	switch content[0] {
+	case '"', '\'':
+		p := &parser{src: []byte(content)}
+		var s string
+		if content[0] == '"' {
+			s, err = p.doubleQuoted()
+		} else {
+			s, err = p.singleQuoted()
+		}
+		if err != nil || strings.Contains(content[:p.at], "\n") {
+			return "", "", false, nil
+		}
+		rest = strings.TrimLeft(content[p.at:], " \t")
+		if rest == ":" || strings.HasPrefix(rest, ": ") || strings.HasPrefix(rest, ":\t") {
+			return s, rest[1:], true, nil
+		}
+		return "", "", false, nil
+	case '?':
+		if len(content) == 1 || content[1] == ' ' || content[1] == '\t' {
+			return "", "", false, errors.New("complex mapping keys are not supported")
+		}
+	case '[', '{', '#', '|', '>', '&', '*', '!', '%', '@', '`':
+		return "", "", false, nil
+	}
+	for i := 0; i < len(content); i++ {
+		switch content[i] {
+		case ':':
+			if i+1 == len(content) || content[i+1] == ' ' || content[i+1] == '\t' {
+				return strings.TrimSpace(content[:i]), content[i+1:], true, nil
+			}
+		case '#':
+			if content[i-1] == ' ' || content[i-1] == '\t' {
+				return "", "", false, nil
+			}
+		}
+	}
+	return "", "", false, nil
+}
+
+// mapping reads a block mapping with its keys indented by ind spaces.
+func (p *parser) mapping(ind int) (*node, error) {
+	m := &mapping{}
+	for first := true; ; first = false {
+		p.skipEmpty()
+		if p.eof() {
+			break
+		}
+		line := p.line(p.n)
+		i, err := p.indent(line)
+		if err != nil {
+			return nil, err
+		}
+		if i < ind || i == 0 && isDocMarker(line) {
+			break
+		}
+		if i > ind {
+			return nil, p.errorf("bad indentation of a mapping entry")
+		}
+		key, rest, ok, err := splitKey(line[ind:])
+		if err != nil {
+			return nil, p.errorf("%s", err.Error())
+		}
+		if !ok {
+			return nil, p.errorf("expected a mapping entry, found %q", strings.TrimSpace(line))
+		}
+		for _, k := range m.keys {
+			if k == key {
+				return nil, p.errorf("duplicate key %q", key)
+			}
+		}
+
+		before := p.takePending(first)
+		value, err := p.value(len(line)-len(rest), ind, false)
+		if err != nil {
+			return nil, err
+		}
+		value.before = before
+		m.keys = append(m.keys, key)
+		m.values = append(m.values, value)
+	}
+	return &node{value: m}, nil
+}
+
+// sequence reads a block sequence with its "-" indented by ind spaces.
+func (p *parser) sequence(ind int) (*node, error) {
+	items := []*node{}
+	for first := true; ; first = false {
+		p.skipEmpty()
+		if p.eof() {
+			break
+		}
+		line := p.line(p.n)
+		i, err := p.indent(line)
+		if err != nil {
+			return nil, err
+		}
+		if i < ind || i == 0 && isDocMarker(line) {
+			break
+		}
+		if i > ind {
+			return nil, p.errorf("bad indentation of a sequence entry")
+		}
+		if !isSeqEntry(line[ind:]) {
+			break
+		}
+
+		before := p.takePending(first)
+		// Replace "-" by a space, so that a mapping or sequence starting on the
+		// same line is read like a block at its own column.
+		p.src[p.starts[p.n]+ind] = ' '
+		item, err := p.value(ind+1, ind, true)
+		if err != nil {
+			return nil, err
+		}
+		item.before = before
+		items = append(items, item)
+	}
+	return &node{value: items}, nil
+}
+
+// value reads the value starting at column col of the current line, the value
+// of a mapping entry or a sequence item indented by ind spaces. If item is
+// true the value is a sequence item, which can be a mapping or a sequence
+// starting on the same line.
+func (p *parser) value(col, ind int, item bool) (*node, error) {
+	line := p.line(p.n)
+	rest := strings.TrimLeft(line[col:], " \t")
+	col = len(line) - len(rest)
+
+	if rest == "" || rest[0] == '#' {
+		// The value is on the following lines.
+		p.n++
+		p.skipEmpty()
+		var v *node
+		var err error
+		if !item && !p.eof() && strings.HasPrefix(p.line(p.n), strings.Repeat(" ", ind)) &&
+			isSeqEntry(p.line(p.n)[ind:]) {
+
+			// A sequence can have the same indentation as its key.
+			v, err = p.sequence(ind)
+		} else {
+			v, err = p.block(ind + 1)
+		}
+		if err != nil {
+			return nil, err
+		}
+		v.after = rest
+		return v, nil
+	}
+
+	if item {
+		if _, _, ok, _ := splitKey(rest); ok || isSeqEntry(rest) {
+			return p.block(ind + 1)
+		}
+	}
+
+	switch rest[0] {
+	case '|', '>':
+		return p.blockScalar(rest, ind)
+	case '"', '\'', '[', '{':
+		p.at = p.starts[p.n] + col
+		v, err := p.flowValue()
+		if err != nil {
+			return nil, err
+		}
+		return v, p.endValue(v)
+	}
+	return p.plain(rest, ind)
+}
+
+// endValue checks the rest of the line after a quoted or flow value read by
+// flowValue(), and moves to the next line.
+func (p *parser) endValue(v *node) error {
+	p.n = sort.SearchInts(p.starts, p.at+1) - 1
+	line := p.line(p.n)
+	rest := strings.TrimSpace(line[p.at-p.starts[p.n]:])
+	if rest != "" && rest[0] != '#' {
+		return p.errorf("unexpected %q after value", rest)
+	}
+	v.after = rest
+	p.n++
+	return nil
+}
+
+// plain reads a plain scalar starting with text, continued on the following
+// lines that are indented by more than ind spaces.
+func (p *parser) plain(text string, ind int) (*node, error) {
+	if err := checkIndicator(text[0]); err != nil {
+		return nil, p.errorf("%s", err.Error())
+	}
+	v := &node{}
+	if i := commentStart(text); i >= 0 {
+		v.after = text[i:]
+		text = text[:i]
+	}
+	parts := []string{strings.TrimSpace(text)}
+	start := p.n
+	p.n++
+
+	if v.after == "" {
+		for k := p.n; k < len(p.starts); k++ {
+			line := p.line(k)
+			t := strings.TrimSpace(line)
+			if t == "" {
+				continue
+			}
+			if t[0] == '#' || len(line)-len(strings.TrimLeft(line, " ")) <= ind ||
+				ind < 0 && isDocMarker(line) {
+				break
+			}
+			// Lines are joined by a space, empty lines become line feeds.
+			sep := strings.Repeat("\n", k-p.n)
+			if sep == "" {
+				sep = " "
+			}
+			if _, _, ok, _ := splitKey(t); ok {
+				p.n = k
+				return nil, p.errorf("bad indentation of a mapping entry")
+			}
+			if i := commentStart(t); i >= 0 {
+				v.after = t[i:]
+				t = strings.TrimSpace(t[:i])
+			}
+			parts = append(parts, sep, t)
+			p.n = k + 1
+			if v.after != "" {
+				break
+			}
+		}
+	}
+
+	if len(parts) > 1 {
+		v.value = strings.Join(parts, "")
+		return v, nil
+	}
+	var err error
+	if v.value, err = resolve(parts[0]); err != nil {
+		p.n = start
+		return nil, p.errorf("%s", err.Error())
+	}
+	return v, nil
+}
+
+// checkIndicator returns an error if a value cannot start with c.
+func checkIndicator(c byte) error {
+	switch c {
+	case '&', '*':
+		return errors.New("anchors and aliases are not supported")
+	case '!':
+		return errors.New("tags are not supported")
+	case '@', '`':
+		return fmt.Errorf("a plain scalar cannot start with %q", c)
+	}
+	return nil
+}
+
+// blockScalar reads a literal (|) or folded (>) block scalar, header being
+// the text of its first line.
+func (p *parser) blockScalar(header string, ind int) (*node, error) {
+	literal := header[0] == '|'
+	var chomp byte
+	contentIndent := -1
+	i := 1
+	for ; i < len(header) && header[i] != ' ' && header[i] != '\t'; i++ {
+		switch c := header[i]; {
+		case c == '-' || c == '+':
+			chomp = c
+		case c >= '1' && c <= '9':
+			contentIndent = int(c - '0')
+			if ind > 0 {
+				contentIndent += ind
+			}
+		default:
+			return nil, p.errorf("invalid block scalar header %q", header)
+		}
+	}
+	v := &node{after: strings.TrimSpace(header[i:])}
+	if v.after != "" && v.after[0] != '#' {
+		return nil, p.errorf("invalid block scalar header %q", header)
+	}
+
+	var lines []string
+	for p.n++; !p.eof(); p.n++ {
+		line := p.line(p.n)
+		if strings.TrimSpace(line) == "" {
+			if contentIndent >= 0 && len(line) > contentIndent {
+				lines = append(lines, line[contentIndent:])
+			} else {
+				lines = append(lines, "")
+			}
+			continue
+		}
+		i := len(line) - len(strings.TrimLeft(line, " "))
+		if contentIndent < 0 {
+			if i <= ind {
+				break
+			}
+			contentIndent = i
+		}
+		if i < contentIndent {
+			break
+		}
+		lines = append(lines, line[contentIndent:])
+	}
+
+	end := len(lines)
+	for end > 0 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	body := lines[:end]
+	var s string
+	if literal {
+		s = strings.Join(body, "\n")
+	} else {
+		s = fold(body)
+	}
+	switch chomp {
+	case '-':
+	case '+':
+		if len(body) > 0 {
+			s += "\n"
+		}
+		s += strings.Repeat("\n", len(lines)-end)
+	default:
+		if len(body) > 0 {
+			s += "\n"
+		}
+	}
+	v.value = s
+	return v, nil
+}
+
+// fold joins the lines of a folded block scalar. Lines are joined by a space,
+// unless one of them is more indented, and empty lines become line feeds.
+func fold(lines []string) string {
+	var sb strings.Builder
+	for i, l := range lines {
+		if i > 0 {
+			prev := lines[i-1]
+			switch {
+			case l == "":
+				sb.WriteByte('\n')
+			case prev == "":
+			case l[0] == ' ' || l[0] == '\t' || prev[0] == ' ' || prev[0] == '\t':
+				sb.WriteByte('\n')
+			default:
+				sb.WriteByte(' ')
+			}
+		}
+		sb.WriteString(l)
+	}
+	return sb.String()
+}
+
+// flowSpace skips whitespace, line feeds and comments inside flow values.
+func (p *parser) flowSpace() {
+	for p.at < len(p.src) {
+		switch p.src[p.at] {
+		case ' ', '\t', '\n':
+			p.at++
+		case '#':
+			for p.at < len(p.src) && p.src[p.at] != '\n' {
+				p.at++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// flowValue reads a quoted scalar, a flow sequence or a flow mapping at p.at,
+// or a plain scalar inside them.
+func (p *parser) flowValue() (*node, error) {
+	p.flowSpace()
+	if p.at >= len(p.src) {
+		return nil, p.errorAt(p.at, "unexpected end of document")
+	}
+	switch c := p.src[p.at]; c {
+	case '"', '\'':
+		var s string
+		var err error
+		if c == '"' {
+			s, err = p.doubleQuoted()
+		} else {
+			s, err = p.singleQuoted()
+		}
+		return &node{value: s}, err
+
+	case '[':
+		p.at++
+		items := []*node{}
+		for {
+			p.flowSpace()
+			if p.at < len(p.src) && p.src[p.at] == ']' {
+				p.at++
+				return &node{value: items}, nil
+			}
+			item, err := p.flowValue()
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+			if err := p.flowSeparator(']'); err != nil {
+				return nil, err
+			}
+		}
+
+	case '{':
+		p.at++
+		m := &mapping{}
+		for {
+			p.flowSpace()
+			if p.at < len(p.src) && p.src[p.at] == '}' {
+				p.at++
+				return &node{value: m}, nil
+			}
+			k, err := p.flowValue()
+			if err != nil {
+				return nil, err
+			}
+			key, ok := k.value.(string)
+			if !ok {
+				if k.value == nil {
+					key = "null"
+				} else if key, ok = flowKeyText(k.value); !ok {
+					return nil, p.errorAt(p.at, "complex mapping keys are not supported")
+				}
+			}
+			for _, other := range m.keys {
+				if other == key {
+					return nil, p.errorAt(p.at, "duplicate key %q", key)
+				}
+			}
+			value := &node{}
+			p.flowSpace()
+			if p.at < len(p.src) && p.src[p.at] == ':' {
+				p.at++
+				p.flowSpace()
+				if p.at < len(p.src) && p.src[p.at] != ',' && p.src[p.at] != '}' {
+					if value, err = p.flowValue(); err != nil {
+						return nil, err
+					}
+				}
+			}
+			m.keys = append(m.keys, key)
+			m.values = append(m.values, value)
+			if err := p.flowSeparator('}'); err != nil {
+				return nil, err
+			}
+		}
+
+	case ']', '}', ',':
+		return nil, p.errorAt(p.at, "unexpected %q", c)
+	}
+
+	if err := checkIndicator(p.src[p.at]); err != nil {
+		return nil, p.errorAt(p.at, "%s", err.Error())
+	}
+	start := p.at
+	for ; p.at < len(p.src); p.at++ {
+		c := p.src[p.at]
+		if c == ',' || c == '[' || c == ']' || c == '{' || c == '}' || c == '\n' ||
+			c == '#' && (p.src[p.at-1] == ' ' || p.src[p.at-1] == '\t') {
+			break
+		}
+		if c == ':' && (p.at+1 == len(p.src) ||
+			strings.IndexByte(" \t\n,[]{}", p.src[p.at+1]) >= 0) {
+			break
+		}
+	}
+	v, err := resolve(strings.TrimSpace(string(p.src[start:p.at])))
+	if err != nil {
+		return nil, p.errorAt(start, "%s", err.Error())
+	}
+	return &node{value: v}, nil
+}
+
+// flowKeyText returns the text of a boolean or number used as a key.
+func flowKeyText(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case bool:
+		return strconv.FormatBool(v), true
+	case json.Number:
+		return string(v), true
+	}
+	return "", false
+}
+
+// flowSeparator reads the comma after a value in a flow collection, unless
+// the collection ends with the character end.
+func (p *parser) flowSeparator(end byte) error {
+	p.flowSpace()
+	if p.at >= len(p.src) {
+		return p.errorAt(p.at, "unexpected end of document")
+	}
+	switch p.src[p.at] {
+	case ',':
+		p.at++
+		return nil
+	case end:
+		return nil
+	}
+	return p.errorAt(p.at, "expected ',' or '%c'", end)
+}
+
+var quotedEscapes = map[byte]string{
+	'0': "\x00", 'a': "\a", 'b': "\b", 't': "\t", '\t': "\t", 'n': "\n", 'v': "\v",
+	'f': "\f", 'r': "\r", 'e': "\x1b", ' ': " ", '"': "\"", '/': "/", '\\': "\\",
+	'N': "\u0085", '_': "\u00A0", 'L': "\u2028", 'P': "\u2029",
+}
+
+// doubleQuoted reads a double-quoted scalar at p.at.
+func (p *parser) doubleQuoted() (string, error) {
+	start := p.at
+	var buf []byte
+	for p.at++; p.at < len(p.src); {
+		c := p.src[p.at]
+		switch c {
+		case '"':
+			p.at++
+			return string(buf), nil
+
+		case '\\':
+			if p.at+1 >= len(p.src) {
+				return "", p.errorAt(start, "unterminated quoted scalar")
+			}
+			e := p.src[p.at+1]
+			p.at += 2
+			if s, ok := quotedEscapes[e]; ok {
+				buf = append(buf, s...)
+				continue
+			}
+			switch e {
+			case 'x', 'u', 'U':
+				n := map[byte]int{'x': 2, 'u': 4, 'U': 8}[e]
+				if p.at+n > len(p.src) {
+					return "", p.errorAt(start, "invalid escape sequence")
+				}
+				r, err := strconv.ParseUint(string(p.src[p.at:p.at+n]), 16, 32)
+				if err != nil {
+					return "", p.errorAt(p.at, "invalid escape sequence")
+				}
+				buf = append(buf, string(rune(r))...)
+				p.at += n
+			case '\n':
+				// An escaped line break joins the lines.
+				for p.at < len(p.src) && (p.src[p.at] == ' ' || p.src[p.at] == '\t') {
+					p.at++
+				}
+			default:
+				return "", p.errorAt(p.at, "invalid escape sequence \\%c", e)
+			}
+
+		case '\n':
+			buf = p.foldQuoted(buf)
+
+		default:
+			buf = append(buf, c)
+			p.at++
+		}
+	}
+	return "", p.errorAt(start, "unterminated quoted scalar")
+}
+
+// singleQuoted reads a single-quoted scalar at p.at.
+func (p *parser) singleQuoted() (string, error) {
+	start := p.at
+	var buf []byte
+	for p.at++; p.at < len(p.src); {
+		c := p.src[p.at]
+		switch {
+		case c == '\'' && p.at+1 < len(p.src) && p.src[p.at+1] == '\'':
+			buf = append(buf, '\'')
+			p.at += 2
+		case c == '\'':
+			p.at++
+			return string(buf), nil
+		case c == '\n':
+			buf = p.foldQuoted(buf)
+		default:
+			buf = append(buf, c)
+			p.at++
+		}
+	}
+	return "", p.errorAt(start, "unterminated quoted scalar")
+}
+
+// foldQuoted handles a line feed at p.at inside a quoted scalar: a single line
+// feed becomes a space and the line feeds of empty lines are kept. Whitespace
+// around line feeds is removed.
+func (p *parser) foldQuoted(buf []byte) []byte {
+	for len(buf) > 0 && (buf[len(buf)-1] == ' ' || buf[len(buf)-1] == '\t') {
+		buf = buf[:len(buf)-1]
+	}
+	feeds := 0
+	for p.at < len(p.src) {
+		switch p.src[p.at] {
+		case '\n':
+			feeds++
+		case ' ', '\t':
+		default:
+			if feeds == 1 {
+				return append(buf, ' ')
+			}
+			return append(buf, strings.Repeat("\n", feeds-1)...)
+		}
+		p.at++
+	}
+	return buf
+}
+
+var (
+	intRegex    = regexp.MustCompile(`^[-+]?[0-9]+$`)
+	floatRegex  = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+	numberRegex = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+)
+
+// resolve returns the value of the plain scalar s.
+func resolve(s string) (interface{}, error) {
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	case ".inf", ".Inf", ".INF", "+.inf", "+.Inf", "+.INF", "-.inf", "-.Inf", "-.INF",
+		".nan", ".NaN", ".NAN":
+		return nil, fmt.Errorf("%s cannot be written in Hjson", s)
+	}
+
+	if len(s) > 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'o') {
+		base := 16
+		if s[1] == 'o' {
+			base = 8
+		}
+		if n, ok := new(big.Int).SetString(s[2:], base); ok && n.Sign() >= 0 && s[2] != '+' {
+			return json.Number(n.String()), nil
+		}
+		return s, nil
+	}
+
+	if intRegex.MatchString(s) || floatRegex.MatchString(s) {
+		t := strings.TrimPrefix(s, "+")
+		if numberRegex.MatchString(t) {
+			return json.Number(t), nil
+		}
+		if n, ok := new(big.Int).SetString(t, 10); ok {
+			// Leading zeros.
+			return json.Number(n.String()), nil
+		}
+		f, err := strconv.ParseFloat(t, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s cannot be written in Hjson", s)
+		}
+		return json.Number(strconv.FormatFloat(f, 'g', -1, 64)), nil
+	}
+	return s, nil
+}
+
+// toHjson returns n as a hjson.Node tree, with the comments indented for a
+// value at the given depth.
+func (n *node) toHjson(depth int) *hjson.Node {
+	indent := strings.Repeat("  ", depth)
+	hn := &hjson.Node{}
+	if len(n.before) > 0 {
+		hn.Cm.Before = commentText(n.before, indent) + indent
+	}
+
+	switch v := n.value.(type) {
+	case []*node:
+		arr := make([]interface{}, len(v))
+		for i, item := range v {
+			arr[i] = item.toHjson(depth + 1)
+		}
+		hn.Value = arr
+	case *mapping:
+		om := hjson.NewOrderedMap()
+		for i, key := range v.keys {
+			om.Set(key, v.values[i].toHjson(depth+1))
+		}
+		hn.Value = om
+	default:
+		hn.Value = v
+		if n.after != "" {
+			hn.Cm.After = " " + n.after
+		}
+		return hn
+	}
+
+	if n.after != "" {
+		hn.Cm.InsideFirst = " " + n.after
+	}
+	if len(n.last) > 0 {
+		hn.Cm.InsideLast = commentText(n.last, indent+"  ")
+	}
+	return hn
+}
+
+// commentText returns the comment lines, each indented and ending with a line
+// feed.
+func commentText(lines []string, indent string) string {
+	var sb strings.Builder
+	for _, l := range lines {
+		if l != "" {
+			sb.WriteString(indent + l)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
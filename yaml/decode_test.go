@@ -0,0 +1,113 @@
+package yaml
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFromYAML(t *testing.T) {
+	out, err := FromYAML([]byte(`%YAML 1.2
+---
+# Server settings
+name: 'it''s'   # the name
+port: 0x1F90
+debug: False
+ratio: 1.50
+hosts: [a.example.com, "b: example"]
+db:
+  user: admin
+
+  # optional
+  pass:
+users:
+- name: ann
+  roles:
+  - admin
+  - - nested
+- {name: bob}
+text: |
+  line one
+  line two
+folded: >-
+  one
+  two
+quoted: "tab\there
+  folded"
+"quoted key": ~
+# end
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{
+  # Server settings
+  name: "it's" # the name
+  port: 8080
+  debug: false
+  ratio: 1.50
+  hosts: [
+    a.example.com
+    b: example
+  ]
+  db: {
+    user: admin
+
+    # optional
+    pass: null
+  }
+  users: [
+    {
+      name: ann
+      roles: [
+        admin
+        [
+          nested
+        ]
+      ]
+    }
+    {
+      name: bob
+    }
+  ]
+  text:
+    '''
+    line one
+    line two
+
+    '''
+  folded: one two
+  quoted: '''tab	here folded'''
+  "quoted key": null
+  # end
+}`
+	if string(out) != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, out)
+	}
+}
+
+func TestFromYAMLErrors(t *testing.T) {
+	cases := []struct {
+		yaml string
+		err  string
+	}{
+		{"a: &x 1\nb: *x\n", "yaml: line 1: anchors and aliases are not supported"},
+		{"a: !!str 1\n", "yaml: line 1: tags are not supported"},
+		{"a: 1\n---\nb: 2\n", "yaml: line 2: multiple documents are not supported"},
+		{"a:\n\tb: 1\n", "yaml: line 2: tabs cannot be used for indentation"},
+		{"a: 1\na: 2\n", `yaml: line 2: duplicate key "a"`},
+		{"a: .inf\n", "yaml: line 1: .inf cannot be written in Hjson"},
+		{"a:\n  b: 1\n   c: 2\n", "yaml: line 3: bad indentation of a mapping entry"},
+		{"a: [1, 2\n", "yaml: line 2: unexpected end of document"},
+		{"? a\n: 1\n", "yaml: line 1: complex mapping keys are not supported"},
+	}
+	for _, c := range cases {
+		_, err := FromYAML([]byte(c.yaml))
+		if err == nil || err.Error() != c.err {
+			t.Errorf("For %q expected error %q, got %v", c.yaml, c.err, err)
+		}
+	}
+
+	if _, err := FromYAML([]byte("a: \xff")); err == nil || !strings.Contains(err.Error(), "UTF-8") {
+		t.Errorf("Expected an error for invalid UTF-8, got %v", err)
+	}
+}
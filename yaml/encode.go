@@ -0,0 +1,342 @@
+package yaml
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/bingoohuang/hjson"
+)
+
+// writer writes YAML.
+type writer struct {
+	bytes.Buffer
+}
+
+// ToYAML converts the Hjson document data to YAML. Objects are written as
+// block mappings with their keys in the original order and arrays as block
+// sequences. Comments are written as YAML comments, all starting with "#",
+// before the values they precede or after the values on the same line.
+//
+// Strings are written without quotes if YAML 1.2 and YAML 1.1 parsers read
+// them back as the same strings (so "yes", "off" and "0777" are quoted),
+// multiline strings as literal block scalars (|) and other strings in double
+// quotes. Numbers keep the text they have in data.
+func ToYAML(data []byte) ([]byte, error) {
+	root, err := hjson.ParseNode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var w writer
+	w.comments(root.Cm.Before, "")
+	w.comments(root.Cm.Key, "")
+	switch v := root.Value.(type) {
+	case *hjson.OrderedMap:
+		if len(v.Keys) > 0 {
+			w.comments(root.Cm.InsideFirst, "")
+			w.mapping(v, "", "")
+			w.comments(root.Cm.InsideLast, "")
+			w.comments(root.Cm.After, "")
+			return w.Bytes(), nil
+		}
+	case []interface{}:
+		if len(v) > 0 {
+			w.comments(root.Cm.InsideFirst, "")
+			w.sequence(v, "")
+			w.comments(root.Cm.InsideLast, "")
+			w.comments(root.Cm.After, "")
+			return w.Bytes(), nil
+		}
+	}
+	head, body := scalar(root, "  ")
+	w.WriteString(head)
+	w.endLine(root.Cm.InsideFirst, root.Cm.InsideLast, root.Cm.After)
+	w.WriteString(body)
+	return w.Bytes(), nil
+}
+
+// commentLines returns the comments in txt, which holds comments and
+// whitespace, as YAML comment lines. Empty lines are returned as empty
+// strings.
+func commentLines(txt string) []string {
+	var lines []string
+	hasComment := false
+	for i := 0; i < len(txt); {
+		rest := txt[i:]
+		switch {
+		case rest[0] == '#' || strings.HasPrefix(rest, "//"):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			line := strings.TrimRight(rest[:end], " \t\r")
+			if line[0] == '#' {
+				line = line[1:]
+			} else {
+				line = line[2:]
+			}
+			lines = append(lines, "#"+line)
+			hasComment = true
+			i += end
+
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				end = len(rest) - 2
+			}
+			block := strings.Split(rest[2:2+end], "\n")
+			for n, line := range block {
+				line = strings.TrimRight(line, " \t\r")
+				if n > 0 {
+					line = strings.TrimLeft(line, " \t")
+					if strings.HasPrefix(line, "*") {
+						line = line[1:]
+					} else if line != "" {
+						line = " " + line
+					}
+				}
+				if line == "" && (n == 0 || n == len(block)-1) && len(block) > 1 {
+					continue
+				}
+				lines = append(lines, "#"+line)
+			}
+			hasComment = true
+			i += end + 4
+
+		case rest[0] == '\n':
+			if !hasComment {
+				lines = append(lines, "")
+			}
+			hasComment = false
+			i++
+
+		default:
+			i++
+		}
+	}
+	return lines
+}
+
+// comments writes the comments in txt on lines of their own.
+func (w *writer) comments(txt, indent string) {
+	empty := false
+	for _, line := range commentLines(txt) {
+		if line == "" {
+			if !empty && w.Len() > 0 {
+				w.WriteString("\n")
+			}
+			empty = true
+			continue
+		}
+		empty = false
+		w.WriteString(indent + line + "\n")
+	}
+}
+
+// endLine writes the comments in txts, if any, and a line feed.
+func (w *writer) endLine(txts ...string) {
+	var lines []string
+	for _, txt := range txts {
+		for _, line := range commentLines(txt) {
+			if line != "" {
+				lines = append(lines, line)
+			}
+		}
+	}
+	if len(lines) > 0 {
+		w.WriteString(" " + strings.Join(lines, " "))
+	}
+	w.WriteString("\n")
+}
+
+// mapping writes the members of om as a block mapping. The first key is
+// written after lead, the other keys after indent.
+func (w *writer) mapping(om *hjson.OrderedMap, indent, lead string) {
+	for i, key := range om.Keys {
+		child := om.Map[key].(*hjson.Node)
+		w.comments(child.Cm.Before, indent)
+		if i == 0 {
+			w.WriteString(lead)
+		} else {
+			w.WriteString(indent)
+		}
+		w.WriteString(quoteKey(key) + ":")
+		w.value(child, indent)
+	}
+}
+
+// sequence writes the elements of arr as a block sequence.
+func (w *writer) sequence(arr []interface{}, indent string) {
+	for _, elem := range arr {
+		child := elem.(*hjson.Node)
+		w.comments(child.Cm.Before, indent)
+		if om, ok := child.Value.(*hjson.OrderedMap); ok && len(om.Keys) > 0 &&
+			child.Cm.InsideFirst == "" {
+
+			// Start the mapping on the line of the "-".
+			w.mapping(om, indent+"  ", indent+"- ")
+			w.comments(child.Cm.InsideLast, indent+"  ")
+			w.comments(child.Cm.After, indent+"  ")
+			continue
+		}
+		w.WriteString(indent + "-")
+		w.value(child, indent)
+	}
+}
+
+// value writes the value of a mapping entry or a sequence item, after the
+// key or "-".
+func (w *writer) value(node *hjson.Node, indent string) {
+	switch v := node.Value.(type) {
+	case *hjson.OrderedMap:
+		if len(v.Keys) == 0 {
+			w.WriteString(" {}")
+			break
+		}
+		w.endLine(node.Cm.Key, node.Cm.InsideFirst)
+		w.mapping(v, indent+"  ", indent+"  ")
+		w.comments(node.Cm.InsideLast, indent+"  ")
+		w.comments(node.Cm.After, indent+"  ")
+		return
+
+	case []interface{}:
+		if len(v) == 0 {
+			w.WriteString(" []")
+			break
+		}
+		w.endLine(node.Cm.Key, node.Cm.InsideFirst)
+		w.sequence(v, indent+"  ")
+		w.comments(node.Cm.InsideLast, indent+"  ")
+		w.comments(node.Cm.After, indent+"  ")
+		return
+
+	default:
+		head, body := scalar(node, indent+"  ")
+		w.WriteString(" " + head)
+		w.endLine(node.Cm.Key, node.Cm.After)
+		w.WriteString(body)
+		return
+	}
+	w.endLine(node.Cm.Key, node.Cm.InsideFirst, node.Cm.InsideLast, node.Cm.After)
+}
+
+var jsonNumberRegex = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+
+// scalar returns the text of a scalar value or an empty collection. For block
+// scalars the text is split in the header and the body, with its lines
+// indented by indent.
+func scalar(node *hjson.Node, indent string) (head, body string) {
+	switch v := node.Value.(type) {
+	case nil:
+		return "null", ""
+	case bool:
+		return strconv.FormatBool(v), ""
+	case float64:
+		if jsonNumberRegex.MatchString(node.Lit) {
+			return node.Lit, ""
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64), ""
+	case json.Number:
+		return string(v), ""
+	case string:
+		return quoteString(v, indent)
+	case *hjson.OrderedMap:
+		// Only empty collections are written as scalars.
+		return "{}", ""
+	case []interface{}:
+		return "[]", ""
+	}
+	return "null", ""
+}
+
+// yaml11Regex matches the plain scalars that YAML 1.1 resolves to other types
+// than strings: booleans (like yes and off), integers (like 0b101, 017, 1_000
+// and 1:30), floats (like 1_000.5 and .5), timestamps, merge keys and values.
+// YAML 1.2 reads most of them as strings, but many parsers still use YAML 1.1.
+var yaml11Regex = regexp.MustCompile(`^(?:` +
+	`y|Y|yes|Yes|YES|n|N|no|No|NO|on|On|ON|off|Off|OFF|` +
+	`[-+]?0b[0-1_]+|[-+]?0[0-7_]+|[-+]?(?:0|[1-9][0-9_]*)|[-+]?0x[0-9a-fA-F_]+|` +
+	`[-+]?[1-9][0-9_]*(?::[0-5]?[0-9])+|` +
+	`[-+]?(?:[0-9][0-9_]*)?\.[0-9.]*(?:[eE][-+][0-9]+)?|` +
+	`[-+]?[0-9][0-9_]*(?::[0-5]?[0-9])+\.[0-9_]*|` +
+	`[0-9]{4}-[0-9]{2}-[0-9]{2}|` +
+	`[0-9]{4}-[0-9]{1,2}-[0-9]{1,2}(?:[Tt]|[ \t]+)[0-9]{1,2}:[0-9]{2}:[0-9]{2}(?:\.[0-9]*)?` +
+	`(?:[ \t]*Z|[-+][0-9]{1,2}(?::[0-9]{2})?)?|` +
+	`<<|=)$`)
+
+// isPlain returns true if s can be written as a plain scalar and is read back
+// as the same string, by YAML 1.2 and by YAML 1.1 parsers.
+func isPlain(s string) bool {
+	if s == "" || s != strings.TrimSpace(s) || isDocMarker(s) {
+		return false
+	}
+	if strings.IndexByte("-?:,[]{}#&*!|>'\"%@`", s[0]) >= 0 &&
+		!(strings.IndexByte("-?:", s[0]) >= 0 && len(s) > 1 && s[1] != ' ') {
+		return false
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return false
+	}
+	for _, r := range s {
+		if r != ' ' && !unicode.IsPrint(r) || r == '\uFEFF' {
+			return false
+		}
+	}
+	if yaml11Regex.MatchString(s) {
+		return false
+	}
+	v, err := resolve(s)
+	_, ok := v.(string)
+	return err == nil && ok
+}
+
+// quoteKey returns the text of a mapping key.
+func quoteKey(key string) string {
+	if isPlain(key) {
+		return key
+	}
+	return strconv.Quote(key)
+}
+
+// quoteString returns the text of a string value, as a plain scalar, a
+// literal block scalar or a double-quoted scalar.
+func quoteString(s, indent string) (head, body string) {
+	if isPlain(s) {
+		return s, ""
+	}
+
+	trimmed := strings.TrimRight(s, "\n")
+	canBlock := strings.Contains(trimmed, "\n") && trimmed[0] != ' ' && trimmed[0] != '\t'
+	for _, r := range trimmed {
+		if r != '\n' && r != '\t' && !unicode.IsPrint(r) && r != ' ' {
+			canBlock = false
+		}
+	}
+	if !canBlock {
+		return strconv.Quote(s), ""
+	}
+
+	chomp := "-"
+	switch len(s) - len(trimmed) {
+	case 0:
+	case 1:
+		chomp = ""
+	default:
+		chomp = "+"
+	}
+	var sb strings.Builder
+	for _, line := range strings.Split(trimmed, "\n") {
+		if line != "" {
+			sb.WriteString(indent + line)
+		}
+		sb.WriteString("\n")
+	}
+	if chomp == "+" {
+		sb.WriteString(strings.Repeat("\n", len(s)-len(trimmed)-1))
+	}
+	return "|" + chomp, sb.String()
+}
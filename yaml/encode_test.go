@@ -0,0 +1,147 @@
+package yaml
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bingoohuang/hjson"
+)
+
+const sampleHjson = `# Server settings
+{
+  // the name
+  name: demo server
+  port: 8080 # default port
+  ratio: 1.50
+  /* hosts
+     to serve */
+  hosts: [
+    a.example.com
+    "b: example"
+    "true"
+  ]
+  db: {
+    user: admin
+
+    pass: "  spaced"
+    empty: {}
+    list: []
+  }
+  text:
+    '''
+    line one
+    line two
+    '''
+  users: [
+    {
+      name: ann
+      role: admin
+    }
+    [
+      1
+      2
+    ]
+  ]
+  nothing: null
+  "key: with colon": "x\ty"
+  # trailing
+}`
+
+func TestToYAML(t *testing.T) {
+	out, err := ToYAML([]byte(sampleHjson))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `# Server settings
+# the name
+name: demo server
+port: 8080 # default port
+ratio: 1.50
+# hosts
+# to serve
+hosts:
+  - a.example.com
+  - "b: example"
+  - "true"
+db:
+  user: admin
+
+  pass: "  spaced"
+  empty: {}
+  list: []
+text: |-
+  line one
+  line two
+users:
+  - name: ann
+    role: admin
+  -
+    - 1
+    - 2
+nothing: null
+"key: with colon": "x\ty"
+# trailing
+`
+	if string(out) != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, out)
+	}
+
+	for _, doc := range []string{"[]", "{}", "5", "'''\n  a\n  b\n'''", "\"\"", "[\n  {}\n  [\n    x\n  ]\n]"} {
+		out, err := ToYAML([]byte(doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		back, err := FromYAML(out)
+		if err != nil {
+			t.Fatalf("%s: %v", out, err)
+		}
+		checkSame(t, doc, string(back))
+	}
+}
+
+func TestToYAMLQuotesYAML11(t *testing.T) {
+	for _, s := range []string{
+		"yes", "No", "ON", "off", "y", "N", "0b101", "0777", "1_000", "0x1F", "1:30",
+		"1_000.5", ".5", "+.inf", ".NaN", "2001-12-14", "2001-12-14t21:59:43.10-05:00", "<<", "=",
+	} {
+		out, err := ToYAML([]byte(`["` + s + `"]`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp := "- \"" + s + "\"\n"; string(out) != exp {
+			t.Errorf("Expected %q, got %q", exp, out)
+		}
+	}
+	for _, s := range []string{"yess", "nothing", "one", "1.2a", "x=1", "v1.2"} {
+		if !isPlain(s) {
+			t.Errorf("Expected %q to be written without quotes", s)
+		}
+	}
+}
+
+func TestYAMLRoundTrip(t *testing.T) {
+	out, err := ToYAML([]byte(sampleHjson))
+	if err != nil {
+		t.Fatal(err)
+	}
+	back, err := FromYAML(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSame(t, sampleHjson, string(back))
+}
+
+// checkSame checks that the Hjson documents a and b hold the same values.
+func checkSame(t *testing.T, a, b string) {
+	t.Helper()
+	var va, vb interface{}
+	if err := hjson.Unmarshal([]byte(a), &va); err != nil {
+		t.Fatal(err)
+	}
+	if err := hjson.Unmarshal([]byte(b), &vb); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(va, vb) {
+		t.Errorf("Expected:\n%#v\nGot:\n%#v", va, vb)
+	}
+}